@@ -21,23 +21,32 @@ package main
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/ShopOnGO/ShopOnGO/configs"
+	"github.com/ShopOnGO/ShopOnGO/internal/account"
 	"github.com/ShopOnGO/ShopOnGO/internal/admin"
+	"github.com/ShopOnGO/ShopOnGO/internal/audit"
 	"github.com/ShopOnGO/ShopOnGO/internal/auth"
+	"github.com/ShopOnGO/ShopOnGO/internal/auth/mfa"
 	"github.com/ShopOnGO/ShopOnGO/internal/auth/passwordreset"
+	"github.com/ShopOnGO/ShopOnGO/internal/auth/verification"
 	"github.com/ShopOnGO/ShopOnGO/internal/brand"
 	"github.com/ShopOnGO/ShopOnGO/internal/cart"
 	"github.com/ShopOnGO/ShopOnGO/internal/category"
 	"github.com/ShopOnGO/ShopOnGO/internal/chat"
 	"github.com/ShopOnGO/ShopOnGO/internal/home"
+	"github.com/ShopOnGO/ShopOnGO/internal/identity"
 	"github.com/ShopOnGO/ShopOnGO/internal/link"
 	"github.com/ShopOnGO/ShopOnGO/internal/notification"
 	"github.com/ShopOnGO/ShopOnGO/internal/product"
 	"github.com/ShopOnGO/ShopOnGO/internal/productVariant"
 	"github.com/ShopOnGO/ShopOnGO/internal/question"
+	"github.com/ShopOnGO/ShopOnGO/internal/rbac"
 	"github.com/ShopOnGO/ShopOnGO/internal/review"
+	"github.com/ShopOnGO/ShopOnGO/internal/seller"
 	"github.com/ShopOnGO/ShopOnGO/internal/stat"
+	"github.com/ShopOnGO/ShopOnGO/internal/suspension"
 	"github.com/ShopOnGO/ShopOnGO/internal/user"
 
 	"github.com/ShopOnGO/ShopOnGO/migrations"
@@ -45,8 +54,11 @@ import (
 	"github.com/ShopOnGO/ShopOnGO/pkg/event"
 	"github.com/ShopOnGO/ShopOnGO/pkg/kafkaService"
 	"github.com/ShopOnGO/ShopOnGO/pkg/logger"
+	"github.com/ShopOnGO/ShopOnGO/pkg/media"
 	"github.com/ShopOnGO/ShopOnGO/pkg/middleware"
 	"github.com/ShopOnGO/ShopOnGO/pkg/oauth2"
+	"github.com/ShopOnGO/ShopOnGO/pkg/oauthprovider"
+	"github.com/ShopOnGO/ShopOnGO/pkg/password"
 	"github.com/ShopOnGO/ShopOnGO/pkg/redisdb"
 	"github.com/gorilla/mux"
 
@@ -63,8 +75,14 @@ func App() http.Handler {
 
 	consoleLvl := conf.LogLevel
 	fileLvl := conf.FileLogLevel
+	logger.SetFormat(conf.LogFormat)
+	logger.SetConsoleOutput(conf.LogOutput.Console())
 	logger.InitLogger(consoleLvl, fileLvl)
-	logger.EnableFileLogging("TailorNado_main")
+	logger.AddRedactedKeys(conf.LogRedactKeys...)
+	logger.SetRetention(conf.LogRetention)
+	if conf.LogOutput.File() {
+		logger.EnableFileLogging("TailorNado_main")
+	}
 
 	db := db.NewDB(conf)
 	redis := redisdb.NewRedisDB(conf)
@@ -78,6 +96,7 @@ func App() http.Handler {
 	// REPOSITORIES
 	linkRepository := link.NewLinkRepository(db)
 	userRepository := user.NewUserRepository(db)
+	productRepository := product.NewProductRepository(db)
 	statRepository := stat.NewStatRepository(db)
 	chatRepository := chat.NewChatRepository(db)
 	categoryRepository := category.NewCategoryRepository(db)
@@ -85,34 +104,139 @@ func App() http.Handler {
 	cartRepository := cart.NewCartRepository(db)
 	refreshTokenRepository := oauth2.NewRedisRefreshTokenRepository(redis)
 	resetPasswordRepository := passwordreset.NewRedisResetRepository(redis)
+	verificationRepository := verification.NewRedisVerificationRepository(redis)
+	mfaRepository := mfa.NewMFARepository(db)
+	mfaChallengeRepository := mfa.NewRedisChallengeRepository(redis)
+	identityRepository := identity.NewIdentityRepository(db)
+	oauthStateRepository := auth.NewRedisOAuthStateRepository(redis)
+	sellerApplicationRepository := seller.NewSellerApplicationRepository(db)
+	rbacRepository := rbac.NewRBACRepository(db)
+	auditRepository := audit.NewAuditRepository(db)
+	suspensionRepository := suspension.NewSuspensionRepository(db)
+	banCache := suspension.NewRedisBanCache(redis)
+	roleCache := user.NewRedisRoleCache(redis, conf.OAuth.JWTTTL)
+	tokenGuard := &middleware.TokenGuard{Bans: banCache, Roles: roleCache}
+	accountRepository := account.NewAccountRepository(db)
+	exportStore := account.NewRedisExportStore(redis)
+	emailChangeStore := account.NewRedisEmailChangeStore(redis)
+	chatSessionStore := chat.NewRedisSessionStore(redis, conf.Chat.ReplicaID)
 
 	// Services
+	passwordPolicy := password.NewPolicy(conf.Password)
+	permissionService := rbac.NewPermissionService(rbacRepository)
+	if err := permissionService.SeedDefaults(); err != nil {
+		logger.Errorf("❌ Failed to seed role permissions: %v", err)
+	}
+	auditService := audit.NewAuditService(auditRepository)
 	authService := auth.NewAuthService(userRepository)
 	homeService := home.NewHomeService(categoryRepository, brandsRepository)
 	cartService := cart.NewCartService(cartRepository)
-	chatService := chat.NewChatService(chatRepository)
+	chatService := chat.NewChatService(chat.ChatServiceDeps{
+		Config:         conf.Chat,
+		ChatRepository: chatRepository,
+		Sessions:       chatSessionStore,
+		Broker:         chat.NewRedisBroker(redis),
+		Permissions:    permissionService,
+		Users:          userRepository,
+		Audit:          auditService,
+		Media:          media.NewClient(conf.Media.URL),
+		Scanner:        media.NewScanner(conf.Media.ClamAVAddr),
+		MaxUploadSize:  conf.Media.MaxUploadSize,
+	})
 	statService := stat.NewStatService(&stat.StatServiceDeps{
 		StatRepository: statRepository,
 		EventBus:       eventBus,
 	})
 
-	oauth2Service := oauth2.NewOAuth2Service(conf, refreshTokenRepository)
-	resetService := passwordreset.NewResetService(conf, resetPasswordRepository, userRepository, kafkaProducers["reset"])
+	oauth2Service := oauth2.NewOAuth2Service(conf, refreshTokenRepository, banCache)
+	resetService := passwordreset.NewResetService(conf, resetPasswordRepository, userRepository, oauth2Service, kafkaProducers["reset"])
+	verificationService := verification.NewVerificationService(conf, verificationRepository, userRepository, kafkaProducers["reset"])
+	mfaService := mfa.NewMFAService(conf, mfaRepository, mfaChallengeRepository)
+	identityService := identity.NewIdentityService(identityRepository, userRepository)
+	oauthProviders := oauthprovider.NewRegistryFromConfig(conf, &http.Client{Timeout: 10 * time.Second})
+	sellerService := seller.NewSellerService(sellerApplicationRepository, userRepository, oauth2Service, kafkaProducers["notifications"], roleCache)
+	suspensionService := suspension.NewSuspensionService(suspension.SuspensionServiceDeps{
+		Repository:     suspensionRepository,
+		Cache:          banCache,
+		UserRepository: userRepository,
+		Sessions:       oauth2Service,
+		Audit:          auditService,
+		Chat:           chatService,
+		Permissions:    permissionService,
+	})
+	if err := suspensionService.SyncCache(); err != nil {
+		logger.Errorf("❌ Failed to sync ban cache: %v", err)
+	}
+	accountDeps := account.AccountServiceDeps{
+		Conf:           conf,
+		Repository:     accountRepository,
+		Exports:        exportStore,
+		EmailChanges:   emailChangeStore,
+		UserRepository: userRepository,
+		Sessions:       oauth2Service,
+		Media:          media.NewClient(conf.Media.URL),
+	}
+	if producer, ok := kafkaProducers["users"]; ok {
+		accountDeps.Events = producer
+	}
+	if producer, ok := kafkaProducers["reset"]; ok {
+		accountDeps.Notifications = producer
+	}
+	accountService := account.NewAccountService(accountDeps)
 
 	//Handlers
 	auth.NewAuthHandler(router, auth.AuthHandlerDeps{
+		Config:              conf,
+		Guard:               tokenGuard,
+		AuthService:         authService,
+		OAuth2Service:       oauth2Service,
+		VerificationService: verificationService,
+		MFAService:          mfaService,
+		IdentityService:     identityService,
+		StateRepository:     oauthStateRepository,
+		Providers:           oauthProviders,
+		PasswordPolicy:      passwordPolicy,
+	})
+	mfa.NewMFAHandler(router, mfa.MFAHandlerDeps{
+		Config:         conf,
+		Guard:          tokenGuard,
+		MFAService:     mfaService,
+		OAuth2Service:  oauth2Service,
+		UserRepository: userRepository,
+	})
+	identity.NewIdentityHandler(router, identity.IdentityHandlerDeps{
+		Config:          conf,
+		Guard:           tokenGuard,
+		IdentityService: identityService,
+	})
+	seller.NewSellerHandler(router, seller.SellerHandlerDeps{
 		Config:        conf,
-		AuthService:   authService,
-		OAuth2Service: oauth2Service,
+		Guard:         tokenGuard,
+		SellerService: sellerService,
+		Verifier:      verificationService,
+		Permissions:   permissionService,
+	})
+	suspension.NewSuspensionHandler(router, suspension.SuspensionHandlerDeps{
+		Config:            conf,
+		Guard:             tokenGuard,
+		SuspensionService: suspensionService,
+		Permissions:       permissionService,
+	})
+	rbac.NewRBACHandler(router, rbac.RBACHandlerDeps{
+		Config:            conf,
+		Guard:             tokenGuard,
+		PermissionService: permissionService,
 	})
 	link.NewLinkHandler(router, link.LinkHandlerDeps{
 		LinkRepository: linkRepository,
 		EventBus:       eventBus,
 		Config:         conf,
+		Guard:          tokenGuard,
 	})
 	stat.NewStatHandler(router, stat.StatHandlerDeps{
 		StatRepository: statRepository,
 		Config:         conf,
+		Guard:          tokenGuard,
 	})
 	home.NewHomeHandler(router, home.HomeHandlerDeps{
 		HomeService: homeService,
@@ -127,36 +251,71 @@ func App() http.Handler {
 		Config:  conf,
 	})
 	passwordreset.NewResetHandler(router, passwordreset.ResetHandlerDeps{
-		ResetService: resetService,
-		Config:       conf,
+		ResetService:   resetService,
+		Config:         conf,
+		PasswordPolicy: passwordPolicy,
+	})
+	verification.NewVerificationHandler(router, verification.VerificationHandlerDeps{
+		VerificationService: verificationService,
+		Config:              conf,
 	})
 
 	review.NewReviewHandler(router, review.ReviewHandlerDeps{
-		Kafka:  kafkaProducers["reviews"],
-		Config: conf,
+		Kafka:    kafkaProducers["reviews"],
+		Config:   conf,
+		Guard:    tokenGuard,
+		Verifier: verificationService,
 	})
 	question.NewQuestionHandler(router, question.QuestionHandlerDeps{
 		Kafka:  kafkaProducers["reviews"],
 		Config: conf,
+		Guard:  tokenGuard,
 	})
 	notification.NewNotificationHandler(router, notification.NotificationHandlerDeps{
 		Kafka:  kafkaProducers["notifications"],
 		Config: conf,
+		Guard:  tokenGuard,
 	})
 	product.NewProductHandler(router, product.ProductHandlerDeps{
-		Kafka:  kafkaProducers["products"],
-		Config: conf,
+		Kafka:       kafkaProducers["products"],
+		Config:      conf,
+		Guard:       tokenGuard,
+		Permissions: permissionService,
 	})
 	productVariant.NewProductVariantHandler(router, productVariant.ProductVariantHandlerDeps{
-		Kafka:  kafkaProducers["productVariants"],
-		Config: conf,
+		Kafka:       kafkaProducers["productVariants"],
+		Config:      conf,
+		Guard:       tokenGuard,
+		Permissions: permissionService,
 	})
 
 	chat.NewChatHandler(router, chat.ChatHandlerDeps{
 		ChatService: chatService,
 		Config:      conf,
+		Guard:       tokenGuard,
+		Permissions: permissionService,
+	})
+	admin.NewAdminHandler(router, admin.AdminHandlerDeps{
+		Config:      conf,
+		Guard:       tokenGuard,
+		Permissions: permissionService,
+		Audit:       auditService,
+		Users:       userRepository,
+		Products:    productRepository,
+	})
+	audit.NewAuditHandler(router, audit.AuditHandlerDeps{
+		Config:       conf,
+		Guard:        tokenGuard,
+		AuditService: auditService,
+		Permissions:  permissionService,
+	})
+
+	account.NewAccountHandler(router, account.AccountHandlerDeps{
+		Config:         conf,
+		Guard:          tokenGuard,
+		AccountService: accountService,
+		PasswordPolicy: passwordPolicy,
 	})
-	admin.NewAdminHandler(router)
 
 	// swagger
 	router.PathPrefix("/swagger/").Handler(httpSwagger.WrapHandler)
@@ -165,13 +324,19 @@ func App() http.Handler {
 
 	//обработчик подписки ( бесконечно сидит отдельно и ждёт пока не придут сообщения)
 	go statService.AddClick()
+	// сборка архивов с персональными данными
+	go accountService.Run()
 
 	//Middlewares
 	stack := middleware.Chain(
 		middleware.CORS,
+		middleware.RequestID,
 		middleware.Logging,
 	)
 
+	// шаблон маршрута в полях логгера запроса
+	router.Use(middleware.Route)
+
 	// Обработка статических файлов (например, /static/js/notifications.js)
 	router.PathPrefix("/static/").Handler(http.StripPrefix("/static/", http.FileServer(http.Dir("static"))))
 
@@ -16,12 +16,36 @@ type Config struct {
 	Redis        RedisConfig
 	OAuth        OAuthConfig
 	Google       GoogleConfig
+	Providers    []OAuthProviderConfig
 	Code         CodeConfig
+	Verification VerificationConfig
+	MFA          MFAConfig
 	Kafka        KafkaConfig
+	Media        MediaConfig
+	Chat         ChatConfig
+	Password     PasswordConfig
 	LogLevel     logger.LogLevel
 	FileLogLevel logger.LogLevel
+	// LogRedactKeys — дополнительные ключи, значения которых маскируются в логах
+	LogRedactKeys []string
+	LogFormat     logger.Format // "text" или "json" (одна запись — одна строка)
+	LogOutput     LogOutput
+	LogRetention  logger.Retention
 }
 
+// LogOutput — куда пишутся логи.
+type LogOutput string
+
+const (
+	LogOutputConsole LogOutput = "console"
+	LogOutputFile    LogOutput = "file"
+	LogOutputBoth    LogOutput = "both"
+)
+
+func (o LogOutput) Console() bool { return o != LogOutputFile }
+
+func (o LogOutput) File() bool { return o != LogOutputConsole }
+
 type DbConfig struct {
 	Dsn string
 }
@@ -44,12 +68,73 @@ type CodeConfig struct {
 	RateLimitTTL time.Duration
 }
 
+type VerificationConfig struct {
+	LinkURL           string   // базовый URL страницы подтверждения, к нему добавляется ?token=
+	RestrictedActions []string // действия, запрещенные до подтверждения email: "review", "seller_upgrade"
+	Secret            string   // ключ HMAC для кодов в Redis и подписи ссылок подтверждения
+}
+
+type MFAConfig struct {
+	Issuer        string        // имя сервиса в приложении-аутентификаторе
+	RequiredRoles []string      // роли, которым 2FA обязательна, например "seller,admin"
+	ChallengeTTL  time.Duration // время жизни MFA challenge-токена между шагами входа
+	LockoutTTL    time.Duration // блокировка проверки кода после серии неверных попыток
+	SecretKey     string        // ключ шифрования TOTP-секретов в базе
+}
+
+type PasswordConfig struct {
+	MinLength       int
+	RequiredClasses []string // "letter", "lower", "upper", "digit", "symbol"
+	BreachedListDir string   // каталог с диапазонами SHA-1 утекших паролей; пусто — проверка отключена
+}
+
+// Стратегии автоматического назначения менеджера в чате
+const (
+	ChatAssignLeastBusy  = "least_busy"  // менеджеру с наименьшим числом активных сессий
+	ChatAssignRoundRobin = "round_robin" // по кругу среди свободных
+)
+
+type ChatConfig struct {
+	AutoAssign            bool   // раздавать ожидающих менеджерам автоматически; ручной take работает всегда
+	AssignStrategy        string // ChatAssignLeastBusy или ChatAssignRoundRobin
+	MaxSessionsPerManager int    // предел одновременных сессий при автоназначении, 0 — без предела
+	ReplicaID             string // имя реплики в учете соединений, по умолчанию hostname (имя пода)
+}
+
+type MediaConfig struct {
+	URL           string // эндпоинт загрузки Media Service
+	MaxUploadSize int64  // предел файла во вложениях чата, байт
+	ClamAVAddr    string // host:port clamd; пусто — файлы не проверяются антивирусом
+}
+
 type GoogleConfig struct {
 	ClientID     string
 	ClientSecret string
 	RedirectURL  string
 }
 
+// OAuthProviderConfig описывает внешний провайдер входа. Если задан Issuer,
+// эндпоинты берутся из OIDC discovery; явно заданные URL имеют приоритет.
+type OAuthProviderConfig struct {
+	Name         string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+
+	Issuer      string
+	AuthURL     string
+	TokenURL    string
+	UserInfoURL string
+
+	// Имена полей ответа userinfo; по умолчанию стандартные claims OIDC
+	SubjectClaim       string
+	EmailClaim         string
+	EmailVerifiedClaim string
+	NameClaim          string
+	TrustEmail         bool // провайдер отдает только подтвержденные email (GitHub, VK)
+}
+
 type KafkaConfig struct {
 	Brokers []string
 	Topics  map[string]string // например: {"notifications": "notifications-topic", "reviews": "review-events"}
@@ -113,6 +198,92 @@ func LoadConfig() *Config {
 			logger.Error("Invalid CODE_RATE_LIMIT_TTL, using default 24h", err.Error())
 		}
 	}
+	restrictedActions := []string{"review", "seller_upgrade"}
+	if raw, ok := os.LookupEnv("UNVERIFIED_RESTRICTED_ACTIONS"); ok {
+		restrictedActions = parseList(raw)
+	}
+	mfaIssuer := os.Getenv("MFA_ISSUER")
+	if mfaIssuer == "" {
+		mfaIssuer = "ShopOnGO"
+	}
+	mfaChallengeTTL := 5 * time.Minute
+	if raw := os.Getenv("MFA_CHALLENGE_TTL"); raw != "" {
+		if val, err := time.ParseDuration(raw); err == nil {
+			mfaChallengeTTL = val
+		} else {
+			logger.Error("Invalid MFA_CHALLENGE_TTL, using default 5m", err.Error())
+		}
+	}
+	mfaLockoutTTL := 15 * time.Minute
+	if raw := os.Getenv("MFA_LOCKOUT_TTL"); raw != "" {
+		if val, err := time.ParseDuration(raw); err == nil {
+			mfaLockoutTTL = val
+		} else {
+			logger.Error("Invalid MFA_LOCKOUT_TTL, using default 15m", err.Error())
+		}
+	}
+	verifySecret := os.Getenv("EMAIL_VERIFY_SECRET")
+	if verifySecret == "" {
+		logger.Error("EMAIL_VERIFY_SECRET is not set, verification codes and links are signed with an empty key")
+	}
+	mfaSecretKey := os.Getenv("MFA_SECRET_KEY")
+	if mfaSecretKey == "" {
+		logger.Error("MFA_SECRET_KEY is not set, 2FA enrollment is unavailable")
+	}
+	mediaURL := os.Getenv("MEDIA_SERVICE_URL")
+	if mediaURL == "" {
+		mediaURL = "http://media_container:8084/media-service/uploads"
+	}
+	mediaMaxUpload := int64(10 << 20)
+	if raw := os.Getenv("MEDIA_MAX_UPLOAD_MB"); raw != "" {
+		if val, err := strconv.ParseInt(raw, 10, 64); err == nil && val > 0 {
+			mediaMaxUpload = val << 20
+		} else {
+			logger.Error("Invalid MEDIA_MAX_UPLOAD_MB, using default 10")
+		}
+	}
+	chatConfig := ChatConfig{
+		AutoAssign:            true,
+		AssignStrategy:        ChatAssignLeastBusy,
+		MaxSessionsPerManager: 5,
+	}
+	if raw := os.Getenv("CHAT_AUTO_ASSIGN"); raw != "" {
+		if val, err := strconv.ParseBool(raw); err == nil {
+			chatConfig.AutoAssign = val
+		} else {
+			logger.Error("Invalid CHAT_AUTO_ASSIGN, using default true")
+		}
+	}
+	switch strategy := strings.ToLower(os.Getenv("CHAT_ASSIGN_STRATEGY")); strategy {
+	case "":
+	case ChatAssignLeastBusy, ChatAssignRoundRobin:
+		chatConfig.AssignStrategy = strategy
+	default:
+		logger.Error("Invalid CHAT_ASSIGN_STRATEGY, using default least_busy")
+	}
+	if raw := os.Getenv("CHAT_MAX_SESSIONS_PER_MANAGER"); raw != "" {
+		if val, err := strconv.Atoi(raw); err == nil && val >= 0 {
+			chatConfig.MaxSessionsPerManager = val
+		} else {
+			logger.Error("Invalid CHAT_MAX_SESSIONS_PER_MANAGER, using default 5")
+		}
+	}
+	chatConfig.ReplicaID = os.Getenv("CHAT_REPLICA_ID")
+	if chatConfig.ReplicaID == "" {
+		chatConfig.ReplicaID, _ = os.Hostname()
+	}
+	passwordMinLength := 8
+	if raw := os.Getenv("PASSWORD_MIN_LENGTH"); raw != "" {
+		if val, err := strconv.Atoi(raw); err == nil && val > 0 {
+			passwordMinLength = val
+		} else {
+			logger.Error("Invalid PASSWORD_MIN_LENGTH, using default 8")
+		}
+	}
+	passwordClasses := []string{"letter", "digit"}
+	if raw, ok := os.LookupEnv("PASSWORD_REQUIRED_CLASSES"); ok {
+		passwordClasses = parseList(raw)
+	}
 	brokersRaw := os.Getenv("KAFKA_BROKERS")
 	brokers := strings.Split(brokersRaw, ",")
 	// logger
@@ -126,6 +297,46 @@ func LoadConfig() *Config {
 		fileLogLevelStr = "INFO"
 	}
 	FileLogLevel := ParseLogLevel(fileLogLevelStr)
+	logOutput := LogOutput(strings.ToLower(os.Getenv("LOG_OUTPUT")))
+	switch logOutput {
+	case LogOutputConsole, LogOutputFile, LogOutputBoth:
+	default:
+		logOutput = LogOutputBoth
+	}
+	logRetention := logger.Retention{
+		MaxAge:       7 * 24 * time.Hour,
+		MaxTotalSize: 1024 << 20,
+		MaxFileSize:  100 << 20,
+		Compress:     true,
+	}
+	if raw := os.Getenv("LOG_MAX_AGE"); raw != "" {
+		if val, err := time.ParseDuration(raw); err == nil && val >= 0 {
+			logRetention.MaxAge = val
+		} else {
+			logger.Error("Invalid LOG_MAX_AGE, using default 168h")
+		}
+	}
+	if raw := os.Getenv("LOG_MAX_TOTAL_SIZE_MB"); raw != "" {
+		if val, err := strconv.ParseInt(raw, 10, 64); err == nil && val >= 0 {
+			logRetention.MaxTotalSize = val << 20
+		} else {
+			logger.Error("Invalid LOG_MAX_TOTAL_SIZE_MB, using default 1024")
+		}
+	}
+	if raw := os.Getenv("LOG_MAX_FILE_SIZE_MB"); raw != "" {
+		if val, err := strconv.ParseInt(raw, 10, 64); err == nil && val >= 0 {
+			logRetention.MaxFileSize = val << 20
+		} else {
+			logger.Error("Invalid LOG_MAX_FILE_SIZE_MB, using default 100")
+		}
+	}
+	if raw := os.Getenv("LOG_COMPRESS"); raw != "" {
+		if val, err := strconv.ParseBool(raw); err == nil {
+			logRetention.Compress = val
+		} else {
+			logger.Error("Invalid LOG_COMPRESS, using default true")
+		}
+	}
 
 	return &Config{
 		Db: DbConfig{
@@ -152,12 +363,40 @@ func LoadConfig() *Config {
 			MaxRequests:  maxRequests,
 			RateLimitTTL: rateLimitTTL,
 		},
+		Verification: VerificationConfig{
+			LinkURL:           os.Getenv("EMAIL_VERIFY_URL"),
+			RestrictedActions: restrictedActions,
+			Secret:            verifySecret,
+		},
+		MFA: MFAConfig{
+			Issuer:        mfaIssuer,
+			RequiredRoles: parseList(os.Getenv("MFA_REQUIRED_ROLES")),
+			ChallengeTTL:  mfaChallengeTTL,
+			LockoutTTL:    mfaLockoutTTL,
+			SecretKey:     mfaSecretKey,
+		},
+		Providers: loadOAuthProviders(parseList(os.Getenv("OAUTH_PROVIDERS"))),
 		Kafka: KafkaConfig{
 			Brokers: brokers,
 			Topics:  parseKafkaTopics(os.Getenv("KAFKA_TOPICS")),
 		},
-		LogLevel:     LogLevel,
-		FileLogLevel: FileLogLevel,
+		Media: MediaConfig{
+			URL:           mediaURL,
+			MaxUploadSize: mediaMaxUpload,
+			ClamAVAddr:    os.Getenv("CLAMAV_ADDR"),
+		},
+		Chat: chatConfig,
+		Password: PasswordConfig{
+			MinLength:       passwordMinLength,
+			RequiredClasses: passwordClasses,
+			BreachedListDir: os.Getenv("PASSWORD_BREACHED_DIR"),
+		},
+		LogLevel:      LogLevel,
+		FileLogLevel:  FileLogLevel,
+		LogRedactKeys: parseList(os.Getenv("LOG_REDACT_KEYS")),
+		LogFormat:     logger.ParseFormat(os.Getenv("LOG_FORMAT")),
+		LogOutput:     logOutput,
+		LogRetention:  logRetention,
 	}
 
 }
@@ -173,6 +412,45 @@ func parseKafkaTopics(s string) map[string]string {
 	return topics
 }
 
+// loadOAuthProviders читает настройки провайдеров из OAUTH_<NAME>_* для каждого имени из OAUTH_PROVIDERS.
+func loadOAuthProviders(names []string) []OAuthProviderConfig {
+	var providers []OAuthProviderConfig
+	for _, name := range names {
+		prefix := "OAUTH_" + strings.ToUpper(name) + "_"
+		env := func(key string) string { return os.Getenv(prefix + key) }
+
+		trustEmail, _ := strconv.ParseBool(env("TRUST_EMAIL"))
+		providers = append(providers, OAuthProviderConfig{
+			Name:               strings.ToLower(name),
+			ClientID:           env("CLIENT_ID"),
+			ClientSecret:       env("CLIENT_SECRET"),
+			RedirectURL:        env("REDIRECT_URL"),
+			Scopes:             parseList(env("SCOPES")),
+			Issuer:             env("ISSUER"),
+			AuthURL:            env("AUTH_URL"),
+			TokenURL:           env("TOKEN_URL"),
+			UserInfoURL:        env("USERINFO_URL"),
+			SubjectClaim:       env("SUBJECT_CLAIM"),
+			EmailClaim:         env("EMAIL_CLAIM"),
+			EmailVerifiedClaim: env("EMAIL_VERIFIED_CLAIM"),
+			NameClaim:          env("NAME_CLAIM"),
+			TrustEmail:         trustEmail,
+		})
+	}
+	return providers
+}
+
+// parseList разбирает список значений, разделенных запятыми, пропуская пустые.
+func parseList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 // parseLogLevel преобразует строку в LogLevel.
 func ParseLogLevel(s string) logger.LogLevel {
 	switch s {
@@ -0,0 +1,76 @@
+package account
+
+import "github.com/ShopOnGO/ShopOnGO/pkg/logger"
+
+func (service *AccountService) ListAddresses(userID uint) ([]Address, error) {
+	return service.Repository.ListAddresses(userID)
+}
+
+// CreateAddress добавляет адрес. Первый адрес пользователя всегда становится основным.
+func (service *AccountService) CreateAddress(userID uint, data *AddressRequest) (*Address, error) {
+	count, err := service.Repository.CountAddresses(userID)
+	if err != nil {
+		return nil, err
+	}
+	if count >= maxAddresses {
+		return nil, ErrAddressLimit
+	}
+
+	address := &Address{UserID: userID}
+	applyAddress(address, data)
+	if count == 0 {
+		address.IsDefault = true
+	}
+	if err := service.Repository.SaveAddress(address); err != nil {
+		return nil, err
+	}
+	logger.Infof("🏠 Пользователь %d добавил адрес %d", userID, address.ID)
+	return address, nil
+}
+
+// UpdateAddress заменяет поля адреса. Снять флаг основного можно только
+// назначив основным другой адрес.
+func (service *AccountService) UpdateAddress(userID, id uint, data *AddressRequest) (*Address, error) {
+	address, err := service.Repository.FindAddress(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	wasDefault := address.IsDefault
+	applyAddress(address, data)
+	address.IsDefault = address.IsDefault || wasDefault
+	if err := service.Repository.SaveAddress(address); err != nil {
+		return nil, err
+	}
+	return address, nil
+}
+
+func (service *AccountService) SetDefaultAddress(userID, id uint) (*Address, error) {
+	address, err := service.Repository.FindAddress(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if address.IsDefault {
+		return address, nil
+	}
+	address.IsDefault = true
+	if err := service.Repository.SaveAddress(address); err != nil {
+		return nil, err
+	}
+	return address, nil
+}
+
+func (service *AccountService) DeleteAddress(userID, id uint) error {
+	return service.Repository.DeleteAddress(userID, id)
+}
+
+func applyAddress(address *Address, data *AddressRequest) {
+	address.Label = data.Label
+	address.Recipient = data.Recipient
+	address.Phone = data.Phone
+	address.Country = data.Country
+	address.Region = data.Region
+	address.City = data.City
+	address.Street = data.Street
+	address.PostalCode = data.PostalCode
+	address.IsDefault = data.IsDefault
+}
@@ -0,0 +1,79 @@
+package account
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ShopOnGO/ShopOnGO/pkg/redisdb"
+	"github.com/go-redis/redis/v8"
+)
+
+// RedisEmailChangeStore хранит ожидающую смену email и счетчик неверных кодов.
+type RedisEmailChangeStore struct {
+	redis *redisdb.RedisDB
+}
+
+func NewRedisEmailChangeStore(r *redisdb.RedisDB) *RedisEmailChangeStore {
+	return &RedisEmailChangeStore{redis: r}
+}
+
+// SavePending заменяет предыдущий запрос и обнуляет счетчик попыток.
+func (s *RedisEmailChangeStore) SavePending(userID uint, pending *PendingEmailChange) error {
+	data, err := json.Marshal(pending)
+	if err != nil {
+		return err
+	}
+	ttl := time.Until(pending.ExpiresAt)
+	if ttl <= 0 {
+		ttl = time.Minute
+	}
+	_, err = s.redis.TxPipelined(context.Background(), func(pipe redis.Pipeliner) error {
+		pipe.Set(context.Background(), s.key(userID), data, ttl)
+		pipe.Del(context.Background(), s.attemptsKey(userID))
+		return nil
+	})
+	return err
+}
+
+// GetPending возвращает nil, nil, если запроса нет или код истек.
+func (s *RedisEmailChangeStore) GetPending(userID uint) (*PendingEmailChange, error) {
+	data, err := s.redis.Get(context.Background(), s.key(userID)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var pending PendingEmailChange
+	if err := json.Unmarshal(data, &pending); err != nil {
+		return nil, err
+	}
+	return &pending, nil
+}
+
+func (s *RedisEmailChangeStore) DeletePending(userID uint) error {
+	return s.redis.Del(context.Background(), s.key(userID), s.attemptsKey(userID)).Err()
+}
+
+func (s *RedisEmailChangeStore) IncrementAttempts(userID uint, ttl time.Duration) (int, error) {
+	key := s.attemptsKey(userID)
+	attempts, err := s.redis.Incr(context.Background(), key).Result()
+	if err != nil {
+		return 0, err
+	}
+	if attempts == 1 {
+		s.redis.Expire(context.Background(), key, ttl)
+	}
+	return int(attempts), nil
+}
+
+func (s *RedisEmailChangeStore) key(userID uint) string {
+	return fmt.Sprintf("email_change:%d", userID)
+}
+
+func (s *RedisEmailChangeStore) attemptsKey(userID uint) string {
+	return fmt.Sprintf("email_change_attempts:%d", userID)
+}
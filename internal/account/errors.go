@@ -0,0 +1,22 @@
+package account
+
+import "errors"
+
+var (
+	ErrUserNotFound   = errors.New("user not found")
+	ErrWrongPassword  = errors.New("wrong password")
+	ErrNoPassword     = errors.New("account has no password, use password reset to set one")
+	ErrSamePassword   = errors.New("new password must differ from the current one")
+	ErrExportNotFound = errors.New("no data export requested")
+	ErrExportNotReady = errors.New("data export is not ready yet")
+
+	ErrEmailTaken          = errors.New("email is already in use")
+	ErrSameEmail           = errors.New("new email matches the current one")
+	ErrNoPendingEmail      = errors.New("no pending email change")
+	ErrInvalidCode         = errors.New("invalid confirmation code")
+	ErrTooManyAttempts     = errors.New("too many attempts, request a new code")
+	ErrStoreFieldsReadonly = errors.New("store fields can be changed by sellers only")
+	ErrInvalidImage        = errors.New("profile image must be a JPEG, PNG, GIF or WebP file")
+	ErrAddressNotFound     = errors.New("address not found")
+	ErrAddressLimit        = errors.New("address book is full")
+)
@@ -0,0 +1,443 @@
+package account
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ShopOnGO/ShopOnGO/configs"
+	"github.com/ShopOnGO/ShopOnGO/pkg/logger"
+	"github.com/ShopOnGO/ShopOnGO/pkg/middleware"
+	"github.com/ShopOnGO/ShopOnGO/pkg/password"
+	"github.com/ShopOnGO/ShopOnGO/pkg/req"
+	"github.com/ShopOnGO/ShopOnGO/pkg/res"
+	"github.com/gorilla/mux"
+)
+
+// maxProfileImageSize — предельный размер фото профиля.
+const maxProfileImageSize = 5 << 20
+
+type AccountHandlerDeps struct {
+	*configs.Config
+	Guard *middleware.TokenGuard
+	*AccountService
+	PasswordPolicy *password.Policy
+}
+
+type AccountHandler struct {
+	*configs.Config
+	*AccountService
+	PasswordPolicy *password.Policy
+}
+
+func NewAccountHandler(router *mux.Router, deps AccountHandlerDeps) {
+	handler := &AccountHandler{
+		Config:         deps.Config,
+		AccountService: deps.AccountService,
+		PasswordPolicy: deps.PasswordPolicy,
+	}
+	router.Handle("/me/export", middleware.IsAuthed(handler.Export(), deps.Config, deps.Guard)).Methods("GET")
+	router.Handle("/me/export/download", middleware.IsAuthed(handler.DownloadExport(), deps.Config, deps.Guard)).Methods("GET")
+	router.Handle("/me", middleware.IsAuthed(handler.Delete(), deps.Config, deps.Guard)).Methods("DELETE")
+
+	router.Handle("/me", middleware.IsAuthed(handler.GetProfile(), deps.Config, deps.Guard)).Methods("GET")
+	router.Handle("/me", middleware.IsAuthed(handler.UpdateProfile(), deps.Config, deps.Guard)).Methods("PATCH")
+	router.Handle("/me/password", middleware.IsAuthed(handler.ChangePassword(), deps.Config, deps.Guard)).Methods("POST")
+	router.Handle("/me/email/confirm", middleware.IsAuthed(handler.ConfirmEmail(), deps.Config, deps.Guard)).Methods("POST")
+	router.Handle("/me/profile-image", middleware.IsAuthed(handler.UploadProfileImage(), deps.Config, deps.Guard)).Methods("POST")
+
+	router.Handle("/me/addresses", middleware.IsAuthed(handler.ListAddresses(), deps.Config, deps.Guard)).Methods("GET")
+	router.Handle("/me/addresses", middleware.IsAuthed(handler.CreateAddress(), deps.Config, deps.Guard)).Methods("POST")
+	router.Handle("/me/addresses/{id:[0-9]+}", middleware.IsAuthed(handler.UpdateAddress(), deps.Config, deps.Guard)).Methods("PUT")
+	router.Handle("/me/addresses/{id:[0-9]+}", middleware.IsAuthed(handler.DeleteAddress(), deps.Config, deps.Guard)).Methods("DELETE")
+	router.Handle("/me/addresses/{id:[0-9]+}/default", middleware.IsAuthed(handler.SetDefaultAddress(), deps.Config, deps.Guard)).Methods("POST")
+}
+
+// Export starts or reports a personal data export.
+// @Summary      Выгрузка персональных данных
+// @Description  Ставит в очередь сборку ZIP-архива с профилем, корзинами, избранным, отзывами, вопросами и сообщениями чата. Повторный вызов возвращает статус текущего задания. Готовый архив хранится 24 часа.
+// @Tags         account
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Success      200  {object}  ExportStatusResponse  "Архив готов"
+// @Success      202  {object}  ExportStatusResponse  "Выгрузка в процессе"
+// @Failure      404  {string}  string  "Пользователь не найден"
+// @Router       /me/export [get]
+func (h *AccountHandler) Export() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, _ := r.Context().Value(middleware.ContextUserIDKey).(uint)
+
+		job, err := h.AccountService.RequestExport(userID)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		resp := ExportStatusResponse{ExportJob: job}
+		status := http.StatusAccepted
+		if job.Status == ExportDone {
+			resp.DownloadURL = "/me/export/download"
+			status = http.StatusOK
+		}
+		res.Json(w, resp, status)
+	}
+}
+
+// DownloadExport returns the finished archive.
+// @Summary      Скачать выгрузку
+// @Description  Возвращает готовый ZIP-архив с персональными данными.
+// @Tags         account
+// @Produce      application/zip
+// @Security     ApiKeyAuth
+// @Success      200  {file}    file
+// @Failure      404  {string}  string  "Выгрузка не запрашивалась или истекла"
+// @Failure      409  {string}  string  "Выгрузка еще не готова"
+// @Router       /me/export/download [get]
+func (h *AccountHandler) DownloadExport() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, _ := r.Context().Value(middleware.ContextUserIDKey).(uint)
+
+		archive, err := h.AccountService.Download(userID)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="shopongo-export-%d.zip"`, userID))
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(archive)
+	}
+}
+
+// Delete anonymizes the caller's account.
+// @Summary      Удалить аккаунт
+// @Description  Обезличивает аккаунт: персональные данные стираются, email заменяется, все сессии завершаются. Отзывы, вопросы и заказы сохраняются без привязки к личности. Требует пароль (если он задан) и confirm = "DELETE".
+// @Tags         account
+// @Accept       json
+// @Security     ApiKeyAuth
+// @Param        body  body  DeleteAccountRequest  true  "Подтверждение"
+// @Success      204
+// @Failure      401  {string}  string  "Неверный пароль"
+// @Failure      404  {string}  string  "Пользователь не найден"
+// @Router       /me [delete]
+func (h *AccountHandler) Delete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := req.HandleBody[DeleteAccountRequest](&w, r)
+		if err != nil {
+			return
+		}
+		userID, _ := r.Context().Value(middleware.ContextUserIDKey).(uint)
+
+		if err := h.AccountService.DeleteAccount(r.Context(), userID, body.Password); err != nil {
+			writeError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// GetProfile returns the caller's profile.
+// @Summary      Профиль
+// @Description  Возвращает профиль текущего пользователя и email, ожидающий подтверждения.
+// @Tags         account
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Success      200  {object}  ProfileResponse
+// @Failure      404  {string}  string  "Пользователь не найден"
+// @Router       /me [get]
+func (h *AccountHandler) GetProfile() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, _ := r.Context().Value(middleware.ContextUserIDKey).(uint)
+
+		profile, err := h.AccountService.GetProfile(userID)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		res.Json(w, profile, http.StatusOK)
+	}
+}
+
+// UpdateProfile edits profile fields.
+// @Summary      Изменить профиль
+// @Description  Меняет только переданные поля. Поля магазина доступны продавцам. Новый email вступает в силу после подтверждения кодом из письма (POST /me/email/confirm).
+// @Tags         account
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        body  body  UpdateProfileRequest  true  "Изменяемые поля"
+// @Success      200  {object}  ProfileResponse
+// @Failure      400  {string}  string  "Email совпадает с текущим"
+// @Failure      403  {string}  string  "Поля магазина доступны только продавцам"
+// @Failure      409  {string}  string  "Email уже занят"
+// @Router       /me [patch]
+func (h *AccountHandler) UpdateProfile() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := req.HandleBody[UpdateProfileRequest](&w, r)
+		if err != nil {
+			return
+		}
+		userID, _ := r.Context().Value(middleware.ContextUserIDKey).(uint)
+
+		profile, err := h.AccountService.UpdateProfile(r.Context(), userID, body)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		res.Json(w, profile, http.StatusOK)
+	}
+}
+
+// ChangePassword changes the caller's password.
+// @Summary      Сменить пароль
+// @Description  Меняет пароль после проверки текущего. Новый пароль проверяется по политике (длина, классы символов, список утечек). Все остальные сессии завершаются, текущая получает новую пару токенов: access-токен в теле, refresh-токен в cookie.
+// @Tags         account
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        body  body  ChangePasswordRequest  true  "Текущий и новый пароль"
+// @Success      200  {object}  ChangePasswordResponse
+// @Failure      400  {string}  string  "Пароль не соответствует политике или совпадает с текущим"
+// @Failure      401  {string}  string  "Неверный текущий пароль"
+// @Failure      409  {string}  string  "У аккаунта нет пароля"
+// @Router       /me/password [post]
+func (h *AccountHandler) ChangePassword() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := req.HandleBody[ChangePasswordRequest](&w, r)
+		if err != nil {
+			return
+		}
+		if err := h.PasswordPolicy.Validate(body.NewPassword); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		userID, _ := r.Context().Value(middleware.ContextUserIDKey).(uint)
+
+		accessToken, refreshToken, err := h.AccountService.ChangePassword(userID, body.CurrentPassword, body.NewPassword)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		http.SetCookie(w, &http.Cookie{
+			Name:     "refresh_token",
+			Value:    refreshToken,
+			HttpOnly: true,
+			Path:     "/",
+			Expires:  time.Now().Add(h.Config.Redis.RefreshTokenTTL),
+		})
+		res.Json(w, ChangePasswordResponse{Token: accessToken}, http.StatusOK)
+	}
+}
+
+// ConfirmEmail applies a pending email change.
+// @Summary      Подтвердить новый email
+// @Description  Применяет новый email по коду из письма. После 5 неверных попыток запрос смены отменяется.
+// @Tags         account
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        body  body  ConfirmEmailRequest  true  "Код из письма"
+// @Success      200  {object}  ProfileResponse
+// @Failure      400  {string}  string  "Неверный код"
+// @Failure      404  {string}  string  "Нет запроса на смену email"
+// @Failure      409  {string}  string  "Email уже занят"
+// @Failure      429  {string}  string  "Слишком много попыток"
+// @Router       /me/email/confirm [post]
+func (h *AccountHandler) ConfirmEmail() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := req.HandleBody[ConfirmEmailRequest](&w, r)
+		if err != nil {
+			return
+		}
+		userID, _ := r.Context().Value(middleware.ContextUserIDKey).(uint)
+
+		profile, err := h.AccountService.ConfirmEmail(userID, body.Code)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		res.Json(w, profile, http.StatusOK)
+	}
+}
+
+// UploadProfileImage uploads a new avatar.
+// @Summary      Загрузить фото профиля
+// @Description  Принимает изображение (JPEG, PNG, GIF, WebP, до 5 МБ) в поле file, загружает его в Media Service и сохраняет ссылку в профиле.
+// @Tags         account
+// @Accept       multipart/form-data
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        file  formData  file  true  "Изображение"
+// @Success      200  {object}  ProfileResponse
+// @Failure      400  {string}  string  "Файл не является изображением"
+// @Router       /me/profile-image [post]
+func (h *AccountHandler) UploadProfileImage() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		r.Body = http.MaxBytesReader(w, r.Body, maxProfileImageSize+1<<10)
+		if err := r.ParseMultipartForm(maxProfileImageSize); err != nil {
+			http.Error(w, "file too big", http.StatusBadRequest)
+			return
+		}
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			http.Error(w, "file is required", http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+		userID, _ := r.Context().Value(middleware.ContextUserIDKey).(uint)
+
+		profile, err := h.AccountService.UploadProfileImage(userID, file)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		res.Json(w, profile, http.StatusOK)
+	}
+}
+
+// ListAddresses returns the address book.
+// @Summary      Адреса доставки
+// @Description  Возвращает адреса пользователя, основной адрес — первым.
+// @Tags         account
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Success      200  {object}  AddressListResponse
+// @Router       /me/addresses [get]
+func (h *AccountHandler) ListAddresses() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, _ := r.Context().Value(middleware.ContextUserIDKey).(uint)
+
+		addresses, err := h.AccountService.ListAddresses(userID)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		res.Json(w, AddressListResponse{Addresses: addresses}, http.StatusOK)
+	}
+}
+
+// CreateAddress adds an address.
+// @Summary      Добавить адрес
+// @Description  Добавляет адрес доставки. Первый адрес становится основным автоматически. Не больше 20 адресов.
+// @Tags         account
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        body  body  AddressRequest  true  "Адрес"
+// @Success      201  {object}  Address
+// @Failure      409  {string}  string  "Адресная книга заполнена"
+// @Router       /me/addresses [post]
+func (h *AccountHandler) CreateAddress() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := req.HandleBody[AddressRequest](&w, r)
+		if err != nil {
+			return
+		}
+		userID, _ := r.Context().Value(middleware.ContextUserIDKey).(uint)
+
+		address, err := h.AccountService.CreateAddress(userID, body)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		res.Json(w, address, http.StatusCreated)
+	}
+}
+
+// UpdateAddress replaces an address.
+// @Summary      Изменить адрес
+// @Tags         account
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id    path  int             true  "ID адреса"
+// @Param        body  body  AddressRequest  true  "Адрес"
+// @Success      200  {object}  Address
+// @Failure      404  {string}  string  "Адрес не найден"
+// @Router       /me/addresses/{id} [put]
+func (h *AccountHandler) UpdateAddress() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := req.HandleBody[AddressRequest](&w, r)
+		if err != nil {
+			return
+		}
+		userID, _ := r.Context().Value(middleware.ContextUserIDKey).(uint)
+		id, _ := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+
+		address, err := h.AccountService.UpdateAddress(userID, uint(id), body)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		res.Json(w, address, http.StatusOK)
+	}
+}
+
+// SetDefaultAddress marks an address as default.
+// @Summary      Сделать адрес основным
+// @Tags         account
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id  path  int  true  "ID адреса"
+// @Success      200  {object}  Address
+// @Failure      404  {string}  string  "Адрес не найден"
+// @Router       /me/addresses/{id}/default [post]
+func (h *AccountHandler) SetDefaultAddress() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, _ := r.Context().Value(middleware.ContextUserIDKey).(uint)
+		id, _ := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+
+		address, err := h.AccountService.SetDefaultAddress(userID, uint(id))
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		res.Json(w, address, http.StatusOK)
+	}
+}
+
+// DeleteAddress removes an address.
+// @Summary      Удалить адрес
+// @Description  Удаляет адрес. Если он был основным, основным становится самый новый из оставшихся.
+// @Tags         account
+// @Security     ApiKeyAuth
+// @Param        id  path  int  true  "ID адреса"
+// @Success      204
+// @Failure      404  {string}  string  "Адрес не найден"
+// @Router       /me/addresses/{id} [delete]
+func (h *AccountHandler) DeleteAddress() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, _ := r.Context().Value(middleware.ContextUserIDKey).(uint)
+		id, _ := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+
+		if err := h.AccountService.DeleteAddress(userID, uint(id)); err != nil {
+			writeError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, ErrUserNotFound), errors.Is(err, ErrExportNotFound),
+		errors.Is(err, ErrNoPendingEmail), errors.Is(err, ErrAddressNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, ErrWrongPassword):
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+	case errors.Is(err, ErrExportNotReady), errors.Is(err, ErrEmailTaken), errors.Is(err, ErrAddressLimit),
+		errors.Is(err, ErrNoPassword):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, ErrSameEmail), errors.Is(err, ErrInvalidCode), errors.Is(err, ErrInvalidImage),
+		errors.Is(err, ErrSamePassword):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, ErrStoreFieldsReadonly):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, ErrTooManyAttempts):
+		http.Error(w, err.Error(), http.StatusTooManyRequests)
+	default:
+		logger.FromContext(r.Context()).Errorw("❌ account error", logger.Err(err))
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,70 @@
+package account
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ShopOnGO/ShopOnGO/pkg/redisdb"
+	"github.com/go-redis/redis/v8"
+)
+
+// exportTTL — сколько хранятся задание и готовый архив.
+const exportTTL = 24 * time.Hour
+
+// RedisExportStore хранит последнее задание на выгрузку и архив пользователя.
+// У пользователя одновременно существует не больше одной выгрузки.
+type RedisExportStore struct {
+	redis *redisdb.RedisDB
+}
+
+func NewRedisExportStore(r *redisdb.RedisDB) *RedisExportStore {
+	return &RedisExportStore{redis: r}
+}
+
+func (s *RedisExportStore) SaveJob(job *ExportJob) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	return s.redis.Set(context.Background(), s.jobKey(job.UserID), data, exportTTL).Err()
+}
+
+// GetJob возвращает nil, nil, если задания нет или оно истекло.
+func (s *RedisExportStore) GetJob(userID uint) (*ExportJob, error) {
+	data, err := s.redis.Get(context.Background(), s.jobKey(userID)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var job ExportJob
+	if err := json.Unmarshal(data, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (s *RedisExportStore) SaveArchive(userID uint, archive []byte) error {
+	return s.redis.Set(context.Background(), s.fileKey(userID), archive, exportTTL).Err()
+}
+
+// GetArchive возвращает nil, nil, если архив уже удален по TTL.
+func (s *RedisExportStore) GetArchive(userID uint) ([]byte, error) {
+	data, err := s.redis.Get(context.Background(), s.fileKey(userID)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	return data, err
+}
+
+func (s *RedisExportStore) jobKey(userID uint) string {
+	return fmt.Sprintf("export_job:%d", userID)
+}
+
+func (s *RedisExportStore) fileKey(userID uint) string {
+	return fmt.Sprintf("export_file:%d", userID)
+}
@@ -0,0 +1,92 @@
+package account
+
+import (
+	"time"
+
+	"github.com/ShopOnGO/ShopOnGO/internal/cart"
+	"github.com/ShopOnGO/ShopOnGO/internal/chat"
+	"github.com/ShopOnGO/ShopOnGO/internal/favorites"
+	"github.com/ShopOnGO/ShopOnGO/internal/identity"
+	"github.com/ShopOnGO/ShopOnGO/internal/question"
+	"github.com/ShopOnGO/ShopOnGO/internal/review"
+)
+
+const (
+	ExportPending = "pending"
+	ExportRunning = "running"
+	ExportDone    = "done"
+	ExportFailed  = "failed"
+)
+
+// ExportJob — задание на выгрузку персональных данных, хранится в Redis.
+type ExportJob struct {
+	ID          string     `json:"id"`
+	UserID      uint       `json:"user_id"`
+	Status      string     `json:"status"`
+	Error       string     `json:"error,omitempty"`
+	Size        int        `json:"size,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+// Profile — данные пользователя для выгрузки, без хеша пароля и служебных полей.
+type Profile struct {
+	ID              uint       `json:"id"`
+	Name            string     `json:"name"`
+	Email           string     `json:"email"`
+	EmailVerified   bool       `json:"email_verified"`
+	Role            string     `json:"role"`
+	Provider        string     `json:"provider"`
+	Phone           string     `json:"phone,omitempty"`
+	ProfileImage    string     `json:"profile_image,omitempty"`
+	StoreName       *string    `json:"store_name,omitempty"`
+	StoreAddress    *string    `json:"store_address,omitempty"`
+	StorePhone      *string    `json:"store_phone,omitempty"`
+	AcceptTerms     bool       `json:"accept_terms"`
+	CreatedAt       time.Time  `json:"created_at"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
+}
+
+// ExportData — все, что попадает в архив. Каждое поле — отдельный JSON-файл.
+type ExportData struct {
+	Profile    Profile                 `json:"profile"`
+	Identities []identity.UserIdentity `json:"identities"`
+	Addresses  []Address               `json:"addresses"`
+	Carts      []cart.Cart             `json:"carts"`
+	Favorites  []favorites.Favorite    `json:"favorites"`
+	Reviews    []review.Review         `json:"reviews"`
+	Questions  []question.Question     `json:"questions"`
+	Messages   []chat.Message          `json:"messages"`
+}
+
+// maxAddresses — сколько адресов может сохранить один пользователь.
+const maxAddresses = 20
+
+// Address — адрес доставки из адресной книги пользователя. У пользователя
+// не больше одного адреса по умолчанию.
+type Address struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	UserID     uint      `gorm:"not null;index" json:"user_id"`
+	Label      string    `json:"label"`
+	Recipient  string    `gorm:"not null" json:"recipient"`
+	Phone      string    `gorm:"not null" json:"phone"`
+	Country    string    `gorm:"not null" json:"country"`
+	Region     string    `json:"region"`
+	City       string    `gorm:"not null" json:"city"`
+	Street     string    `gorm:"not null" json:"street"`
+	PostalCode string    `gorm:"not null" json:"postal_code"`
+	IsDefault  bool      `gorm:"not null;default:false" json:"is_default"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+func (Address) TableName() string {
+	return "user_addresses"
+}
+
+// PendingEmailChange — ожидающая подтверждения смена email, хранится в Redis.
+type PendingEmailChange struct {
+	Email     string    `json:"email"`
+	CodeHash  string    `json:"code_hash"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
@@ -0,0 +1,42 @@
+package account
+
+import (
+	"fmt"
+
+	"github.com/ShopOnGO/ShopOnGO/pkg/logger"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ChangePassword меняет пароль после проверки текущего и завершает все сессии.
+// Текущая сессия продолжается с новой парой токенов, которую возвращает метод.
+// Соответствие политике паролей проверяет вызывающая сторона.
+func (service *AccountService) ChangePassword(userID uint, currentPassword, newPassword string) (string, string, error) {
+	u, err := service.activeUser(userID)
+	if err != nil {
+		return "", "", err
+	}
+	if u.PasswordHash == "" {
+		return "", "", ErrNoPassword
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(currentPassword)); err != nil {
+		return "", "", ErrWrongPassword
+	}
+	if currentPassword == newPassword {
+		return "", "", ErrSamePassword
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return "", "", fmt.Errorf("hash new password: %w", err)
+	}
+	if err := service.UserRepository.UpdateUserPassword(u.ID, string(hash)); err != nil {
+		return "", "", err
+	}
+	if err := service.Sessions.RevokeAll(u.ID); err != nil {
+		logger.Errorf("❌ не удалось завершить сессии пользователя %d после смены пароля: %v", u.ID, err)
+		return "", "", err
+	}
+	logger.Infof("🔑 Пользователь %d сменил пароль, остальные сессии завершены", u.ID)
+
+	return service.Sessions.GenerateTokens(u.ID, u.Role)
+}
@@ -0,0 +1,58 @@
+package account
+
+type DeleteAccountRequest struct {
+	Password string `json:"password"` // обязателен, если у аккаунта есть пароль
+	Confirm  string `json:"confirm" validate:"required,eq=DELETE"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required"`
+	ConfirmPassword string `json:"confirm_password" validate:"required,eqfield=NewPassword"`
+}
+
+// ChangePasswordResponse — новый access-токен текущей сессии; refresh-токен приходит в cookie.
+type ChangePasswordResponse struct {
+	Token string `json:"token"`
+}
+
+type ExportStatusResponse struct {
+	*ExportJob
+	DownloadURL string `json:"download_url,omitempty"`
+}
+
+// UpdateProfileRequest — частичное обновление профиля: меняются только переданные поля.
+// Новый email применяется после подтверждения кодом (POST /me/email/confirm).
+type UpdateProfileRequest struct {
+	Name         *string `json:"name" validate:"omitempty,min=1,max=100"`
+	Email        *string `json:"email" validate:"omitempty,email,max=255"`
+	Phone        *string `json:"phone" validate:"omitempty,e164"`
+	StoreName    *string `json:"store_name" validate:"omitempty,min=1,max=255"`
+	StoreAddress *string `json:"store_address" validate:"omitempty,max=500"`
+	StorePhone   *string `json:"store_phone" validate:"omitempty,e164"`
+}
+
+type ProfileResponse struct {
+	Profile
+	PendingEmail string `json:"pending_email,omitempty"`
+}
+
+type ConfirmEmailRequest struct {
+	Code string `json:"code" validate:"required,len=6,numeric"`
+}
+
+type AddressRequest struct {
+	Label      string `json:"label" validate:"max=50"`
+	Recipient  string `json:"recipient" validate:"required,max=100"`
+	Phone      string `json:"phone" validate:"required,e164"`
+	Country    string `json:"country" validate:"required,iso3166_1_alpha2"`
+	Region     string `json:"region" validate:"max=100"`
+	City       string `json:"city" validate:"required,max=100"`
+	Street     string `json:"street" validate:"required,max=255"`
+	PostalCode string `json:"postal_code" validate:"required,alphanum,max=12"`
+	IsDefault  bool   `json:"is_default"`
+}
+
+type AddressListResponse struct {
+	Addresses []Address `json:"addresses"`
+}
@@ -0,0 +1,226 @@
+package account
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/ShopOnGO/ShopOnGO/internal/auth/passwordreset"
+	"github.com/ShopOnGO/ShopOnGO/internal/user"
+	"github.com/ShopOnGO/ShopOnGO/pkg/logger"
+)
+
+// maxEmailCodeAttempts ограничивает число неверных попыток ввода кода смены email.
+const maxEmailCodeAttempts = 5
+
+var allowedImageTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// GetProfile возвращает профиль и адрес, ожидающий подтверждения (если есть).
+func (service *AccountService) GetProfile(userID uint) (*ProfileResponse, error) {
+	u, err := service.activeUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	resp := &ProfileResponse{Profile: profileOf(u)}
+	pending, err := service.EmailChanges.GetPending(userID)
+	if err != nil {
+		return nil, err
+	}
+	if pending != nil {
+		resp.PendingEmail = pending.Email
+	}
+	return resp, nil
+}
+
+// UpdateProfile применяет переданные поля. Поля магазина доступны только продавцам.
+// Смена email не применяется сразу: на новый адрес уходит код подтверждения.
+func (service *AccountService) UpdateProfile(ctx context.Context, userID uint, data *UpdateProfileRequest) (*ProfileResponse, error) {
+	u, err := service.activeUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := map[string]any{}
+	if data.Name != nil {
+		fields["name"] = strings.TrimSpace(*data.Name)
+	}
+	if data.Phone != nil {
+		fields["phone"] = *data.Phone
+	}
+	if data.StoreName != nil || data.StoreAddress != nil || data.StorePhone != nil {
+		if u.Role != "seller" {
+			return nil, ErrStoreFieldsReadonly
+		}
+		if data.StoreName != nil {
+			fields["store_name"] = strings.TrimSpace(*data.StoreName)
+		}
+		if data.StoreAddress != nil {
+			fields["store_address"] = *data.StoreAddress
+		}
+		if data.StorePhone != nil {
+			fields["store_phone"] = *data.StorePhone
+		}
+	}
+
+	if data.Email != nil {
+		if err := service.requestEmailChange(ctx, u, *data.Email); err != nil {
+			return nil, err
+		}
+	}
+	if len(fields) > 0 {
+		if err := service.Repository.UpdateProfile(userID, fields); err != nil {
+			return nil, err
+		}
+		logger.Infof("👤 Профиль пользователя %d обновлен", userID)
+	}
+	return service.GetProfile(userID)
+}
+
+func (service *AccountService) requestEmailChange(ctx context.Context, u *user.User, email string) error {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if email == strings.ToLower(u.Email) {
+		return ErrSameEmail
+	}
+	taken, err := service.Repository.EmailTaken(email, u.ID)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return ErrEmailTaken
+	}
+	if service.Notifications == nil {
+		return errors.New("notifications producer is not configured")
+	}
+
+	code, err := passwordreset.GenerateCode()
+	if err != nil {
+		return err
+	}
+	pending := &PendingEmailChange{
+		Email:     email,
+		CodeHash:  service.hashEmailCode(u.ID, email, code),
+		ExpiresAt: service.now().Add(service.Conf.Code.CodeTTL),
+	}
+	if err := service.EmailChanges.SavePending(u.ID, pending); err != nil {
+		return err
+	}
+
+	event := map[string]interface{}{
+		"action":   "create",
+		"category": "AUTHVERIFY",
+		"subtype":  "SEND_EMAIL_CHANGE_CODE",
+		"userID":   u.ID,
+		"wasInDlq": false,
+		"payload": map[string]interface{}{
+			"code":          code,
+			"subject":       "Подтверждение нового email",
+			"expiresAt":     pending.ExpiresAt.Unix(),
+			"email":         email,
+			"previousEmail": u.Email,
+		},
+	}
+	eventBytes, err := json.Marshal(event)
+	if err != nil {
+		logger.Error("❌ ошибка сериализации события: " + err.Error())
+		return err
+	}
+	if err := service.Notifications.Produce(ctx, []byte("email-change"), eventBytes); err != nil {
+		logger.Errorf("❌ ошибка отправки сообщения в Kafka: %v", err)
+		return err
+	}
+	logger.Infof("📨 Код смены email для пользователя %d отправлен в Kafka", u.ID)
+	return nil
+}
+
+// ConfirmEmail применяет новый email, если код верный. Адрес проверяется на
+// занятость повторно: за время ожидания его мог занять другой пользователь.
+func (service *AccountService) ConfirmEmail(userID uint, code string) (*ProfileResponse, error) {
+	if _, err := service.activeUser(userID); err != nil {
+		return nil, err
+	}
+	pending, err := service.EmailChanges.GetPending(userID)
+	if err != nil {
+		return nil, err
+	}
+	if pending == nil {
+		return nil, ErrNoPendingEmail
+	}
+	if !hmac.Equal([]byte(pending.CodeHash), []byte(service.hashEmailCode(userID, pending.Email, code))) {
+		attempts, err := service.EmailChanges.IncrementAttempts(userID, pending.ExpiresAt.Sub(service.now()))
+		if err != nil {
+			return nil, err
+		}
+		if attempts >= maxEmailCodeAttempts {
+			service.EmailChanges.DeletePending(userID)
+			return nil, ErrTooManyAttempts
+		}
+		return nil, ErrInvalidCode
+	}
+
+	taken, err := service.Repository.EmailTaken(pending.Email, userID)
+	if err != nil {
+		return nil, err
+	}
+	if taken {
+		service.EmailChanges.DeletePending(userID)
+		return nil, ErrEmailTaken
+	}
+	if err := service.Repository.SetEmail(userID, pending.Email, service.now()); err != nil {
+		return nil, err
+	}
+	if err := service.EmailChanges.DeletePending(userID); err != nil {
+		logger.Error("❌ ошибка при удалении запроса смены email: " + err.Error())
+	}
+	logger.Infof("✅ Пользователь %d сменил email", userID)
+	return service.GetProfile(userID)
+}
+
+// UploadProfileImage проверяет, что файл — изображение, загружает его в Media Service
+// и сохраняет URL в профиле.
+func (service *AccountService) UploadProfileImage(userID uint, file io.Reader) (*ProfileResponse, error) {
+	if _, err := service.activeUser(userID); err != nil {
+		return nil, err
+	}
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, ErrInvalidImage
+	}
+	head = head[:n]
+	ext, ok := allowedImageTypes[http.DetectContentType(head)]
+	if !ok {
+		return nil, ErrInvalidImage
+	}
+
+	filename := fmt.Sprintf("avatar-%d-%s%s", userID, newJobID()[:8], ext)
+	url, err := service.Media.Upload(io.MultiReader(bytes.NewReader(head), file), filename)
+	if err != nil {
+		return nil, fmt.Errorf("upload profile image: %w", err)
+	}
+	if err := service.Repository.UpdateProfile(userID, map[string]any{"profile_image": url}); err != nil {
+		return nil, err
+	}
+	logger.Infof("🖼️ Пользователь %d обновил фото профиля", userID)
+	return service.GetProfile(userID)
+}
+
+// hashEmailCode хранит в Redis не код, а HMAC от него, привязанный к пользователю и адресу.
+func (service *AccountService) hashEmailCode(userID uint, email, code string) string {
+	mac := hmac.New(sha256.New, []byte(service.Conf.OAuth.Secret))
+	mac.Write([]byte(fmt.Sprintf("%d|%s|%s", userID, email, code)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
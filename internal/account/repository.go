@@ -0,0 +1,212 @@
+package account
+
+import (
+	"errors"
+	"time"
+
+	"github.com/ShopOnGO/ShopOnGO/internal/auth/mfa"
+	"github.com/ShopOnGO/ShopOnGO/internal/cart"
+	"github.com/ShopOnGO/ShopOnGO/internal/favorites"
+	"github.com/ShopOnGO/ShopOnGO/internal/identity"
+	"github.com/ShopOnGO/ShopOnGO/internal/user"
+	"github.com/ShopOnGO/ShopOnGO/pkg/db"
+	"gorm.io/gorm"
+)
+
+type AccountRepository struct {
+	Database *db.Db
+}
+
+func NewAccountRepository(database *db.Db) *AccountRepository {
+	return &AccountRepository{Database: database}
+}
+
+// CollectExport собирает данные пользователя из всех таблиц, где он упоминается.
+func (repo *AccountRepository) CollectExport(u *user.User) (*ExportData, error) {
+	data := &ExportData{Profile: profileOf(u)}
+	database := repo.Database.DB
+
+	queries := []*gorm.DB{
+		database.Where("user_id = ?", u.ID).Order("id").Find(&data.Identities),
+		database.Where("user_id = ?", u.ID).Order("id").Find(&data.Addresses),
+		database.Preload("CartItems.ProductVariant").Where("user_id = ?", u.ID).Order("id").Find(&data.Carts),
+		database.Preload("ProductVariant").Where("user_id = ?", u.ID).Order("id").Find(&data.Favorites),
+		database.Where("user_id = ?", u.ID).Order("id").Find(&data.Reviews),
+		database.Where("user_id = ?", u.ID).Order("id").Find(&data.Questions),
+		database.Where("from_id = ? OR to_id = ?", u.ID, u.ID).Order("id").Find(&data.Messages),
+	}
+	for _, q := range queries {
+		if q.Error != nil {
+			return nil, q.Error
+		}
+	}
+	return data, nil
+}
+
+// Anonymize обезличивает аккаунт одной транзакцией. Отзывы, вопросы, сообщения и
+// заказы остаются (они нужны продавцам и для учета), но больше не связаны с человеком.
+// Корзины, избранное, адреса, привязки провайдеров и 2FA удаляются.
+func (repo *AccountRepository) Anonymize(userID uint, scrambledEmail string) error {
+	return repo.Database.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&user.User{}).Where("id = ?", userID).Updates(map[string]any{
+			"name":           "Deleted user",
+			"email":          scrambledEmail,
+			"password_hash":  nil,
+			"status":         user.StatusDeleted,
+			"email_verified": false,
+			"phone":          nil,
+			"profile_image":  nil,
+			"store_name":     nil,
+			"store_address":  nil,
+			"store_phone":    nil,
+			"ban_reason":     nil,
+			"banned_until":   nil,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrUserNotFound
+		}
+
+		var cartIDs []uint
+		if err := tx.Model(&cart.Cart{}).Where("user_id = ?", userID).Pluck("id", &cartIDs).Error; err != nil {
+			return err
+		}
+		if len(cartIDs) > 0 {
+			if err := tx.Unscoped().Where("cart_id IN ?", cartIDs).Delete(&cart.CartItem{}).Error; err != nil {
+				return err
+			}
+		}
+
+		for _, model := range []any{
+			&cart.Cart{},
+			&favorites.Favorite{},
+			&identity.UserIdentity{},
+			&mfa.MFASettings{},
+			&mfa.RecoveryCode{},
+			&Address{},
+		} {
+			if err := tx.Unscoped().Where("user_id = ?", userID).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// UpdateProfile обновляет только переданные колонки пользователя.
+func (repo *AccountRepository) UpdateProfile(userID uint, fields map[string]any) error {
+	return repo.Database.DB.Model(&user.User{}).Where("id = ?", userID).Updates(fields).Error
+}
+
+// SetEmail применяет подтвержденный email: адрес сразу считается подтвержденным.
+func (repo *AccountRepository) SetEmail(userID uint, email string, verifiedAt time.Time) error {
+	return repo.Database.DB.Model(&user.User{}).Where("id = ?", userID).Updates(map[string]any{
+		"email":             email,
+		"email_verified":    true,
+		"email_verified_at": verifiedAt,
+	}).Error
+}
+
+// EmailTaken проверяет, занят ли email другим пользователем (включая удаленных через soft delete).
+func (repo *AccountRepository) EmailTaken(email string, exceptID uint) (bool, error) {
+	var count int64
+	err := repo.Database.DB.Unscoped().Model(&user.User{}).
+		Where("LOWER(email) = LOWER(?) AND id <> ?", email, exceptID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (repo *AccountRepository) ListAddresses(userID uint) ([]Address, error) {
+	var addresses []Address
+	err := repo.Database.DB.Where("user_id = ?", userID).
+		Order("is_default DESC, id").
+		Find(&addresses).Error
+	return addresses, err
+}
+
+func (repo *AccountRepository) FindAddress(userID, id uint) (*Address, error) {
+	var address Address
+	err := repo.Database.DB.Where("id = ? AND user_id = ?", id, userID).First(&address).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrAddressNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &address, nil
+}
+
+func (repo *AccountRepository) CountAddresses(userID uint) (int64, error) {
+	var count int64
+	err := repo.Database.DB.Model(&Address{}).Where("user_id = ?", userID).Count(&count).Error
+	return count, err
+}
+
+// SaveAddress создает или обновляет адрес. Если адрес помечен основным,
+// флаг снимается с остальных адресов пользователя в той же транзакции.
+func (repo *AccountRepository) SaveAddress(address *Address) error {
+	return repo.Database.DB.Transaction(func(tx *gorm.DB) error {
+		if address.IsDefault {
+			if err := clearDefault(tx, address.UserID, address.ID); err != nil {
+				return err
+			}
+		}
+		return tx.Save(address).Error
+	})
+}
+
+// DeleteAddress удаляет адрес; если он был основным, основным становится самый новый из оставшихся.
+func (repo *AccountRepository) DeleteAddress(userID, id uint) error {
+	return repo.Database.DB.Transaction(func(tx *gorm.DB) error {
+		var address Address
+		err := tx.Where("id = ? AND user_id = ?", id, userID).First(&address).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrAddressNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if err := tx.Delete(&address).Error; err != nil {
+			return err
+		}
+		if !address.IsDefault {
+			return nil
+		}
+		var next Address
+		err = tx.Where("user_id = ?", userID).Order("id DESC").First(&next).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return tx.Model(&next).Update("is_default", true).Error
+	})
+}
+
+func clearDefault(tx *gorm.DB, userID, exceptID uint) error {
+	return tx.Model(&Address{}).
+		Where("user_id = ? AND id <> ? AND is_default", userID, exceptID).
+		Update("is_default", false).Error
+}
+
+func profileOf(u *user.User) Profile {
+	return Profile{
+		ID:              u.ID,
+		Name:            u.Name,
+		Email:           u.Email,
+		EmailVerified:   u.EmailVerified,
+		Role:            u.Role,
+		Provider:        u.Provider,
+		Phone:           u.Phone,
+		ProfileImage:    u.ProfileImage,
+		StoreName:       u.StoreName,
+		StoreAddress:    u.StoreAddress,
+		StorePhone:      u.StorePhone,
+		AcceptTerms:     u.AcceptTerms,
+		CreatedAt:       u.CreatedAt,
+		EmailVerifiedAt: u.EmailVerifiedAt,
+	}
+}
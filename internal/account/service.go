@@ -0,0 +1,344 @@
+package account
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/ShopOnGO/ShopOnGO/configs"
+	"github.com/ShopOnGO/ShopOnGO/internal/user"
+	"github.com/ShopOnGO/ShopOnGO/pkg/di"
+	"github.com/ShopOnGO/ShopOnGO/pkg/logger"
+	"github.com/ShopOnGO/ShopOnGO/pkg/oauth2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// staleExportAfter — задание в очереди дольше этого считается потерянным
+// (например, сервис перезапустился) и может быть запущено заново.
+const staleExportAfter = 10 * time.Minute
+
+type Repository interface {
+	CollectExport(u *user.User) (*ExportData, error)
+	Anonymize(userID uint, scrambledEmail string) error
+
+	UpdateProfile(userID uint, fields map[string]any) error
+	SetEmail(userID uint, email string, verifiedAt time.Time) error
+	EmailTaken(email string, exceptID uint) (bool, error)
+
+	ListAddresses(userID uint) ([]Address, error)
+	FindAddress(userID, id uint) (*Address, error)
+	CountAddresses(userID uint) (int64, error)
+	SaveAddress(address *Address) error
+	DeleteAddress(userID, id uint) error
+}
+
+type ExportStore interface {
+	SaveJob(job *ExportJob) error
+	GetJob(userID uint) (*ExportJob, error)
+	SaveArchive(userID uint, archive []byte) error
+	GetArchive(userID uint) ([]byte, error)
+}
+
+type EmailChangeStore interface {
+	SavePending(userID uint, pending *PendingEmailChange) error
+	GetPending(userID uint) (*PendingEmailChange, error)
+	DeletePending(userID uint) error
+	IncrementAttempts(userID uint, ttl time.Duration) (int, error)
+}
+
+// Uploader загружает файл во внешнее хранилище и возвращает его URL (media.Client).
+type Uploader interface {
+	Upload(file io.Reader, filename string) (string, error)
+}
+
+// EventProducer — отправка событий в Kafka (kafkaService.KafkaService).
+type EventProducer interface {
+	Produce(ctx context.Context, key, value []byte) error
+}
+
+type AccountService struct {
+	Conf           *configs.Config
+	Repository     Repository
+	Exports        ExportStore
+	EmailChanges   EmailChangeStore
+	UserRepository di.IUserRepository
+	Sessions       oauth2.OAuth2Service
+	Media          Uploader
+	Events         EventProducer
+	Notifications  EventProducer
+
+	queue chan uint
+	now   func() time.Time
+}
+
+type AccountServiceDeps struct {
+	Conf           *configs.Config
+	Repository     Repository
+	Exports        ExportStore
+	EmailChanges   EmailChangeStore
+	UserRepository di.IUserRepository
+	Sessions       oauth2.OAuth2Service
+	Media          Uploader
+	Events         EventProducer // события пользователей; может быть nil, если топик не настроен
+	Notifications  EventProducer // письма с кодами; может быть nil, если топик не настроен
+}
+
+func NewAccountService(deps AccountServiceDeps) *AccountService {
+	return &AccountService{
+		Conf:           deps.Conf,
+		Repository:     deps.Repository,
+		Exports:        deps.Exports,
+		EmailChanges:   deps.EmailChanges,
+		UserRepository: deps.UserRepository,
+		Sessions:       deps.Sessions,
+		Media:          deps.Media,
+		Events:         deps.Events,
+		Notifications:  deps.Notifications,
+		queue:          make(chan uint, 64),
+		now:            time.Now,
+	}
+}
+
+// RequestExport ставит выгрузку в очередь или возвращает уже существующее задание.
+// Готовый архив не пересобирается, пока не истечет его TTL.
+func (service *AccountService) RequestExport(userID uint) (*ExportJob, error) {
+	u, err := service.activeUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	job, err := service.Exports.GetJob(u.ID)
+	if err != nil {
+		return nil, err
+	}
+	if job != nil {
+		switch job.Status {
+		case ExportDone:
+			return job, nil
+		case ExportPending, ExportRunning:
+			if service.now().Sub(job.CreatedAt) < staleExportAfter {
+				return job, nil
+			}
+		}
+	}
+
+	job = &ExportJob{
+		ID:        newJobID(),
+		UserID:    u.ID,
+		Status:    ExportPending,
+		CreatedAt: service.now(),
+	}
+	if err := service.Exports.SaveJob(job); err != nil {
+		return nil, err
+	}
+	select {
+	case service.queue <- u.ID:
+	default:
+		// очередь переполнена — задание останется pending и будет перезапущено по таймауту
+		logger.Warnf("⚠️ очередь выгрузок переполнена, пользователь %d", u.ID)
+	}
+	return job, nil
+}
+
+func (service *AccountService) ExportStatus(userID uint) (*ExportJob, error) {
+	job, err := service.Exports.GetJob(userID)
+	if err != nil {
+		return nil, err
+	}
+	if job == nil {
+		return nil, ErrExportNotFound
+	}
+	return job, nil
+}
+
+// Download возвращает готовый ZIP-архив.
+func (service *AccountService) Download(userID uint) ([]byte, error) {
+	job, err := service.ExportStatus(userID)
+	if err != nil {
+		return nil, err
+	}
+	if job.Status != ExportDone {
+		return nil, ErrExportNotReady
+	}
+	archive, err := service.Exports.GetArchive(userID)
+	if err != nil {
+		return nil, err
+	}
+	if archive == nil {
+		return nil, ErrExportNotFound
+	}
+	return archive, nil
+}
+
+// Run обрабатывает очередь выгрузок. Запускается один раз в отдельной горутине.
+func (service *AccountService) Run() {
+	for userID := range service.queue {
+		service.process(userID)
+	}
+}
+
+func (service *AccountService) process(userID uint) {
+	job, err := service.Exports.GetJob(userID)
+	if err != nil || job == nil || job.Status != ExportPending {
+		return
+	}
+	job.Status = ExportRunning
+	if err := service.Exports.SaveJob(job); err != nil {
+		logger.Errorf("❌ не удалось обновить задание выгрузки %s: %v", job.ID, err)
+		return
+	}
+
+	archive, err := service.buildArchive(userID)
+	if err == nil {
+		err = service.Exports.SaveArchive(userID, archive)
+	}
+
+	completedAt := service.now()
+	job.CompletedAt = &completedAt
+	if err != nil {
+		logger.Errorf("❌ выгрузка данных пользователя %d не удалась: %v", userID, err)
+		job.Status = ExportFailed
+		job.Error = "export failed"
+	} else {
+		job.Status = ExportDone
+		job.Size = len(archive)
+		logger.Infof("📦 Выгрузка данных пользователя %d готова (%d байт)", userID, len(archive))
+	}
+	if err := service.Exports.SaveJob(job); err != nil {
+		logger.Errorf("❌ не удалось обновить задание выгрузки %s: %v", job.ID, err)
+	}
+}
+
+func (service *AccountService) buildArchive(userID uint) ([]byte, error) {
+	u, err := service.activeUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	data, err := service.Repository.CollectExport(u)
+	if err != nil {
+		return nil, err
+	}
+
+	files := []struct {
+		name    string
+		content any
+	}{
+		{"manifest.json", map[string]any{
+			"user_id":      u.ID,
+			"generated_at": service.now().UTC(),
+			"files":        []string{"profile.json", "identities.json", "addresses.json", "carts.json", "favorites.json", "reviews.json", "questions.json", "messages.json"},
+			"notes": []string{
+				"Статистика переходов по коротким ссылкам не привязана к пользователю и в выгрузку не входит.",
+				"Пароль и секреты 2FA не выгружаются.",
+			},
+		}},
+		{"profile.json", data.Profile},
+		{"identities.json", data.Identities},
+		{"addresses.json", data.Addresses},
+		{"carts.json", data.Carts},
+		{"favorites.json", data.Favorites},
+		{"reviews.json", data.Reviews},
+		{"questions.json", data.Questions},
+		{"messages.json", data.Messages},
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range files {
+		w, err := zw.Create(f.name)
+		if err != nil {
+			return nil, err
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(f.content); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// DeleteAccount обезличивает аккаунт по запросу самого пользователя: проверяет пароль
+// (если он задан), стирает персональные данные, завершает все сессии и отправляет
+// событие user.deleted. Заказы и финансовые записи не затрагиваются.
+func (service *AccountService) DeleteAccount(ctx context.Context, userID uint, password string) error {
+	u, err := service.activeUser(userID)
+	if err != nil {
+		return err
+	}
+	if u.PasswordHash != "" {
+		if err := bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(password)); err != nil {
+			return ErrWrongPassword
+		}
+	}
+
+	if err := service.Repository.Anonymize(u.ID, scrambledEmail(u.ID)); err != nil {
+		return err
+	}
+	if err := service.Sessions.RevokeAll(u.ID); err != nil {
+		logger.Errorf("❌ не удалось завершить сессии удаленного пользователя %d: %v", u.ID, err)
+	}
+	logger.Infof("🗑️ Аккаунт пользователя %d удален по запросу владельца", u.ID)
+
+	service.emitDeleted(ctx, u)
+	return nil
+}
+
+func (service *AccountService) emitDeleted(ctx context.Context, u *user.User) {
+	if service.Events == nil {
+		logger.Warn("⚠️ Kafka-продюсер пользователей не настроен, событие user.deleted не отправлено")
+		return
+	}
+	event := map[string]interface{}{
+		"action":   "delete",
+		"category": "USER",
+		"subtype":  "user.deleted",
+		"userID":   u.ID,
+		"wasInDlq": false,
+		"payload": map[string]interface{}{
+			"userID":    u.ID,
+			"role":      u.Role,
+			"deletedAt": service.now().UTC(),
+		},
+	}
+	eventBytes, err := json.Marshal(event)
+	if err != nil {
+		logger.Error("❌ ошибка сериализации события: " + err.Error())
+		return
+	}
+	if err := service.Events.Produce(ctx, []byte("user.deleted"), eventBytes); err != nil {
+		logger.Errorf("❌ ошибка отправки сообщения в Kafka: %v", err)
+		return
+	}
+	logger.Infof("📨 Событие user.deleted для пользователя %d отправлено в Kafka", u.ID)
+}
+
+// activeUser возвращает пользователя, если аккаунт не удален.
+func (service *AccountService) activeUser(userID uint) (*user.User, error) {
+	u, err := service.UserRepository.FindByID(userID)
+	if err != nil || u == nil || u.Status == user.StatusDeleted {
+		return nil, ErrUserNotFound
+	}
+	return u, nil
+}
+
+// scrambledEmail — уникальный несуществующий адрес: освобождает исходный email
+// для повторной регистрации и не позволяет войти в удаленный аккаунт.
+func scrambledEmail(userID uint) string {
+	return fmt.Sprintf("deleted-%d-%s@deleted.invalid", userID, newJobID()[:8])
+}
+
+func newJobID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,465 @@
+package account
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/ShopOnGO/ShopOnGO/configs"
+	"github.com/ShopOnGO/ShopOnGO/internal/review"
+	"github.com/ShopOnGO/ShopOnGO/internal/user"
+	"github.com/ShopOnGO/ShopOnGO/internal/user/usertest"
+	"github.com/ShopOnGO/ShopOnGO/pkg/oauth2/oauth2test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// fakeUsers дополняет общий двойник методами AccountRepository.
+type fakeUsers struct {
+	*usertest.Repository
+	addresses map[uint]*Address
+}
+
+// CollectExport/Anonymize ведут себя как AccountRepository на уровне полей пользователя.
+func (f *fakeUsers) CollectExport(u *user.User) (*ExportData, error) {
+	return &ExportData{
+		Profile: profileOf(u),
+		Reviews: []review.Review{{UserID: u.ID, Comment: "great"}},
+	}, nil
+}
+func (f *fakeUsers) Anonymize(userID uint, email string) error {
+	u := f.Users[userID]
+	u.Name, u.Email, u.PasswordHash, u.Status = "Deleted user", email, "", user.StatusDeleted
+	return nil
+}
+
+func (f *fakeUsers) UpdateProfile(userID uint, fields map[string]any) error {
+	u := f.Users[userID]
+	for column, value := range fields {
+		switch column {
+		case "name":
+			u.Name = value.(string)
+		case "phone":
+			u.Phone = value.(string)
+		case "profile_image":
+			u.ProfileImage = value.(string)
+		case "store_name":
+			name := value.(string)
+			u.StoreName = &name
+		}
+	}
+	return nil
+}
+func (f *fakeUsers) SetEmail(userID uint, email string, verifiedAt time.Time) error {
+	u := f.Users[userID]
+	u.Email, u.EmailVerified, u.EmailVerifiedAt = email, true, &verifiedAt
+	return nil
+}
+func (f *fakeUsers) EmailTaken(email string, exceptID uint) (bool, error) {
+	for _, u := range f.Users {
+		if u.ID != exceptID && u.Email == email {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Адреса ведут себя как AccountRepository: основной адрес у пользователя один.
+func (f *fakeUsers) ListAddresses(userID uint) ([]Address, error) {
+	var list []Address
+	for _, a := range f.addresses {
+		if a.UserID == userID {
+			list = append(list, *a)
+		}
+	}
+	return list, nil
+}
+func (f *fakeUsers) FindAddress(userID, id uint) (*Address, error) {
+	a, ok := f.addresses[id]
+	if !ok || a.UserID != userID {
+		return nil, ErrAddressNotFound
+	}
+	found := *a
+	return &found, nil
+}
+func (f *fakeUsers) CountAddresses(userID uint) (int64, error) {
+	list, _ := f.ListAddresses(userID)
+	return int64(len(list)), nil
+}
+func (f *fakeUsers) SaveAddress(address *Address) error {
+	if address.ID == 0 {
+		address.ID = uint(len(f.addresses) + 1)
+	}
+	if address.IsDefault {
+		for _, a := range f.addresses {
+			if a.UserID == address.UserID {
+				a.IsDefault = false
+			}
+		}
+	}
+	saved := *address
+	f.addresses[address.ID] = &saved
+	return nil
+}
+func (f *fakeUsers) DeleteAddress(userID, id uint) error {
+	if _, err := f.FindAddress(userID, id); err != nil {
+		return err
+	}
+	delete(f.addresses, id)
+	return nil
+}
+
+type fakeEmailChanges struct {
+	pending  map[uint]*PendingEmailChange
+	attempts map[uint]int
+}
+
+func (f *fakeEmailChanges) SavePending(userID uint, pending *PendingEmailChange) error {
+	f.pending[userID], f.attempts[userID] = pending, 0
+	return nil
+}
+func (f *fakeEmailChanges) GetPending(userID uint) (*PendingEmailChange, error) {
+	return f.pending[userID], nil
+}
+func (f *fakeEmailChanges) DeletePending(userID uint) error {
+	delete(f.pending, userID)
+	return nil
+}
+func (f *fakeEmailChanges) IncrementAttempts(userID uint, ttl time.Duration) (int, error) {
+	f.attempts[userID]++
+	return f.attempts[userID], nil
+}
+
+type fakeMedia struct {
+	uploaded []string
+}
+
+func (f *fakeMedia) Upload(file io.Reader, filename string) (string, error) {
+	f.uploaded = append(f.uploaded, filename)
+	return "https://cdn.example.com/" + filename, nil
+}
+
+type fakeExports struct {
+	jobs     map[uint]*ExportJob
+	archives map[uint][]byte
+}
+
+func (f *fakeExports) SaveJob(job *ExportJob) error {
+	saved := *job
+	f.jobs[job.UserID] = &saved
+	return nil
+}
+func (f *fakeExports) GetJob(userID uint) (*ExportJob, error) {
+	job, ok := f.jobs[userID]
+	if !ok {
+		return nil, nil
+	}
+	found := *job
+	return &found, nil
+}
+func (f *fakeExports) SaveArchive(userID uint, archive []byte) error {
+	f.archives[userID] = archive
+	return nil
+}
+func (f *fakeExports) GetArchive(userID uint) ([]byte, error) { return f.archives[userID], nil }
+
+type fakeEvents struct {
+	keys   []string
+	values [][]byte
+}
+
+func (f *fakeEvents) Produce(ctx context.Context, key, value []byte) error {
+	f.keys = append(f.keys, string(key))
+	f.values = append(f.values, value)
+	return nil
+}
+
+type fixture struct {
+	service       *AccountService
+	users         *fakeUsers
+	exports       *fakeExports
+	emailChanges  *fakeEmailChanges
+	sessions      *oauth2test.Sessions
+	media         *fakeMedia
+	events        *fakeEvents
+	notifications *fakeEvents
+	now           time.Time
+}
+
+func newFixture(t *testing.T) *fixture {
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	f := &fixture{
+		users: &fakeUsers{Repository: usertest.NewRepository(
+			&user.User{Model: gorm.Model{ID: 1}, Name: "Anna", Email: "anna@example.com", PasswordHash: string(hash), Role: "buyer", Status: user.StatusActive},
+			&user.User{Model: gorm.Model{ID: 2}, Name: "Oleg", Email: "oleg@example.com", Role: "buyer", Provider: "google", Status: user.StatusActive},
+		), addresses: map[uint]*Address{}},
+		exports:       &fakeExports{jobs: map[uint]*ExportJob{}, archives: map[uint][]byte{}},
+		emailChanges:  &fakeEmailChanges{pending: map[uint]*PendingEmailChange{}, attempts: map[uint]int{}},
+		sessions:      &oauth2test.Sessions{},
+		media:         &fakeMedia{},
+		events:        &fakeEvents{},
+		notifications: &fakeEvents{},
+		now:           time.Unix(1700000000, 0),
+	}
+	conf := &configs.Config{
+		OAuth: configs.OAuthConfig{Secret: "test-secret"},
+		Code:  configs.CodeConfig{CodeTTL: 15 * time.Minute},
+	}
+	f.service = NewAccountService(AccountServiceDeps{
+		Conf:           conf,
+		Repository:     f.users,
+		Exports:        f.exports,
+		EmailChanges:   f.emailChanges,
+		UserRepository: f.users,
+		Sessions:       f.sessions,
+		Media:          f.media,
+		Events:         f.events,
+		Notifications:  f.notifications,
+	})
+	f.service.now = func() time.Time { return f.now }
+	return f
+}
+
+func TestExport(t *testing.T) {
+	t.Run("Job is queued, built and downloadable", func(t *testing.T) {
+		f := newFixture(t)
+
+		job, err := f.service.RequestExport(1)
+		require.NoError(t, err)
+		assert.Equal(t, ExportPending, job.Status)
+		_, err = f.service.Download(1)
+		assert.ErrorIs(t, err, ErrExportNotReady)
+
+		f.service.process(<-f.service.queue)
+
+		status, err := f.service.ExportStatus(1)
+		require.NoError(t, err)
+		assert.Equal(t, ExportDone, status.Status)
+
+		archive, err := f.service.Download(1)
+		require.NoError(t, err)
+		zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+		require.NoError(t, err)
+
+		files := map[string][]byte{}
+		for _, file := range zr.File {
+			rc, err := file.Open()
+			require.NoError(t, err)
+			files[file.Name], _ = io.ReadAll(rc)
+			rc.Close()
+		}
+		for _, name := range []string{"manifest.json", "profile.json", "carts.json", "favorites.json", "reviews.json", "questions.json", "messages.json"} {
+			assert.Contains(t, files, name)
+		}
+		var profile Profile
+		require.NoError(t, json.Unmarshal(files["profile.json"], &profile))
+		assert.Equal(t, "anna@example.com", profile.Email)
+		assert.NotContains(t, string(files["profile.json"]), "$2a$", "хеш пароля не выгружается")
+		assert.Contains(t, string(files["reviews.json"]), "great")
+	})
+
+	t.Run("Repeated request returns the running job", func(t *testing.T) {
+		f := newFixture(t)
+		first, _ := f.service.RequestExport(1)
+		second, _ := f.service.RequestExport(1)
+		assert.Equal(t, first.ID, second.ID)
+
+		f.now = f.now.Add(staleExportAfter + time.Second)
+		third, _ := f.service.RequestExport(1)
+		assert.NotEqual(t, first.ID, third.ID, "зависшее задание перезапускается")
+	})
+
+	t.Run("No export requested", func(t *testing.T) {
+		f := newFixture(t)
+		_, err := f.service.Download(1)
+		assert.ErrorIs(t, err, ErrExportNotFound)
+	})
+}
+
+func TestDeleteAccount(t *testing.T) {
+	t.Run("Wrong password keeps the account", func(t *testing.T) {
+		f := newFixture(t)
+		err := f.service.DeleteAccount(context.Background(), 1, "wrong")
+		assert.ErrorIs(t, err, ErrWrongPassword)
+		assert.Equal(t, user.StatusActive, f.users.Users[1].Status)
+		assert.Empty(t, f.events.keys)
+	})
+
+	t.Run("Account is anonymized, sessions revoked and event emitted", func(t *testing.T) {
+		f := newFixture(t)
+		require.NoError(t, f.service.DeleteAccount(context.Background(), 1, "secret"))
+
+		u := f.users.Users[1]
+		assert.Equal(t, user.StatusDeleted, u.Status)
+		assert.NotEqual(t, "anna@example.com", u.Email)
+		assert.Contains(t, u.Email, "@deleted.invalid")
+		assert.Empty(t, u.PasswordHash)
+		assert.Equal(t, []uint{1}, f.sessions.Revoked)
+
+		require.Equal(t, []string{"user.deleted"}, f.events.keys)
+		assert.Contains(t, string(f.events.values[0]), `"subtype":"user.deleted"`)
+
+		err := f.service.DeleteAccount(context.Background(), 1, "secret")
+		assert.ErrorIs(t, err, ErrUserNotFound, "повторное удаление невозможно")
+	})
+
+	t.Run("OAuth account without password", func(t *testing.T) {
+		f := newFixture(t)
+		require.NoError(t, f.service.DeleteAccount(context.Background(), 2, ""))
+		assert.Equal(t, user.StatusDeleted, f.users.Users[2].Status)
+	})
+}
+
+func TestUpdateProfile(t *testing.T) {
+	t.Run("Plain fields are applied immediately", func(t *testing.T) {
+		f := newFixture(t)
+		name, phone := "Anna K.", "+79991234567"
+
+		profile, err := f.service.UpdateProfile(context.Background(), 1, &UpdateProfileRequest{Name: &name, Phone: &phone})
+		require.NoError(t, err)
+		assert.Equal(t, "Anna K.", profile.Name)
+		assert.Equal(t, "+79991234567", profile.Phone)
+	})
+
+	t.Run("Store fields are for sellers only", func(t *testing.T) {
+		f := newFixture(t)
+		store := "Shop"
+		_, err := f.service.UpdateProfile(context.Background(), 1, &UpdateProfileRequest{StoreName: &store})
+		assert.ErrorIs(t, err, ErrStoreFieldsReadonly)
+
+		f.users.Users[1].Role = "seller"
+		profile, err := f.service.UpdateProfile(context.Background(), 1, &UpdateProfileRequest{StoreName: &store})
+		require.NoError(t, err)
+		assert.Equal(t, "Shop", *profile.StoreName)
+	})
+
+	t.Run("Email taken by another user", func(t *testing.T) {
+		f := newFixture(t)
+		email := "oleg@example.com"
+		_, err := f.service.UpdateProfile(context.Background(), 1, &UpdateProfileRequest{Email: &email})
+		assert.ErrorIs(t, err, ErrEmailTaken)
+		assert.Empty(t, f.notifications.keys)
+	})
+}
+
+func TestEmailChange(t *testing.T) {
+	requestChange := func(t *testing.T, f *fixture) string {
+		email := "Anna.New@Example.com"
+		profile, err := f.service.UpdateProfile(context.Background(), 1, &UpdateProfileRequest{Email: &email})
+		require.NoError(t, err)
+		assert.Equal(t, "anna@example.com", profile.Email, "email меняется только после подтверждения")
+		assert.Equal(t, "anna.new@example.com", profile.PendingEmail)
+
+		require.Len(t, f.notifications.values, 1)
+		var event struct {
+			Payload struct {
+				Code  string `json:"code"`
+				Email string `json:"email"`
+			} `json:"payload"`
+		}
+		require.NoError(t, json.Unmarshal(f.notifications.values[0], &event))
+		assert.Equal(t, "anna.new@example.com", event.Payload.Email)
+		assert.NotContains(t, f.emailChanges.pending[1].CodeHash, event.Payload.Code)
+		return event.Payload.Code
+	}
+
+	t.Run("Correct code applies the new email", func(t *testing.T) {
+		f := newFixture(t)
+		code := requestChange(t, f)
+
+		profile, err := f.service.ConfirmEmail(1, code)
+		require.NoError(t, err)
+		assert.Equal(t, "anna.new@example.com", profile.Email)
+		assert.True(t, profile.EmailVerified)
+		assert.Empty(t, profile.PendingEmail)
+	})
+
+	t.Run("Wrong codes cancel the change", func(t *testing.T) {
+		f := newFixture(t)
+		requestChange(t, f)
+
+		for i := 1; i < maxEmailCodeAttempts; i++ {
+			_, err := f.service.ConfirmEmail(1, "000000")
+			assert.ErrorIs(t, err, ErrInvalidCode)
+		}
+		_, err := f.service.ConfirmEmail(1, "000000")
+		assert.ErrorIs(t, err, ErrTooManyAttempts)
+		_, err = f.service.ConfirmEmail(1, "000000")
+		assert.ErrorIs(t, err, ErrNoPendingEmail)
+	})
+}
+
+func TestUploadProfileImage(t *testing.T) {
+	f := newFixture(t)
+	png := append([]byte("\x89PNG\r\n\x1a\n"), make([]byte, 64)...)
+
+	profile, err := f.service.UploadProfileImage(1, bytes.NewReader(png))
+	require.NoError(t, err)
+	require.Len(t, f.media.uploaded, 1)
+	assert.Equal(t, "https://cdn.example.com/"+f.media.uploaded[0], profile.ProfileImage)
+
+	_, err = f.service.UploadProfileImage(1, bytes.NewReader([]byte("<html>not an image</html>")))
+	assert.ErrorIs(t, err, ErrInvalidImage)
+}
+
+func TestAddresses(t *testing.T) {
+	f := newFixture(t)
+	data := &AddressRequest{Recipient: "Anna", Phone: "+79991234567", Country: "RU", City: "Moscow", Street: "Tverskaya 1", PostalCode: "125009"}
+
+	first, err := f.service.CreateAddress(1, data)
+	require.NoError(t, err)
+	assert.True(t, first.IsDefault, "первый адрес становится основным")
+
+	second, err := f.service.CreateAddress(1, data)
+	require.NoError(t, err)
+	assert.False(t, second.IsDefault)
+
+	_, err = f.service.SetDefaultAddress(1, second.ID)
+	require.NoError(t, err)
+	assert.False(t, f.users.addresses[first.ID].IsDefault)
+
+	updated, err := f.service.UpdateAddress(1, second.ID, data)
+	require.NoError(t, err)
+	assert.True(t, updated.IsDefault, "обновление не снимает флаг основного")
+
+	_, err = f.service.UpdateAddress(2, first.ID, data)
+	assert.ErrorIs(t, err, ErrAddressNotFound, "чужой адрес недоступен")
+
+	for len(f.users.addresses) < maxAddresses {
+		_, err = f.service.CreateAddress(1, data)
+		require.NoError(t, err)
+	}
+	_, err = f.service.CreateAddress(1, data)
+	assert.ErrorIs(t, err, ErrAddressLimit)
+}
+
+func TestChangePassword(t *testing.T) {
+	t.Run("Current password is required", func(t *testing.T) {
+		f := newFixture(t)
+		_, _, err := f.service.ChangePassword(1, "wrong", "new-secret-1")
+		assert.ErrorIs(t, err, ErrWrongPassword)
+		_, _, err = f.service.ChangePassword(1, "secret", "secret")
+		assert.ErrorIs(t, err, ErrSamePassword)
+		_, _, err = f.service.ChangePassword(2, "", "new-secret-1")
+		assert.ErrorIs(t, err, ErrNoPassword)
+		assert.Empty(t, f.sessions.Revoked)
+	})
+
+	t.Run("Password is replaced and other sessions revoked", func(t *testing.T) {
+		f := newFixture(t)
+		_, _, err := f.service.ChangePassword(1, "secret", "new-secret-1")
+		require.NoError(t, err)
+
+		hash := f.users.Users[1].PasswordHash
+		assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(hash), []byte("new-secret-1")))
+		assert.Equal(t, []uint{1}, f.sessions.Revoked)
+	})
+}
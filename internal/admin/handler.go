@@ -11,7 +11,10 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/ShopOnGO/ShopOnGO/configs"
+	"github.com/ShopOnGO/ShopOnGO/internal/rbac"
 	"github.com/ShopOnGO/ShopOnGO/pkg/logger"
+	"github.com/ShopOnGO/ShopOnGO/pkg/middleware"
 	pb "github.com/ShopOnGO/admin-proto/pkg/service"
 	"github.com/gorilla/mux"
 	"google.golang.org/grpc"
@@ -21,18 +24,34 @@ import (
 	"google.golang.org/protobuf/encoding/protojson"
 )
 
+type AdminHandlerDeps struct {
+	Config      *configs.Config
+	Guard       *middleware.TokenGuard
+	Permissions middleware.PermissionChecker
+	Audit       AuditRecorder
+	Users       UserLookup
+	Products    ProductLookup
+}
+
 type AdminHandler struct {
 	Clients *GRPCClients
 }
 
-func InitGRPCClients() *GRPCClients {
-	conn, err := grpc.Dial("admin_container:50051", grpc.WithTransportCredentials(insecure.NewCredentials()))
+// InitGRPCClients подключается к admin-сервису. Каждый вызов несет в metadata
+// пользователя и ID запроса, изменяющие вызовы пишутся в журнал аудита.
+func InitGRPCClients(recorder AuditRecorder, users UserLookup, products ProductLookup) *GRPCClients {
+	auditor := &auditor{recorder: recorder, users: users, products: products}
+	conn, err := grpc.Dial("admin_container:50051",
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		// аудит первым: чтение состояния "до" само проходит через identityInterceptor
+		grpc.WithChainUnaryInterceptor(auditor.intercept, identityInterceptor),
+	)
 
 	if err != nil {
 		log.Fatalf("Ошибка подключения к gRPC серверу: %v", err)
 	}
 	fmt.Println("grpc connected")
-	return &GRPCClients{
+	clients := &GRPCClients{
 		CategoryClient:       pb.NewCategoryServiceClient(conn), //done
 		BrandClient:          pb.NewBrandServiceClient(conn),    //done
 		LinkClient:           pb.NewLinkServiceClient(conn),     //done
@@ -42,11 +61,17 @@ func InitGRPCClients() *GRPCClients {
 		HomeClient:           pb.NewHomeServiceClient(conn),     //done
 		ProductVariantClient: pb.NewProductVariantServiceClient(conn),
 	}
+	auditor.clients = clients
+	return clients
 }
 
-func NewAdminHandler(router *mux.Router) {
+func NewAdminHandler(router *mux.Router, deps AdminHandlerDeps) {
 	handler := &AdminHandler{
-		Clients: InitGRPCClients(),
+		Clients: InitGRPCClients(deps.Audit, deps.Users, deps.Products),
+	}
+	// все /admin/* маршруты требуют токен и права роли
+	protect := func(permission string, next http.HandlerFunc) http.Handler {
+		return middleware.IsAuthed(middleware.RequirePermission(next, permission, deps.Permissions), deps.Config, deps.Guard)
 	}
 	//Home
 	router.HandleFunc("GET /home", handler.GetHomeData)
@@ -55,45 +80,45 @@ func NewAdminHandler(router *mux.Router) {
 	router.HandleFunc("POST /stats/click", handler.AddClick)
 
 	// Users
-	router.HandleFunc("/admin/users", handler.CreateUser).Methods("POST")
-	router.HandleFunc("/admin/users/by-email", handler.GetUserByEmail).Methods("POST")
-	router.HandleFunc("/admin/users", handler.GetUserByEmail).Methods("GET")
-	router.HandleFunc("/admin/users/{id}", handler.UpdateUser).Methods("PUT")
-	router.HandleFunc("/admin/users/{id}", handler.DeleteUser).Methods("DELETE")
+	router.Handle("/admin/users", protect(rbac.PermUserManage, handler.CreateUser)).Methods("POST")
+	router.Handle("/admin/users/by-email", protect(rbac.PermUserManage, handler.GetUserByEmail)).Methods("POST")
+	router.Handle("/admin/users", protect(rbac.PermUserManage, handler.GetUserByEmail)).Methods("GET")
+	router.Handle("/admin/users/{id}", protect(rbac.PermUserManage, handler.UpdateUser)).Methods("PUT")
+	router.Handle("/admin/users/{id}", protect(rbac.PermUserManage, handler.DeleteUser)).Methods("DELETE")
 	// router.HandleFunc("/admin/users/all", handler.DeleteAllUsers).Methods("DELETE")
 
 	//ProductVariants
-	router.HandleFunc("/admin/products/{product_id}/variants/add", handler.CreateProductVariant).Methods("POST")
-	router.HandleFunc("/admin/products/{product_id}/find", handler.GetVariant).Methods("POST")
-	router.HandleFunc("/admin/products/{product_id}/variants", handler.ListVariants).Methods("POST")
-	router.HandleFunc("/admin/products/{product_id}/variants/{id}", handler.UpdateProductVariant).Methods("PUT")
-	router.HandleFunc("/admin/products/{product_id}/variants/{id}/stock", handler.ManageStock).Methods("POST")
-	router.HandleFunc("/admin/products/{product_id}/variants/{id}", handler.DeleteVariant).Methods("DELETE")
+	router.Handle("/admin/products/{product_id}/variants/add", protect(rbac.PermCatalogManage, handler.CreateProductVariant)).Methods("POST")
+	router.Handle("/admin/products/{product_id}/find", protect(rbac.PermCatalogManage, handler.GetVariant)).Methods("POST")
+	router.Handle("/admin/products/{product_id}/variants", protect(rbac.PermCatalogManage, handler.ListVariants)).Methods("POST")
+	router.Handle("/admin/products/{product_id}/variants/{id}", protect(rbac.PermCatalogManage, handler.UpdateProductVariant)).Methods("PUT")
+	router.Handle("/admin/products/{product_id}/variants/{id}/stock", protect(rbac.PermCatalogManage, handler.ManageStock)).Methods("POST")
+	router.Handle("/admin/products/{product_id}/variants/{id}", protect(rbac.PermCatalogManage, handler.DeleteVariant)).Methods("DELETE")
 
 	// Products
-	router.HandleFunc("/admin/products", handler.CreateProduct).Methods("POST")
-	router.HandleFunc("/admin/products/featured", handler.GetFeaturedProducts).Methods("GET")
-	router.HandleFunc("/admin/products", handler.UpdateProduct).Methods("PUT")
-	router.HandleFunc("/admin/products", handler.DeleteProduct).Methods("DELETE")
+	router.Handle("/admin/products", protect(rbac.PermCatalogManage, handler.CreateProduct)).Methods("POST")
+	router.Handle("/admin/products/featured", protect(rbac.PermCatalogManage, handler.GetFeaturedProducts)).Methods("GET")
+	router.Handle("/admin/products", protect(rbac.PermCatalogManage, handler.UpdateProduct)).Methods("PUT")
+	router.Handle("/admin/products", protect(rbac.PermCatalogManage, handler.DeleteProduct)).Methods("DELETE")
 	// router.HandleFunc("/admin/products/all", handler.DeleteAllProducts).Methods("DELETE")
 	//Дописать как реализовать?
 	// при нажатии нужно получать сразу все варианты, и потом пользователь уже будет с кешем этих данных разбираться, что ему нужно.
 	//
 
 	//Brands
-	router.HandleFunc("/admin/brands", handler.CreateBrand).Methods("POST")
-	router.HandleFunc("/admin/brands/featured", handler.GetFeaturedBrands).Methods("GET")
-	router.HandleFunc("/admin/brands/{id}", handler.UpdateBrand).Methods("PUT")
-	router.HandleFunc("/admin/brands", handler.DeleteBrand).Methods("DELETE")
+	router.Handle("/admin/brands", protect(rbac.PermCatalogManage, handler.CreateBrand)).Methods("POST")
+	router.Handle("/admin/brands/featured", protect(rbac.PermCatalogManage, handler.GetFeaturedBrands)).Methods("GET")
+	router.Handle("/admin/brands/{id}", protect(rbac.PermCatalogManage, handler.UpdateBrand)).Methods("PUT")
+	router.Handle("/admin/brands", protect(rbac.PermCatalogManage, handler.DeleteBrand)).Methods("DELETE")
 	// router.HandleFunc("/admin/brands/all", handler.DeleteAllBrands).Methods("DELETE")
 
 	// Categories
-	router.HandleFunc("/admin/categories", handler.CreateCategory).Methods("POST")
-	router.HandleFunc("/admin/categories/featured", handler.GetFeaturedCategories).Methods("GET")
-	router.HandleFunc("/admin/categories/{id:[0-9]+}", handler.GetCategoryByID).Methods("GET")
-	router.HandleFunc("/admin/categories/{id:[0-9]+}", handler.UpdateCategory).Methods("PUT")
-	router.HandleFunc("/admin/categories", handler.DeleteCategory).Methods("DELETE") // по имени из body
-	router.HandleFunc("/admin/categories/all", handler.DeleteAllCategories).Methods("DELETE")
+	router.Handle("/admin/categories", protect(rbac.PermCatalogManage, handler.CreateCategory)).Methods("POST")
+	router.Handle("/admin/categories/featured", protect(rbac.PermCatalogManage, handler.GetFeaturedCategories)).Methods("GET")
+	router.Handle("/admin/categories/{id:[0-9]+}", protect(rbac.PermCatalogManage, handler.GetCategoryByID)).Methods("GET")
+	router.Handle("/admin/categories/{id:[0-9]+}", protect(rbac.PermCatalogManage, handler.UpdateCategory)).Methods("PUT")
+	router.Handle("/admin/categories", protect(rbac.PermCatalogManage, handler.DeleteCategory)).Methods("DELETE") // по имени из body
+	router.Handle("/admin/categories/all", protect(rbac.PermCatalogManage, handler.DeleteAllCategories)).Methods("DELETE")
 
 }
 
@@ -116,7 +141,7 @@ func (a *AdminHandler) CreateCategory(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 	if req.Name == "" {
 		log.Printf("CreateCategory error: category name is required")
@@ -153,7 +178,7 @@ func (a *AdminHandler) GetCategoryByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
 	if categoryID == 0 {
@@ -202,7 +227,7 @@ func (a *AdminHandler) UpdateCategory(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Category ID can't be null", http.StatusBadRequest)
 		return
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
 	resp, err := a.Clients.CategoryClient.UpdateCategory(ctx, &req)
@@ -224,7 +249,7 @@ func (a *AdminHandler) UpdateCategory(w http.ResponseWriter, r *http.Request) {
 // @Failure        500 {string} string "Ошибка сервера"
 // @Router         /admin/categories/featured [get]
 func (a *AdminHandler) GetFeaturedCategories(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
 	resp, err := a.Clients.CategoryClient.GetFeaturedCategories(ctx, &pb.GetFeaturedCategoriesRequest{Amount: 5})
@@ -255,7 +280,7 @@ func (a *AdminHandler) DeleteCategory(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
 	_, err := a.Clients.CategoryClient.DeleteCategory(ctx, &req)
@@ -277,7 +302,7 @@ func (a *AdminHandler) DeleteCategory(w http.ResponseWriter, r *http.Request) {
 // @Failure        500 {string} string "Ошибка сервера"
 // @Router         /admin/categories/all [delete]
 func (a *AdminHandler) DeleteAllCategories(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
 	resp, err := a.Clients.CategoryClient.GetFeaturedCategories(ctx, &pb.GetFeaturedCategoriesRequest{Amount: 0, Unscoped: true})
@@ -317,7 +342,7 @@ func (a *AdminHandler) CreateBrand(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
 	resp, err := a.Clients.BrandClient.CreateBrand(ctx, &req)
@@ -341,7 +366,7 @@ func (a *AdminHandler) CreateBrand(w http.ResponseWriter, r *http.Request) {
 // @Failure      500 {string} string "Ошибка сервера"
 // @Router       /admin/brands/featured [get]
 func (a *AdminHandler) GetFeaturedBrands(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
 	resp, err := a.Clients.BrandClient.GetFeaturedBrands(ctx, &pb.GetFeaturedBrandsRequest{Amount: 5, Unscoped: true})
@@ -381,7 +406,7 @@ func (a *AdminHandler) UpdateBrand(w http.ResponseWriter, r *http.Request) {
 	}
 	req.Id = brandID
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
 	if req.Name == "" {
@@ -417,7 +442,7 @@ func (a *AdminHandler) DeleteBrand(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
 	_, err := a.Clients.BrandClient.DeleteBrand(ctx, &req)
@@ -437,7 +462,7 @@ func (a *AdminHandler) DeleteBrand(w http.ResponseWriter, r *http.Request) {
 // @Failure        500 {string} string "Ошибка сервера"
 // @Router         /admin/brands/all [delete]
 func (a *AdminHandler) DeleteAllBrands(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
 	resp, err := a.Clients.BrandClient.GetFeaturedBrands(ctx, &pb.GetFeaturedBrandsRequest{Amount: 0, Unscoped: true})
@@ -474,7 +499,7 @@ func (a *AdminHandler) CreateProduct(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
 	if req.Name == "" || req.CategoryId == 0 {
@@ -522,7 +547,7 @@ func (a *AdminHandler) GetFeaturedProducts(w http.ResponseWriter, r *http.Reques
 		IncludeDeleted: includeDeleted,
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
 	resp, err := a.Clients.ProductClient.GetFeaturedProducts(ctx, req)
@@ -555,7 +580,7 @@ func (a *AdminHandler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
 	if req.Model.Id == 0 {
@@ -587,7 +612,7 @@ func (a *AdminHandler) DeleteProduct(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
 	if req.Id == 0 {
@@ -612,7 +637,7 @@ func (a *AdminHandler) DeleteProduct(w http.ResponseWriter, r *http.Request) {
 // @Failure        500 {string} string "Ошибка сервера"
 // @Router         /admin/products/all [delete]
 func (a *AdminHandler) DeleteAllProducts(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
 	resp, err := a.Clients.ProductClient.GetFeaturedProducts(ctx, &pb.FeaturedRequest{Amount: 0, IncludeDeleted: true})
@@ -648,7 +673,7 @@ func (a *AdminHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 	if req.Email == "" {
 		http.Error(w, "Email can't be null", http.StatusBadRequest)
@@ -682,7 +707,7 @@ func (a *AdminHandler) GetUserByEmail(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
 	if req.Email == "" {
@@ -726,7 +751,7 @@ func (a *AdminHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 	}
 	req.Model = &pb.Model{Id: uint32(userID)}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 	if req.Model.Id == 0 {
 		errMsg := "user ID is required for update"
@@ -762,7 +787,7 @@ func (a *AdminHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 	if userID == 0 {
 		errMsg := "user ID is required for update"
@@ -787,7 +812,7 @@ func (a *AdminHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
 // @Failure        500 {string} string "Ошибка сервера"
 // @Router         /admin/users/all [delete]
 func (a *AdminHandler) DeleteAllUsers(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
 	users := []string{"test@example.com", "test-updated@example.com"}
@@ -825,7 +850,7 @@ func (a *AdminHandler) AddClick(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
 	_, err := a.Clients.StatClient.AddClick(ctx, &req)
@@ -848,7 +873,7 @@ func (a *AdminHandler) AddClick(w http.ResponseWriter, r *http.Request) {
 // @Failure        500 {string} string "Ошибка сервера"
 // @Router         /admin/home [get]
 func (a *AdminHandler) GetHomeData(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
 	resp, err := a.Clients.HomeClient.GetHomeData(ctx, &pb.EmptyRequest{})
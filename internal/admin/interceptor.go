@@ -0,0 +1,251 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/ShopOnGO/ShopOnGO/internal/audit"
+	"github.com/ShopOnGO/ShopOnGO/internal/product"
+	"github.com/ShopOnGO/ShopOnGO/internal/user"
+	"github.com/ShopOnGO/ShopOnGO/pkg/logger"
+	"github.com/ShopOnGO/ShopOnGO/pkg/middleware"
+	pb "github.com/ShopOnGO/admin-proto/pkg/service"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// Ключи metadata, по которым admin-сервис узнает, кто выполняет вызов.
+const (
+	MetadataUserID    = "x-user-id"
+	MetadataUserRole  = "x-user-role"
+	MetadataRequestID = "x-request-id"
+)
+
+// AuditRecorder пишет запись в журнал аудита.
+type AuditRecorder interface {
+	Record(ctx context.Context, entry *audit.Entry) error
+}
+
+// UserLookup находит пользователя в локальной базе: admin-сервис ищет
+// пользователей только по email, а удаление приходит с ID.
+type UserLookup interface {
+	FindByID(id uint) (*user.User, error)
+}
+
+// ProductLookup находит товар по ID в общей с admin-сервисом базе: чтения товара
+// по ID у admin-сервиса нет.
+type ProductLookup interface {
+	FindByID(id uint) (*product.Product, error)
+}
+
+// beforeLoader читает состояние ресурса до изменения: ответ admin-сервиса
+// или запись из локальной базы.
+type beforeLoader func(ctx context.Context, a *auditor, req any) (any, error)
+
+type auditedMethod struct {
+	Action string
+	Before beforeLoader
+}
+
+// auditedMethods — изменяющие вызовы admin-сервиса. Чтения в журнал не попадают.
+var auditedMethods = map[string]auditedMethod{
+	pb.CategoryService_CreateCategory_FullMethodName: {Action: "category.create"},
+	pb.CategoryService_UpdateCategory_FullMethodName: {Action: "category.update", Before: func(ctx context.Context, a *auditor, req any) (any, error) {
+		return a.clients.CategoryClient.FindCategoryByID(ctx, &pb.FindCategoryByIDRequest{Id: req.(*pb.UpdateCategoryRequest).GetId()})
+	}},
+	pb.CategoryService_DeleteCategory_FullMethodName: {Action: "category.delete", Before: func(ctx context.Context, a *auditor, req any) (any, error) {
+		return a.clients.CategoryClient.FindCategoryByName(ctx, &pb.FindCategoryByNameRequest{Name: req.(*pb.DeleteCategoryByNameRequest).GetName()})
+	}},
+
+	pb.BrandService_CreateBrand_FullMethodName: {Action: "brand.create"},
+	pb.BrandService_UpdateBrand_FullMethodName: {Action: "brand.update", Before: func(ctx context.Context, a *auditor, req any) (any, error) {
+		return a.clients.BrandClient.FindBrandByID(ctx, &pb.FindBrandByIDRequest{Id: req.(*pb.UpdateBrandRequest).GetId()})
+	}},
+	pb.BrandService_DeleteBrand_FullMethodName: {Action: "brand.delete", Before: func(ctx context.Context, a *auditor, req any) (any, error) {
+		return a.clients.BrandClient.FindBrandByName(ctx, &pb.FindBrandByNameRequest{Name: req.(*pb.DeleteBrandRequest).GetName()})
+	}},
+
+	pb.ProductService_CreateProduct_FullMethodName: {Action: "product.create"},
+	pb.ProductService_UpdateProduct_FullMethodName: {Action: "product.update", Before: func(ctx context.Context, a *auditor, req any) (any, error) {
+		return loadProduct(a, uint64(req.(*pb.Product).GetModel().GetId()))
+	}},
+	pb.ProductService_DeleteProduct_FullMethodName: {Action: "product.delete", Before: func(ctx context.Context, a *auditor, req any) (any, error) {
+		return loadProduct(a, req.(*pb.DeleteProductRequest).GetId())
+	}},
+
+	pb.ProductVariantService_CreateVariant_FullMethodName: {Action: "product_variant.create"},
+	pb.ProductVariantService_UpdateVariant_FullMethodName: {Action: "product_variant.update", Before: func(ctx context.Context, a *auditor, req any) (any, error) {
+		return loadVariant(ctx, a.clients, req.(*pb.ProductVariant).GetModel().GetId())
+	}},
+	pb.ProductVariantService_DeleteVariant_FullMethodName: {Action: "product_variant.delete", Before: func(ctx context.Context, a *auditor, req any) (any, error) {
+		return loadVariant(ctx, a.clients, req.(*pb.DeleteVariantRequest).GetId())
+	}},
+	pb.ProductVariantService_ManageStock_FullMethodName: {Action: "product_variant.stock", Before: func(ctx context.Context, a *auditor, req any) (any, error) {
+		return loadVariant(ctx, a.clients, req.(*pb.StockRequest).GetVariantId())
+	}},
+
+	pb.UserService_CreateUser_FullMethodName: {Action: "user.create"},
+	pb.UserService_UpdateUser_FullMethodName: {Action: "user.update", Before: func(ctx context.Context, a *auditor, req any) (any, error) {
+		return a.clients.UserClient.FindUserByEmail(ctx, &pb.EmailRequest{Email: req.(*pb.User).GetEmail()})
+	}},
+	pb.UserService_DeleteUser_FullMethodName: {Action: "user.delete", Before: func(ctx context.Context, a *auditor, req any) (any, error) {
+		return loadUser(ctx, a, req.(*pb.DeleteUserRequest).GetId())
+	}},
+}
+
+var errBeforeNotFound = errors.New("resource not found")
+
+func loadVariant(ctx context.Context, c *GRPCClients, id uint32) (any, error) {
+	return c.ProductVariantClient.GetVariant(ctx, &pb.VariantRequest{Identifier: &pb.VariantRequest_Id{Id: id}})
+}
+
+func loadProduct(a *auditor, id uint64) (any, error) {
+	if a.products == nil {
+		return nil, errors.New("product lookup is not configured")
+	}
+	p, err := a.products.FindByID(uint(id))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("product %d: %w", id, errBeforeNotFound)
+	}
+	return p, err
+}
+
+// loadUser находит email пользователя по ID в локальной базе и читает его
+// состояние в admin-сервисе.
+func loadUser(ctx context.Context, a *auditor, id uint64) (any, error) {
+	if a.users == nil {
+		return nil, errors.New("user lookup is not configured")
+	}
+	u, err := a.users.FindByID(uint(id))
+	if err != nil {
+		return nil, err
+	}
+	if u == nil {
+		return nil, fmt.Errorf("user %d: %w", id, errBeforeNotFound)
+	}
+	return a.clients.UserClient.FindUserByEmail(ctx, &pb.EmailRequest{Email: u.Email})
+}
+
+// identityInterceptor передает admin-сервису пользователя и ID запроса из контекста HTTP-запроса.
+func identityInterceptor(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	var pairs []string
+	if userID, ok := ctx.Value(middleware.ContextUserIDKey).(uint); ok {
+		pairs = append(pairs, MetadataUserID, strconv.FormatUint(uint64(userID), 10))
+	}
+	if role, ok := ctx.Value(middleware.ContextRolesKey).(string); ok && role != "" {
+		pairs = append(pairs, MetadataUserRole, role)
+	}
+	if requestID := middleware.RequestIDFromContext(ctx); requestID != "" {
+		pairs = append(pairs, MetadataRequestID, requestID)
+	}
+	if len(pairs) > 0 {
+		ctx = metadata.AppendToOutgoingContext(ctx, pairs...)
+	}
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
+
+// auditor пишет в журнал каждый изменяющий вызов: запрос, состояние до и ответ после.
+type auditor struct {
+	recorder AuditRecorder
+	clients  *GRPCClients
+	users    UserLookup
+	products ProductLookup
+}
+
+func (a *auditor) intercept(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	audited, ok := auditedMethods[method]
+	if !ok || a.recorder == nil {
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+
+	entry := &audit.Entry{
+		Action:     audited.Action,
+		ResourceID: resourceID(req),
+		Request:    payloadJSON(req),
+	}
+	if audited.Before != nil {
+		before, err := audited.Before(ctx, a, req)
+		if err != nil {
+			logger.Warnf("⚠️ Audit: failed to load state before %s: %v", audited.Action, err)
+		} else {
+			entry.Before = payloadJSON(before)
+		}
+	}
+
+	err := invoker(ctx, method, req, reply, cc, opts...)
+	entry.Success = err == nil
+	if err != nil {
+		entry.Error = status.Convert(err).Message()
+	} else {
+		entry.After = payloadJSON(reply)
+		if entry.ResourceID == "" {
+			entry.ResourceID = resourceID(reply)
+		}
+	}
+
+	// результат вызова не меняем, но потерянная запись должна быть видна в логах
+	if recordErr := a.recorder.Record(ctx, entry); recordErr != nil {
+		logger.Errorf("❌ Audit: failed to record %s (request %s): %v",
+			audited.Action, middleware.RequestIDFromContext(ctx), recordErr)
+	}
+	return err
+}
+
+// payloadJSON сериализует сообщения gRPC через protojson, остальное — как audit.JSON.
+func payloadJSON(v any) datatypes.JSON {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return audit.JSON(v)
+	}
+	if msg == nil {
+		return nil
+	}
+	data, err := protojson.Marshal(msg)
+	if err != nil {
+		logger.Errorf("❌ Audit: failed to marshal %T: %v", v, err)
+		return nil
+	}
+	return datatypes.JSON(data)
+}
+
+// resourceID достает ID ресурса из сообщения: поле id/variant_id, в том числе во
+// вложенных сущностях (UserResponse.user.model.id), иначе имя — для удаления по имени.
+func resourceID(v any) string {
+	msg, ok := v.(proto.Message)
+	if !ok || msg == nil {
+		return ""
+	}
+	return findResourceID(msg.ProtoReflect(), 3)
+}
+
+func findResourceID(m protoreflect.Message, depth int) string {
+	fields := m.Descriptor().Fields()
+	for _, name := range []protoreflect.Name{"id", "variant_id"} {
+		if fd := fields.ByName(name); fd != nil && m.Has(fd) {
+			return fmt.Sprint(m.Get(fd).Interface())
+		}
+	}
+	if depth > 0 {
+		for i := 0; i < fields.Len(); i++ {
+			fd := fields.Get(i)
+			if fd.Kind() != protoreflect.MessageKind || fd.IsList() || fd.IsMap() || !m.Has(fd) {
+				continue
+			}
+			if id := findResourceID(m.Get(fd).Message(), depth-1); id != "" {
+				return id
+			}
+		}
+	}
+	if fd := fields.ByName("name"); fd != nil && m.Has(fd) {
+		return m.Get(fd).String()
+	}
+	return ""
+}
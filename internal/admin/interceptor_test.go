@@ -0,0 +1,194 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ShopOnGO/ShopOnGO/internal/audit"
+	"github.com/ShopOnGO/ShopOnGO/internal/product"
+	"github.com/ShopOnGO/ShopOnGO/internal/user"
+	"github.com/ShopOnGO/ShopOnGO/pkg/middleware"
+	pb "github.com/ShopOnGO/admin-proto/pkg/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"gorm.io/gorm"
+)
+
+type memoryRecorder struct {
+	entries []*audit.Entry
+}
+
+func (m *memoryRecorder) Record(ctx context.Context, entry *audit.Entry) error {
+	m.entries = append(m.entries, entry)
+	return nil
+}
+
+type failingRecorder struct{}
+
+func (failingRecorder) Record(ctx context.Context, entry *audit.Entry) error {
+	return errors.New("db unavailable")
+}
+
+// fakeCategoryClient отдает текущее состояние категории для записи "до".
+type fakeCategoryClient struct {
+	pb.CategoryServiceClient
+	current *pb.Category
+}
+
+func (f *fakeCategoryClient) FindCategoryByID(ctx context.Context, in *pb.FindCategoryByIDRequest, opts ...grpc.CallOption) (*pb.FindCategoryByIDResponse, error) {
+	return &pb.FindCategoryByIDResponse{Category: f.current}, nil
+}
+
+// fakeProducts — товары локальной базы.
+type fakeProducts map[uint]*product.Product
+
+func (f fakeProducts) FindByID(id uint) (*product.Product, error) {
+	if p, ok := f[id]; ok {
+		return p, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+type fakeUserClient struct {
+	pb.UserServiceClient
+}
+
+func (f *fakeUserClient) FindUserByEmail(ctx context.Context, in *pb.EmailRequest, opts ...grpc.CallOption) (*pb.UserResponse, error) {
+	return &pb.UserResponse{User: &pb.User{Model: &pb.Model{Id: 9}, Email: in.Email}}, nil
+}
+
+type fakeUsers struct{}
+
+func (fakeUsers) FindByID(id uint) (*user.User, error) {
+	return &user.User{Model: gorm.Model{ID: id}, Email: "old@example.com"}, nil
+}
+
+func newTestAuditor() (*auditor, *memoryRecorder) {
+	recorder := &memoryRecorder{}
+	clients := &GRPCClients{
+		CategoryClient: &fakeCategoryClient{
+			current: &pb.Category{Model: &pb.Model{Id: 5}, Name: "Обувь"},
+		},
+		UserClient: &fakeUserClient{},
+	}
+	products := fakeProducts{
+		3: {Model: gorm.Model{ID: 3}, Name: "Кеды", CategoryID: 5},
+		4: {Model: gorm.Model{ID: 4}, Name: "Ботинки", CategoryID: 5},
+	}
+	return &auditor{recorder: recorder, clients: clients, users: fakeUsers{}, products: products}, recorder
+}
+
+// invokerReturning имитирует admin-сервис: заполняет ответ или возвращает ошибку.
+func invokerReturning(resp proto.Message, err error) grpc.UnaryInvoker {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		if err != nil {
+			return err
+		}
+		proto.Merge(reply.(proto.Message), resp)
+		return nil
+	}
+}
+
+func TestAuditInterceptor(t *testing.T) {
+	req := &pb.UpdateCategoryRequest{Id: 5, Name: "Кроссовки"}
+
+	t.Run("Mutation recorded with before and after", func(t *testing.T) {
+		a, recorder := newTestAuditor()
+		updated := &pb.UpdateCategoryResponse{Category: &pb.Category{Model: &pb.Model{Id: 5}, Name: "Кроссовки"}}
+
+		err := a.intercept(context.Background(), pb.CategoryService_UpdateCategory_FullMethodName,
+			req, &pb.UpdateCategoryResponse{}, nil, invokerReturning(updated, nil))
+		require.NoError(t, err)
+		require.Len(t, recorder.entries, 1)
+
+		entry := recorder.entries[0]
+		assert.Equal(t, "category.update", entry.Action)
+		assert.Equal(t, "5", entry.ResourceID)
+		assert.True(t, entry.Success)
+		assert.Contains(t, string(entry.Before), "Обувь")
+		assert.Contains(t, string(entry.After), "Кроссовки")
+	})
+
+	t.Run("Failed mutation recorded with error", func(t *testing.T) {
+		a, recorder := newTestAuditor()
+
+		err := a.intercept(context.Background(), pb.CategoryService_UpdateCategory_FullMethodName,
+			req, &pb.UpdateCategoryResponse{}, nil, invokerReturning(nil, status.Error(codes.NotFound, "category not found")))
+		assert.Error(t, err)
+		require.Len(t, recorder.entries, 1)
+		assert.False(t, recorder.entries[0].Success)
+		assert.Equal(t, "category not found", recorder.entries[0].Error)
+		assert.Nil(t, recorder.entries[0].After)
+	})
+
+	t.Run("Product update loads state by id", func(t *testing.T) {
+		a, recorder := newTestAuditor()
+		update := &pb.Product{Model: &pb.Model{Id: 4}, Name: "Сапоги", CategoryId: 5}
+
+		err := a.intercept(context.Background(), pb.ProductService_UpdateProduct_FullMethodName,
+			update, &pb.ProductResponse{}, nil, invokerReturning(&pb.ProductResponse{Product: update}, nil))
+		require.NoError(t, err)
+		require.Len(t, recorder.entries, 1)
+		assert.Contains(t, string(recorder.entries[0].Before), "Ботинки")
+	})
+
+	t.Run("Product and user deletes load state before", func(t *testing.T) {
+		a, recorder := newTestAuditor()
+
+		err := a.intercept(context.Background(), pb.ProductService_DeleteProduct_FullMethodName,
+			&pb.DeleteProductRequest{Id: 3}, &pb.Error{}, nil, invokerReturning(&pb.Error{}, nil))
+		require.NoError(t, err)
+		err = a.intercept(context.Background(), pb.UserService_DeleteUser_FullMethodName,
+			&pb.DeleteUserRequest{Id: 9}, &pb.Error{}, nil, invokerReturning(&pb.Error{}, nil))
+		require.NoError(t, err)
+
+		require.Len(t, recorder.entries, 2)
+		assert.Equal(t, "product.delete", recorder.entries[0].Action)
+		assert.Contains(t, string(recorder.entries[0].Before), "Кеды")
+		assert.Equal(t, "user.delete", recorder.entries[1].Action)
+		assert.Equal(t, "9", recorder.entries[1].ResourceID)
+		assert.Contains(t, string(recorder.entries[1].Before), "old@example.com")
+	})
+
+	t.Run("Record failure does not change the call result", func(t *testing.T) {
+		a, _ := newTestAuditor()
+		a.recorder = failingRecorder{}
+		updated := &pb.UpdateCategoryResponse{Category: &pb.Category{Model: &pb.Model{Id: 5}, Name: "Кроссовки"}}
+
+		err := a.intercept(context.Background(), pb.CategoryService_UpdateCategory_FullMethodName,
+			req, &pb.UpdateCategoryResponse{}, nil, invokerReturning(updated, nil))
+		assert.NoError(t, err)
+	})
+
+	t.Run("Reads are not audited", func(t *testing.T) {
+		a, recorder := newTestAuditor()
+
+		err := a.intercept(context.Background(), pb.CategoryService_GetFeaturedCategories_FullMethodName,
+			&pb.GetFeaturedCategoriesRequest{}, &pb.GetFeaturedCategoriesResponse{}, nil, invokerReturning(&pb.GetFeaturedCategoriesResponse{}, nil))
+		assert.NoError(t, err)
+		assert.Empty(t, recorder.entries)
+	})
+}
+
+func TestIdentityInterceptor(t *testing.T) {
+	ctx := context.WithValue(context.Background(), middleware.ContextUserIDKey, uint(42))
+	ctx = context.WithValue(ctx, middleware.ContextRolesKey, "admin")
+	ctx = context.WithValue(ctx, middleware.ContextRequestIDKey, "req-1")
+
+	var md metadata.MD
+	invoker := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		md, _ = metadata.FromOutgoingContext(ctx)
+		return errors.New("stop")
+	}
+	identityInterceptor(ctx, "/proto.UserService/DeleteUser", nil, nil, nil, invoker)
+
+	assert.Equal(t, []string{"42"}, md.Get(MetadataUserID))
+	assert.Equal(t, []string{"admin"}, md.Get(MetadataUserRole))
+	assert.Equal(t, []string{"req-1"}, md.Get(MetadataRequestID))
+}
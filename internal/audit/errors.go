@@ -0,0 +1,5 @@
+package audit
+
+import "errors"
+
+var ErrInvalidFilter = errors.New("invalid audit filter: actor_id must be a number, from/to must be RFC3339")
@@ -0,0 +1,108 @@
+package audit
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ShopOnGO/ShopOnGO/configs"
+	"github.com/ShopOnGO/ShopOnGO/internal/rbac"
+	"github.com/ShopOnGO/ShopOnGO/pkg/logger"
+	"github.com/ShopOnGO/ShopOnGO/pkg/middleware"
+	"github.com/ShopOnGO/ShopOnGO/pkg/res"
+	"github.com/gorilla/mux"
+)
+
+type AuditHandlerDeps struct {
+	*configs.Config
+	Guard *middleware.TokenGuard
+	*AuditService
+	Permissions middleware.PermissionChecker
+}
+
+type AuditHandler struct {
+	*configs.Config
+	*AuditService
+}
+
+func NewAuditHandler(router *mux.Router, deps AuditHandlerDeps) {
+	handler := &AuditHandler{
+		Config:       deps.Config,
+		AuditService: deps.AuditService,
+	}
+	protected := middleware.IsAuthed(
+		middleware.RequirePermission(handler.List(), rbac.PermAuditRead, deps.Permissions),
+		deps.Config,
+		deps.Guard,
+	)
+	router.Handle("/admin/audit", protected).Methods("GET")
+}
+
+// List returns audit log entries.
+// @Summary      Журнал аудита
+// @Description  Возвращает записи журнала аудита административных действий, новые сначала. Требует права audit:read.
+// @Tags         admin
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        actor_id     query  int     false  "ID пользователя, выполнившего действие"
+// @Param        action       query  string  false  "Действие, например category.update"
+// @Param        resource     query  string  false  "Ресурс, например category"
+// @Param        resource_id  query  string  false  "ID или имя ресурса"
+// @Param        request_id   query  string  false  "X-Request-ID запроса"
+// @Param        from         query  string  false  "Начало периода, RFC3339"
+// @Param        to           query  string  false  "Конец периода (не включая), RFC3339"
+// @Param        limit        query  int     false  "По умолчанию 50, не больше 200"
+// @Param        offset       query  int     false  "Смещение"
+// @Success      200  {object}  ListResponse
+// @Failure      400  {string}  string  "Некорректный фильтр"
+// @Failure      403  {string}  string  "Forbidden"
+// @Router       /admin/audit [get]
+func (h *AuditHandler) List() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		filter, err := parseFilter(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		entries, err := h.AuditService.List(filter)
+		if err != nil {
+			logger.Error("❌ audit list error: " + err.Error())
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+		res.Json(w, ListResponse{Entries: entries}, http.StatusOK)
+	}
+}
+
+func parseFilter(r *http.Request) (Filter, error) {
+	query := r.URL.Query()
+	filter := Filter{
+		Action:     query.Get("action"),
+		Resource:   query.Get("resource"),
+		ResourceID: query.Get("resource_id"),
+		RequestID:  query.Get("request_id"),
+	}
+	filter.Limit, _ = strconv.Atoi(query.Get("limit"))
+	filter.Offset, _ = strconv.Atoi(query.Get("offset"))
+
+	if v := query.Get("actor_id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return filter, ErrInvalidFilter
+		}
+		filter.ActorID = uint(id)
+	}
+	for name, dst := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		v := query.Get(name)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return filter, ErrInvalidFilter
+		}
+		*dst = &t
+	}
+	return filter, nil
+}
@@ -0,0 +1,29 @@
+package audit
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// Entry — запись журнала аудита. Журнал только дополняется: у записи нет
+// UpdatedAt/DeletedAt, а в БД изменения и удаления запрещены правилами (см. migrations).
+type Entry struct {
+	ID         uint           `gorm:"primaryKey" json:"id"`
+	CreatedAt  time.Time      `gorm:"index" json:"created_at"`
+	ActorID    uint           `gorm:"index" json:"actor_id"`
+	ActorRole  string         `json:"actor_role"`
+	Action     string         `gorm:"not null;index" json:"action"`
+	Resource   string         `gorm:"not null;index" json:"resource"`
+	ResourceID string         `gorm:"index" json:"resource_id,omitempty"`
+	RequestID  string         `gorm:"index" json:"request_id,omitempty"`
+	Request    datatypes.JSON `json:"request,omitempty" swaggertype:"object"`
+	Before     datatypes.JSON `json:"before,omitempty" swaggertype:"object"`
+	After      datatypes.JSON `json:"after,omitempty" swaggertype:"object"`
+	Success    bool           `json:"success"`
+	Error      string         `json:"error,omitempty"`
+}
+
+func (Entry) TableName() string {
+	return "audit_entries"
+}
@@ -0,0 +1,20 @@
+package audit
+
+import "time"
+
+// Filter — условия выборки журнала. Пустые поля не ограничивают выборку.
+type Filter struct {
+	ActorID    uint
+	Action     string
+	Resource   string
+	ResourceID string
+	RequestID  string
+	From       *time.Time
+	To         *time.Time
+	Limit      int
+	Offset     int
+}
+
+type ListResponse struct {
+	Entries []Entry `json:"entries"`
+}
@@ -0,0 +1,47 @@
+package audit
+
+import (
+	"github.com/ShopOnGO/ShopOnGO/pkg/db"
+)
+
+// AuditRepository умеет только добавлять и читать записи.
+type AuditRepository struct {
+	Database *db.Db
+}
+
+func NewAuditRepository(database *db.Db) *AuditRepository {
+	return &AuditRepository{Database: database}
+}
+
+func (repo *AuditRepository) Create(entry *Entry) error {
+	return repo.Database.DB.Create(entry).Error
+}
+
+func (repo *AuditRepository) List(filter Filter) ([]Entry, error) {
+	query := repo.Database.DB.Model(&Entry{})
+	if filter.ActorID != 0 {
+		query = query.Where("actor_id = ?", filter.ActorID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.Resource != "" {
+		query = query.Where("resource = ?", filter.Resource)
+	}
+	if filter.ResourceID != "" {
+		query = query.Where("resource_id = ?", filter.ResourceID)
+	}
+	if filter.RequestID != "" {
+		query = query.Where("request_id = ?", filter.RequestID)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+
+	var entries []Entry
+	result := query.Order("id DESC").Limit(filter.Limit).Offset(filter.Offset).Find(&entries)
+	return entries, result.Error
+}
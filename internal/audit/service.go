@@ -0,0 +1,79 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/ShopOnGO/ShopOnGO/pkg/logger"
+	"github.com/ShopOnGO/ShopOnGO/pkg/middleware"
+	"gorm.io/datatypes"
+)
+
+const (
+	defaultListLimit = 50
+	maxListLimit     = 200
+)
+
+type Repository interface {
+	Create(entry *Entry) error
+	List(filter Filter) ([]Entry, error)
+}
+
+type AuditService struct {
+	Repository Repository
+}
+
+func NewAuditService(repository Repository) *AuditService {
+	return &AuditService{Repository: repository}
+}
+
+// Record сохраняет запись. Кто и в рамках какого запроса действовал, берется из
+// контекста (IsAuthed, RequestID), если не задано явно. Resource по умолчанию —
+// часть Action до точки: "category.update" -> "category".
+func (service *AuditService) Record(ctx context.Context, entry *Entry) error {
+	if entry.ActorID == 0 {
+		entry.ActorID, _ = ctx.Value(middleware.ContextUserIDKey).(uint)
+	}
+	if entry.ActorRole == "" {
+		entry.ActorRole, _ = ctx.Value(middleware.ContextRolesKey).(string)
+	}
+	if entry.RequestID == "" {
+		entry.RequestID = middleware.RequestIDFromContext(ctx)
+	}
+	if entry.Resource == "" {
+		entry.Resource, _, _ = strings.Cut(entry.Action, ".")
+	}
+
+	if err := service.Repository.Create(entry); err != nil {
+		logger.Errorf("❌ Failed to write audit entry %s by user %d: %v", entry.Action, entry.ActorID, err)
+		return err
+	}
+	return nil
+}
+
+func (service *AuditService) List(filter Filter) ([]Entry, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultListLimit
+	}
+	if filter.Limit > maxListLimit {
+		filter.Limit = maxListLimit
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+	return service.Repository.List(filter)
+}
+
+// JSON сериализует состояние для полей Request/Before/After. nil остается nil.
+func JSON(v any) datatypes.JSON {
+	if v == nil {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		logger.Errorf("❌ Failed to marshal audit payload: %v", err)
+		return nil
+	}
+	return datatypes.JSON(data)
+}
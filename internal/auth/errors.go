@@ -1,10 +1,14 @@
 package auth
 
+import (
+	"errors"
+	"fmt"
+)
+
 const (
 	ErrUserExists       = "user exists"
 	ErrWrongCredentials = "wrong email or password"
 	ErrWrongPassword = "wrong password"
-	ErrGoogleAuthToLocalFailed = "your account is registered through google, sign in through google"
 	ErrRefreshTokenNotFound = "refresh token not found"
 	ErrFailedToExchangeToken = "failed to exchange token"
 	ErrFailedToGetUserInfo = "failed to get user info"
@@ -21,4 +25,24 @@ const (
 	FailedToUpdatePassword = "failed to update password"
 	ErrRecordNotFound = "record not found"
 	ErrorCreatingorFindingUser = "error creating or finding user"
+	ErrFailedToStartMFA = "failed to start two-factor authentication"
+	ErrProviderEmailNotVerified = "provider account email is not verified"
+	ErrFailedToSaveOAuthState = "failed to save oauth state"
+	ErrForeignAccount = "you can only change your own account"
+	ErrLinkAccountMismatch = "password does not match the account being linked"
 )
+
+// Ошибки, которые обработчики различают через errors.Is. Блокировка аккаунта —
+// middleware.ErrAccountSuspended, общая для всех пакетов.
+var (
+	ErrRoleRequiresApplication     = errors.New("this role cannot be self-assigned, submit a seller application instead")
+	ErrAccountLinkRequired         = errors.New("account with this email already exists, confirm your password to link the provider")
+	ErrRegisteredWithOtherProvider = errors.New("account with this email is registered through another provider, sign in with it and link this provider in your profile")
+	ErrPasswordlessAccount         = errors.New("account has no password, sign in through its provider")
+)
+
+// passwordlessAccountError дополняет ErrPasswordlessAccount провайдером, через который
+// зарегистрирован аккаунт.
+func passwordlessAccountError(provider string) error {
+	return fmt.Errorf("%w (%s)", ErrPasswordlessAccount, provider)
+}
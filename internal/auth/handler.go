@@ -2,64 +2,86 @@ package auth
 
 import (
 	"context"
-	"encoding/json"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
 	"net/http"
 	"time"
 
 	"github.com/ShopOnGO/ShopOnGO/configs"
 	_ "github.com/ShopOnGO/ShopOnGO/docs"
+	"github.com/ShopOnGO/ShopOnGO/internal/auth/mfa"
+	"github.com/ShopOnGO/ShopOnGO/internal/auth/verification"
+	"github.com/ShopOnGO/ShopOnGO/internal/identity"
+	"github.com/ShopOnGO/ShopOnGO/pkg/logger"
 	"github.com/ShopOnGO/ShopOnGO/pkg/middleware"
 	"github.com/ShopOnGO/ShopOnGO/pkg/oauth2"
+	"github.com/ShopOnGO/ShopOnGO/pkg/oauthprovider"
+	"github.com/ShopOnGO/ShopOnGO/pkg/password"
 	"github.com/ShopOnGO/ShopOnGO/pkg/req"
 	"github.com/ShopOnGO/ShopOnGO/pkg/res"
 	"github.com/gorilla/mux"
 
-	googleOAuth2 "golang.org/x/oauth2"
-	"golang.org/x/oauth2/google"
+	xoauth2 "golang.org/x/oauth2"
 )
 
 type AuthHandlerDeps struct {
 	*configs.Config
+	Guard *middleware.TokenGuard
 	*AuthService
-	OAuth2Service oauth2.OAuth2Service
+	OAuth2Service       oauth2.OAuth2Service
+	VerificationService *verification.VerificationService
+	MFAService          *mfa.MFAService
+	IdentityService     *identity.IdentityService
+	StateRepository     OAuthStateRepository
+	Providers           *oauthprovider.Registry
+	PasswordPolicy      *password.Policy
 }
 type AuthHandler struct {
 	*configs.Config
 	*AuthService
-	OAuth2Service oauth2.OAuth2Service
-}
-
-type GoogleUserInfo struct {
-	ID            string `json:"id"`
-	Name          string `json:"name"`
-	Email         string `json:"email"`
-	VerifiedEmail bool   `json:"verified_email"`
-	Picture       string `json:"picture"`
+	OAuth2Service       oauth2.OAuth2Service
+	VerificationService *verification.VerificationService
+	MFAService          *mfa.MFAService
+	IdentityService     *identity.IdentityService
+	StateRepository     OAuthStateRepository
+	Providers           *oauthprovider.Registry
+	PasswordPolicy      *password.Policy
 }
 
 func NewAuthHandler(router *mux.Router, deps AuthHandlerDeps) {
 	handler := &AuthHandler{
-		Config:        deps.Config,
-		AuthService:   deps.AuthService,
-		OAuth2Service: deps.OAuth2Service,
+		Config:              deps.Config,
+		AuthService:         deps.AuthService,
+		OAuth2Service:       deps.OAuth2Service,
+		VerificationService: deps.VerificationService,
+		MFAService:          deps.MFAService,
+		IdentityService:     deps.IdentityService,
+		StateRepository:     deps.StateRepository,
+		Providers:           deps.Providers,
+		PasswordPolicy:      deps.PasswordPolicy,
 	}
 	router.HandleFunc("/auth/login", handler.Login()).Methods("POST")
-	router.HandleFunc("/oauth/google/login", handler.GoogleLogin).Methods("GET")
+	router.Handle("/oauth/link/confirm", handler.LinkConfirm()).Methods("POST")
+	router.HandleFunc("/oauth/{provider}/login", handler.ProviderLogin).Methods("GET")
+	router.Handle("/auth/identities/{provider}/link", middleware.IsAuthed(handler.LinkProvider(), deps.Config, deps.Guard)).Methods("POST")
 	router.HandleFunc("/auth/register", handler.Register()).Methods("POST")
-	router.Handle("/auth/logout", middleware.IsAuthed(handler.Logout(), deps.Config)).Methods("POST")
-	router.Handle("/auth/change/role", middleware.IsAuthed(handler.ChangeUserRole(), deps.Config)).Methods("POST")
+	router.Handle("/auth/logout", middleware.IsAuthed(handler.Logout(), deps.Config, deps.Guard)).Methods("POST")
+	router.Handle("/auth/change/role", middleware.IsAuthed(handler.ChangeUserRole(), deps.Config, deps.Guard)).Methods("POST")
 }
 
 // Login аутентифицирует пользователя и выдает JWT токен
 // @Summary  Вход в систему
-// @Description Принимает email и пароль пользователя для аутентификации. В случае успеха возвращает JWT access-токен в теле ответа и устанавливает refresh-токен в HTTP-cookie.
+// @Description Принимает email и пароль пользователя для аутентификации. В случае успеха возвращает JWT access-токен в теле ответа и устанавливает refresh-токен в HTTP-cookie. Если у пользователя включена 2FA (или ее требует политика роли), возвращает 202 с mfa_token для второго шага /auth/mfa/verify (или /auth/mfa/enroll).
 // @Tags auth
 // @Accept  json
 // @Produce json
 // @Param  body body LoginRequest true "Данные для входа в систему"
 // @Success 200 {object} LoginResponse "Успешная аутентификация"
+// @Success 202 {object} mfa.ChallengeResponse "Требуется второй фактор"
 // @Failure  400 {object} res.ErrorResponse "Некорректный JSON или невалидные данные"
 // @Failure 401 {object} res.ErrorResponse "Неверные учетные данные (email или пароль)"
+// @Failure 403 {object} res.ErrorResponse "Аккаунт заблокирован"
 // @Failure  500 {object} res.ErrorResponse "Ошибка сервера при обработке запроса"
 // @Router  /auth/login [post]
 func (h *AuthHandler) Login() http.HandlerFunc {
@@ -70,6 +92,10 @@ func (h *AuthHandler) Login() http.HandlerFunc {
 		}
 
 		userID, err := h.AuthService.Login(body.Email, body.Password)
+		if errors.Is(err, middleware.ErrAccountSuspended) {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusUnauthorized)
 			return
@@ -81,6 +107,16 @@ func (h *AuthHandler) Login() http.HandlerFunc {
 			return
 		}
 
+		challenge, err := h.MFAService.StartLogin(userID, role)
+		if err != nil {
+			http.Error(w, ErrFailedToStartMFA+": "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if challenge != nil {
+			res.Json(w, challenge, http.StatusAccepted)
+			return
+		}
+
 		jwtToken, refreshToken, err := h.OAuth2Service.GenerateTokens(userID, role)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -111,13 +147,13 @@ func (h *AuthHandler) Login() http.HandlerFunc {
 
 // Register регистрирует нового пользователя и возвращает JWT токен
 // @Summary Регистрация нового пользователя
-// @Description Создает новую учетную запись с указанными email, паролем и именем. Возвращает JWT access-токен в теле ответа и устанавливает refresh-токен в HTTP-cookie.
+// @Description Создает новую учетную запись с указанными email, паролем и именем. Аккаунт создается с неподтвержденным email, код подтверждения отправляется на почту. Возвращает JWT access-токен в теле ответа и устанавливает refresh-токен в HTTP-cookie.
 // @Tags  auth
 // @Accept  json
 // @Produce json
 // @Param body body RegisterRequest true "Данные для регистрации"
 // @Success 201 {object} LoginResponse "Успешная регистрация и аутентификация"
-// @Failure 400 {object} res.ErrorResponse "Некорректный JSON или невалидные данные"
+// @Failure 400 {object} res.ErrorResponse "Некорректный JSON, невалидные данные или пароль не соответствует политике"
 // @Failure 409 {object} res.ErrorResponse "Пользователь с таким email уже существует"
 // @Failure 500 {object} res.ErrorResponse "Ошибка сервера при обработке запроса"
 // @Router  /auth/register [post]
@@ -128,12 +164,22 @@ func (h *AuthHandler) Register() http.HandlerFunc {
 			return
 		}
 
+		if err := h.PasswordPolicy.Validate(body.Password); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
 		userID, err := h.AuthService.Register(body.Email, body.Password, body.Name)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusUnauthorized)
 			return
 		}
 
+		// Ошибка отправки не мешает регистрации: код можно запросить повторно
+		if err := h.VerificationService.SendVerification(body.Email); err != nil {
+			logger.Error("❌ не удалось отправить код подтверждения: " + err.Error())
+		}
+
 		role, err := h.AuthService.GetUserRole(body.Email)
 		if err != nil {
 			http.Error(w, ErrFailedToGetUserRole+": "+err.Error(), http.StatusInternalServerError)
@@ -161,66 +207,258 @@ func (h *AuthHandler) Register() http.HandlerFunc {
 	}
 }
 
-// GoogleLogin выполняет аутентификацию пользователя через Google OAuth2
-// @Summary Авторизация через Google
-// @Description Первый шаг: перенаправляет пользователя на страницу согласия Google. После получения кода авторизации (на втором шаге) обменивает его на токены и получает информацию о пользователе. Регистрирует/аутентифицирует пользователя и возвращает JWT access-токен.
+// ProviderLogin выполняет аутентификацию пользователя через внешний провайдер (Google, Yandex, GitHub, ...)
+// @Summary Авторизация через внешний провайдер
+// @Description Первый шаг: создает одноразовый state и PKCE verifier (хранятся в Redis 10 минут) и перенаправляет пользователя на страницу согласия провайдера. На втором шаге проверяет state, обменивает код на токены и получает информацию о пользователе. Если аккаунт провайдера уже привязан или создается новый пользователь, возвращает JWT access-токен. Если существует локальный аккаунт с тем же email, возвращает 409 и link_token для подтверждения паролем через /oauth/link/confirm.
 // @Tags  auth
 // @Accept  json
 // @Produce json
-// @Param code query string false "Код авторизации от Google (автоматически передается после редиректа)"
-// @Success 200 {object} LoginResponse "Успешная авторизация, возвращает JWT access-токен"
+// @Param provider path string true "Имя провайдера из конфигурации, например google"
+// @Param code query string false "Код авторизации (автоматически передается после редиректа)"
+// @Param state query string false "State из первого шага (автоматически передается после редиректа)"
+// @Success 200 {object} map[string]string "Успешная авторизация, возвращает JWT access-токен"
+// @Success 202 {object} mfa.ChallengeResponse "Требуется второй фактор"
+// @Failure 400 {object} res.ErrorResponse "Неверный, просроченный или выданный другому браузеру state"
+// @Failure 403 {object} res.ErrorResponse "Email аккаунта провайдера не подтвержден"
+// @Failure 404 {object} res.ErrorResponse "Провайдер не настроен"
+// @Failure 409 {object} LinkRequiredResponse "Требуется подтвердить привязку к существующему аккаунту"
 // @Failure 500 {object} res.ErrorResponse "Ошибка при обмене кода на токен или получении данных пользователя"
-// @Router  /oauth/google/login [get]
-func (h *AuthHandler) GoogleLogin(w http.ResponseWriter, r *http.Request) {
-
-	googleOauthConfig := &googleOAuth2.Config{
-		ClientID:     h.Config.Google.ClientID,
-		ClientSecret: h.Config.Google.ClientSecret,
-		RedirectURL:  h.Config.Google.RedirectURL,
-		Scopes: []string{
-			"https://www.googleapis.com/auth/userinfo.email",
-			"https://www.googleapis.com/auth/userinfo.profile",
-		},
-		Endpoint: google.Endpoint,
+// @Router  /oauth/{provider}/login [get]
+func (h *AuthHandler) ProviderLogin(w http.ResponseWriter, r *http.Request) {
+	providerName := mux.Vars(r)["provider"]
+	provider, err := h.Providers.Get(providerName)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
 	}
 
-	// Если параметр code отсутствует, перенаправляем пользователя на страницу согласия Google
+	// Если параметр code отсутствует, перенаправляем пользователя на страницу согласия провайдера
 	code := r.URL.Query().Get("code")
 	if code == "" {
-		// можно добавить state для безопасности, пока используется простая строка "state-token"
-		url := googleOauthConfig.AuthCodeURL("state-token", googleOAuth2.AccessTypeOffline)
+		url, err := h.providerAuthURL(r.Context(), w, provider, 0)
+		if err != nil {
+			http.Error(w, ErrFailedToSaveOAuthState+": "+err.Error(), http.StatusInternalServerError)
+			return
+		}
 		http.Redirect(w, r, url, http.StatusTemporaryRedirect)
 		return
 	}
 
+	rawState := r.URL.Query().Get("state")
+	if !checkStateCookie(w, r, rawState) {
+		http.Error(w, ErrInvalidOAuthState.Error(), http.StatusBadRequest)
+		return
+	}
+	state, err := h.StateRepository.ConsumeState(rawState)
+	if err != nil {
+		if errors.Is(err, ErrInvalidOAuthState) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if state.Provider != providerName {
+		http.Error(w, ErrInvalidOAuthState.Error(), http.StatusBadRequest)
+		return
+	}
+
 	// обменчик кода на токен
-	token, err := googleOauthConfig.Exchange(context.Background(), code)
+	userInfo, err := provider.Exchange(r.Context(), code, state.Verifier)
 	if err != nil {
 		http.Error(w, ErrFailedToExchangeToken+": "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	client := googleOauthConfig.Client(context.Background(), token)
-	resp, err := client.Get("https://www.googleapis.com/oauth2/v2/userinfo")
+	// Привязка из профиля: пользователь уже авторизован, входить не нужно
+	if state.LinkUserID != 0 {
+		if err := h.IdentityService.Link(state.LinkUserID, providerName, userInfo.Subject, userInfo.Email); err != nil {
+			writeIdentityError(w, err)
+			return
+		}
+		res.Json(w, map[string]string{"message": "Account linked"}, http.StatusOK)
+		return
+	}
+
+	userID, err := h.IdentityService.FindUserID(providerName, userInfo.Subject)
+	if err != nil && !errors.Is(err, identity.ErrIdentityNotFound) {
+		http.Error(w, ErrorCreatingorFindingUser+": "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err == nil {
+		u, err := h.AuthService.UserRepository.FindByID(userID)
+		if err != nil {
+			http.Error(w, ErrorCreatingorFindingUser+": "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		h.completeOAuthLogin(w, u.ID, u.Role)
+		return
+	}
+
+	// Новый аккаунт провайдера: email должен быть подтвержден, иначе его нельзя сопоставлять с аккаунтами
+	if !userInfo.EmailVerified {
+		http.Error(w, ErrProviderEmailNotVerified, http.StatusForbidden)
+		return
+	}
+
+	user, err := h.AuthService.GetOrCreateUserByProvider(providerName, *userInfo)
 	if err != nil {
-		http.Error(w, ErrFailedToGetUserInfo+": "+err.Error(), http.StatusInternalServerError)
+		switch {
+		case errors.Is(err, ErrAccountLinkRequired):
+			h.requireLinkConfirmation(w, user.ID, providerName, userInfo)
+		case errors.Is(err, ErrRegisteredWithOtherProvider):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, ErrorCreatingorFindingUser+": "+err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	if err := h.IdentityService.Link(user.ID, providerName, userInfo.Subject, userInfo.Email); err != nil {
+		writeIdentityError(w, err)
 		return
 	}
-	defer resp.Body.Close() //пока так
 
-	var userInfo GoogleUserInfo
-	if err := json.NewDecoder(resp.Body).Decode(&userInfo); err != nil {
-		http.Error(w, ErrFailedToDecodeUserInfo+": "+err.Error(), http.StatusInternalServerError)
+	h.completeOAuthLogin(w, user.ID, user.Role)
+}
+
+// LinkConfirm привязывает провайдера к существующему локальному аккаунту после ввода пароля
+// @Summary Подтверждение привязки провайдера
+// @Description Принимает link_token из ответа 409 /oauth/{provider}/login и пароль локального аккаунта. Токен одноразовый: при неверном пароле вход через провайдера нужно начать заново. После привязки выполняет вход (с учетом 2FA).
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param body body LinkConfirmRequest true "link_token и пароль"
+// @Success 200 {object} map[string]string "Аккаунт привязан, возвращает JWT access-токен"
+// @Success 202 {object} mfa.ChallengeResponse "Требуется второй фактор"
+// @Failure 400 {object} res.ErrorResponse "Неверный или просроченный link_token"
+// @Failure 401 {object} res.ErrorResponse "Неверный пароль"
+// @Failure 409 {object} res.ErrorResponse "Аккаунт провайдера уже привязан к другому пользователю"
+// @Router /oauth/link/confirm [post]
+func (h *AuthHandler) LinkConfirm() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := req.HandleBody[LinkConfirmRequest](&w, r)
+		if err != nil {
+			return
+		}
+
+		link, err := h.StateRepository.ConsumePendingLink(body.LinkToken)
+		if err != nil {
+			if errors.Is(err, ErrInvalidLinkToken) {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		u, err := h.AuthService.UserRepository.FindByID(link.UserID)
+		if err != nil {
+			http.Error(w, ErrUserNotFound, http.StatusUnauthorized)
+			return
+		}
+		userID, err := h.AuthService.Login(u.Email, body.Password)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		if userID != link.UserID {
+			http.Error(w, ErrLinkAccountMismatch, http.StatusUnauthorized)
+			return
+		}
+
+		if err := h.IdentityService.Link(link.UserID, link.Provider, link.Subject, link.Email); err != nil {
+			writeIdentityError(w, err)
+			return
+		}
+
+		h.completeOAuthLogin(w, u.ID, u.Role)
+	}
+}
+
+// LinkProvider начинает привязку провайдера к текущему аккаунту
+// @Summary Привязка провайдера к профилю
+// @Description Возвращает URL страницы согласия провайдера. После редиректа на /oauth/{provider}/login аккаунт провайдера привязывается к текущему пользователю. Запрос нужно отправлять с cookie: ответ выставляет cookie oauth_state, без которой callback отклоняется.
+// @Tags auth
+// @Produce json
+// @Security ApiKeyAuth
+// @Param provider path string true "Имя провайдера, например google"
+// @Success 200 {object} AuthURLResponse "URL для перехода"
+// @Failure 401 {object} res.ErrorResponse "Неавторизован"
+// @Failure 404 {object} res.ErrorResponse "Провайдер не настроен"
+// @Router /auth/identities/{provider}/link [post]
+func (h *AuthHandler) LinkProvider() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, _ := r.Context().Value(middleware.ContextUserIDKey).(uint)
+		provider, err := h.Providers.Get(mux.Vars(r)["provider"])
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		url, err := h.providerAuthURL(r.Context(), w, provider, userID)
+		if err != nil {
+			http.Error(w, ErrFailedToSaveOAuthState+": "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		res.Json(w, AuthURLResponse{URL: url}, http.StatusOK)
+	}
+}
+
+// providerAuthURL сохраняет одноразовый state с PKCE verifier, привязывает его
+// cookie к браузеру и строит URL согласия.
+func (h *AuthHandler) providerAuthURL(ctx context.Context, w http.ResponseWriter, provider oauthprovider.Provider, linkUserID uint) (string, error) {
+	state, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+	verifier := xoauth2.GenerateVerifier()
+	url, err := provider.AuthCodeURL(ctx, state, verifier)
+	if err != nil {
+		return "", err
+	}
+	data := &OAuthState{Provider: provider.Name(), Verifier: verifier, LinkUserID: linkUserID}
+	if err := h.StateRepository.SaveState(state, data, oauthStateTTL); err != nil {
+		return "", err
+	}
+	setStateCookie(w, state)
+	return url, nil
+}
+
+func (h *AuthHandler) requireLinkConfirmation(w http.ResponseWriter, userID uint, provider string, userInfo *oauthprovider.UserInfo) {
+	linkToken, err := randomToken()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	link := &PendingLink{UserID: userID, Provider: provider, Subject: userInfo.Subject, Email: userInfo.Email}
+	if err := h.StateRepository.SavePendingLink(linkToken, link, pendingLinkTTL); err != nil {
+		http.Error(w, ErrFailedToSaveOAuthState+": "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	res.Json(w, LinkRequiredResponse{
+		LinkRequired: true,
+		LinkToken:    linkToken,
+		Email:        userInfo.Email,
+	}, http.StatusConflict)
+}
 
-	user, err := h.AuthService.GetOrCreateUserByGoogle(userInfo)
+// completeOAuthLogin выдает токены после входа через провайдера или запускает второй фактор.
+func (h *AuthHandler) completeOAuthLogin(w http.ResponseWriter, userID uint, role string) {
+	if err := h.AuthService.EnsureActive(userID); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	challenge, err := h.MFAService.StartLogin(userID, role)
 	if err != nil {
-		http.Error(w, ErrorCreatingorFindingUser+": "+err.Error(), http.StatusInternalServerError)
+		http.Error(w, ErrFailedToStartMFA+": "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if challenge != nil {
+		res.Json(w, challenge, http.StatusAccepted)
 		return
 	}
 
-	jwtToken, refreshToken, err := h.OAuth2Service.GenerateTokens(user.ID, user.Role)
+	jwtToken, refreshToken, err := h.OAuth2Service.GenerateTokens(userID, role)
 	if err != nil {
 		http.Error(w, ErrFailedToGenerateTokens+": "+err.Error(), http.StatusInternalServerError)
 		return
@@ -234,12 +472,25 @@ func (h *AuthHandler) GoogleLogin(w http.ResponseWriter, r *http.Request) {
 		Expires:  time.Now().Add(h.Config.Redis.RefreshTokenTTL),
 	})
 
-	response := map[string]string{
-		"access_token": jwtToken,
+	res.Json(w, map[string]string{"access_token": jwtToken}, http.StatusOK)
+}
+
+func writeIdentityError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, identity.ErrAlreadyLinked), errors.Is(err, identity.ErrProviderAlreadyLinked):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		logger.Error("❌ ошибка привязки аккаунта: " + err.Error())
+		http.Error(w, err.Error(), http.StatusInternalServerError)
 	}
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(response)
+}
+
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
 }
 
 // Logout завершает сеанс пользователя и удаляет refresh-токен из cookie
@@ -290,7 +541,7 @@ func (h *AuthHandler) Logout() http.HandlerFunc {
 
 // ChangeUserRole изменяет роль пользователя
 // @Summary        Изменение роли пользователя
-// @Description    Изменяет роль пользователя, требует авторизации (Bearer токен)
+// @Description    Обновляет данные текущего пользователя и позволяет вернуться к роли покупателя. Стать продавцом можно только через заявку /seller/applications, повышение до других ролей самостоятельно невозможно. Требует авторизации (Bearer токен).
 // @Tags           auth
 // @Accept         json
 // @Produce        json
@@ -298,7 +549,7 @@ func (h *AuthHandler) Logout() http.HandlerFunc {
 // @Success        200 {object} map[string]string "Сообщение об успешном изменении роли"
 // @Failure        400 {string} string "Некорректные данные"
 // @Failure        401 {string} string "Неавторизован"
-// @Failure        403 {string} string "Недостаточно прав"
+// @Failure        403 {string} string "Роль нельзя назначить самостоятельно или email чужого аккаунта"
 // @Failure        500 {string} string "Ошибка сервера"
 // @Router         /auth/change/role [post]
 func (h *AuthHandler) ChangeUserRole() http.HandlerFunc {
@@ -309,8 +560,23 @@ func (h *AuthHandler) ChangeUserRole() http.HandlerFunc {
 			return
 		}
 
+		currentUserID, _ := r.Context().Value(middleware.ContextUserIDKey).(uint)
+		currentUser, err := h.AuthService.UserRepository.FindByID(currentUserID)
+		if err != nil {
+			http.Error(w, ErrUserNotFound, http.StatusUnauthorized)
+			return
+		}
+		if currentUser.Email != body.Email {
+			http.Error(w, ErrForeignAccount, http.StatusForbidden)
+			return
+		}
+
 		// Обновляем роль пользователя в базе данных
 		if err := h.AuthService.UpdateUser(body); err != nil {
+			if errors.Is(err, ErrRoleRequiresApplication) {
+				http.Error(w, err.Error(), http.StatusForbidden)
+				return
+			}
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
@@ -0,0 +1,95 @@
+package mfa
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/ShopOnGO/ShopOnGO/pkg/redisdb"
+)
+
+// RedisChallengeRepository хранит MFA challenge-токены между шагами входа.
+type RedisChallengeRepository struct {
+	redis *redisdb.RedisDB
+}
+
+func NewRedisChallengeRepository(r *redisdb.RedisDB) *RedisChallengeRepository {
+	return &RedisChallengeRepository{redis: r}
+}
+
+func (r *RedisChallengeRepository) SaveChallenge(token string, challenge *Challenge, ttl time.Duration) error {
+	data, err := json.Marshal(challenge)
+	if err != nil {
+		return err
+	}
+	return r.redis.Set(context.Background(), r.key(token), data, ttl).Err()
+}
+
+func (r *RedisChallengeRepository) GetChallenge(token string) (*Challenge, error) {
+	data, err := r.redis.Get(context.Background(), r.key(token)).Result()
+	if err == redis.Nil {
+		return nil, ErrInvalidChallenge
+	}
+	if err != nil {
+		return nil, err
+	}
+	var challenge Challenge
+	if err := json.Unmarshal([]byte(data), &challenge); err != nil {
+		return nil, err
+	}
+	return &challenge, nil
+}
+
+func (r *RedisChallengeRepository) DeleteChallenge(token string) error {
+	return r.redis.Del(context.Background(), r.key(token), r.attemptsKey(token)).Err()
+}
+
+func (r *RedisChallengeRepository) IncrementAttempts(token string, ttl time.Duration) (int, error) {
+	return r.increment(r.attemptsKey(token), ttl)
+}
+
+// UserAttempts возвращает число неверных кодов пользователя вне входа (отключение 2FA,
+// новые коды восстановления).
+func (r *RedisChallengeRepository) UserAttempts(userID uint) (int, error) {
+	count, err := r.redis.Get(context.Background(), r.userAttemptsKey(userID)).Int()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return count, err
+}
+
+func (r *RedisChallengeRepository) IncrementUserAttempts(userID uint, ttl time.Duration) (int, error) {
+	return r.increment(r.userAttemptsKey(userID), ttl)
+}
+
+func (r *RedisChallengeRepository) ResetUserAttempts(userID uint) error {
+	return r.redis.Del(context.Background(), r.userAttemptsKey(userID)).Err()
+}
+
+func (r *RedisChallengeRepository) increment(key string, ttl time.Duration) (int, error) {
+	count, err := r.redis.Incr(context.Background(), key).Result()
+	if err != nil {
+		return 0, err
+	}
+	if count == 1 {
+		if err := r.redis.Expire(context.Background(), key, ttl).Err(); err != nil {
+			return 0, err
+		}
+	}
+	return int(count), nil
+}
+
+func (r *RedisChallengeRepository) key(token string) string {
+	return fmt.Sprintf("mfa_challenge:%s", token)
+}
+
+func (r *RedisChallengeRepository) attemptsKey(token string) string {
+	return fmt.Sprintf("mfa_challenge_attempts:%s", token)
+}
+
+func (r *RedisChallengeRepository) userAttemptsKey(userID uint) string {
+	return fmt.Sprintf("mfa_user_attempts:%d", userID)
+}
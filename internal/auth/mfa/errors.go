@@ -0,0 +1,14 @@
+package mfa
+
+import "errors"
+
+var (
+	ErrNotEnrolled      = errors.New("two-factor authentication is not enabled")
+	ErrAlreadyEnabled   = errors.New("two-factor authentication is already enabled")
+	ErrInvalidCode      = errors.New("invalid two-factor code")
+	ErrInvalidChallenge = errors.New("invalid or expired mfa token")
+	ErrTooManyAttempts  = errors.New("too many attempts, sign in again")
+	ErrLocked           = errors.New("too many invalid codes, try again later")
+	ErrRequiredByPolicy = errors.New("two-factor authentication is required for your role")
+	ErrUnauthorized     = errors.New("authorization or mfa token required")
+)
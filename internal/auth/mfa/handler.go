@@ -0,0 +1,311 @@
+package mfa
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/ShopOnGO/ShopOnGO/configs"
+	"github.com/ShopOnGO/ShopOnGO/pkg/di"
+	"github.com/ShopOnGO/ShopOnGO/pkg/logger"
+	"github.com/ShopOnGO/ShopOnGO/pkg/middleware"
+	"github.com/ShopOnGO/ShopOnGO/pkg/oauth2"
+	"github.com/ShopOnGO/ShopOnGO/pkg/req"
+	"github.com/ShopOnGO/ShopOnGO/pkg/res"
+	"github.com/gorilla/mux"
+)
+
+type MFAHandlerDeps struct {
+	*configs.Config
+	Guard *middleware.TokenGuard
+	*MFAService
+	OAuth2Service  oauth2.OAuth2Service
+	UserRepository di.IUserRepository
+}
+
+type MFAHandler struct {
+	*configs.Config
+	Guard *middleware.TokenGuard
+	*MFAService
+	OAuth2Service  oauth2.OAuth2Service
+	UserRepository di.IUserRepository
+}
+
+func NewMFAHandler(router *mux.Router, deps MFAHandlerDeps) {
+	handler := &MFAHandler{
+		Config:         deps.Config,
+		Guard:          deps.Guard,
+		MFAService:     deps.MFAService,
+		OAuth2Service:  deps.OAuth2Service,
+		UserRepository: deps.UserRepository,
+	}
+	// enroll доступен и по access-токену, и по mfa_token, если политика роли требует 2FA при входе
+	router.Handle("/auth/mfa/enroll", handler.enrollAuth(handler.Enroll())).Methods("POST")
+	router.Handle("/auth/mfa/enroll/confirm", handler.enrollAuth(handler.ConfirmEnroll())).Methods("POST")
+	router.Handle("/auth/mfa/verify", handler.Verify()).Methods("POST")
+	router.Handle("/auth/mfa/disable", middleware.IsAuthed(handler.Disable(), deps.Config, deps.Guard)).Methods("POST")
+	router.Handle("/auth/mfa/recovery-codes", middleware.IsAuthed(handler.RegenerateRecoveryCodes(), deps.Config, deps.Guard)).Methods("POST")
+}
+
+// Enroll starts TOTP enrollment.
+// @Summary      Start 2FA enrollment
+// @Description  Generates a new TOTP secret and an otpauth:// URI for the QR code. Requires either a Bearer access token or an mfa_token returned by login when the role policy requires 2FA.
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        body  body  EnrollRequest  false  "mfa_token from login (optional)"
+// @Success      200   {object}  EnrollResponse
+// @Failure      401   {string}  string  "Not authorized"
+// @Failure      403   {string}  string  "Account suspended"
+// @Failure      409   {string}  string  "2FA already enabled"
+// @Router       /auth/mfa/enroll [post]
+func (h *MFAHandler) Enroll() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := req.Decode[EnrollRequest](r.Body)
+		if err != nil && !errors.Is(err, io.EOF) {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+		userID, _, err := h.resolveUser(r, body.MFAToken)
+		if errors.Is(err, middleware.ErrAccountSuspended) {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		u, err := h.UserRepository.FindByID(userID)
+		if err != nil {
+			http.Error(w, "user not found", http.StatusUnauthorized)
+			return
+		}
+
+		resp, err := h.MFAService.Enroll(userID, u.Email)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		res.Json(w, resp, http.StatusOK)
+	}
+}
+
+// ConfirmEnroll enables 2FA with the first code from the authenticator app.
+// @Summary      Confirm 2FA enrollment
+// @Description  Verifies the first TOTP code, enables 2FA and returns one-time recovery codes (shown only once). When called with mfa_token, also completes the login and returns an access token.
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        body  body  ConfirmEnrollRequest  true  "TOTP code"
+// @Success      200   {object}  ConfirmEnrollResponse
+// @Failure      400   {string}  string  "Invalid code"
+// @Failure      401   {string}  string  "Not authorized"
+// @Failure      403   {string}  string  "Account suspended"
+// @Failure      429   {string}  string  "Too many invalid codes"
+// @Router       /auth/mfa/enroll/confirm [post]
+func (h *MFAHandler) ConfirmEnroll() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := req.HandleBody[ConfirmEnrollRequest](&w, r)
+		if err != nil {
+			return
+		}
+		userID, role, err := h.resolveUser(r, body.MFAToken)
+		if errors.Is(err, middleware.ErrAccountSuspended) {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		codes, err := h.MFAService.ConfirmEnrollment(userID, body.Code)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		resp := ConfirmEnrollResponse{RecoveryCodes: codes}
+		if body.MFAToken != "" {
+			if err := h.CompleteEnrollment(body.MFAToken); err != nil {
+				logger.Error("❌ failed to delete mfa challenge: " + err.Error())
+			}
+			resp.Token, err = h.issueTokens(w, userID, role)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+		res.Json(w, resp, http.StatusOK)
+	}
+}
+
+// Verify completes a two-step login.
+// @Summary      Verify 2FA code
+// @Description  Second login step: exchanges the mfa_token from /auth/login and a TOTP or recovery code for an access token and refresh cookie.
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        body  body  VerifyRequest  true  "mfa_token and code or recovery_code"
+// @Success      200   {object}  VerifyResponse
+// @Failure      400   {string}  string  "Invalid code"
+// @Failure      401   {string}  string  "Invalid or expired mfa token"
+// @Failure      429   {string}  string  "Too many attempts"
+// @Router       /auth/mfa/verify [post]
+func (h *MFAHandler) Verify() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := req.HandleBody[VerifyRequest](&w, r)
+		if err != nil {
+			return
+		}
+
+		challenge, err := h.VerifyLogin(body.MFAToken, body.Code, body.RecoveryCode)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		token, err := h.issueTokens(w, challenge.UserID, challenge.Role)
+		if errors.Is(err, middleware.ErrAccountSuspended) {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		res.Json(w, VerifyResponse{Token: token}, http.StatusOK)
+	}
+}
+
+// Disable turns 2FA off.
+// @Summary      Disable 2FA
+// @Description  Disables 2FA after a valid TOTP code. Not allowed for roles where the policy requires 2FA.
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        body  body  CodeRequest  true  "Current TOTP code"
+// @Success      200   {object}  map[string]string
+// @Failure      400   {string}  string  "Invalid code"
+// @Failure      403   {string}  string  "Required by policy"
+// @Failure      429   {string}  string  "Too many invalid codes"
+// @Router       /auth/mfa/disable [post]
+func (h *MFAHandler) Disable() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := req.HandleBody[CodeRequest](&w, r)
+		if err != nil {
+			return
+		}
+		userID, _ := r.Context().Value(middleware.ContextUserIDKey).(uint)
+		role, _ := r.Context().Value(middleware.ContextRolesKey).(string)
+
+		if err := h.MFAService.Disable(userID, role, body.Code); err != nil {
+			writeError(w, err)
+			return
+		}
+		res.Json(w, map[string]string{"message": "Two-factor authentication disabled"}, http.StatusOK)
+	}
+}
+
+// RegenerateRecoveryCodes issues a new set of recovery codes.
+// @Summary      Regenerate recovery codes
+// @Description  Invalidates all previous recovery codes and returns a new set. Requires a valid TOTP code.
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        body  body  CodeRequest  true  "Current TOTP code"
+// @Success      200   {object}  RecoveryCodesResponse
+// @Failure      400   {string}  string  "Invalid code"
+// @Failure      429   {string}  string  "Too many invalid codes"
+// @Router       /auth/mfa/recovery-codes [post]
+func (h *MFAHandler) RegenerateRecoveryCodes() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := req.HandleBody[CodeRequest](&w, r)
+		if err != nil {
+			return
+		}
+		userID, _ := r.Context().Value(middleware.ContextUserIDKey).(uint)
+
+		codes, err := h.MFAService.RegenerateRecoveryCodes(userID, body.Code)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		res.Json(w, RecoveryCodesResponse{RecoveryCodes: codes}, http.StatusOK)
+	}
+}
+
+// enrollAuth проверяет Bearer-токен через IsAuthed (включая проверку блокировки).
+// Запрос без Authorization пропускается дальше: пользователь определяется по mfa_token из тела.
+func (h *MFAHandler) enrollAuth(next http.Handler) http.Handler {
+	authed := middleware.IsAuthed(next, h.Config, h.Guard)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		authed.ServeHTTP(w, r)
+	})
+}
+
+// resolveUser определяет пользователя по контексту IsAuthed или по mfa_token назначения enroll.
+func (h *MFAHandler) resolveUser(r *http.Request, mfaToken string) (uint, string, error) {
+	if userID, ok := r.Context().Value(middleware.ContextUserIDKey).(uint); ok {
+		role, _ := r.Context().Value(middleware.ContextRolesKey).(string)
+		return userID, role, nil
+	}
+	if mfaToken != "" {
+		challenge, err := h.ResolveChallenge(mfaToken, PurposeEnroll)
+		if err != nil {
+			return 0, "", err
+		}
+		if err := h.Guard.CheckBanned(challenge.UserID); err != nil {
+			return 0, "", err
+		}
+		return challenge.UserID, challenge.Role, nil
+	}
+	return 0, "", ErrUnauthorized
+}
+
+func (h *MFAHandler) issueTokens(w http.ResponseWriter, userID uint, role string) (string, error) {
+	// пользователя могли заблокировать, пока он вводил код
+	if err := h.Guard.CheckBanned(userID); err != nil {
+		return "", err
+	}
+	jwtToken, refreshToken, err := h.OAuth2Service.GenerateTokens(userID, role)
+	if err != nil {
+		return "", err
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     "refresh_token",
+		Value:    refreshToken,
+		HttpOnly: true,
+		Path:     "/",
+		Expires:  time.Now().Add(h.Config.Redis.RefreshTokenTTL),
+	})
+	return jwtToken, nil
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrInvalidChallenge):
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+	case errors.Is(err, ErrTooManyAttempts), errors.Is(err, ErrLocked):
+		http.Error(w, err.Error(), http.StatusTooManyRequests)
+	case errors.Is(err, ErrRequiredByPolicy):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, ErrAlreadyEnabled):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, ErrInvalidCode), errors.Is(err, ErrNotEnrolled):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		logger.Error("❌ mfa error: " + err.Error())
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,39 @@
+package mfa
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	PurposeLogin  = "login"  // пароль проверен, ждем TOTP или код восстановления
+	PurposeEnroll = "enroll" // политика роли требует 2FA, а она еще не настроена
+)
+
+// MFASettings хранит TOTP-секрет пользователя. Enabled становится true только
+// после подтверждения первым кодом, до этого секрет считается черновиком.
+type MFASettings struct {
+	gorm.Model   `swaggerignore:"true"`
+	UserID       uint       `gorm:"uniqueIndex;not null"`
+	Secret       string     `gorm:"not null"`
+	Enabled      bool       `gorm:"not null;default:false"`
+	EnabledAt    *time.Time `gorm:"default:null"`
+	LastUsedStep int64      `gorm:"not null;default:0"` // защита от повторного использования кода
+}
+
+// RecoveryCode — одноразовый код восстановления, хранится только его SHA-256.
+type RecoveryCode struct {
+	ID        uint       `gorm:"primaryKey"`
+	UserID    uint       `gorm:"not null;index"`
+	CodeHash  string     `gorm:"not null;index"`
+	UsedAt    *time.Time `gorm:"default:null"`
+	CreatedAt time.Time
+}
+
+// Challenge — промежуточное состояние входа, живет в Redis под случайным токеном.
+type Challenge struct {
+	UserID  uint   `json:"user_id"`
+	Role    string `json:"role"`
+	Purpose string `json:"purpose"`
+}
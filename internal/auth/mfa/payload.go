@@ -0,0 +1,46 @@
+package mfa
+
+// ChallengeResponse возвращается вместо токенов, если для входа нужен второй фактор.
+type ChallengeResponse struct {
+	MFARequired           bool   `json:"mfa_required"`
+	MFAEnrollmentRequired bool   `json:"mfa_enrollment_required"`
+	MFAToken              string `json:"mfa_token"`
+	ExpiresIn             int    `json:"expires_in"` // секунды
+}
+
+type EnrollRequest struct {
+	MFAToken string `json:"mfa_token,omitempty"` // для входа по политике роли без access-токена
+}
+
+type EnrollResponse struct {
+	Secret     string `json:"secret"`
+	OtpauthURI string `json:"otpauth_uri"`
+}
+
+type ConfirmEnrollRequest struct {
+	MFAToken string `json:"mfa_token,omitempty"`
+	Code     string `json:"code" validate:"required,len=6,numeric"`
+}
+
+type ConfirmEnrollResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+	Token         string   `json:"token,omitempty"` // выдается, если подключение было частью входа
+}
+
+type VerifyRequest struct {
+	MFAToken     string `json:"mfa_token" validate:"required"`
+	Code         string `json:"code" validate:"required_without=RecoveryCode,omitempty,len=6,numeric"`
+	RecoveryCode string `json:"recovery_code" validate:"required_without=Code"`
+}
+
+type VerifyResponse struct {
+	Token string `json:"token"`
+}
+
+type CodeRequest struct {
+	Code string `json:"code" validate:"required,len=6,numeric"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
@@ -0,0 +1,108 @@
+package mfa
+
+import (
+	"errors"
+	"time"
+
+	"github.com/ShopOnGO/ShopOnGO/pkg/db"
+	"gorm.io/gorm"
+)
+
+type MFARepository struct {
+	Database *db.Db
+}
+
+func NewMFARepository(database *db.Db) *MFARepository {
+	return &MFARepository{Database: database}
+}
+
+// GetSettings возвращает nil без ошибки, если пользователь никогда не начинал настройку 2FA.
+func (repo *MFARepository) GetSettings(userID uint) (*MFASettings, error) {
+	var settings MFASettings
+	result := repo.Database.DB.Where("user_id = ?", userID).First(&settings)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &settings, nil
+}
+
+// SavePendingSecret создает или перезаписывает еще не подтвержденный секрет.
+func (repo *MFARepository) SavePendingSecret(userID uint, secret string) error {
+	return repo.Database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&MFASettings{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&MFASettings{UserID: userID, Secret: secret}).Error
+	})
+}
+
+// Enable включает 2FA и заменяет коды восстановления одним действием.
+func (repo *MFARepository) Enable(userID uint, step int64, codeHashes []string) error {
+	return repo.Database.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		err := tx.Model(&MFASettings{}).Where("user_id = ?", userID).Updates(map[string]interface{}{
+			"enabled":        true,
+			"enabled_at":     now,
+			"last_used_step": step,
+		}).Error
+		if err != nil {
+			return err
+		}
+		return replaceRecoveryCodes(tx, userID, codeHashes)
+	})
+}
+
+func (repo *MFARepository) Disable(userID uint) error {
+	return repo.Database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&MFASettings{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&RecoveryCode{}).Error
+	})
+}
+
+// UpdateLastUsedStep атомарно сдвигает последний использованный интервал.
+// Возвращает false, если код этого интервала уже был использован.
+func (repo *MFARepository) UpdateLastUsedStep(userID uint, step int64) (bool, error) {
+	result := repo.Database.DB.Model(&MFASettings{}).
+		Where("user_id = ? AND last_used_step < ?", userID, step).
+		Update("last_used_step", step)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (repo *MFARepository) ReplaceRecoveryCodes(userID uint, codeHashes []string) error {
+	return repo.Database.DB.Transaction(func(tx *gorm.DB) error {
+		return replaceRecoveryCodes(tx, userID, codeHashes)
+	})
+}
+
+// UseRecoveryCode помечает код использованным. Возвращает false, если кода нет или он уже погашен.
+func (repo *MFARepository) UseRecoveryCode(userID uint, codeHash string) (bool, error) {
+	result := repo.Database.DB.Model(&RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func replaceRecoveryCodes(tx *gorm.DB, userID uint, codeHashes []string) error {
+	if err := tx.Where("user_id = ?", userID).Delete(&RecoveryCode{}).Error; err != nil {
+		return err
+	}
+	codes := make([]RecoveryCode, 0, len(codeHashes))
+	for _, hash := range codeHashes {
+		codes = append(codes, RecoveryCode{UserID: userID, CodeHash: hash})
+	}
+	if len(codes) == 0 {
+		return nil
+	}
+	return tx.Create(&codes).Error
+}
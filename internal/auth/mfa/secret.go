@@ -0,0 +1,65 @@
+package mfa
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+)
+
+// sealedPrefix отличает зашифрованный секрет от записей, сохраненных до шифрования.
+const sealedPrefix = "enc:v1:"
+
+var errSecretKeyMissing = errors.New("MFA_SECRET_KEY is not configured")
+
+// sealSecret шифрует TOTP-секрет перед записью в базу (AES-256-GCM, ключ — SHA-256 от MFA_SECRET_KEY).
+func (service *MFAService) sealSecret(secret string) (string, error) {
+	aead, err := service.secretCipher()
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(secret), nil)
+	return sealedPrefix + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// openSecret расшифровывает секрет из базы. Старые незашифрованные значения возвращаются как есть.
+func (service *MFAService) openSecret(stored string) (string, error) {
+	if !strings.HasPrefix(stored, sealedPrefix) {
+		return stored, nil
+	}
+	data, err := base64.RawStdEncoding.DecodeString(strings.TrimPrefix(stored, sealedPrefix))
+	if err != nil {
+		return "", err
+	}
+	aead, err := service.secretCipher()
+	if err != nil {
+		return "", err
+	}
+	if len(data) < aead.NonceSize() {
+		return "", errors.New("mfa secret is corrupted")
+	}
+	secret, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], nil)
+	if err != nil {
+		return "", err
+	}
+	return string(secret), nil
+}
+
+func (service *MFAService) secretCipher() (cipher.AEAD, error) {
+	if service.Conf.MFA.SecretKey == "" {
+		return nil, errSecretKeyMissing
+	}
+	key := sha256.Sum256([]byte(service.Conf.MFA.SecretKey))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
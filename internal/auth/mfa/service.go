@@ -0,0 +1,361 @@
+package mfa
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/ShopOnGO/ShopOnGO/configs"
+	"github.com/ShopOnGO/ShopOnGO/pkg/logger"
+	"github.com/ShopOnGO/ShopOnGO/pkg/totp"
+)
+
+const (
+	recoveryCodesCount = 10
+	maxChallengeTries  = 5
+	totpSkew           = 1 // допускаем расхождение часов на один интервал
+)
+
+type Repository interface {
+	GetSettings(userID uint) (*MFASettings, error)
+	SavePendingSecret(userID uint, secret string) error
+	Enable(userID uint, step int64, codeHashes []string) error
+	Disable(userID uint) error
+	UpdateLastUsedStep(userID uint, step int64) (bool, error)
+	ReplaceRecoveryCodes(userID uint, codeHashes []string) error
+	UseRecoveryCode(userID uint, codeHash string) (bool, error)
+}
+
+type ChallengeRepository interface {
+	SaveChallenge(token string, challenge *Challenge, ttl time.Duration) error
+	GetChallenge(token string) (*Challenge, error)
+	DeleteChallenge(token string) error
+	IncrementAttempts(token string, ttl time.Duration) (int, error)
+	UserAttempts(userID uint) (int, error)
+	IncrementUserAttempts(userID uint, ttl time.Duration) (int, error)
+	ResetUserAttempts(userID uint) error
+}
+
+type MFAService struct {
+	Conf       *configs.Config
+	Repository Repository
+	Challenges ChallengeRepository
+	now        func() time.Time
+}
+
+func NewMFAService(conf *configs.Config, repository Repository, challenges ChallengeRepository) *MFAService {
+	return &MFAService{
+		Conf:       conf,
+		Repository: repository,
+		Challenges: challenges,
+		now:        time.Now,
+	}
+}
+
+// IsRequired сообщает, требует ли политика 2FA для роли.
+func (service *MFAService) IsRequired(role string) bool {
+	for _, r := range service.Conf.MFA.RequiredRoles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+func (service *MFAService) IsEnabled(userID uint) (bool, error) {
+	settings, err := service.Repository.GetSettings(userID)
+	if err != nil {
+		return false, err
+	}
+	return settings != nil && settings.Enabled, nil
+}
+
+// StartLogin вызывается после успешной проверки пароля. Если второй фактор
+// не нужен, возвращает nil и вызывающий сразу выдает токены.
+func (service *MFAService) StartLogin(userID uint, role string) (*ChallengeResponse, error) {
+	enabled, err := service.IsEnabled(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	purpose := ""
+	switch {
+	case enabled:
+		purpose = PurposeLogin
+	case service.IsRequired(role):
+		purpose = PurposeEnroll
+	default:
+		return nil, nil
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	challenge := &Challenge{UserID: userID, Role: role, Purpose: purpose}
+	if err := service.Challenges.SaveChallenge(token, challenge, service.Conf.MFA.ChallengeTTL); err != nil {
+		return nil, err
+	}
+	return &ChallengeResponse{
+		MFARequired:           purpose == PurposeLogin,
+		MFAEnrollmentRequired: purpose == PurposeEnroll,
+		MFAToken:              token,
+		ExpiresIn:             int(service.Conf.MFA.ChallengeTTL.Seconds()),
+	}, nil
+}
+
+// ResolveChallenge возвращает challenge нужного назначения, не погашая его.
+func (service *MFAService) ResolveChallenge(token, purpose string) (*Challenge, error) {
+	challenge, err := service.Challenges.GetChallenge(token)
+	if err != nil {
+		return nil, err
+	}
+	if challenge.Purpose != purpose {
+		return nil, ErrInvalidChallenge
+	}
+	return challenge, nil
+}
+
+// Enroll создает новый секрет. До подтверждения кодом 2FA не включена.
+func (service *MFAService) Enroll(userID uint, account string) (*EnrollResponse, error) {
+	enabled, err := service.IsEnabled(userID)
+	if err != nil {
+		return nil, err
+	}
+	if enabled {
+		return nil, ErrAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := service.sealSecret(secret)
+	if err != nil {
+		return nil, err
+	}
+	if err := service.Repository.SavePendingSecret(userID, sealed); err != nil {
+		return nil, err
+	}
+	return &EnrollResponse{
+		Secret:     secret,
+		OtpauthURI: totp.URI(service.Conf.MFA.Issuer, account, secret),
+	}, nil
+}
+
+// ConfirmEnrollment включает 2FA после первого верного кода и выдает коды восстановления.
+func (service *MFAService) ConfirmEnrollment(userID uint, code string) ([]string, error) {
+	settings, err := service.Repository.GetSettings(userID)
+	if err != nil {
+		return nil, err
+	}
+	if settings == nil {
+		return nil, ErrNotEnrolled
+	}
+	if settings.Enabled {
+		return nil, ErrAlreadyEnabled
+	}
+
+	secret, err := service.openSecret(settings.Secret)
+	if err != nil {
+		return nil, err
+	}
+	var step int64
+	err = service.verifyLimited(userID, func() error {
+		var ok bool
+		if step, ok = totp.Validate(secret, code, service.now(), totpSkew); !ok {
+			return ErrInvalidCode
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := service.Repository.Enable(userID, step, hashes); err != nil {
+		return nil, err
+	}
+	logger.Infof("🔐 2FA enabled for user %d", userID)
+	return codes, nil
+}
+
+// VerifyLogin проверяет второй фактор и гасит challenge. Возвращает данные
+// пользователя, для которого нужно выдать токены. Неверные коды считаются и на
+// challenge, и на пользователя: новый вход с паролем не дает новых попыток.
+func (service *MFAService) VerifyLogin(token, code, recoveryCode string) (*Challenge, error) {
+	challenge, err := service.ResolveChallenge(token, PurposeLogin)
+	if err != nil {
+		return nil, err
+	}
+
+	err = service.verifyLimited(challenge.UserID, func() error {
+		if recoveryCode != "" {
+			return service.useRecoveryCode(challenge.UserID, recoveryCode)
+		}
+		return service.VerifyCode(challenge.UserID, code)
+	})
+	if errors.Is(err, ErrLocked) {
+		service.Challenges.DeleteChallenge(token)
+		return nil, err
+	}
+	if err != nil {
+		attempts, incErr := service.Challenges.IncrementAttempts(token, service.Conf.MFA.ChallengeTTL)
+		if incErr != nil {
+			return nil, incErr
+		}
+		if attempts >= maxChallengeTries {
+			service.Challenges.DeleteChallenge(token)
+			return nil, ErrTooManyAttempts
+		}
+		return nil, err
+	}
+
+	if err := service.Challenges.DeleteChallenge(token); err != nil {
+		logger.Error("❌ failed to delete mfa challenge: " + err.Error())
+	}
+	return challenge, nil
+}
+
+// CompleteEnrollment гасит challenge входа по политике после подключения 2FA.
+func (service *MFAService) CompleteEnrollment(token string) error {
+	return service.Challenges.DeleteChallenge(token)
+}
+
+// VerifyCode проверяет TOTP-код включенной 2FA. Один и тот же код не принимается дважды.
+func (service *MFAService) VerifyCode(userID uint, code string) error {
+	settings, err := service.Repository.GetSettings(userID)
+	if err != nil {
+		return err
+	}
+	if settings == nil || !settings.Enabled {
+		return ErrNotEnrolled
+	}
+
+	secret, err := service.openSecret(settings.Secret)
+	if err != nil {
+		return err
+	}
+	step, ok := totp.Validate(secret, code, service.now(), totpSkew)
+	if !ok {
+		return ErrInvalidCode
+	}
+	fresh, err := service.Repository.UpdateLastUsedStep(userID, step)
+	if err != nil {
+		return err
+	}
+	if !fresh {
+		return ErrInvalidCode
+	}
+	return nil
+}
+
+// Disable выключает 2FA. Для ролей, где она обязательна, отключение запрещено.
+func (service *MFAService) Disable(userID uint, role, code string) error {
+	if service.IsRequired(role) {
+		return ErrRequiredByPolicy
+	}
+	if err := service.verifyLimited(userID, func() error { return service.VerifyCode(userID, code) }); err != nil {
+		return err
+	}
+	logger.Infof("🔓 2FA disabled for user %d", userID)
+	return service.Repository.Disable(userID)
+}
+
+// RegenerateRecoveryCodes выпускает новый набор кодов, старые перестают действовать.
+func (service *MFAService) RegenerateRecoveryCodes(userID uint, code string) ([]string, error) {
+	if err := service.verifyLimited(userID, func() error { return service.VerifyCode(userID, code) }); err != nil {
+		return nil, err
+	}
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := service.Repository.ReplaceRecoveryCodes(userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// verifyLimited выполняет проверку кода check с учетом неверных попыток пользователя:
+// после maxChallengeTries ошибок любая проверка блокируется на MFA.LockoutTTL.
+func (service *MFAService) verifyLimited(userID uint, check func() error) error {
+	attempts, err := service.Challenges.UserAttempts(userID)
+	if err != nil {
+		return err
+	}
+	if attempts >= maxChallengeTries {
+		return ErrLocked
+	}
+
+	err = check()
+	if errors.Is(err, ErrInvalidCode) {
+		attempts, incErr := service.Challenges.IncrementUserAttempts(userID, service.Conf.MFA.LockoutTTL)
+		if incErr != nil {
+			return incErr
+		}
+		if attempts >= maxChallengeTries {
+			logger.Infof("🔒 2FA code checks locked for user %d", userID)
+			return ErrLocked
+		}
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	if err := service.Challenges.ResetUserAttempts(userID); err != nil {
+		logger.Error("❌ failed to reset mfa attempts: " + err.Error())
+	}
+	return nil
+}
+
+func (service *MFAService) useRecoveryCode(userID uint, code string) error {
+	ok, err := service.Repository.UseRecoveryCode(userID, hashRecoveryCode(code))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrInvalidCode
+	}
+	logger.Infof("🔐 Recovery code used by user %d", userID)
+	return nil
+}
+
+// generateRecoveryCodes возвращает коды вида xxxxx-xxxxx и их хеши для хранения.
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, recoveryCodesCount)
+	hashes := make([]string, 0, recoveryCodesCount)
+	for i := 0; i < recoveryCodesCount; i++ {
+		b := make([]byte, 5)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		raw := hex.EncodeToString(b)
+		code := raw[:5] + "-" + raw[5:]
+		codes = append(codes, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode нормализует ввод (регистр, дефисы, пробелы) перед хешированием.
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,299 @@
+package mfa
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ShopOnGO/ShopOnGO/configs"
+	"github.com/ShopOnGO/ShopOnGO/pkg/totp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryRepository — Repository в памяти для одного пользователя.
+type memoryRepository struct {
+	settings *MFASettings
+	codes    map[string]bool // hash -> использован
+}
+
+func (m *memoryRepository) GetSettings(userID uint) (*MFASettings, error) { return m.settings, nil }
+
+func (m *memoryRepository) SavePendingSecret(userID uint, secret string) error {
+	m.settings = &MFASettings{UserID: userID, Secret: secret}
+	return nil
+}
+
+func (m *memoryRepository) Enable(userID uint, step int64, codeHashes []string) error {
+	m.settings.Enabled = true
+	m.settings.LastUsedStep = step
+	return m.ReplaceRecoveryCodes(userID, codeHashes)
+}
+
+func (m *memoryRepository) Disable(userID uint) error {
+	m.settings = nil
+	m.codes = nil
+	return nil
+}
+
+func (m *memoryRepository) UpdateLastUsedStep(userID uint, step int64) (bool, error) {
+	if m.settings.LastUsedStep >= step {
+		return false, nil
+	}
+	m.settings.LastUsedStep = step
+	return true, nil
+}
+
+func (m *memoryRepository) ReplaceRecoveryCodes(userID uint, codeHashes []string) error {
+	m.codes = map[string]bool{}
+	for _, h := range codeHashes {
+		m.codes[h] = false
+	}
+	return nil
+}
+
+func (m *memoryRepository) UseRecoveryCode(userID uint, codeHash string) (bool, error) {
+	used, ok := m.codes[codeHash]
+	if !ok || used {
+		return false, nil
+	}
+	m.codes[codeHash] = true
+	return true, nil
+}
+
+type memoryChallenges struct {
+	challenges   map[string]*Challenge
+	attempts     map[string]int
+	userAttempts map[uint]int
+}
+
+func (m *memoryChallenges) SaveChallenge(token string, c *Challenge, ttl time.Duration) error {
+	m.challenges[token] = c
+	return nil
+}
+
+func (m *memoryChallenges) GetChallenge(token string) (*Challenge, error) {
+	c, ok := m.challenges[token]
+	if !ok {
+		return nil, ErrInvalidChallenge
+	}
+	return c, nil
+}
+
+func (m *memoryChallenges) DeleteChallenge(token string) error {
+	delete(m.challenges, token)
+	delete(m.attempts, token)
+	return nil
+}
+
+func (m *memoryChallenges) IncrementAttempts(token string, ttl time.Duration) (int, error) {
+	m.attempts[token]++
+	return m.attempts[token], nil
+}
+
+func (m *memoryChallenges) UserAttempts(userID uint) (int, error) {
+	return m.userAttempts[userID], nil
+}
+
+func (m *memoryChallenges) IncrementUserAttempts(userID uint, ttl time.Duration) (int, error) {
+	m.userAttempts[userID]++
+	return m.userAttempts[userID], nil
+}
+
+func (m *memoryChallenges) ResetUserAttempts(userID uint) error {
+	delete(m.userAttempts, userID)
+	return nil
+}
+
+func newTestService(requiredRoles ...string) (*MFAService, *memoryRepository) {
+	conf := &configs.Config{MFA: configs.MFAConfig{
+		Issuer:        "ShopOnGO",
+		RequiredRoles: requiredRoles,
+		ChallengeTTL:  time.Minute,
+		SecretKey:     "test-key",
+	}}
+	repo := &memoryRepository{}
+	challenges := &memoryChallenges{
+		challenges:   map[string]*Challenge{},
+		attempts:     map[string]int{},
+		userAttempts: map[uint]int{},
+	}
+	return NewMFAService(conf, repo, challenges), repo
+}
+
+// enroll подключает 2FA и возвращает секрет и коды восстановления.
+func enroll(t *testing.T, service *MFAService, now time.Time) (string, []string) {
+	service.now = func() time.Time { return now }
+	resp, err := service.Enroll(1, "seller@example.com")
+	require.NoError(t, err)
+	code, _ := totp.CodeAt(resp.Secret, totp.Step(now))
+	recovery, err := service.ConfirmEnrollment(1, code)
+	require.NoError(t, err)
+	return resp.Secret, recovery
+}
+
+func TestStartLogin(t *testing.T) {
+	t.Run("No MFA - tokens issued directly", func(t *testing.T) {
+		service, _ := newTestService()
+		challenge, err := service.StartLogin(1, "buyer")
+		assert.NoError(t, err)
+		assert.Nil(t, challenge)
+	})
+
+	t.Run("Required by policy - enrollment challenge", func(t *testing.T) {
+		service, _ := newTestService("seller", "admin")
+		challenge, err := service.StartLogin(1, "seller")
+		require.NoError(t, err)
+		require.NotNil(t, challenge)
+		assert.True(t, challenge.MFAEnrollmentRequired)
+		assert.False(t, challenge.MFARequired)
+	})
+
+	t.Run("Enabled - login challenge", func(t *testing.T) {
+		service, _ := newTestService()
+		enroll(t, service, time.Unix(1700000000, 0))
+
+		challenge, err := service.StartLogin(1, "buyer")
+		require.NoError(t, err)
+		require.NotNil(t, challenge)
+		assert.True(t, challenge.MFARequired)
+	})
+}
+
+func TestVerifyLogin(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+
+	t.Run("Success with next code, replay rejected", func(t *testing.T) {
+		service, _ := newTestService()
+		secret, _ := enroll(t, service, now)
+
+		later := now.Add(totp.Period)
+		service.now = func() time.Time { return later }
+		code, _ := totp.CodeAt(secret, totp.Step(later))
+
+		challenge, _ := service.StartLogin(1, "buyer")
+		c, err := service.VerifyLogin(challenge.MFAToken, code, "")
+		require.NoError(t, err)
+		assert.Equal(t, uint(1), c.UserID)
+
+		// challenge одноразовый
+		_, err = service.VerifyLogin(challenge.MFAToken, code, "")
+		assert.ErrorIs(t, err, ErrInvalidChallenge)
+
+		// тот же код в новом входе не принимается
+		challenge, _ = service.StartLogin(1, "buyer")
+		_, err = service.VerifyLogin(challenge.MFAToken, code, "")
+		assert.ErrorIs(t, err, ErrInvalidCode)
+	})
+
+	t.Run("Recovery code is single use", func(t *testing.T) {
+		service, _ := newTestService()
+		_, recovery := enroll(t, service, now)
+
+		challenge, _ := service.StartLogin(1, "buyer")
+		_, err := service.VerifyLogin(challenge.MFAToken, "", recovery[0])
+		require.NoError(t, err)
+
+		challenge, _ = service.StartLogin(1, "buyer")
+		_, err = service.VerifyLogin(challenge.MFAToken, "", recovery[0])
+		assert.ErrorIs(t, err, ErrInvalidCode)
+	})
+
+	t.Run("Too many attempts", func(t *testing.T) {
+		service, _ := newTestService()
+		enroll(t, service, now)
+
+		challenge, _ := service.StartLogin(1, "buyer")
+		for i := 1; i < maxChallengeTries; i++ {
+			_, err := service.VerifyLogin(challenge.MFAToken, "000000", "")
+			assert.ErrorIs(t, err, ErrInvalidCode)
+		}
+		_, err := service.VerifyLogin(challenge.MFAToken, "000000", "")
+		assert.ErrorIs(t, err, ErrLocked)
+		_, err = service.VerifyLogin(challenge.MFAToken, "000000", "")
+		assert.ErrorIs(t, err, ErrInvalidChallenge)
+
+		// новый вход с паролем не дает новых попыток
+		challenge, _ = service.StartLogin(1, "buyer")
+		_, err = service.VerifyLogin(challenge.MFAToken, "000000", "")
+		assert.ErrorIs(t, err, ErrLocked)
+	})
+
+	t.Run("Challenge limit applies before user lockout", func(t *testing.T) {
+		service, _ := newTestService()
+		enroll(t, service, now)
+		challenges := service.Challenges.(*memoryChallenges)
+
+		challenge, _ := service.StartLogin(1, "buyer")
+		challenges.attempts[challenge.MFAToken] = maxChallengeTries - 1
+		_, err := service.VerifyLogin(challenge.MFAToken, "000000", "")
+		assert.ErrorIs(t, err, ErrTooManyAttempts)
+	})
+
+	t.Run("Enrollment challenge cannot be used to log in", func(t *testing.T) {
+		service, _ := newTestService("admin")
+		challenge, _ := service.StartLogin(1, "admin")
+		_, err := service.VerifyLogin(challenge.MFAToken, "000000", "")
+		assert.ErrorIs(t, err, ErrInvalidChallenge)
+	})
+}
+
+func TestDisable_RequiredByPolicy(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	service, repo := newTestService("seller")
+	secret, _ := enroll(t, service, now)
+
+	later := now.Add(totp.Period)
+	service.now = func() time.Time { return later }
+	code, _ := totp.CodeAt(secret, totp.Step(later))
+
+	assert.ErrorIs(t, service.Disable(1, "seller", code), ErrRequiredByPolicy)
+	assert.NoError(t, service.Disable(1, "buyer", code))
+	assert.Nil(t, repo.settings)
+}
+
+func TestConfirmEnrollment_Lockout(t *testing.T) {
+	service, _ := newTestService()
+	_, err := service.Enroll(1, "seller@example.com")
+	require.NoError(t, err)
+
+	for i := 1; i < maxChallengeTries; i++ {
+		_, err := service.ConfirmEnrollment(1, "000000")
+		assert.ErrorIs(t, err, ErrInvalidCode)
+	}
+	_, err = service.ConfirmEnrollment(1, "000000")
+	assert.ErrorIs(t, err, ErrLocked)
+}
+
+func TestRegenerateRecoveryCodes_Lockout(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	service, _ := newTestService()
+	secret, _ := enroll(t, service, now)
+
+	for i := 1; i < maxChallengeTries; i++ {
+		_, err := service.RegenerateRecoveryCodes(1, "000000")
+		assert.ErrorIs(t, err, ErrInvalidCode)
+	}
+	_, err := service.RegenerateRecoveryCodes(1, "000000")
+	assert.ErrorIs(t, err, ErrLocked)
+
+	// даже верный код не принимается до истечения блокировки
+	later := now.Add(totp.Period)
+	service.now = func() time.Time { return later }
+	code, _ := totp.CodeAt(secret, totp.Step(later))
+	assert.ErrorIs(t, service.Disable(1, "buyer", code), ErrLocked)
+}
+
+func TestSecretStoredEncrypted(t *testing.T) {
+	service, repo := newTestService()
+	secret, _ := enroll(t, service, time.Unix(1700000000, 0))
+
+	assert.NotContains(t, repo.settings.Secret, secret)
+	opened, err := service.openSecret(repo.settings.Secret)
+	require.NoError(t, err)
+	assert.Equal(t, secret, opened)
+
+	// секреты, сохраненные до шифрования, продолжают работать
+	legacy, err := service.openSecret(secret)
+	require.NoError(t, err)
+	assert.Equal(t, secret, legacy)
+}
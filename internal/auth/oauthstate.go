@@ -0,0 +1,142 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/ShopOnGO/ShopOnGO/pkg/redisdb"
+)
+
+const (
+	oauthStateTTL  = 10 * time.Minute
+	pendingLinkTTL = 10 * time.Minute
+
+	// oauthStateCookie привязывает state к браузеру, который начал вход: без него
+	// чужой callback со своим state нельзя подсунуть жертве (login CSRF)
+	oauthStateCookie = "oauth_state"
+)
+
+var (
+	ErrInvalidOAuthState = errors.New("invalid or expired oauth state")
+	ErrInvalidLinkToken  = errors.New("invalid or expired link token")
+)
+
+// OAuthState сохраняется между редиректом к провайдеру и callback.
+// LinkUserID != 0 означает, что авторизованный пользователь привязывает провайдера из профиля.
+type OAuthState struct {
+	Provider   string `json:"provider"`
+	Verifier   string `json:"verifier"`
+	LinkUserID uint   `json:"link_user_id,omitempty"`
+}
+
+// setStateCookie сохраняет в браузере хеш state на время жизни самого state.
+func setStateCookie(w http.ResponseWriter, state string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     oauthStateCookie,
+		Value:    hashState(state),
+		Path:     "/oauth",
+		MaxAge:   int(oauthStateTTL.Seconds()),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// checkStateCookie сверяет state из callback с cookie браузера и сразу удаляет cookie.
+func checkStateCookie(w http.ResponseWriter, r *http.Request, state string) bool {
+	http.SetCookie(w, &http.Cookie{
+		Name:     oauthStateCookie,
+		Path:     "/oauth",
+		MaxAge:   -1,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	cookie, err := r.Cookie(oauthStateCookie)
+	if err != nil || state == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(hashState(state))) == 1
+}
+
+func hashState(state string) string {
+	sum := sha256.Sum256([]byte(state))
+	return hex.EncodeToString(sum[:])
+}
+
+// PendingLink ожидает подтверждения паролем локального аккаунта с тем же email.
+type PendingLink struct {
+	UserID   uint   `json:"user_id"`
+	Provider string `json:"provider"`
+	Subject  string `json:"subject"`
+	Email    string `json:"email"`
+}
+
+type OAuthStateRepository interface {
+	SaveState(state string, data *OAuthState, ttl time.Duration) error
+	ConsumeState(state string) (*OAuthState, error)
+	SavePendingLink(token string, link *PendingLink, ttl time.Duration) error
+	ConsumePendingLink(token string) (*PendingLink, error)
+}
+
+type RedisOAuthStateRepository struct {
+	redis *redisdb.RedisDB
+}
+
+func NewRedisOAuthStateRepository(r *redisdb.RedisDB) *RedisOAuthStateRepository {
+	return &RedisOAuthStateRepository{redis: r}
+}
+
+func (r *RedisOAuthStateRepository) SaveState(state string, data *OAuthState, ttl time.Duration) error {
+	return r.set(fmt.Sprintf("oauth_state:%s", state), data, ttl)
+}
+
+// ConsumeState читает и удаляет state одной командой, поэтому он одноразовый.
+func (r *RedisOAuthStateRepository) ConsumeState(state string) (*OAuthState, error) {
+	var data OAuthState
+	if err := r.getDel(fmt.Sprintf("oauth_state:%s", state), &data); err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, ErrInvalidOAuthState
+		}
+		return nil, err
+	}
+	return &data, nil
+}
+
+func (r *RedisOAuthStateRepository) SavePendingLink(token string, link *PendingLink, ttl time.Duration) error {
+	return r.set(fmt.Sprintf("oauth_link:%s", token), link, ttl)
+}
+
+func (r *RedisOAuthStateRepository) ConsumePendingLink(token string) (*PendingLink, error) {
+	var link PendingLink
+	if err := r.getDel(fmt.Sprintf("oauth_link:%s", token), &link); err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, ErrInvalidLinkToken
+		}
+		return nil, err
+	}
+	return &link, nil
+}
+
+func (r *RedisOAuthStateRepository) set(key string, value interface{}, ttl time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return r.redis.Set(context.Background(), key, data, ttl).Err()
+}
+
+func (r *RedisOAuthStateRepository) getDel(key string, dest interface{}) error {
+	data, err := r.redis.GetDel(context.Background(), key).Result()
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal([]byte(data), dest)
+}
@@ -0,0 +1,14 @@
+package passwordreset
+
+import "errors"
+
+var (
+	ErrProviderAccount = errors.New("сброс пароля недоступен для пользователей, зарегистрированных через внешний провайдер")
+	ErrUserNotFound    = errors.New("пользователь не найден")
+	ErrTooManyRequests = errors.New("превышено количество запросов на сброс пароля, попробуйте позже")
+	ErrCodeNotFound    = errors.New("код не найден, запросите сброс пароля повторно")
+	ErrCodeExpired     = errors.New("код истек, запросите новый")
+	ErrInvalidCode     = errors.New("неверный код")
+	ErrTooManyAttempts = errors.New("превышено количество попыток ввода кода, запросите новый")
+	ErrInvalidTicket   = errors.New("ссылка для сброса пароля недействительна или истекла, подтвердите код повторно")
+)
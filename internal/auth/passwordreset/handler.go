@@ -2,27 +2,33 @@ package passwordreset
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 
 	"github.com/ShopOnGO/ShopOnGO/configs"
 	"github.com/ShopOnGO/ShopOnGO/pkg/logger"
+	"github.com/ShopOnGO/ShopOnGO/pkg/password"
+	"github.com/ShopOnGO/ShopOnGO/pkg/req"
 	"github.com/gorilla/mux"
 )
 
 type ResetHandlerDeps struct {
 	*configs.Config
 	*ResetService
+	PasswordPolicy *password.Policy
 }
 
 type ResetHandler struct {
 	*configs.Config
 	*ResetService
+	PasswordPolicy *password.Policy
 }
 
 func NewResetHandler(router *mux.Router, deps ResetHandlerDeps) {
 	handler := &ResetHandler{
-		Config:       deps.Config,
-		ResetService: deps.ResetService,
+		Config:         deps.Config,
+		ResetService:   deps.ResetService,
+		PasswordPolicy: deps.PasswordPolicy,
 	}
 	router.Handle("/auth/reset", handler.Reset()).Methods("POST")
 	router.Handle("/auth/reset/verify", handler.VerifyCode()).Methods("POST")
@@ -65,16 +71,17 @@ func (h *ResetHandler) Reset() http.HandlerFunc {
 	}
 }
 
-// VerifyCode checks the validity of the reset code sent to the user.
+// VerifyCode checks the reset code and exchanges it for a reset ticket.
 // @Summary      Verify Reset Code
-// @Description  Validates the provided reset code against the one stored for the email and confirms the reset request.
+// @Description  Validates the reset code sent to the email. On success the code is consumed and a single-use reset_token valid for 10 minutes is returned; it must be passed to /auth/reset/password. After 5 wrong attempts the code is invalidated.
 // @Tags         auth
 // @Accept       json
 // @Produce      json
 // @Param        body  body  VerifyCodeRequest  true  "Data for code verification"
-// @Success      200   {string}  string  "Code verified successfully"
+// @Success      200   {object}  VerifyCodeResponse  "Code verified, reset token issued"
 // @Failure      400   {string}  string  "Invalid input data"
 // @Failure      401   {string}  string  "Invalid or expired code"
+// @Failure      429   {string}  string  "Too many attempts"
 // @Router       /auth/reset/verify [post]
 func (h *ResetHandler) VerifyCode() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -83,44 +90,52 @@ func (h *ResetHandler) VerifyCode() http.HandlerFunc {
 			http.Error(w, "Неверные данные", http.StatusBadRequest)
 			return
 		}
-		if err := h.VerifyCodeByEmail(req.Email, req.Code); err != nil {
-			http.Error(w, err.Error(), http.StatusUnauthorized)
+		ticket, err := h.VerifyCodeByEmail(req.Email, req.Code)
+		if err != nil {
+			writeError(w, err)
 			return
 		}
-		response := map[string]string{"message": "Verification code is valid"}
+		response := VerifyCodeResponse{ResetToken: ticket, ExpiresIn: int(resetTicketTTL.Seconds())}
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
 		json.NewEncoder(w).Encode(response)
 	}
 }
 
-// ResetPassword updates the user's password after successful code verification.
+// ResetPassword updates the user's password using the reset ticket.
 // @Summary      Update Password
-// @Description  Verifies the provided reset code and, if valid, updates the user's password.
+// @Description  Sets a new password using the single-use reset_token from /auth/reset/verify. All existing sessions of the user are revoked.
 // @Tags         auth
 // @Accept       json
 // @Produce      json
 // @Param        body  body  ResetPasswordRequest  true  "Data for password update"
 // @Success      200   {string} string  "Password successfully updated"
-// @Failure      400   {string} string  "Invalid input data"
-// @Failure      401   {string} string  "Invalid or expired code"
+// @Failure      400   {string} string  "Invalid input data or password does not meet the policy"
+// @Failure      401   {string} string  "Invalid or expired reset token"
 // @Failure      500   {string} string  "Server error during password update"
 // @Router       /auth/reset/password [post]
 func (h *ResetHandler) ResetPassword() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		var req ResetPasswordRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		body, err := req.Decode[ResetPasswordRequest](r.Body)
+		if err != nil {
 			logger.Error("❌ error decoding request body: " + err.Error())
 			http.Error(w, "Неверные данные", http.StatusBadRequest)
 			return
 		}
-		logger.Info("🔑 Запрос на установку нового пароля для email: " + req.Email)
-		if err := h.ResetService.ResetPassword(req.Email, req.NewPassword); err != nil {
-			logger.Error("❌ ошибка при установке нового пароля для email " + req.Email + ": " + err.Error())
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+		if err := req.Validate(body); err != nil {
+			http.Error(w, "Неверные данные: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		// проверяем до ResetPassword: тикет одноразовый и сгорает при использовании
+		if err := h.PasswordPolicy.Validate(body.NewPassword); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := h.ResetService.ResetPassword(body.ResetToken, body.NewPassword); err != nil {
+			logger.Error("❌ ошибка при установке нового пароля: " + err.Error())
+			writeError(w, err)
 			return
 		}
-		logger.Info("✅ Пароль успешно обновлен для email: " + req.Email)
 		response := map[string]string{"message": "Password successfully updated"}
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
@@ -160,3 +175,15 @@ func (h *ResetHandler) ResendCode() http.HandlerFunc {
 		json.NewEncoder(w).Encode(response)
 	}
 }
+
+func writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrTooManyAttempts):
+		http.Error(w, err.Error(), http.StatusTooManyRequests)
+	case errors.Is(err, ErrCodeNotFound), errors.Is(err, ErrCodeExpired),
+		errors.Is(err, ErrInvalidCode), errors.Is(err, ErrInvalidTicket):
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
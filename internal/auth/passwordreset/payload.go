@@ -9,8 +9,13 @@ type VerifyCodeRequest struct {
     Code  string `json:"code" validate:"required"`
 }
 
+type VerifyCodeResponse struct {
+	ResetToken string `json:"reset_token"`
+	ExpiresIn  int    `json:"expires_in"`
+}
+
 type ResetPasswordRequest struct {
-	Email    		string `json:"email" validate:"required,email"`
-	NewPassword 	string `json:"new_password" validate:"required"`
+	ResetToken      string `json:"reset_token" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required"`
 	ConfirmPassword string `json:"confirm_password" validate:"required,eqfield=NewPassword"`
 }
@@ -19,14 +19,19 @@ func NewRedisResetRepository(r *redisdb.RedisDB) *RedisResetRepository {
     return &RedisResetRepository{redis: r}
 }
 
-func (r *RedisResetRepository) SaveToken(email, code string, expiresAt time.Time) error {
+// SaveToken сохраняет хеш кода; новый код обнуляет счетчик неверных попыток.
+func (r *RedisResetRepository) SaveToken(email, codeHash string, expiresAt time.Time) error {
     ttl := time.Until(expiresAt)
     if ttl <= 0 {
         ttl = time.Minute // запас
     }
     key := r.key(email)
 	logger.Info("🔑 Сохранение токена для email: " + email)
-	err := r.redis.Set(context.Background(), key, code, ttl).Err()
+	_, err := r.redis.TxPipelined(context.Background(), func(pipe redis.Pipeliner) error {
+		pipe.Set(context.Background(), key, codeHash, ttl)
+		pipe.Del(context.Background(), r.attemptsKey(email))
+		return nil
+	})
     if err != nil {
         logger.Error("❌ ошибка при сохранении токена в Redis для email " + email + ": " + err.Error())
         return err
@@ -40,7 +45,7 @@ func (r *RedisResetRepository) GetToken(email string) (string, time.Time, error)
     key := r.key(email)
     res, err := r.redis.Get(context.Background(), key).Result()
     if err == redis.Nil {
-        return "", time.Time{}, fmt.Errorf("token not found")
+        return "", time.Time{}, ErrCodeNotFound
     }
     if err != nil {
         return "", time.Time{}, err
@@ -58,13 +63,48 @@ func (r *RedisResetRepository) GetToken(email string) (string, time.Time, error)
 
 func (r *RedisResetRepository) DeleteToken(email string) error {
     key := r.key(email)
-    return r.redis.Del(context.Background(), key).Err()
+    return r.redis.Del(context.Background(), key, r.attemptsKey(email)).Err()
+}
+
+func (r *RedisResetRepository) IncrementAttempts(email string, ttl time.Duration) (int, error) {
+	key := r.attemptsKey(email)
+	count, err := r.redis.Incr(context.Background(), key).Result()
+	if err != nil {
+		return 0, err
+	}
+	if count == 1 {
+		if err := r.redis.Expire(context.Background(), key, ttl).Err(); err != nil {
+			return 0, err
+		}
+	}
+	return int(count), nil
+}
+
+func (r *RedisResetRepository) SaveTicket(ticketHash, email string, ttl time.Duration) error {
+	return r.redis.Set(context.Background(), r.ticketKey(ticketHash), email, ttl).Err()
+}
+
+// ConsumeTicket возвращает email и удаляет тикет одной командой, поэтому тикет одноразовый.
+func (r *RedisResetRepository) ConsumeTicket(ticketHash string) (string, error) {
+	email, err := r.redis.GetDel(context.Background(), r.ticketKey(ticketHash)).Result()
+	if err == redis.Nil {
+		return "", ErrInvalidTicket
+	}
+	return email, err
 }
 
 func (r *RedisResetRepository) key(email string) string {
     return fmt.Sprintf("reset_token:%s", email)
 }
 
+func (r *RedisResetRepository) attemptsKey(email string) string {
+	return fmt.Sprintf("reset_attempts:%s", email)
+}
+
+func (r *RedisResetRepository) ticketKey(ticketHash string) string {
+	return fmt.Sprintf("reset_ticket:%s", ticketHash)
+}
+
 func (r *RedisResetRepository) GetResetCodeCount(email string) (int, error) {
 	key := r.requestCountKey(email)
 	count, err := r.redis.Get(context.Background(), key).Int()
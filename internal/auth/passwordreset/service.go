@@ -2,9 +2,12 @@ package passwordreset
 
 import (
 	"context"
+	"crypto/hmac"
 	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"time"
 
@@ -12,21 +15,29 @@ import (
 	"github.com/ShopOnGO/ShopOnGO/pkg/di"
 	"github.com/ShopOnGO/ShopOnGO/pkg/kafkaService"
 	"github.com/ShopOnGO/ShopOnGO/pkg/logger"
+	"github.com/ShopOnGO/ShopOnGO/pkg/oauth2"
 	"golang.org/x/crypto/bcrypt"
 )
 
+const (
+	maxVerifyAttempts = 5                // неверных попыток на один код
+	resetTicketTTL    = 10 * time.Minute // время на ввод нового пароля после проверки кода
+)
+
 type ResetService struct {
 	Conf           *configs.Config
 	Kafka          *kafkaService.KafkaService
 	Storage        di.IRedisResetRepository
 	UserRepository di.IUserRepository
+	Sessions       oauth2.OAuth2Service
 }
 
-func NewResetService(conf *configs.Config, storage di.IRedisResetRepository, user di.IUserRepository, kafka *kafkaService.KafkaService) *ResetService {
+func NewResetService(conf *configs.Config, storage di.IRedisResetRepository, user di.IUserRepository, sessions oauth2.OAuth2Service, kafka *kafkaService.KafkaService) *ResetService {
 	return &ResetService{
 		Conf:           conf,
 		Storage:        storage,
 		UserRepository: user,
+		Sessions:       sessions,
 		Kafka:          kafka,
 	}
 }
@@ -52,9 +63,9 @@ func (service *ResetService) RequestReset(toEmail string) error {
 		return err
 	}
 
-	if user.Provider == "google" {
-		logger.Error("❌ ошибка сброс пароля для зарегистрированного через Google пользователя")
-		return errors.New("сброс пароля недоступен для пользователей, зарегистрированных через Google")
+	if user.Provider != "local" {
+		logger.Error("❌ ошибка сброс пароля для зарегистрированного через внешний провайдер пользователя")
+		return ErrProviderAccount
 	}
 
 	requests, err := service.Storage.GetResetCodeCount(toEmail)
@@ -63,7 +74,7 @@ func (service *ResetService) RequestReset(toEmail string) error {
 		return err
 	}
 	if requests >= service.Conf.Code.MaxRequests {
-		return errors.New("превышено количество запросов на сброс пароля, попробуйте позже")
+		return ErrTooManyRequests
 	}
 
 	// Увеличиваем счетчик запросов
@@ -78,7 +89,7 @@ func (service *ResetService) RequestReset(toEmail string) error {
 		return err
 	}
 	expiresAt := time.Now().Add(service.Conf.Code.CodeTTL)
-	if err := service.Storage.SaveToken(toEmail, code, expiresAt); err != nil {
+	if err := service.Storage.SaveToken(toEmail, service.hashCode(toEmail, code), expiresAt); err != nil {
 		logger.Error("❌ ошибка при сохранении токена в хранилище: " + err.Error())
 		return err
 	}
@@ -127,62 +138,75 @@ func (service *ResetService) RequestReset(toEmail string) error {
 	return nil
 }
 
-func (service *ResetService) VerifyCodeByEmail(toEmail, code string) error {
-	storedCode, expiresAt, err := service.Storage.GetToken(toEmail)
+// VerifyCodeByEmail проверяет код из письма и обменивает его на одноразовый тикет,
+// который нужно предъявить в ResetPassword. Код после этого больше не действует.
+func (service *ResetService) VerifyCodeByEmail(toEmail, code string) (string, error) {
+	storedHash, expiresAt, err := service.Storage.GetToken(toEmail)
 	if err != nil {
-		return err
+		return "", err
 	}
 	if time.Now().After(expiresAt) {
-		return errors.New("код истек")
+		return "", ErrCodeExpired
 	}
-	if storedCode != code {
-		return errors.New("неверный код")
+	if !hmac.Equal([]byte(storedHash), []byte(service.hashCode(toEmail, code))) {
+		attempts, err := service.Storage.IncrementAttempts(toEmail, time.Until(expiresAt))
+		if err != nil {
+			return "", err
+		}
+		if attempts >= maxVerifyAttempts {
+			service.Storage.DeleteToken(toEmail)
+			logger.Info("🔒 Код сброса пароля аннулирован после превышения попыток для email: " + toEmail)
+			return "", ErrTooManyAttempts
+		}
+		return "", ErrInvalidCode
 	}
-	// надо ли
-	// return service.Storage.DeleteToken(toEmail)
-	return nil
-}
 
-func (service *ResetService) ResetPassword(toEmail, newPassword string) error {
-	user, err := service.UserRepository.FindByEmail(toEmail)
-	if err != nil {
-		logger.Error("❌ ошибка при поиске пользователя по email: " + err.Error())
-		return fmt.Errorf("пользователь не найден")
-	}
-
-	if user.Provider == "google" {
-		return errors.New("сброс пароля недоступен для пользователей, зарегистрированных через Google")
+	if err := service.Storage.DeleteToken(toEmail); err != nil {
+		logger.Error("❌ ошибка при удалении кода для email " + toEmail + ": " + err.Error())
+		return "", err
 	}
 
-	// storedCode, expiresAt, err := service.Storage.GetToken(toEmail)
-	_, expiresAt, err := service.Storage.GetToken(toEmail)
+	ticket, err := randomTicket()
 	if err != nil {
-		logger.Error("❌ не удалось получить токен для email " + toEmail + ": " + err.Error())
-		return fmt.Errorf("код не найден, запросите сброс пароля повторно")
+		return "", err
 	}
-	if time.Now().After(expiresAt) {
-		return errors.New("код истек, запросите новый")
+	if err := service.Storage.SaveTicket(hashTicket(ticket), toEmail, resetTicketTTL); err != nil {
+		logger.Error("❌ ошибка при сохранении тикета сброса для email " + toEmail + ": " + err.Error())
+		return "", err
 	}
-	// if storedCode != code {
-	// 	return errors.New("неверный код")
-	// }
-	if err := service.Storage.DeleteToken(toEmail); err != nil {
-		logger.Error("❌ ошибка при удалении токена для email " + toEmail + ": " + err.Error())
+	return ticket, nil
+}
+
+// ResetPassword устанавливает новый пароль по тикету из VerifyCodeByEmail
+// и завершает все сессии пользователя.
+func (service *ResetService) ResetPassword(ticket, newPassword string) error {
+	toEmail, err := service.Storage.ConsumeTicket(hashTicket(ticket))
+	if err != nil {
 		return err
 	}
 
+	user, err := service.UserRepository.FindByEmail(toEmail)
+	if err != nil {
+		logger.Error("❌ ошибка при поиске пользователя по email: " + err.Error())
+		return ErrUserNotFound
+	}
+
 	newPasswordHash, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
 
 	if err != nil {
 		return fmt.Errorf("FailedToHashNewPassword: %w", err)
 	}
 
-	logger.Info("Хешированный пароль:", string(newPasswordHash))
 	if err := service.UserRepository.UpdateUserPassword(user.ID, string(newPasswordHash)); err != nil {
 		logger.Error("❌ ошибка при обновлении пароля для email " + toEmail + ": " + err.Error())
 		return err
 	}
 	logger.Info("✅ Пароль успешно обновлен для email: " + toEmail)
+
+	if err := service.Sessions.RevokeAll(user.ID); err != nil {
+		logger.Error("❌ ошибка при завершении сессий пользователя " + toEmail + ": " + err.Error())
+		return err
+	}
 	return nil
 }
 
@@ -190,11 +214,11 @@ func (service *ResetService) ResendCode(toEmail string) error {
 	user, err := service.UserRepository.FindByEmail(toEmail)
 	if err != nil {
 		logger.Error("❌ ошибка при поиске пользователя по email: " + err.Error())
-		return fmt.Errorf("пользователь не найден")
+		return ErrUserNotFound
 	}
 
-	if user.Provider == "google" {
-		return errors.New("сброс пароля недоступен для пользователей, зарегистрированных через Google")
+	if user.Provider != "local" {
+		return ErrProviderAccount
 	}
 
 	requests, err := service.Storage.GetResetCodeCount(toEmail)
@@ -203,7 +227,7 @@ func (service *ResetService) ResendCode(toEmail string) error {
 		return err
 	}
 	if requests >= service.Conf.Code.MaxRequests {
-		return errors.New("превышено количество запросов на сброс пароля, попробуйте позже")
+		return ErrTooManyRequests
 	}
 	if err := service.Storage.IncrementResetCodeCount(toEmail, service.Conf.Code.RateLimitTTL); err != nil {
 		logger.Error("❌ ошибка при обновлении счетчика запросов для email: " + err.Error())
@@ -218,7 +242,7 @@ func (service *ResetService) ResendCode(toEmail string) error {
 	}
 	expiresAt := time.Now().Add(service.Conf.Code.CodeTTL)
 
-	if err := service.Storage.SaveToken(toEmail, code, expiresAt); err != nil {
+	if err := service.Storage.SaveToken(toEmail, service.hashCode(toEmail, code), expiresAt); err != nil {
 		logger.Error("❌ ошибка при сохранении токена для email " + toEmail + ": " + err.Error())
 		return err
 	}
@@ -265,3 +289,23 @@ func (service *ResetService) ResendCode(toEmail string) error {
 	logger.Info("📨 Повторное событие восстановления пароля отправлено в Kafka для email: " + toEmail)
 	return nil
 }
+
+// hashCode хранит в Redis не сам код, а HMAC от него, привязанный к email.
+func (service *ResetService) hashCode(email, code string) string {
+	mac := hmac.New(sha256.New, []byte(service.Conf.OAuth.Secret))
+	mac.Write([]byte(email + "|" + code))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func randomTicket() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashTicket(ticket string) string {
+	sum := sha256.Sum256([]byte(ticket))
+	return hex.EncodeToString(sum[:])
+}
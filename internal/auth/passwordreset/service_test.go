@@ -0,0 +1,112 @@
+package passwordreset
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ShopOnGO/ShopOnGO/configs"
+	"github.com/ShopOnGO/ShopOnGO/internal/user"
+	"github.com/ShopOnGO/ShopOnGO/internal/user/usertest"
+	"github.com/ShopOnGO/ShopOnGO/pkg/oauth2/oauth2test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// fakeStorage хранит коды и тикеты в памяти вместо Redis.
+type fakeStorage struct {
+	codes    map[string]string
+	attempts map[string]int
+	tickets  map[string]string
+}
+
+func (f *fakeStorage) SaveToken(email, codeHash string, expiresAt time.Time) error {
+	f.codes[email] = codeHash
+	f.attempts[email] = 0
+	return nil
+}
+
+func (f *fakeStorage) GetToken(email string) (string, time.Time, error) {
+	hash, ok := f.codes[email]
+	if !ok {
+		return "", time.Time{}, ErrCodeNotFound
+	}
+	return hash, time.Now().Add(time.Minute), nil
+}
+
+func (f *fakeStorage) DeleteToken(email string) error {
+	delete(f.codes, email)
+	delete(f.attempts, email)
+	return nil
+}
+
+func (f *fakeStorage) IncrementAttempts(email string, ttl time.Duration) (int, error) {
+	f.attempts[email]++
+	return f.attempts[email], nil
+}
+
+func (f *fakeStorage) SaveTicket(ticketHash, email string, ttl time.Duration) error {
+	f.tickets[ticketHash] = email
+	return nil
+}
+
+func (f *fakeStorage) ConsumeTicket(ticketHash string) (string, error) {
+	email, ok := f.tickets[ticketHash]
+	if !ok {
+		return "", ErrInvalidTicket
+	}
+	delete(f.tickets, ticketHash)
+	return email, nil
+}
+
+func (f *fakeStorage) GetResetCodeCount(email string) (int, error)                   { return 0, nil }
+func (f *fakeStorage) IncrementResetCodeCount(email string, ttl time.Duration) error { return nil }
+
+func newTestService() (*ResetService, *fakeStorage, *usertest.Repository, *oauth2test.Sessions) {
+	conf := &configs.Config{OAuth: configs.OAuthConfig{Secret: "testsecret"}}
+	storage := &fakeStorage{codes: map[string]string{}, attempts: map[string]int{}, tickets: map[string]string{}}
+	users := usertest.NewRepository(&user.User{Model: gorm.Model{ID: 7}, Email: "a@a.ru", Provider: "local"})
+	sessions := &oauth2test.Sessions{}
+	return NewResetService(conf, storage, users, sessions, nil), storage, users, sessions
+}
+
+func TestResetFlow(t *testing.T) {
+	service, storage, users, sessions := newTestService()
+	storage.SaveToken("a@a.ru", service.hashCode("a@a.ru", "123456"), time.Now().Add(time.Minute))
+	assert.NotContains(t, storage.codes["a@a.ru"], "123456", "код не должен храниться в открытом виде")
+
+	ticket, err := service.VerifyCodeByEmail("a@a.ru", "123456")
+	require.NoError(t, err)
+
+	_, err = service.VerifyCodeByEmail("a@a.ru", "123456")
+	assert.ErrorIs(t, err, ErrCodeNotFound, "код одноразовый")
+
+	require.NoError(t, service.ResetPassword(ticket, "newpassword"))
+	assert.NotEmpty(t, users.Users[7].PasswordHash)
+	assert.Equal(t, []uint{7}, sessions.Revoked)
+
+	assert.ErrorIs(t, service.ResetPassword(ticket, "another"), ErrInvalidTicket, "тикет одноразовый")
+}
+
+func TestResetPassword_WithoutTicket(t *testing.T) {
+	service, storage, users, _ := newTestService()
+	storage.SaveToken("a@a.ru", service.hashCode("a@a.ru", "123456"), time.Now().Add(time.Minute))
+
+	assert.ErrorIs(t, service.ResetPassword("guessed", "newpassword"), ErrInvalidTicket)
+	assert.Empty(t, users.Users[7].PasswordHash)
+}
+
+func TestVerifyCode_AttemptsExhausted(t *testing.T) {
+	service, storage, _, _ := newTestService()
+	storage.SaveToken("a@a.ru", service.hashCode("a@a.ru", "123456"), time.Now().Add(time.Minute))
+
+	for i := 1; i < maxVerifyAttempts; i++ {
+		_, err := service.VerifyCodeByEmail("a@a.ru", "000000")
+		assert.ErrorIs(t, err, ErrInvalidCode)
+	}
+	_, err := service.VerifyCodeByEmail("a@a.ru", "000000")
+	assert.ErrorIs(t, err, ErrTooManyAttempts)
+
+	_, err = service.VerifyCodeByEmail("a@a.ru", "123456")
+	assert.ErrorIs(t, err, ErrCodeNotFound, "после исчерпания попыток даже верный код не принимается")
+}
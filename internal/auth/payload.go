@@ -18,7 +18,7 @@ type ChangeRoleRequest struct {
 	Email           string `json:"email" validate:"required,email"`
 	CurrentPassword string `json:"current_password" validate:"required"`
 	Phone           string `json:"phone,omitempty" validate:"omitempty,e164"`
-	NewRole         string `json:"new_role" validate:"required,oneof=buyer seller moderator"`
+	NewRole         string `json:"new_role" validate:"required,oneof=buyer seller"`
 
 	// Поля для продавца
 	StoreName    string `json:"store_name" validate:"required_if=NewRole seller"`
@@ -28,3 +28,19 @@ type ChangeRoleRequest struct {
 	// Согласие с условиями
 	AcceptTerms bool `json:"accept_terms,omitempty" validate:"omitempty,eq=true"`
 }
+
+type LinkConfirmRequest struct {
+	LinkToken string `json:"link_token" validate:"required"`
+	Password  string `json:"password" validate:"required"`
+}
+
+// LinkRequiredResponse возвращается, если аккаунт провайдера совпал по email с локальным.
+type LinkRequiredResponse struct {
+	LinkRequired bool   `json:"link_required"`
+	LinkToken    string `json:"link_token"`
+	Email        string `json:"email"`
+}
+
+type AuthURLResponse struct {
+	URL string `json:"url"`
+}
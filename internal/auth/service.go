@@ -3,10 +3,13 @@ package auth
 import (
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/ShopOnGO/ShopOnGO/internal/user"
 	"github.com/ShopOnGO/ShopOnGO/pkg/di"
 	"github.com/ShopOnGO/ShopOnGO/pkg/logger"
+	"github.com/ShopOnGO/ShopOnGO/pkg/middleware"
+	"github.com/ShopOnGO/ShopOnGO/pkg/oauthprovider"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
@@ -30,9 +33,9 @@ func (service *AuthService) Register(email, password, name string) (uint, error)
 	}
 
 	if existedUser != nil {
-		// Если пользователь найден, проверяем его провайдера
-		if existedUser.Provider == "google" || existedUser.PasswordHash == "" {
-			return 0, errors.New(ErrGoogleAuthToLocalFailed) // У Google-юзеров нет пароля
+		// аккаунт без пароля создан через провайдера: регистрация по паролю его не заменяет
+		if existedUser.PasswordHash == "" {
+			return 0, passwordlessAccountError(existedUser.Provider)
 		}
 		return 0, errors.New(ErrUserExists) // Пользователь с таким email уже существует
 	}
@@ -62,24 +65,49 @@ func (service *AuthService) Login(email, password string) (uint, error) {
 		return 0, errors.New(ErrWrongCredentials)
 	}
 
-	if existedUser.Provider == "google" || existedUser.PasswordHash == "" {
-		return 0, errors.New(ErrGoogleAuthToLocalFailed) // У Google-юзеров нет пароля
+	// у аккаунтов, созданных через провайдера, нет пароля
+	if existedUser.PasswordHash == "" {
+		return 0, passwordlessAccountError(existedUser.Provider)
 	}
-	logger.Info("Сохраненный пароль в БД:", existedUser.PasswordHash)
-
-	logger.Info("Введенный пароль: " + password)
-	logger.Info("Хеш пароля из БД: " + existedUser.PasswordHash)
-
 	err := bcrypt.CompareHashAndPassword([]byte(existedUser.PasswordHash), []byte(password)) //дефолтная cost даёт 2^10 раундов шифрования
 	if err != nil {
 		logger.Error("❌ Ошибка сравнения паролей: " + err.Error())
 		return 0, errors.New(ErrWrongCredentials)
 	}
+	// статус проверяем после пароля, чтобы не раскрывать блокировку посторонним
+	if err := checkActive(existedUser); err != nil {
+		return 0, err
+	}
 
 	return existedUser.ID, nil
 }
 
-func (service *AuthService) GetOrCreateUserByGoogle(userInfo GoogleUserInfo) (*user.User, error) {
+// EnsureActive проверяет, что пользователь может войти: не заблокирован и не удален.
+func (service *AuthService) EnsureActive(userID uint) error {
+	u, err := service.UserRepository.FindByID(userID)
+	if err != nil {
+		return err
+	}
+	if u == nil {
+		return errors.New(ErrUserNotFound)
+	}
+	return checkActive(u)
+}
+
+func checkActive(u *user.User) error {
+	switch {
+	case u.Status == user.StatusDeleted:
+		return errors.New(ErrWrongCredentials)
+	case u.IsBanned(time.Now()):
+		return middleware.ErrAccountSuspended
+	}
+	return nil
+}
+
+// GetOrCreateUserByProvider находит пользователя по email от провайдера или создает нового.
+// Аккаунт, зарегистрированный иначе, не возвращается молча: для локального нужна
+// привязка с подтверждением пароля, для аккаунта без пароля — вход через его провайдера.
+func (service *AuthService) GetOrCreateUserByProvider(provider string, userInfo oauthprovider.UserInfo) (*user.User, error) {
 	userInPostgres, err := service.UserRepository.FindByEmail(userInfo.Email)
 	var role string
 	if err != nil {
@@ -87,10 +115,11 @@ func (service *AuthService) GetOrCreateUserByGoogle(userInfo GoogleUserInfo) (*u
 			// Если пользователь не найден, создаём нового
 			role = "buyer"
 			newUser := &user.User{
-				Name:     userInfo.Name,
-				Email:    userInfo.Email,
-				Role:     role,
-				Provider: "google",
+				Name:          userInfo.Name,
+				Email:         userInfo.Email,
+				Role:          role,
+				Provider:      provider,
+				EmailVerified: userInfo.EmailVerified, // провайдер уже подтвердил адрес
 			}
 			createdUser, err := service.UserRepository.Create(newUser)
 			if err != nil {
@@ -100,7 +129,13 @@ func (service *AuthService) GetOrCreateUserByGoogle(userInfo GoogleUserInfo) (*u
 		}
 		return nil, err
 	}
-	return userInPostgres, nil
+	if userInPostgres.Provider == provider {
+		return userInPostgres, nil
+	}
+	if userInPostgres.PasswordHash == "" {
+		return userInPostgres, ErrRegisteredWithOtherProvider
+	}
+	return userInPostgres, ErrAccountLinkRequired
 }
 
 func (service *AuthService) UpdateUser(data *ChangeRoleRequest) error {
@@ -112,6 +147,12 @@ func (service *AuthService) UpdateUser(data *ChangeRoleRequest) error {
 		return errors.New(ErrUserNotFound)
 	}
 
+	// Самостоятельно можно только вернуться к роли покупателя; роль продавца
+	// выдается через одобренную заявку (internal/seller), остальные — администратором
+	if data.NewRole != "buyer" && data.NewRole != userData.Role {
+		return ErrRoleRequiresApplication
+	}
+
 	userData.Role = data.NewRole
 	userData.Phone = data.Phone
 	if data.NewRole == "seller" {
@@ -18,6 +18,10 @@ func (repo *MockUserRepository) FindByEmail(email string) (*user.User, error) {
 	return nil, nil
 }
 
+func (repo *MockUserRepository) FindByID(id uint) (*user.User, error) {
+	return nil, nil
+}
+
 func (repo *MockUserRepository) Update(*user.User) (*user.User, error) {
 	return nil, nil
 }
@@ -42,6 +46,14 @@ func (repo *MockUserRepository) GetNameByID(id uint) (string, error) {
 	return "", nil
 }
 
+func (repo *MockUserRepository) MarkEmailVerified(id uint) error {
+	return nil
+}
+
+func (repo *MockUserRepository) IsEmailVerified(id uint) (bool, error) {
+	return true, nil
+}
+
 // Тест на регистрацию пользователя
 func TestRegisterSuccess(t *testing.T) {
 	authService := auth.NewAuthService(&MockUserRepository{})
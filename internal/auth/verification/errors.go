@@ -0,0 +1,16 @@
+package verification
+
+import "errors"
+
+var (
+	ErrEmailNotVerified = errors.New("email is not verified")
+	ErrAlreadyVerified  = errors.New("email already verified")
+	ErrCodeNotFound     = errors.New("verification code not found, request a new one")
+	ErrCodeExpired      = errors.New("verification code expired")
+	ErrInvalidCode      = errors.New("invalid verification code")
+	ErrInvalidToken     = errors.New("invalid verification token")
+	ErrTooManyRequests  = errors.New("too many verification requests, try again later")
+	ErrTooManyAttempts  = errors.New("too many attempts, request a new code")
+	ErrNotLocalAccount  = errors.New("email verification is only required for local accounts")
+	ErrUserNotFound     = errors.New("user not found")
+)
@@ -0,0 +1,100 @@
+package verification
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ShopOnGO/ShopOnGO/configs"
+	"github.com/ShopOnGO/ShopOnGO/pkg/logger"
+	"github.com/ShopOnGO/ShopOnGO/pkg/req"
+	"github.com/ShopOnGO/ShopOnGO/pkg/res"
+	"github.com/gorilla/mux"
+)
+
+type VerificationHandlerDeps struct {
+	*configs.Config
+	*VerificationService
+}
+
+type VerificationHandler struct {
+	*configs.Config
+	*VerificationService
+}
+
+func NewVerificationHandler(router *mux.Router, deps VerificationHandlerDeps) {
+	handler := &VerificationHandler{
+		Config:              deps.Config,
+		VerificationService: deps.VerificationService,
+	}
+	router.Handle("/auth/verify-email", handler.VerifyEmail()).Methods("POST")
+	router.Handle("/auth/verify-email/resend", handler.Resend()).Methods("POST")
+}
+
+// VerifyEmail confirms the user's email address.
+// @Summary      Verify Email
+// @Description  Confirms the email address either by the 6-digit code from the letter (email + code) or by the signed token from the verification link.
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        body  body  VerifyEmailRequest  true  "Email and code, or token"
+// @Success      200   {object}  map[string]string  "Email verified"
+// @Failure      400   {string}  string  "Invalid, expired or already used code"
+// @Failure      429   {string}  string  "Too many attempts"
+// @Failure      500   {string}  string  "Server error"
+// @Router       /auth/verify-email [post]
+func (h *VerificationHandler) VerifyEmail() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := req.HandleBody[VerifyEmailRequest](&w, r)
+		if err != nil {
+			return
+		}
+
+		if body.Token != "" {
+			err = h.VerifyToken(body.Token)
+		} else {
+			err = h.VerifyCode(body.Email, body.Code)
+		}
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		res.Json(w, map[string]string{"message": "Email verified successfully"}, http.StatusOK)
+	}
+}
+
+// Resend sends a new verification code.
+// @Summary      Resend Verification Code
+// @Description  Generates a new email verification code and sends it to the user. Limited by CODE_MAX_REQUESTS per CODE_RATE_LIMIT_TTL. The response is the same whether or not the account exists, is external or is already verified.
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        body  body  ResendRequest  true  "Email"
+// @Success      200   {object}  map[string]string  "Code sent if the account exists"
+// @Failure      429   {string}  string  "Too many requests"
+// @Failure      500   {string}  string  "Server error"
+// @Router       /auth/verify-email/resend [post]
+func (h *VerificationHandler) Resend() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := req.HandleBody[ResendRequest](&w, r)
+		if err != nil {
+			return
+		}
+		if err := h.ResendVerification(body.Email); err != nil {
+			writeError(w, err)
+			return
+		}
+		res.Json(w, map[string]string{"message": "If the account exists and is not verified, a verification code was sent"}, http.StatusOK)
+	}
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrTooManyRequests), errors.Is(err, ErrTooManyAttempts):
+		http.Error(w, err.Error(), http.StatusTooManyRequests)
+	case IsVerificationError(err):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		logger.Error("❌ ошибка подтверждения email: " + err.Error())
+		http.Error(w, "failed to process verification", http.StatusInternalServerError)
+	}
+}
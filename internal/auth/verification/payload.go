@@ -0,0 +1,11 @@
+package verification
+
+type VerifyEmailRequest struct {
+	Email string `json:"email" validate:"required_without=Token,omitempty,email"`
+	Code  string `json:"code" validate:"required_without=Token"`
+	Token string `json:"token"` // подписанный токен из ссылки в письме, заменяет пару email+code
+}
+
+type ResendRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
@@ -0,0 +1,97 @@
+package verification
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/ShopOnGO/ShopOnGO/pkg/redisdb"
+)
+
+type RedisVerificationRepository struct {
+	redis *redisdb.RedisDB
+}
+
+func NewRedisVerificationRepository(r *redisdb.RedisDB) *RedisVerificationRepository {
+	return &RedisVerificationRepository{redis: r}
+}
+
+func (r *RedisVerificationRepository) SaveCode(email, codeHash string, expiresAt time.Time) error {
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		ttl = time.Minute
+	}
+	// новый код сбрасывает счетчик неудачных попыток
+	_, err := r.redis.TxPipelined(context.Background(), func(pipe redis.Pipeliner) error {
+		pipe.Set(context.Background(), r.codeKey(email), codeHash, ttl)
+		pipe.Del(context.Background(), r.attemptsKey(email))
+		return nil
+	})
+	return err
+}
+
+func (r *RedisVerificationRepository) GetCode(email string) (string, time.Time, error) {
+	key := r.codeKey(email)
+	code, err := r.redis.Get(context.Background(), key).Result()
+	if err == redis.Nil {
+		return "", time.Time{}, ErrCodeNotFound
+	}
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	ttl, err := r.redis.TTL(context.Background(), key).Result()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return code, time.Now().Add(ttl), nil
+}
+
+func (r *RedisVerificationRepository) DeleteCode(email string) error {
+	return r.redis.Del(context.Background(), r.codeKey(email), r.attemptsKey(email)).Err()
+}
+
+func (r *RedisVerificationRepository) IncrementAttempts(email string, ttl time.Duration) (int, error) {
+	return r.incr(r.attemptsKey(email), ttl)
+}
+
+func (r *RedisVerificationRepository) GetRequestCount(email string) (int, error) {
+	count, err := r.redis.Get(context.Background(), r.requestCountKey(email)).Int()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return count, err
+}
+
+func (r *RedisVerificationRepository) IncrementRequestCount(email string, ttl time.Duration) error {
+	_, err := r.incr(r.requestCountKey(email), ttl)
+	return err
+}
+
+func (r *RedisVerificationRepository) incr(key string, ttl time.Duration) (int, error) {
+	count, err := r.redis.Incr(context.Background(), key).Result()
+	if err != nil {
+		return 0, err
+	}
+	// Если ключ только создан (count == 1), установим время жизни
+	if count == 1 {
+		if err := r.redis.Expire(context.Background(), key, ttl).Err(); err != nil {
+			return 0, err
+		}
+	}
+	return int(count), nil
+}
+
+func (r *RedisVerificationRepository) codeKey(email string) string {
+	return fmt.Sprintf("verify_code:%s", email)
+}
+
+func (r *RedisVerificationRepository) attemptsKey(email string) string {
+	return fmt.Sprintf("verify_attempts:%s", email)
+}
+
+func (r *RedisVerificationRepository) requestCountKey(email string) string {
+	return fmt.Sprintf("verify_requests:%s", email)
+}
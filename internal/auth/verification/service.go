@@ -0,0 +1,266 @@
+package verification
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ShopOnGO/ShopOnGO/configs"
+	"github.com/ShopOnGO/ShopOnGO/internal/auth/passwordreset"
+	"github.com/ShopOnGO/ShopOnGO/pkg/di"
+	"github.com/ShopOnGO/ShopOnGO/pkg/kafkaService"
+	"github.com/ShopOnGO/ShopOnGO/pkg/logger"
+	"gorm.io/gorm"
+)
+
+// maxVerifyAttempts ограничивает число неверных попыток ввода одного кода.
+const maxVerifyAttempts = 5
+
+type VerificationService struct {
+	Conf           *configs.Config
+	Kafka          *kafkaService.KafkaService
+	Storage        di.IRedisVerificationRepository
+	UserRepository di.IUserRepository
+}
+
+func NewVerificationService(conf *configs.Config, storage di.IRedisVerificationRepository, user di.IUserRepository, kafka *kafkaService.KafkaService) *VerificationService {
+	return &VerificationService{
+		Conf:           conf,
+		Storage:        storage,
+		UserRepository: user,
+		Kafka:          kafka,
+	}
+}
+
+// SendVerification генерирует новый код подтверждения и отправляет его через Kafka.
+// Используется и при регистрации, и при повторной отправке.
+func (service *VerificationService) SendVerification(email string) error {
+	// Лимит проверяется до поиска пользователя, чтобы неизвестный email
+	// ограничивался так же, как существующий
+	requests, err := service.Storage.GetRequestCount(email)
+	if err != nil {
+		logger.Error("❌ ошибка при получении количества запросов подтверждения: " + err.Error())
+		return err
+	}
+	if requests >= service.Conf.Code.MaxRequests {
+		return ErrTooManyRequests
+	}
+	if err := service.Storage.IncrementRequestCount(email, service.Conf.Code.RateLimitTTL); err != nil {
+		logger.Error("❌ ошибка при обновлении количества запросов подтверждения: " + err.Error())
+		return err
+	}
+
+	user, err := service.UserRepository.FindByEmail(email)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrUserNotFound
+		}
+		logger.Error("❌ ошибка при поиске пользователя по email: " + err.Error())
+		return err
+	}
+	if user.Provider != "local" {
+		return ErrNotLocalAccount
+	}
+	if user.EmailVerified {
+		return ErrAlreadyVerified
+	}
+
+	code, err := passwordreset.GenerateCode()
+	if err != nil {
+		logger.Error("❌ ошибка при генерации кода: " + err.Error())
+		return err
+	}
+	expiresAt := time.Now().Add(service.Conf.Code.CodeTTL)
+	if err := service.Storage.SaveCode(email, service.hashCode(email, code), expiresAt); err != nil {
+		logger.Error("❌ ошибка при сохранении кода подтверждения: " + err.Error())
+		return err
+	}
+
+	event := map[string]interface{}{
+		"action":   "create",
+		"category": "AUTHVERIFY",
+		"subtype":  "SEND_VERIFY_CODE",
+		"userID":   user.ID,
+		"wasInDlq": false,
+		"payload": map[string]interface{}{
+			"code":      code,
+			"link":      service.verificationLink(email, code, expiresAt),
+			"subject":   "Подтверждение email",
+			"expiresAt": expiresAt.Unix(),
+			"email":     email,
+		},
+	}
+
+	eventBytes, err := json.Marshal(event)
+	if err != nil {
+		logger.Error("❌ ошибка сериализации события: " + err.Error())
+		return err
+	}
+
+	key := []byte("verify-")
+	if err := service.Kafka.Produce(context.Background(), key, eventBytes); err != nil {
+		logger.Errorf("❌ ошибка отправки сообщения в Kafka: %v", err)
+		return err
+	}
+
+	logger.Info("📨 Событие подтверждения email отправлено в Kafka для email: " + email)
+	return nil
+}
+
+// ResendVerification повторно отправляет код по запросу пользователя. Отсутствующий,
+// внешний или уже подтвержденный аккаунт не отличается от успешной отправки,
+// чтобы по ответу нельзя было перебирать зарегистрированные email. Настоящая
+// причина остается только в логах.
+func (service *VerificationService) ResendVerification(email string) error {
+	err := service.SendVerification(email)
+	switch {
+	case errors.Is(err, ErrUserNotFound), errors.Is(err, ErrNotLocalAccount), errors.Is(err, ErrAlreadyVerified):
+		logger.Infof("📭 код подтверждения для %s не отправлен: %v", email, err)
+		return nil
+	}
+	return err
+}
+
+// VerifyCode подтверждает email по коду из письма.
+func (service *VerificationService) VerifyCode(email, code string) error {
+	storedHash, expiresAt, err := service.Storage.GetCode(email)
+	if err != nil {
+		return err
+	}
+	if time.Now().After(expiresAt) {
+		return ErrCodeExpired
+	}
+	if !hmac.Equal([]byte(storedHash), []byte(service.hashCode(email, code))) {
+		attempts, err := service.Storage.IncrementAttempts(email, time.Until(expiresAt))
+		if err != nil {
+			return err
+		}
+		if attempts >= maxVerifyAttempts {
+			service.Storage.DeleteCode(email)
+			return ErrTooManyAttempts
+		}
+		return ErrInvalidCode
+	}
+	return service.markVerified(email)
+}
+
+// VerifyToken подтверждает email по подписанному токену из ссылки.
+// Токен привязан к текущему коду, поэтому повторная отправка делает старые ссылки недействительными.
+func (service *VerificationService) VerifyToken(token string) error {
+	email, expiresAt, err := parseToken(token)
+	if err != nil {
+		return err
+	}
+	if time.Now().After(expiresAt) {
+		return ErrCodeExpired
+	}
+	storedHash, _, err := service.Storage.GetCode(email)
+	if err != nil {
+		return err
+	}
+	if !hmac.Equal([]byte(token), []byte(signToken(service.Conf.Verification.Secret, email, storedHash, expiresAt))) {
+		return ErrInvalidToken
+	}
+	return service.markVerified(email)
+}
+
+// CheckAllowed возвращает ErrEmailNotVerified, если действие запрещено
+// пользователям с неподтвержденным email (см. Verification.RestrictedActions).
+func (service *VerificationService) CheckAllowed(userID uint, action string) error {
+	restricted := false
+	for _, a := range service.Conf.Verification.RestrictedActions {
+		if a == action {
+			restricted = true
+			break
+		}
+	}
+	if !restricted {
+		return nil
+	}
+
+	verified, err := service.UserRepository.IsEmailVerified(userID)
+	if err != nil {
+		return err
+	}
+	if !verified {
+		return ErrEmailNotVerified
+	}
+	return nil
+}
+
+func (service *VerificationService) markVerified(email string) error {
+	user, err := service.UserRepository.FindByEmail(email)
+	if err != nil {
+		return err
+	}
+	if err := service.UserRepository.MarkEmailVerified(user.ID); err != nil {
+		logger.Error("❌ ошибка при подтверждении email " + email + ": " + err.Error())
+		return err
+	}
+	if err := service.Storage.DeleteCode(email); err != nil {
+		logger.Error("❌ ошибка при удалении кода подтверждения: " + err.Error())
+	}
+	logger.Info("✅ Email подтвержден: " + email)
+	return nil
+}
+
+func (service *VerificationService) verificationLink(email, code string, expiresAt time.Time) string {
+	if service.Conf.Verification.LinkURL == "" {
+		return ""
+	}
+	token := signToken(service.Conf.Verification.Secret, email, service.hashCode(email, code), expiresAt)
+	return service.Conf.Verification.LinkURL + "?token=" + url.QueryEscape(token)
+}
+
+// hashCode хранит в Redis не сам код, а HMAC от него, привязанный к email.
+func (service *VerificationService) hashCode(email, code string) string {
+	mac := hmac.New(sha256.New, []byte(service.Conf.Verification.Secret))
+	mac.Write([]byte(email + "|" + code))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// signToken формирует токен вида base64(email).exp.base64(hmac(email|codeHash|exp)).
+func signToken(secret, email, codeHash string, expiresAt time.Time) string {
+	exp := strconv.FormatInt(expiresAt.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(email + "|" + codeHash + "|" + exp))
+	return base64.RawURLEncoding.EncodeToString([]byte(email)) + "." + exp + "." +
+		base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func parseToken(token string) (string, time.Time, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", time.Time{}, ErrInvalidToken
+	}
+	email, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return "", time.Time{}, ErrInvalidToken
+	}
+	exp, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return "", time.Time{}, ErrInvalidToken
+	}
+	return string(email), time.Unix(exp, 0), nil
+}
+
+// IsVerificationError сообщает, что ошибка вызвана данными клиента, а не сбоем сервера.
+func IsVerificationError(err error) bool {
+	for _, target := range []error{
+		ErrAlreadyVerified, ErrCodeNotFound, ErrCodeExpired, ErrInvalidCode,
+		ErrInvalidToken, ErrTooManyAttempts, ErrNotLocalAccount,
+	} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,143 @@
+package verification
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ShopOnGO/ShopOnGO/configs"
+	"github.com/ShopOnGO/ShopOnGO/internal/user"
+	"github.com/ShopOnGO/ShopOnGO/internal/user/usertest"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// fakeStorage хранит коды в памяти вместо Redis.
+type fakeStorage struct {
+	codes    map[string]string
+	expires  map[string]time.Time
+	attempts map[string]int
+}
+
+func newFakeStorage() *fakeStorage {
+	return &fakeStorage{
+		codes:    map[string]string{},
+		expires:  map[string]time.Time{},
+		attempts: map[string]int{},
+	}
+}
+
+func (f *fakeStorage) SaveCode(email, code string, expiresAt time.Time) error {
+	f.codes[email] = code
+	f.expires[email] = expiresAt
+	f.attempts[email] = 0
+	return nil
+}
+
+func (f *fakeStorage) GetCode(email string) (string, time.Time, error) {
+	code, ok := f.codes[email]
+	if !ok {
+		return "", time.Time{}, ErrCodeNotFound
+	}
+	return code, f.expires[email], nil
+}
+
+func (f *fakeStorage) DeleteCode(email string) error {
+	delete(f.codes, email)
+	delete(f.attempts, email)
+	return nil
+}
+
+func (f *fakeStorage) IncrementAttempts(email string, ttl time.Duration) (int, error) {
+	f.attempts[email]++
+	return f.attempts[email], nil
+}
+
+func (f *fakeStorage) GetRequestCount(email string) (int, error) { return 0, nil }
+
+func (f *fakeStorage) IncrementRequestCount(email string, ttl time.Duration) error { return nil }
+
+func newTestService() (*VerificationService, *fakeStorage, *usertest.Repository) {
+	conf := &configs.Config{
+		OAuth: configs.OAuthConfig{Secret: "oauthsecret"},
+		Verification: configs.VerificationConfig{
+			RestrictedActions: []string{"review"},
+			Secret:            "testsecret",
+		},
+	}
+	storage := newFakeStorage()
+	users := usertest.NewRepository(&user.User{Model: gorm.Model{ID: 7}, Email: "a@a.ru", Provider: "local"})
+	return NewVerificationService(conf, storage, users, nil), storage, users
+}
+
+func TestVerifyCode(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		service, storage, users := newTestService()
+		storage.SaveCode("a@a.ru", service.hashCode("a@a.ru", "123456"), time.Now().Add(time.Minute))
+
+		assert.NoError(t, service.VerifyCode("a@a.ru", "123456"))
+		assert.True(t, users.Users[7].EmailVerified)
+		_, _, err := storage.GetCode("a@a.ru")
+		assert.ErrorIs(t, err, ErrCodeNotFound, "код должен быть одноразовым")
+	})
+
+	t.Run("Failure - attempts exhausted", func(t *testing.T) {
+		service, storage, users := newTestService()
+		storage.SaveCode("a@a.ru", service.hashCode("a@a.ru", "123456"), time.Now().Add(time.Minute))
+
+		for i := 1; i < maxVerifyAttempts; i++ {
+			assert.ErrorIs(t, service.VerifyCode("a@a.ru", "000000"), ErrInvalidCode)
+		}
+		assert.ErrorIs(t, service.VerifyCode("a@a.ru", "000000"), ErrTooManyAttempts)
+		// после исчерпания попыток даже верный код не принимается
+		assert.ErrorIs(t, service.VerifyCode("a@a.ru", "123456"), ErrCodeNotFound)
+		assert.False(t, users.Users[7].EmailVerified)
+	})
+}
+
+func TestVerifyToken(t *testing.T) {
+	expiresAt := time.Now().Add(time.Minute)
+
+	t.Run("Success", func(t *testing.T) {
+		service, storage, users := newTestService()
+		storage.SaveCode("a@a.ru", service.hashCode("a@a.ru", "123456"), expiresAt)
+
+		token := signToken("testsecret", "a@a.ru", service.hashCode("a@a.ru", "123456"), expiresAt)
+		assert.NoError(t, service.VerifyToken(token))
+		assert.True(t, users.Users[7].EmailVerified)
+	})
+
+	t.Run("Failure - token for previous code", func(t *testing.T) {
+		service, storage, _ := newTestService()
+		storage.SaveCode("a@a.ru", service.hashCode("a@a.ru", "654321"), expiresAt)
+
+		token := signToken("testsecret", "a@a.ru", service.hashCode("a@a.ru", "123456"), expiresAt)
+		assert.ErrorIs(t, service.VerifyToken(token), ErrInvalidToken)
+	})
+
+	t.Run("Failure - token signed with the OAuth secret", func(t *testing.T) {
+		service, storage, _ := newTestService()
+		storage.SaveCode("a@a.ru", service.hashCode("a@a.ru", "123456"), expiresAt)
+
+		token := signToken("oauthsecret", "a@a.ru", service.hashCode("a@a.ru", "123456"), expiresAt)
+		assert.ErrorIs(t, service.VerifyToken(token), ErrInvalidToken)
+	})
+}
+
+func TestCheckAllowed(t *testing.T) {
+	service, _, users := newTestService()
+
+	assert.ErrorIs(t, service.CheckAllowed(7, "review"), ErrEmailNotVerified)
+	assert.NoError(t, service.CheckAllowed(7, "question"), "неограниченные действия доступны всем")
+
+	users.Users[7].EmailVerified = true
+	assert.NoError(t, service.CheckAllowed(7, "review"))
+}
+
+func TestResendVerificationHidesUnknownEmail(t *testing.T) {
+	service, storage, _ := newTestService()
+	service.Conf.Code.MaxRequests = 3
+
+	assert.ErrorIs(t, service.SendVerification("missing@example.com"), ErrUserNotFound)
+	assert.NoError(t, service.ResendVerification("missing@example.com"))
+	assert.Empty(t, storage.codes)
+}
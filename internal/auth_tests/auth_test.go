@@ -6,6 +6,7 @@ import (
 
 	"github.com/ShopOnGO/ShopOnGO/internal/auth"
 	"github.com/ShopOnGO/ShopOnGO/internal/user"
+	"github.com/ShopOnGO/ShopOnGO/pkg/oauthprovider"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"golang.org/x/crypto/bcrypt"
@@ -38,6 +39,10 @@ func (m *MockUserRepository) FindByEmail(email string) (*user.User, error) {
 	return nil, nil
 }
 
+func (m *MockUserRepository) FindByID(id uint) (*user.User, error) {
+	return nil, nil
+}
+
 func (m *MockUserRepository) Update(u *user.User) (*user.User, error) {
 	if m.updateFunc != nil {
 		return m.updateFunc(u)
@@ -66,6 +71,14 @@ func (m *MockUserRepository) GetNameByID(id uint) (string, error) {
 	return "", nil
 }
 
+func (m *MockUserRepository) MarkEmailVerified(id uint) error {
+	return nil
+}
+
+func (m *MockUserRepository) IsEmailVerified(id uint) (bool, error) {
+	return true, nil
+}
+
 func hashPassword(t *testing.T, password string) string {
 	// require прервет тест, если хеширование не удастся
 	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
@@ -135,8 +148,8 @@ func TestAuthService_Register(t *testing.T) {
 
 		userID, err := service.Register("google@user.com", "password123", "Test User")
 
-		assert.Error(t, err)
-		assert.Equal(t, auth.ErrGoogleAuthToLocalFailed, err.Error())
+		assert.ErrorIs(t, err, auth.ErrPasswordlessAccount)
+		assert.Contains(t, err.Error(), "google")
 		assert.Equal(t, uint(0), userID)
 	})
 
@@ -255,18 +268,19 @@ func TestAuthService_Login(t *testing.T) {
 
 		_, err := service.Login("google@user.com", "password123")
 
-		assert.Error(t, err)
-		assert.Equal(t, auth.ErrGoogleAuthToLocalFailed, err.Error())
+		assert.ErrorIs(t, err, auth.ErrPasswordlessAccount)
+		assert.Contains(t, err.Error(), "google")
 	})
 }
 
-//  Тест AuthService.GetOrCreateUserByGoogle
+//  Тест AuthService.GetOrCreateUserByProvider
 
-func TestAuthService_GetOrCreateUserByGoogle(t *testing.T) {
-	googleInfo := auth.GoogleUserInfo{
-		ID:    "google123",
-		Name:  "Google User",
-		Email: "google@user.com",
+func TestAuthService_GetOrCreateUserByProvider(t *testing.T) {
+	googleInfo := oauthprovider.UserInfo{
+		Subject:       "google123",
+		Name:          "Google User",
+		Email:         "google@user.com",
+		EmailVerified: true,
 	}
 
 	t.Run("Success - Get Existing User", func(t *testing.T) {
@@ -292,7 +306,7 @@ func TestAuthService_GetOrCreateUserByGoogle(t *testing.T) {
 		}
 		service := auth.NewAuthService(mockRepo)
 
-		u, err := service.GetOrCreateUserByGoogle(googleInfo)
+		u, err := service.GetOrCreateUserByProvider("google", googleInfo)
 
 		assert.NoError(t, err)
 		assert.Equal(t, existingUser.ID, u.ID)
@@ -319,19 +333,40 @@ func TestAuthService_GetOrCreateUserByGoogle(t *testing.T) {
 				assert.Equal(t, googleInfo.Name, u.Name)
 				assert.Equal(t, "google", u.Provider)
 				assert.Equal(t, "buyer", u.Role)
+				assert.True(t, u.EmailVerified)
 				u.ID = 1
 				return u, nil
 			},
 		}
 		service := auth.NewAuthService(mockRepo)
 
-		u, err := service.GetOrCreateUserByGoogle(googleInfo)
+		u, err := service.GetOrCreateUserByProvider("google", googleInfo)
 
 		assert.NoError(t, err)
 		assert.Equal(t, newUser.ID, u.ID)
 		assert.Equal(t, "buyer", u.Role)
 	})
 
+	t.Run("Failure - Local account requires link confirmation", func(t *testing.T) {
+		localUser := &user.User{
+			Model:        gorm.Model{ID: 5},
+			Email:        "google@user.com",
+			PasswordHash: "hash",
+			Provider:     "local",
+		}
+		mockRepo := &MockUserRepository{
+			findByEmailFunc: func(email string) (*user.User, error) {
+				return localUser, nil
+			},
+		}
+		service := auth.NewAuthService(mockRepo)
+
+		u, err := service.GetOrCreateUserByProvider("google", googleInfo)
+
+		assert.ErrorIs(t, err, auth.ErrAccountLinkRequired)
+		assert.Equal(t, localUser.ID, u.ID) // возвращается аккаунт, к которому предлагается привязка
+	})
+
 	t.Run("Failure - DB error on Find", func(t *testing.T) {
 		dbError := errors.New("db find error")
 		mockRepo := &MockUserRepository{
@@ -341,7 +376,7 @@ func TestAuthService_GetOrCreateUserByGoogle(t *testing.T) {
 		}
 		service := auth.NewAuthService(mockRepo)
 
-		_, err := service.GetOrCreateUserByGoogle(googleInfo)
+		_, err := service.GetOrCreateUserByProvider("google", googleInfo)
 
 		assert.Error(t, err)
 		assert.Equal(t, dbError, err)
@@ -369,12 +404,38 @@ func TestAuthService_UpdateUser(t *testing.T) {
 		Role:  "buyer",
 	}
 
-	t.Run("Success - Update to Seller", func(t *testing.T) {
-		var updatedUser *user.User
+	t.Run("Failure - Self-promotion to Seller", func(t *testing.T) {
 		mockRepo := &MockUserRepository{
 			findByEmailFunc: func(email string) (*user.User, error) {
 				return existingUser, nil
 			},
+			updateFunc: func(u *user.User) (*user.User, error) {
+				t.Fatal("Update should not be called")
+				return nil, nil
+			},
+		}
+		service := auth.NewAuthService(mockRepo)
+
+		err := service.UpdateUser(changeRequest)
+
+		assert.Error(t, err)
+		assert.ErrorIs(t, err, auth.ErrRoleRequiresApplication)
+		assert.Equal(t, "buyer", existingUser.Role)
+	})
+
+	t.Run("Success - Seller updates store details", func(t *testing.T) {
+		existingSeller := &user.User{
+			Model: gorm.Model{
+				ID: 1,
+			},
+			Email: "user@example.com",
+			Role:  "seller",
+		}
+		var updatedUser *user.User
+		mockRepo := &MockUserRepository{
+			findByEmailFunc: func(email string) (*user.User, error) {
+				return existingSeller, nil
+			},
 			updateFunc: func(u *user.User) (*user.User, error) {
 				updatedUser = u
 				return u, nil
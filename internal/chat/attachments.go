@@ -0,0 +1,134 @@
+package chat
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"unicode/utf8"
+
+	"github.com/ShopOnGO/ShopOnGO/pkg/logger"
+	"github.com/ShopOnGO/ShopOnGO/pkg/media"
+)
+
+// defaultMaxUploadSize — предел вложения, если он не задан в конфиге.
+const defaultMaxUploadSize = 10 << 20
+
+// Uploader загружает файл во внешнее хранилище и возвращает его URL (media.Client).
+type Uploader interface {
+	Upload(file io.Reader, filename string) (string, error)
+}
+
+// allowedMimeTypes — какие файлы можно отправить в чат, как их показывать и с каким
+// расширением хранить. Тип определяется по содержимому, расширение имени не учитывается.
+var allowedMimeTypes = map[string]struct {
+	Kind string
+	Ext  string
+}{
+	"image/jpeg":      {MsgTypeImage, ".jpg"},
+	"image/png":       {MsgTypeImage, ".png"},
+	"image/gif":       {MsgTypeImage, ".gif"},
+	"image/webp":      {MsgTypeImage, ".webp"},
+	"application/pdf": {MsgTypeFile, ".pdf"},
+	"application/zip": {MsgTypeFile, ".zip"}, // в том числе docx и xlsx
+	"text/plain":      {MsgTypeFile, ".txt"},
+}
+
+// Upload проверяет файл (размер, тип по содержимому, антивирус), загружает его в
+// Media Service и запоминает, что URL выдан пользователю ownerID.
+func (c *ChatService) Upload(ctx context.Context, ownerID uint, file io.Reader, fileName string) (*Attachment, error) {
+	data, err := io.ReadAll(io.LimitReader(file, c.maxUploadSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("read attachment: %w", err)
+	}
+	if int64(len(data)) > c.maxUploadSize {
+		return nil, ErrAttachmentTooLarge
+	}
+	if len(data) == 0 {
+		return nil, ErrUnsupportedMedia
+	}
+
+	mimeType, _, err := mime.ParseMediaType(http.DetectContentType(data))
+	if err != nil {
+		return nil, ErrUnsupportedMedia
+	}
+	allowed, ok := allowedMimeTypes[mimeType]
+	if !ok {
+		return nil, ErrUnsupportedMedia
+	}
+
+	if err := c.scanner.Scan(ctx, bytes.NewReader(data)); err != nil {
+		if errors.Is(err, media.ErrInfected) {
+			logger.FromContext(ctx).Warnw("⚠️ Chat: infected attachment rejected", logger.Uint("user_id", ownerID), logger.Err(err))
+			return nil, ErrInfectedAttachment
+		}
+		return nil, fmt.Errorf("scan attachment: %w", err)
+	}
+
+	// Имя в хранилище строится только из проверенного типа: клиентское расширение
+	// (например, .html у текстового файла) не должно попасть в URL. Исходное имя
+	// остается лишь для показа в Attachment.FileName.
+	fileName = cleanFileName(fileName)
+	storedName := fmt.Sprintf("chat-%d-%s%s", ownerID, randomSuffix(), allowed.Ext)
+	url, err := c.media.Upload(bytes.NewReader(data), storedName)
+	if err != nil {
+		return nil, fmt.Errorf("upload attachment: %w", err)
+	}
+
+	attachment := &Attachment{
+		OwnerID:  ownerID,
+		URL:      url,
+		FileName: fileName,
+		MimeType: mimeType,
+		Kind:     allowed.Kind,
+		Size:     int64(len(data)),
+	}
+	if err := c.repo.CreateAttachment(attachment); err != nil {
+		return nil, err
+	}
+	return attachment, nil
+}
+
+// cleanFileName оставляет от имени файла только базовое имя допустимой длины.
+func cleanFileName(name string) string {
+	name = filepath.Base(filepath.Clean("/" + name))
+	if name == "/" || name == "." {
+		name = "file"
+	}
+	for utf8.RuneCountInString(name) > maxFileNameLength {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+	return name
+}
+
+func randomSuffix() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// checkAttachment пропускает вложение, только если его URL выдан отправителю при
+// загрузке. Тип и имя файла берутся из записи о загрузке, а не из кадра клиента.
+func (h *Hub) checkAttachment(req request, msgType, url string) (*Attachment, bool) {
+	if msgType == MsgTypeText {
+		return nil, true
+	}
+	attachment, err := h.ChatRepository.FindAttachment(req.client.ID, url)
+	if err != nil {
+		logger.Error("Chat attachment lookup failed", err)
+		req.fail(ErrCodeUnavailable, "Chat is temporarily unavailable")
+		return nil, false
+	}
+	if attachment == nil {
+		req.fail(ErrCodeForbidden, "Attachment was not uploaded by you")
+		return nil, false
+	}
+	return attachment, true
+}
@@ -0,0 +1,89 @@
+package chat
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ShopOnGO/ShopOnGO/pkg/media"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeUploader выдает уникальные URL вместо загрузки в S3.
+type fakeUploader struct{ n atomic.Int64 }
+
+func (u *fakeUploader) Upload(file io.Reader, filename string) (string, error) {
+	if _, err := io.Copy(io.Discard, file); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("https://cdn.test/%d/%s", u.n.Add(1), filename), nil
+}
+
+// eicarScanner находит "угрозу" в файлах с EICAR.
+type eicarScanner struct{}
+
+func (eicarScanner) Scan(_ context.Context, file io.Reader) error {
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return err
+	}
+	if bytes.Contains(data, []byte("EICAR")) {
+		return fmt.Errorf("%w: Eicar-Test-Signature", media.ErrInfected)
+	}
+	return nil
+}
+
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func TestUploadValidatesAttachments(t *testing.T) {
+	c := newCluster(t, 1)
+	service := c.services[0]
+	service.scanner = eicarScanner{}
+	ctx := context.Background()
+
+	// Тип берется из содержимого, а не из расширения; путь в имени отбрасывается
+	attachment, err := service.Upload(ctx, 1, bytes.NewReader(pngHeader), "../../photo.txt")
+	require.NoError(t, err)
+	assert.Equal(t, MsgTypeImage, attachment.Kind)
+	assert.Equal(t, "image/png", attachment.MimeType)
+	assert.Equal(t, "photo.txt", attachment.FileName)
+	assert.True(t, strings.HasSuffix(attachment.URL, ".png"), attachment.URL)
+	assert.Equal(t, int64(len(pngHeader)), attachment.Size)
+
+	_, err = service.Upload(ctx, 1, strings.NewReader("<html><body>hi</body></html>"), "page.png")
+	assert.ErrorIs(t, err, ErrUnsupportedMedia)
+
+	// Текст под видом страницы хранится как .txt, имя клиента — только для показа
+	attachment, err = service.Upload(ctx, 1, strings.NewReader("just text"), "x.html")
+	require.NoError(t, err)
+	assert.Equal(t, "x.html", attachment.FileName)
+	assert.True(t, strings.HasSuffix(attachment.URL, ".txt"), attachment.URL)
+	_, err = service.Upload(ctx, 1, strings.NewReader("X5O!P%@AP EICAR test file"), "notes.txt")
+	assert.ErrorIs(t, err, ErrInfectedAttachment)
+
+	service.maxUploadSize = 8
+	_, err = service.Upload(ctx, 1, bytes.NewReader(pngHeader), "photo.png")
+	assert.ErrorIs(t, err, ErrAttachmentTooLarge)
+}
+
+func TestMessageAttachmentOwnership(t *testing.T) {
+	c := newCluster(t, 1)
+	user := c.dial(t, 0, 1, "buyer")
+	other := c.dial(t, 0, 2, "buyer")
+
+	attachment, err := c.services[0].Upload(context.Background(), 1, bytes.NewReader(pngHeader), "photo.png")
+	require.NoError(t, err)
+
+	// Чужой URL отправить нельзя
+	id := send(t, other, ReqMessage, IncomingUserMessage{Content: attachment.URL, Type: MsgTypeImage})
+	expect(t, other, `"id":"`+id+`","payload":{"code":"forbidden","message":"Attachment was not uploaded by you"}`)
+
+	// Тип и имя файла берутся из записи о загрузке
+	send(t, user, ReqMessage, IncomingUserMessage{Content: attachment.URL, Type: MsgTypeFile, FileName: "evil.exe"})
+	expect(t, user, `"type":"image","file_name":"photo.png"`)
+}
@@ -0,0 +1,75 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ShopOnGO/ShopOnGO/pkg/redisdb"
+	"github.com/go-redis/redis/v8"
+)
+
+// controlChannel — служебные команды всем репликам (например, закрыть соединения пользователя).
+const controlChannel = "chat:control"
+
+// event — то, что реплики пересылают друг другу через Redis.
+// Data уходит клиентам как есть; Disconnect — закрыть соединения пользователя.
+type event struct {
+	Data       json.RawMessage `json:"data,omitempty"`
+	Disconnect uint            `json:"disconnect,omitempty"`
+}
+
+// sessionChannel — все соединения пользователя в его сессии.
+func sessionChannel(userID uint) string {
+	return fmt.Sprintf("chat:session:%d", userID)
+}
+
+// managerChannel — все соединения менеджера.
+func managerChannel(managerID uint) string {
+	return fmt.Sprintf("chat:manager:%d", managerID)
+}
+
+// RedisBroker рассылает события между репликами через Redis pub/sub.
+// Реплика подписана только на каналы тех, кто подключен к ней.
+type RedisBroker struct {
+	redis  *redisdb.RedisDB
+	pubsub *redis.PubSub
+}
+
+func NewRedisBroker(r *redisdb.RedisDB) *RedisBroker {
+	return &RedisBroker{
+		redis:  r,
+		pubsub: r.Subscribe(context.Background()),
+	}
+}
+
+func (b *RedisBroker) Publish(channel string, ev event) error {
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	return b.redis.Publish(context.Background(), channel, data).Err()
+}
+
+func (b *RedisBroker) Subscribe(channel string) error {
+	return b.pubsub.Subscribe(context.Background(), channel)
+}
+
+func (b *RedisBroker) Unsubscribe(channel string) error {
+	return b.pubsub.Unsubscribe(context.Background(), channel)
+}
+
+// Listen передает handler все события подписанных каналов. Блокирует до Close.
+func (b *RedisBroker) Listen(handler func(channel string, ev event)) {
+	for msg := range b.pubsub.Channel() {
+		var ev event
+		if err := json.Unmarshal([]byte(msg.Payload), &ev); err != nil {
+			continue
+		}
+		handler(msg.Channel, ev)
+	}
+}
+
+func (b *RedisBroker) Close() error {
+	return b.pubsub.Close()
+}
@@ -0,0 +1,165 @@
+package chat
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/ShopOnGO/ShopOnGO/internal/rbac"
+	"github.com/ShopOnGO/ShopOnGO/internal/user"
+	"github.com/ShopOnGO/ShopOnGO/pkg/logger"
+)
+
+// UserFinder загружает пользователя для подстановки в заготовленный ответ (user.UserRepository).
+type UserFinder interface {
+	FindByID(id uint) (*user.User, error)
+}
+
+// cannedPlaceholders — плейсхолдеры заготовленных ответов и их значения.
+var cannedPlaceholders = map[string]func(u *user.User) string{
+	"user.Name":  func(u *user.User) string { return u.Name },
+	"user.Email": func(u *user.User) string { return u.Email },
+}
+
+var placeholderPattern = regexp.MustCompile(`\{\{\s*([^{}]*?)\s*\}\}`)
+
+// checkPlaceholders отклоняет шаблон с неизвестными плейсхолдерами, чтобы опечатка
+// не ушла покупателю как есть.
+func checkPlaceholders(body string) error {
+	for _, match := range placeholderPattern.FindAllStringSubmatch(body, -1) {
+		if _, ok := cannedPlaceholders[match[1]]; !ok {
+			return fmt.Errorf("%w: unknown placeholder %s", ErrInvalidCanned, match[0])
+		}
+	}
+	return nil
+}
+
+// expandCanned подставляет данные пользователя. Значения вставляются как есть и
+// повторно не разбираются.
+func expandCanned(body string, u *user.User) string {
+	return placeholderPattern.ReplaceAllStringFunc(body, func(placeholder string) string {
+		name := placeholderPattern.FindStringSubmatch(placeholder)[1]
+		if value, ok := cannedPlaceholders[name]; ok {
+			return value(u)
+		}
+		return placeholder
+	})
+}
+
+// ListCanned — личные заготовки менеджера и общие.
+func (c *ChatService) ListCanned(managerID uint) ([]CannedResponse, error) {
+	responses, err := c.repo.ListCanned(managerID)
+	if err != nil {
+		return nil, err
+	}
+	if responses == nil {
+		responses = []CannedResponse{}
+	}
+	return responses, nil
+}
+
+func (c *ChatService) CreateCanned(managerID uint, role string, input CannedResponseRequest) (*CannedResponse, error) {
+	if err := c.checkCanned(role, input); err != nil {
+		return nil, err
+	}
+	response := &CannedResponse{
+		OwnerID: managerID,
+		Title:   strings.TrimSpace(input.Title),
+		Body:    input.Body,
+		Shared:  input.Shared,
+	}
+	if err := c.repo.CreateCanned(response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+func (c *ChatService) UpdateCanned(managerID uint, role string, id uint, input CannedResponseRequest) (*CannedResponse, error) {
+	response, err := c.editableCanned(managerID, role, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.checkCanned(role, input); err != nil {
+		return nil, err
+	}
+	response.Title = strings.TrimSpace(input.Title)
+	response.Body = input.Body
+	response.Shared = input.Shared
+	if err := c.repo.UpdateCanned(response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+func (c *ChatService) DeleteCanned(managerID uint, role string, id uint) error {
+	if _, err := c.editableCanned(managerID, role, id); err != nil {
+		return err
+	}
+	return c.repo.DeleteCanned(id)
+}
+
+// checkCanned проверяет шаблон; общие заготовки ведут только руководители (chat:review).
+func (c *ChatService) checkCanned(role string, input CannedResponseRequest) error {
+	if input.Shared && !c.permissions.HasPermission(role, rbac.PermChatReview) {
+		return ErrCannedForbidden
+	}
+	return checkPlaceholders(input.Body)
+}
+
+// editableCanned возвращает заготовку, которую менеджер может менять: свою личную
+// или общую, если он руководитель. Чужие личные заготовки для него не существуют.
+func (c *ChatService) editableCanned(managerID uint, role string, id uint) (*CannedResponse, error) {
+	response, err := c.repo.FindCanned(id)
+	if err != nil {
+		return nil, err
+	}
+	if response == nil || (!response.Shared && response.OwnerID != managerID) {
+		return nil, ErrCannedNotFound
+	}
+	if response.Shared && !c.permissions.HasPermission(role, rbac.PermChatReview) {
+		return nil, ErrCannedForbidden
+	}
+	return response, nil
+}
+
+// handleMacro отправляет пользователю заготовленный ответ, раскрыв плейсхолдеры на
+// сервере: в базу и собеседнику уходит уже готовый текст.
+func (h *Hub) handleMacro(req request, cmd MacroCommand) {
+	if h.ackDuplicate(req) {
+		return
+	}
+	session, err := h.sessions.Get(cmd.UserID)
+	if err != nil {
+		logger.Error("Chat session lookup failed", err)
+		req.fail(ErrCodeUnavailable, "Chat is temporarily unavailable")
+		return
+	}
+	if session == nil || session.ManagerID != req.client.ID {
+		req.fail(ErrCodeNotFound, "No active session with this user")
+		return
+	}
+
+	response, err := h.ChatRepository.FindCanned(cmd.ResponseID)
+	if err != nil {
+		logger.Error("Chat canned response lookup failed", err)
+		req.fail(ErrCodeUnavailable, "Chat is temporarily unavailable")
+		return
+	}
+	if response == nil || (!response.Shared && response.OwnerID != req.client.ID) {
+		req.fail(ErrCodeNotFound, "Canned response not found")
+		return
+	}
+	customer, err := h.directory.FindByID(cmd.UserID)
+	if err != nil {
+		logger.Error("Chat macro user lookup failed", err)
+		req.fail(ErrCodeUnavailable, "Chat is temporarily unavailable")
+		return
+	}
+
+	msg := ManagerMessage{UserID: cmd.UserID, Content: expandCanned(response.Body, customer)}
+	if err := msg.validate(); err != nil {
+		req.fail(ErrCodeBadRequest, err.Error())
+		return
+	}
+	h.handleManagerTextMessage(req, msg)
+}
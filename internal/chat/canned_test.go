@@ -0,0 +1,62 @@
+package chat
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCannedResponsesAndMacros(t *testing.T) {
+	c := newCluster(t, 2)
+	service := c.services[0]
+
+	// Опечатка в плейсхолдере не сохраняется
+	_, err := service.CreateCanned(100, "manager", CannedResponseRequest{Title: "Hi", Body: "Hello, {{user.Nmae}}"})
+	require.ErrorIs(t, err, ErrInvalidCanned)
+	_, err = service.CreateCanned(100, "manager", CannedResponseRequest{Title: "Shared", Body: "Hi", Shared: true})
+	require.ErrorIs(t, err, ErrCannedForbidden, "общие заготовки ведет руководитель")
+
+	personal, err := service.CreateCanned(100, "manager", CannedResponseRequest{Title: "Greeting", Body: "Здравствуйте, {{user.Name}}! Письмо отправим на {{ user.Email }}."})
+	require.NoError(t, err)
+	shared, err := service.CreateCanned(300, "lead", CannedResponseRequest{Title: "Bye", Body: "Всего доброго, {{user.Name}}", Shared: true})
+	require.NoError(t, err)
+	foreign, err := service.CreateCanned(200, "manager", CannedResponseRequest{Title: "Mine", Body: "Только мой"})
+	require.NoError(t, err)
+
+	list, err := service.ListCanned(100)
+	require.NoError(t, err)
+	assert.Len(t, list, 2, "свои и общие, без чужих личных")
+	_, err = service.UpdateCanned(100, "manager", foreign.ID, CannedResponseRequest{Title: "x", Body: "x"})
+	assert.ErrorIs(t, err, ErrCannedNotFound)
+	assert.ErrorIs(t, service.DeleteCanned(100, "manager", shared.ID), ErrCannedForbidden)
+
+	user := c.dial(t, 0, 1, "buyer")
+	manager := c.dial(t, 1, 100, "manager")
+	send(t, user, ReqMessage, IncomingUserMessage{Content: "hello"})
+	expect(t, user, `"type":"ack"`)
+
+	// Макрос только для пользователя, которого менеджер ведет
+	id := send(t, manager, ReqMacro, MacroCommand{UserID: 1, ResponseID: personal.ID})
+	expect(t, manager, `"id":"`+id+`","payload":{"code":"not_found","message":"No active session with this user"}`)
+	send(t, manager, ReqTake, ManagerCommand{UserID: 1})
+	expect(t, manager, `"type":"ack"`)
+
+	id = send(t, manager, ReqMacro, MacroCommand{UserID: 1, ResponseID: personal.ID})
+	expect(t, user, `"content":"Здравствуйте, User 1! Письмо отправим на user1@example.com."`)
+	expect(t, manager, `"type":"ack","id":"`+id+`"`)
+	send(t, manager, ReqMacro, MacroCommand{UserID: 1, ResponseID: shared.ID})
+	expect(t, user, `"content":"Всего доброго, User 1"`)
+
+	id = send(t, manager, ReqMacro, MacroCommand{UserID: 1, ResponseID: foreign.ID})
+	expect(t, manager, `"id":"`+id+`","payload":{"code":"not_found","message":"Canned response not found"}`)
+	sendFrame(t, manager, ReqMacro, "", MacroCommand{UserID: 1, ResponseID: personal.ID})
+	expect(t, manager, `"message":"id is required for messages"`)
+	id = send(t, user, ReqMacro, MacroCommand{UserID: 1, ResponseID: personal.ID})
+	expect(t, user, `"id":"`+id+`","payload":{"code":"forbidden"`)
+
+	// В базе сохранен уже раскрытый текст
+	saved := c.repo.message(2)
+	assert.Equal(t, "Здравствуйте, User 1! Письмо отправим на user1@example.com.", saved.Content)
+	assert.Equal(t, MsgTypeText, saved.Type)
+}
@@ -2,6 +2,7 @@ package chat
 
 import (
 	"log"
+	"sync"
 	"time"
 
 	"github.com/ShopOnGO/ShopOnGO/pkg/logger"
@@ -18,27 +19,41 @@ type Client struct {
 	Conn      *websocket.Conn
 	Send      chan []byte
 	ID        uint
+	Role      string
 	IsManager bool
+	Version   int // согласованная версия протокола
+	// done закрывается, когда соединение завершено. Send не закрываем: в него
+	// могут писать события с других реплик, а запись в закрытый канал — паника.
+	done      chan struct{}
+	closeOnce sync.Once
 }
 
-func NewClient(conn *websocket.Conn, hub *Hub, userID uint, isManager bool) *Client {
+func NewClient(conn *websocket.Conn, hub *Hub, userID uint, role string, isManager bool, version int) *Client {
 	return &Client{
 		Hub:       hub,
 		Conn:      conn,
 		Send:      make(chan []byte, 256),
 		ID:        userID,
+		Role:      role,
 		IsManager: isManager,
+		Version:   version,
+		done:      make(chan struct{}),
 	}
 }
 
+// close сообщает WritePump и отправителям, что соединение завершено.
+func (c *Client) close() {
+	c.closeOnce.Do(func() { close(c.done) })
+}
+
 func (c *Client) ReadPump() {
 	defer func() {
 		c.Hub.unregister <- c
 		c.Conn.Close()
-		close(c.Send) // Закрываем канал Send
+		c.close()
 	}()
 
-	c.Conn.SetReadLimit(512)
+	c.Conn.SetReadLimit(maxFrameBytes)
 	c.Conn.SetReadDeadline(time.Now().Add(pongWait))
 	c.Conn.SetPongHandler(func(string) error {
 
@@ -109,13 +124,14 @@ func (c *Client) WritePump() {
 
 	for {
 		select {
-		case message, ok := <-c.Send:
+		case <-c.done:
+			logger.Debug("Send channel closed", map[string]interface{}{"client_id": c.ID})
+			c.Conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
+			c.Conn.WriteMessage(websocket.CloseMessage, []byte{})
+			return
+
+		case message := <-c.Send:
 			c.Conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
-			if !ok {
-				logger.Debug("Send channel closed", map[string]interface{}{"client_id": c.ID})
-				c.Conn.WriteMessage(websocket.CloseMessage, []byte{})
-				return
-			}
 
 			w, err := c.Conn.NextWriter(websocket.TextMessage) // TODO без буферизации
 			if err != nil {
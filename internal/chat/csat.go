@@ -0,0 +1,85 @@
+package chat
+
+import (
+	"time"
+
+	"github.com/ShopOnGO/ShopOnGO/pkg/logger"
+)
+
+// ratingWindow — сколько после закрытия сессии пользователь может ее оценить.
+const ratingWindow = 7 * 24 * time.Hour
+
+// Шаги группировки отчета (единицы date_trunc)
+const (
+	ReportDay   = "day"
+	ReportWeek  = "week"
+	ReportMonth = "month"
+)
+
+// defaultReportRange — период отчета, если from не задан.
+const defaultReportRange = 30 * 24 * time.Hour
+
+// rateSession сохраняет оценку закрытой сессии. Повтор кадра (в том числе с другой
+// оценкой) подтверждается как дубликат: учитывается первая оценка.
+func (h *Hub) rateSession(req request, input RatingInput) {
+	session, err := h.ChatRepository.FindSession(input.SessionID)
+	if err != nil {
+		logger.Error("Chat session lookup failed", err)
+		req.fail(ErrCodeUnavailable, "Chat is temporarily unavailable")
+		return
+	}
+	if session == nil || session.UserID != req.client.ID || session.Status != SessionClosed ||
+		session.ManagerID == 0 || session.ClosedAt == nil {
+		req.fail(ErrCodeNotFound, "No closed session to rate")
+		return
+	}
+	if time.Since(*session.ClosedAt) > ratingWindow {
+		req.fail(ErrCodeBadRequest, "Rating period has expired")
+		return
+	}
+
+	created, err := h.ChatRepository.CreateRating(&ChatRating{
+		SessionID: session.ID,
+		UserID:    session.UserID,
+		ManagerID: session.ManagerID,
+		ClosedAt:  *session.ClosedAt,
+		Score:     input.Score,
+		Comment:   input.Comment,
+	})
+	if err != nil {
+		logger.Error("Chat rating save failed", err)
+		req.fail(ErrCodeUnavailable, "Chat is temporarily unavailable")
+		return
+	}
+	req.ack(Ack{Duplicate: !created})
+}
+
+// Report — CSAT, время первого ответа и время решения по менеджерам и периодам.
+func (c *ChatService) Report(filter ReportFilter) (*ReportResponse, error) {
+	if filter.Period == "" {
+		filter.Period = ReportWeek
+	}
+	switch filter.Period {
+	case ReportDay, ReportWeek, ReportMonth:
+	default:
+		return nil, ErrInvalidReport
+	}
+	if filter.To.IsZero() {
+		filter.To = time.Now()
+	}
+	if filter.From.IsZero() {
+		filter.From = filter.To.Add(-defaultReportRange)
+	}
+	if !filter.From.Before(filter.To) {
+		return nil, ErrInvalidReport
+	}
+
+	reports, err := c.repo.ManagerReport(filter)
+	if err != nil {
+		return nil, err
+	}
+	if reports == nil {
+		reports = []ManagerReport{}
+	}
+	return &ReportResponse{From: filter.From, To: filter.To, Period: filter.Period, Reports: reports}, nil
+}
@@ -0,0 +1,77 @@
+package chat
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCSATAfterClose(t *testing.T) {
+	c := newCluster(t, 2)
+	user := c.dial(t, 0, 1, "buyer")
+	manager := c.dial(t, 1, 100, "manager")
+
+	send(t, user, ReqMessage, IncomingUserMessage{Content: "hello"})
+	expect(t, user, `"type":"ack"`)
+	send(t, manager, ReqTake, ManagerCommand{UserID: 1})
+	expect(t, manager, `"type":"ack"`)
+	sessionID := c.repo.session(1).ID
+
+	// Оценить можно только закрытую сессию
+	id := send(t, user, ReqRate, RatingInput{SessionID: sessionID, Score: 5})
+	expect(t, user, `"id":"`+id+`","payload":{"code":"not_found","message":"No closed session to rate"}`)
+
+	// Закрытие на одной реплике — просьба об оценке пользователю на другой
+	send(t, manager, ReqClose, ManagerCommand{UserID: 1})
+	expect(t, user, fmt.Sprintf(`{"v":2,"type":"csat","payload":{"session_id":%d,"manager_id":100,"closed_at":`, sessionID))
+
+	id = send(t, user, ReqRate, RatingInput{SessionID: sessionID, Score: 6})
+	expect(t, user, `"id":"`+id+`","payload":{"code":"bad_request","message":"score must be between 1 and 5"}`)
+	id = send(t, manager, ReqRate, RatingInput{SessionID: sessionID, Score: 1})
+	expect(t, manager, `"id":"`+id+`","payload":{"code":"forbidden"`)
+	other := c.dial(t, 0, 2, "buyer")
+	id = send(t, other, ReqRate, RatingInput{SessionID: sessionID, Score: 1})
+	expect(t, other, `"id":"`+id+`","payload":{"code":"not_found"`)
+
+	id = send(t, user, ReqRate, RatingInput{SessionID: sessionID, Score: 4, Comment: "  Быстро помогли  "})
+	ack := expect(t, user, `{"v":2,"type":"ack","id":"`+id+`"`)
+	assert.NotContains(t, ack, "duplicate")
+	id = send(t, user, ReqRate, RatingInput{SessionID: sessionID, Score: 1})
+	expect(t, user, `{"v":2,"type":"ack","id":"`+id+`","payload":{"duplicate":true}}`)
+
+	c.repo.mu.Lock()
+	require.Len(t, c.repo.ratings, 1)
+	rating := c.repo.ratings[0]
+	c.repo.mu.Unlock()
+	assert.Equal(t, uint(1), rating.UserID)
+	assert.Equal(t, uint(100), rating.ManagerID)
+	assert.Equal(t, 4, rating.Score)
+	assert.Equal(t, "Быстро помогли", rating.Comment)
+	assert.Equal(t, *c.repo.session(1).ClosedAt, rating.ClosedAt)
+}
+
+func TestReportFilter(t *testing.T) {
+	c := newCluster(t, 1)
+	service := c.services[0]
+
+	report, err := service.Report(ReportFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, ReportWeek, report.Period)
+	assert.Equal(t, defaultReportRange, report.To.Sub(report.From))
+	assert.NotNil(t, report.Reports)
+
+	to := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	_, err = service.Report(ReportFilter{From: to.AddDate(0, -2, 0), To: to, Period: ReportMonth})
+	require.NoError(t, err)
+	c.repo.mu.Lock()
+	assert.Equal(t, ReportFilter{From: to.AddDate(0, -2, 0), To: to, Period: ReportMonth}, c.repo.report)
+	c.repo.mu.Unlock()
+
+	_, err = service.Report(ReportFilter{Period: "year"})
+	assert.ErrorIs(t, err, ErrInvalidReport)
+	_, err = service.Report(ReportFilter{From: to, To: to})
+	assert.ErrorIs(t, err, ErrInvalidReport)
+}
@@ -0,0 +1,22 @@
+package chat
+
+import "errors"
+
+var (
+	ErrNotServing     = errors.New("no active session with this user")
+	ErrInvalidHistory = errors.New("invalid history request")
+
+	ErrAttachmentTooLarge = errors.New("file is too large")
+	ErrUnsupportedMedia   = errors.New("file type is not allowed")
+	ErrInfectedAttachment = errors.New("file did not pass the virus scan")
+
+	ErrInvalidSearch      = errors.New("invalid search: user_id and manager_id must be numbers, from/to must be RFC3339")
+	ErrUnknownFormat      = errors.New("unknown transcript format: use html, pdf or text")
+	ErrTranscriptNotFound = errors.New("no messages for this transcript")
+
+	ErrCannedNotFound  = errors.New("canned response not found")
+	ErrCannedForbidden = errors.New("only chat leads can manage shared canned responses")
+	ErrInvalidCanned   = errors.New("invalid canned response")
+
+	ErrInvalidReport = errors.New("invalid report: from/to must be RFC3339 with from before to, period must be day, week or month")
+)
@@ -1,42 +1,78 @@
 package chat
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
-	"mime/multipart"
 	"net/http"
-	"path/filepath"
+	"strconv"
+	"time"
 
 	"github.com/ShopOnGO/ShopOnGO/configs"
+	"github.com/ShopOnGO/ShopOnGO/internal/rbac"
 	"github.com/ShopOnGO/ShopOnGO/pkg/logger"
 	"github.com/ShopOnGO/ShopOnGO/pkg/middleware"
+	"github.com/ShopOnGO/ShopOnGO/pkg/req"
+	"github.com/ShopOnGO/ShopOnGO/pkg/res"
 	"github.com/gorilla/mux"
 )
 
 type ChatHandlerDeps struct {
 	ChatService *ChatService
 	Config      *configs.Config
+	Guard       *middleware.TokenGuard
+	Permissions middleware.PermissionChecker
 }
 
 type ChatHandler struct {
 	service *ChatService
 	config  *configs.Config
+	guard   *middleware.TokenGuard
 }
 
 func NewChatHandler(router *mux.Router, deps ChatHandlerDeps) {
 	h := &ChatHandler{
 		service: deps.ChatService,
 		config:  deps.Config,
+		guard:   deps.Guard,
 	}
 	router.Handle("/ws/chat",http.HandlerFunc(h.HandleWebSocket),
 	).Methods("GET")
 	router.Handle("/api/chat/upload", middleware.IsAuthed(
 		http.HandlerFunc(h.HandleFileUpload),
 		deps.Config,
+		deps.Guard,
 	)).Methods("POST")
+	router.Handle("/api/chat/unread", middleware.IsAuthed(
+		http.HandlerFunc(h.HandleUnread),
+		deps.Config,
+		deps.Guard,
+	)).Methods("GET")
+	router.Handle("/api/chat/history", middleware.IsAuthed(
+		http.HandlerFunc(h.HandleHistory),
+		deps.Config,
+		deps.Guard,
+	)).Methods("GET")
+
+	review := func(next http.HandlerFunc) http.Handler {
+		return middleware.IsAuthed(middleware.RequirePermission(next, rbac.PermChatReview, deps.Permissions), deps.Config, deps.Guard)
+	}
+	router.Handle("/api/chat/search", review(h.HandleSearch)).Methods("GET")
+	router.Handle("/api/chat/transcripts/{user_id}", review(h.HandleTranscript)).Methods("GET")
+
+	manage := func(next http.HandlerFunc) http.Handler {
+		return middleware.IsAuthed(middleware.RequirePermission(next, rbac.PermChatManage, deps.Permissions), deps.Config, deps.Guard)
+	}
+	router.Handle("/api/chat/canned", manage(h.HandleListCanned)).Methods("GET")
+	router.Handle("/api/chat/canned", manage(h.HandleCreateCanned)).Methods("POST")
+	router.Handle("/api/chat/canned/{id}", manage(h.HandleUpdateCanned)).Methods("PUT")
+	router.Handle("/api/chat/canned/{id}", manage(h.HandleDeleteCanned)).Methods("DELETE")
+
+	router.Handle("/admin/chat/report", middleware.IsAuthed(
+		middleware.RequirePermission(http.HandlerFunc(h.HandleReport), rbac.PermChatReport, deps.Permissions),
+		deps.Config,
+		deps.Guard,
+	)).Methods("GET")
 
 }
 
@@ -55,7 +91,11 @@ func (h *ChatHandler) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userID, role, err := middleware.ValidateToken(token, h.config.OAuth.Secret)
+	userID, role, err := middleware.ValidateToken(token, h.config.OAuth.Secret, h.guard)
+	if errors.Is(err, middleware.ErrAccountSuspended) {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
 	if err != nil {
 		logger.Error("WS Auth failed", err)
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
@@ -68,10 +108,33 @@ func (h *ChatHandler) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 
 	h.service.ServeWS(w, r.WithContext(ctx))
 }
+
+// HandleFileUpload uploads a chat attachment.
+// @Summary      Upload chat attachment
+// @Description  Checks the file (size, type sniffed from content, virus scan), uploads it to the media service and returns its URL. Only URLs issued here can be sent as image or file messages, and only by the same user.
+// @Tags         chat
+// @Accept       multipart/form-data
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        file  formData  file  true  "Attachment"
+// @Success      200  {object}  UploadResponse
+// @Failure      400  {string}  string  "Error retrieving the file"
+// @Failure      413  {string}  string  "File is too large"
+// @Failure      415  {string}  string  "File type is not allowed"
+// @Failure      422  {string}  string  "File did not pass the virus scan"
+// @Router       /api/chat/upload [post]
 func (h *ChatHandler) HandleFileUpload(w http.ResponseWriter, r *http.Request) {
-	// 1. Получаем файл от клиента (ограничение 10МБ)
+	userID, _ := r.Context().Value(middleware.ContextUserIDKey).(uint)
+
+	// 1. Получаем файл от клиента; запас сверх предела — на остальные поля формы
+	r.Body = http.MaxBytesReader(w, r.Body, h.config.Media.MaxUploadSize+1<<20)
 	if err := r.ParseMultipartForm(10 << 20); err != nil {
-		http.Error(w, "File too big", http.StatusBadRequest)
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, ErrAttachmentTooLarge.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "Invalid multipart form", http.StatusBadRequest)
 		return
 	}
 
@@ -82,79 +145,369 @@ func (h *ChatHandler) HandleFileUpload(w http.ResponseWriter, r *http.Request) {
 	}
 	defer file.Close()
 
-	// 2. Пересылаем файл в Media Service (он вернет нам JSON с URL)
-	mediaServiceURL := "http://media_container:8084/media-service/uploads"
+	// 2. Проверяем и пересылаем файл в Media Service; тип определяется по содержимому
+	attachment, err := h.service.Upload(r.Context(), userID, file, header.Filename)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	// 3. Отдаем ответ фронтенду чата
+	res.Json(w, UploadResponse{
+		URL:      attachment.URL, // Ссылка, которую вернул S3 через Media Service
+		FileName: attachment.FileName,
+		Type:     attachment.Kind,
+		MimeType: attachment.MimeType,
+		Size:     attachment.Size,
+	}, http.StatusOK)
+}
+
+// HandleUnread returns unread chat message counters.
+// @Summary      Unread chat messages
+// @Description  Returns unread support chat messages for the header badge: replies from support for a customer, messages from served users for a manager.
+// @Tags         chat
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Success      200  {object}  UnreadSummary
+// @Failure      401  {string}  string  "Not authorized"
+// @Router       /api/chat/unread [get]
+func (h *ChatHandler) HandleUnread(w http.ResponseWriter, r *http.Request) {
+	userID, _ := r.Context().Value(middleware.ContextUserIDKey).(uint)
+	role, _ := r.Context().Value(middleware.ContextRolesKey).(string)
 
-	uploadedURL, err := sendFileToMediaService(mediaServiceURL, file, header.Filename)
+	summary, err := h.service.Unread(userID, role)
 	if err != nil {
-		// Логируем ошибку, чтобы видеть в консоли чата, что пошло не так
-		logger.Errorf("Error sending to media service: %v\n", err)
-		http.Error(w, "Failed to upload file", http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
+	res.Json(w, summary, http.StatusOK)
+}
+
+// HandleHistory returns a page of the support chat conversation.
+// @Summary      Chat history
+// @Description  Returns messages in both directions older than `before` (latest if omitted), oldest first. Managers pass `user_id` of a user they are currently serving.
+// @Tags         chat
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        before   query  int  false  "Return messages with ID less than this"
+// @Param        limit    query  int  false  "Page size, default 50, max 100"
+// @Param        user_id  query  int  false  "Conversation user (managers only)"
+// @Success      200  {object}  HistoryPage
+// @Failure      400  {string}  string  "Invalid history request"
+// @Failure      401  {string}  string  "Not authorized"
+// @Failure      403  {string}  string  "No active session with this user"
+// @Router       /api/chat/history [get]
+func (h *ChatHandler) HandleHistory(w http.ResponseWriter, r *http.Request) {
+	userID, _ := r.Context().Value(middleware.ContextUserIDKey).(uint)
+	role, _ := r.Context().Value(middleware.ContextRolesKey).(string)
 
-	// 3. Определяем тип для фронтенда (картинка или файл)
-	msgType := "file"
-	ext := filepath.Ext(header.Filename)
-	if ext == ".jpg" || ext == ".jpeg" || ext == ".png" || ext == ".gif" {
-		msgType = "image"
+	var req HistoryRequest
+	query := r.URL.Query()
+	for name, target := range map[string]*uint{"before": &req.Before, "user_id": &req.UserID} {
+		if raw := query.Get(name); raw != "" {
+			val, err := strconv.ParseUint(raw, 10, 64)
+			if err != nil {
+				http.Error(w, ErrInvalidHistory.Error(), http.StatusBadRequest)
+				return
+			}
+			*target = uint(val)
+		}
+	}
+	if raw := query.Get("limit"); raw != "" {
+		val, err := strconv.Atoi(raw)
+		if err != nil {
+			http.Error(w, ErrInvalidHistory.Error(), http.StatusBadRequest)
+			return
+		}
+		req.Limit = val
+	}
+
+	page, err := h.service.History(userID, role, req)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	res.Json(w, page, http.StatusOK)
+}
+
+// HandleSearch searches support conversations.
+// @Summary      Search chat messages
+// @Description  Full-text search over all support conversations with optional filters by user, manager and period. Newest first, or most relevant first when `q` is set. Every search is written to the audit log. Requires chat:review.
+// @Tags         chat
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        q           query  string  false  "Search query (websearch syntax: words, \"phrase\", -exclude, or)"
+// @Param        user_id     query  int     false  "Conversation user"
+// @Param        manager_id  query  int     false  "Manager who served the session or wrote the message"
+// @Param        from        query  string  false  "Period start, RFC3339"
+// @Param        to          query  string  false  "Period end (exclusive), RFC3339"
+// @Param        limit       query  int     false  "Default 50, max 200"
+// @Param        offset      query  int     false  "Offset"
+// @Success      200  {object}  SearchResponse
+// @Failure      400  {string}  string  "Invalid search"
+// @Failure      403  {string}  string  "Forbidden"
+// @Router       /api/chat/search [get]
+func (h *ChatHandler) HandleSearch(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := SearchFilter{Query: query.Get("q")}
+	filter.Limit, _ = strconv.Atoi(query.Get("limit"))
+	filter.Offset, _ = strconv.Atoi(query.Get("offset"))
+	for name, target := range map[string]*uint{"user_id": &filter.UserID, "manager_id": &filter.ManagerID} {
+		if raw := query.Get(name); raw != "" {
+			val, err := strconv.ParseUint(raw, 10, 64)
+			if err != nil {
+				http.Error(w, ErrInvalidSearch.Error(), http.StatusBadRequest)
+				return
+			}
+			*target = uint(val)
+		}
+	}
+	if err := parsePeriod(r, &filter.From, &filter.To); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	hits, err := h.service.Search(r.Context(), filter)
+	if err != nil {
+		writeError(w, r, err)
+		return
 	}
+	res.Json(w, SearchResponse{Hits: hits}, http.StatusOK)
+}
 
-	// 4. Отдаем ответ фронтенду чата
-	resp := UploadResponse{
-		URL:      uploadedURL, // Ссылка, которую вернул S3 через Media Service
-		FileName: header.Filename,
-		Type:     msgType,
+// HandleTranscript exports a support conversation.
+// @Summary      Export chat transcript
+// @Description  Downloads the conversation with a user as HTML, print-ready HTML for PDF (`pdf`) or plain text. Attachments are included as links. Every export is written to the audit log. Requires chat:review.
+// @Tags         chat
+// @Produce      html
+// @Produce      plain
+// @Security     ApiKeyAuth
+// @Param        user_id     path   int     true   "Conversation user"
+// @Param        format      query  string  false  "html (default), pdf or text"
+// @Param        session_id  query  int     false  "Only this support session"
+// @Param        from        query  string  false  "Period start, RFC3339"
+// @Param        to          query  string  false  "Period end (exclusive), RFC3339"
+// @Success      200  {file}    file
+// @Failure      400  {string}  string  "Invalid filter or unknown format"
+// @Failure      403  {string}  string  "Forbidden"
+// @Failure      404  {string}  string  "No messages for this transcript"
+// @Router       /api/chat/transcripts/{user_id} [get]
+func (h *ChatHandler) HandleTranscript(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.ParseUint(mux.Vars(r)["user_id"], 10, 64)
+	if err != nil {
+		http.Error(w, ErrInvalidSearch.Error(), http.StatusBadRequest)
+		return
+	}
+	query := r.URL.Query()
+	var filter TranscriptFilter
+	if raw := query.Get("session_id"); raw != "" {
+		val, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			http.Error(w, ErrInvalidSearch.Error(), http.StatusBadRequest)
+			return
+		}
+		filter.SessionID = uint(val)
+	}
+	if err := parsePeriod(r, &filter.From, &filter.To); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	format := query.Get("format")
+	if format == "" {
+		format = TranscriptHTML
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(resp)
+	transcript, err := h.service.Transcript(r.Context(), uint(userID), filter, format)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", transcript.ContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, transcript.FileName))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(transcript.Body)
 }
 
-// Вспомогательная функция для проксирования файла
-func sendFileToMediaService(targetURL string, file multipart.File, filename string) (string, error) {
-	// Создаем буфер для тела запроса
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
+// HandleListCanned lists canned responses available to the manager.
+// @Summary      List canned responses
+// @Description  Returns the manager's personal canned responses and all shared ones. Requires chat:manage.
+// @Tags         chat
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Success      200  {object}  CannedResponsesResponse
+// @Failure      403  {string}  string  "Forbidden"
+// @Router       /api/chat/canned [get]
+func (h *ChatHandler) HandleListCanned(w http.ResponseWriter, r *http.Request) {
+	userID, _ := r.Context().Value(middleware.ContextUserIDKey).(uint)
 
-	// Создаем поле "file" внутри формы
-	part, err := writer.CreateFormFile("file", filename)
+	responses, err := h.service.ListCanned(userID)
 	if err != nil {
-		return "", err
+		writeError(w, r, err)
+		return
 	}
+	res.Json(w, CannedResponsesResponse{Responses: responses}, http.StatusOK)
+}
+
+// HandleCreateCanned creates a canned response.
+// @Summary      Create canned response
+// @Description  Body may use {{user.Name}} and {{user.Email}}; they are filled in when the response is sent with the `macro` command. Shared responses can be created only with chat:review. Requires chat:manage.
+// @Tags         chat
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        body  body  CannedResponseRequest  true  "Canned response"
+// @Success      201  {object}  CannedResponse
+// @Failure      400  {string}  string  "Unknown placeholder"
+// @Failure      403  {string}  string  "Forbidden"
+// @Router       /api/chat/canned [post]
+func (h *ChatHandler) HandleCreateCanned(w http.ResponseWriter, r *http.Request) {
+	userID, _ := r.Context().Value(middleware.ContextUserIDKey).(uint)
+	role, _ := r.Context().Value(middleware.ContextRolesKey).(string)
 
-	// Копируем содержимое реального файла в форму
-	if _, err := io.Copy(part, file); err != nil {
-		return "", err
+	body, err := req.HandleBody[CannedResponseRequest](&w, r)
+	if err != nil {
+		return
+	}
+	response, err := h.service.CreateCanned(userID, role, *body)
+	if err != nil {
+		writeError(w, r, err)
+		return
 	}
+	res.Json(w, response, http.StatusCreated)
+}
 
-	writer.Close()
+// HandleUpdateCanned updates a canned response.
+// @Summary      Update canned response
+// @Description  Managers edit their personal responses; shared ones require chat:review. Requires chat:manage.
+// @Tags         chat
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id    path  int                    true  "Canned response ID"
+// @Param        body  body  CannedResponseRequest  true  "Canned response"
+// @Success      200  {object}  CannedResponse
+// @Failure      400  {string}  string  "Unknown placeholder"
+// @Failure      403  {string}  string  "Forbidden"
+// @Failure      404  {string}  string  "Canned response not found"
+// @Router       /api/chat/canned/{id} [put]
+func (h *ChatHandler) HandleUpdateCanned(w http.ResponseWriter, r *http.Request) {
+	userID, _ := r.Context().Value(middleware.ContextUserIDKey).(uint)
+	role, _ := r.Context().Value(middleware.ContextRolesKey).(string)
 
-	req, err := http.NewRequest("POST", targetURL, body)
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
 	if err != nil {
-		return "", err
+		http.Error(w, ErrCannedNotFound.Error(), http.StatusNotFound)
+		return
+	}
+	body, err := req.HandleBody[CannedResponseRequest](&w, r)
+	if err != nil {
+		return
 	}
-	req.Header.Set("Content-Type", writer.FormDataContentType())
+	response, err := h.service.UpdateCanned(userID, role, uint(id), *body)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	res.Json(w, response, http.StatusOK)
+}
+
+// HandleDeleteCanned deletes a canned response.
+// @Summary      Delete canned response
+// @Description  Managers delete their personal responses; shared ones require chat:review. Requires chat:manage.
+// @Tags         chat
+// @Security     ApiKeyAuth
+// @Param        id  path  int  true  "Canned response ID"
+// @Success      204
+// @Failure      403  {string}  string  "Forbidden"
+// @Failure      404  {string}  string  "Canned response not found"
+// @Router       /api/chat/canned/{id} [delete]
+func (h *ChatHandler) HandleDeleteCanned(w http.ResponseWriter, r *http.Request) {
+	userID, _ := r.Context().Value(middleware.ContextUserIDKey).(uint)
+	role, _ := r.Context().Value(middleware.ContextRolesKey).(string)
 
-	// Отправляем
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
 	if err != nil {
-		return "", err
+		http.Error(w, ErrCannedNotFound.Error(), http.StatusNotFound)
+		return
+	}
+	if err := h.service.DeleteCanned(userID, role, uint(id)); err != nil {
+		writeError(w, r, err)
+		return
 	}
-	defer resp.Body.Close()
+	w.WriteHeader(http.StatusNoContent)
+}
 
-	if resp.StatusCode != 200 {
-		return "", fmt.Errorf("media service returned status: %d", resp.StatusCode)
+// HandleReport returns support quality metrics per manager.
+// @Summary      Chat quality report
+// @Description  Aggregates sessions closed in [from, to) per manager and per day, week or month: number of sessions and ratings, average score, CSAT (share of 4 and 5 ratings, %), average first response and resolution time in seconds. Times are counted from the user's first message in the session. Requires chat:report.
+// @Tags         admin
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        from    query  string  false  "Period start, RFC3339 (default: 30 days before to)"
+// @Param        to      query  string  false  "Period end (exclusive), RFC3339 (default: now)"
+// @Param        period  query  string  false  "day, week (default) or month"
+// @Success      200  {object}  ReportResponse
+// @Failure      400  {string}  string  "Invalid report"
+// @Failure      403  {string}  string  "Forbidden"
+// @Router       /admin/chat/report [get]
+func (h *ChatHandler) HandleReport(w http.ResponseWriter, r *http.Request) {
+	var from, to *time.Time
+	if err := parsePeriod(r, &from, &to); err != nil {
+		http.Error(w, ErrInvalidReport.Error(), http.StatusBadRequest)
+		return
+	}
+	filter := ReportFilter{Period: r.URL.Query().Get("period")}
+	if from != nil {
+		filter.From = *from
+	}
+	if to != nil {
+		filter.To = *to
 	}
 
-	// Читаем JSON ответ от Media Service: {"url": "..."}
-	var result struct {
-		URL string `json:"url"`
+	report, err := h.service.Report(filter)
+	if err != nil {
+		writeError(w, r, err)
+		return
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return "", err
+	res.Json(w, report, http.StatusOK)
+}
+
+// parsePeriod читает from и to (RFC3339) из query.
+func parsePeriod(r *http.Request, from, to **time.Time) error {
+	for name, dst := range map[string]**time.Time{"from": from, "to": to} {
+		raw := r.URL.Query().Get(name)
+		if raw == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return ErrInvalidSearch
+		}
+		*dst = &t
 	}
+	return nil
+}
 
-	return result.URL, nil
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, ErrInvalidHistory), errors.Is(err, ErrInvalidSearch), errors.Is(err, ErrUnknownFormat):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, ErrTranscriptNotFound), errors.Is(err, ErrCannedNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, ErrCannedForbidden):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, ErrInvalidCanned), errors.Is(err, ErrInvalidReport):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, ErrNotServing):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, ErrAttachmentTooLarge):
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+	case errors.Is(err, ErrUnsupportedMedia):
+		http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+	case errors.Is(err, ErrInfectedAttachment):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	default:
+		logger.FromContext(r.Context()).Errorw("❌ chat error", logger.Err(err))
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
 }
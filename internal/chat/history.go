@@ -0,0 +1,68 @@
+package chat
+
+import (
+	"errors"
+
+	"github.com/ShopOnGO/ShopOnGO/pkg/logger"
+)
+
+// history возвращает страницу переписки. Пользователь видит только свою переписку,
+// менеджер — переписку пользователя, которого он сейчас ведет.
+func (h *Hub) history(requesterID uint, isManager bool, req HistoryRequest) (*HistoryPage, error) {
+	if req.Limit < 0 {
+		return nil, ErrInvalidHistory
+	}
+	limit := req.Limit
+	if limit == 0 {
+		limit = historyLimit
+	}
+	if limit > maxHistoryLimit {
+		limit = maxHistoryLimit
+	}
+
+	userID := requesterID
+	if isManager {
+		if req.UserID == 0 {
+			return nil, ErrInvalidHistory
+		}
+		session, err := h.sessions.Get(req.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if session == nil || session.ManagerID != requesterID {
+			return nil, ErrNotServing
+		}
+		userID = req.UserID
+	}
+
+	// Берем на одно больше, чтобы понять, есть ли еще страницы
+	messages, err := h.ChatRepository.GetMessagesBefore(userID, req.Before, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	page := &HistoryPage{UserID: userID, Messages: messages}
+	if len(messages) > limit {
+		page.Messages = messages[len(messages)-limit:]
+		page.HasMore = true
+	}
+	if page.Messages == nil {
+		page.Messages = []*Message{}
+	}
+	return page, nil
+}
+
+// sendHistoryPage отвечает на кадр history.
+func (h *Hub) sendHistoryPage(req request, input HistoryRequest) {
+	page, err := h.history(req.client.ID, req.client.IsManager, input)
+	switch {
+	case errors.Is(err, ErrNotServing):
+		req.fail(ErrCodeNotFound, "No active session with this user")
+	case errors.Is(err, ErrInvalidHistory):
+		req.fail(ErrCodeBadRequest, "Invalid history request")
+	case err != nil:
+		logger.Error("Chat history load failed", err)
+		req.fail(ErrCodeUnavailable, "Chat is temporarily unavailable")
+	default:
+		req.reply(FrameHistory, page)
+	}
+}
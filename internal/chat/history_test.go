@@ -0,0 +1,55 @@
+package chat
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistoryPagination(t *testing.T) {
+	c := newCluster(t, 2)
+
+	user := c.dial(t, 0, 1, "buyer")
+	manager := c.dial(t, 1, 100, "manager")
+	send(t, user, ReqMessage, IncomingUserMessage{Content: "question 1"})
+	expect(t, user, "question 1")
+	send(t, manager, ReqTake, ManagerCommand{UserID: 1})
+	expect(t, manager, "started with user 1")
+	for i := 2; i <= 5; i++ {
+		send(t, manager, ReqMessage, ManagerMessage{UserID: 1, Content: fmt.Sprintf("answer %d", i)})
+		expect(t, user, fmt.Sprintf("answer %d", i))
+	}
+
+	// Последняя страница: ответы менеджера тоже в истории
+	send(t, user, ReqHistory, HistoryRequest{Limit: 2})
+	page := expect(t, user, `"type":"history"`)
+	assert.Contains(t, page, `"content":"answer 4"`)
+	assert.Contains(t, page, `"content":"answer 5"`)
+	assert.Contains(t, page, `"has_more":true`)
+
+	// Следующая страница — старше первого полученного сообщения
+	send(t, manager, ReqHistory, HistoryRequest{UserID: 1, Before: 4, Limit: 10})
+	page = expect(t, manager, `"type":"history"`)
+	assert.Contains(t, page, `"content":"question 1"`)
+	assert.Contains(t, page, `"content":"answer 3"`)
+	assert.NotContains(t, page, "answer 4")
+	assert.Contains(t, page, `"has_more":false`)
+
+	// REST отдает то же самое
+	rest, err := c.services[0].History(1, "buyer", HistoryRequest{Before: 3})
+	require.NoError(t, err)
+	require.Len(t, rest.Messages, 2)
+	assert.Equal(t, uint(1), rest.Messages[0].ID)
+	assert.False(t, rest.HasMore)
+
+	// Чужая переписка недоступна
+	other := c.dial(t, 0, 200, "manager")
+	send(t, other, ReqHistory, HistoryRequest{UserID: 1})
+	expect(t, other, "No active session with this user")
+	_, err = c.services[0].History(200, "manager", HistoryRequest{UserID: 1})
+	assert.ErrorIs(t, err, ErrNotServing)
+	_, err = c.services[0].History(1, "buyer", HistoryRequest{Limit: -1})
+	assert.ErrorIs(t, err, ErrInvalidHistory)
+}
@@ -1,57 +1,170 @@
 package chat
 
 import (
-	"encoding/json"
 	"fmt"
+	"strconv"
+	"strings"
 	"sync"
-	"sync/atomic"
 	"time"
 
+	"github.com/ShopOnGO/ShopOnGO/configs"
 	"github.com/ShopOnGO/ShopOnGO/pkg/logger"
 )
 
-var sessionIDCounter uint64
-
-type Session struct {
-	ID          uint // Уникальный ID сессии\
-	UserID      uint
-	UserClients map[*Client]bool
-	Manager     *Client
-	History     []*Message
-	mu          sync.RWMutex
+// historyLimit — сколько последних сообщений получает клиент при подключении;
+// столько же по умолчанию в странице истории.
+const historyLimit = 50
+
+// maxHistoryLimit — предел страницы истории.
+const maxHistoryLimit = 100
+
+// Repository — хранилище сообщений и сессий чата.
+type Repository interface {
+	SaveMessage(message *Message) error
+	GetLastMessages(userID uint, limit int) ([]*Message, error)
+	GetMessagesBefore(userID uint, beforeMsgID uint, limit int) ([]*Message, error)
+	FindOpenSession(userID uint) (*ChatSession, error)
+	HasClosedSession(userID uint) (bool, error)
+	CreateSession(session *ChatSession) error
+	UpdateSession(id uint, fields map[string]interface{}) error
+	OpenSessions() ([]ChatSession, error)
+	FindMessageByClientID(fromID uint, clientID string) (*Message, error)
+	CreateAttachment(attachment *Attachment) error
+	FindAttachment(ownerID uint, url string) (*Attachment, error)
+	MarkDelivered(userID uint, fromUser bool, upTo uint, at time.Time) error
+	MarkRead(userID uint, fromUser bool, upTo uint, at time.Time) error
+	UnreadForUser(userID uint) (int64, error)
+	UnreadForManager(managerID uint) ([]UnreadCount, error)
+	ListCanned(ownerID uint) ([]CannedResponse, error)
+	FindCanned(id uint) (*CannedResponse, error)
+	CreateCanned(response *CannedResponse) error
+	UpdateCanned(response *CannedResponse) error
+	DeleteCanned(id uint) error
+	FindSession(id uint) (*ChatSession, error)
+	CreateRating(rating *ChatRating) (bool, error)
+	ManagerReport(filter ReportFilter) ([]ManagerReport, error)
+	SearchMessages(filter SearchFilter) ([]SearchHit, error)
+	TranscriptMessages(userID uint, filter TranscriptFilter) ([]*Message, error)
 }
 
+// Hub обслуживает соединения одной реплики. Сессии, очередь ожидания и счетчики
+// соединений лежат в Redis (RedisSessionStore), а сообщения расходятся по репликам
+// через pub/sub (RedisBroker), поэтому пользователь и его менеджер могут быть
+// подключены к разным подам. Сессии сохраняются в chat_sessions и переживают
+// рестарт, см. Restore.
 type Hub struct {
-	clients         map[*Client]bool    // no mutex
-	sessions        map[uint]*Session   // mutex // по id пользователя
-	managerSessions map[uint][]*Session // по id managera
-	waitingUsers    map[uint]bool       // вместо map[uint]*Session
-	register        chan *Client        // mutex
-	unregister      chan *Client        // mutex
-	ChatRepository  *ChatRepository     // Репозиторий для работы с сообщениями
-	mu              sync.RWMutex
-} // идеал был переписать на sync.map чтобы не забывать мьютексы
-
-func NewHub(chatRepository *ChatRepository) *Hub {
+	users          map[uint]map[*Client]bool // локальные соединения пользователей
+	managers       map[uint]map[*Client]bool // локальные соединения менеджеров
+	register       chan *Client
+	unregister     chan *Client // каналы потокобезопасны!!!
+	ChatRepository Repository
+	sessions       *RedisSessionStore
+	broker         *RedisBroker
+	routing        configs.ChatConfig
+	directory      UserFinder   // данные пользователей для макросов
+	mu             sync.RWMutex // защищает только локальные карты
+	dispatchMu     sync.Mutex   // одна раздача очереди за раз на реплике
+}
+
+func NewHub(chatRepository Repository, sessions *RedisSessionStore, broker *RedisBroker, routing configs.ChatConfig, directory UserFinder) *Hub {
 	return &Hub{
-		clients:         make(map[*Client]bool),
-		sessions:        make(map[uint]*Session), // id пользователей
-		managerSessions: make(map[uint][]*Session),
-		waitingUsers:    make(map[uint]bool),
-		register:        make(chan *Client, 100),
-		unregister:      make(chan *Client, 100), // каналы потокобезопасны!!!
-		ChatRepository:  chatRepository,
+		users:          make(map[uint]map[*Client]bool),
+		managers:       make(map[uint]map[*Client]bool),
+		register:       make(chan *Client, 100),
+		unregister:     make(chan *Client, 100),
+		ChatRepository: chatRepository,
+		sessions:       sessions,
+		broker:         broker,
+		routing:        routing,
+		directory:      directory,
+	}
+}
+
+// Restore восстанавливает в Redis незакрытые сессии из базы: очередь ожидания
+// и назначения менеджеров. Безопасно вызывать с нескольких реплик одновременно.
+// Соединения этой реплики, учтенные до рестарта, сбрасываются: их уже нет.
+func (h *Hub) Restore() error {
+	if err := h.sessions.ResetPresence(); err != nil {
+		logger.Error("Chat presence reset failed", err)
+	}
+	records, err := h.ChatRepository.OpenSessions()
+	if err != nil {
+		return err
+	}
+	for i := range records {
+		record := &records[i]
+		if _, err := h.sessions.Restore(record); err != nil {
+			return err
+		}
+		if record.Status != SessionWaiting {
+			continue
+		}
+		since := record.UpdatedAt
+		if record.WaitingSince != nil {
+			since = *record.WaitingSince
+		}
+		if _, err := h.sessions.Enqueue(record.UserID, since, record.Priority); err != nil {
+			return err
+		}
+	}
+	logger.Infof("💬 Chat: restored %d open sessions", len(records))
+	return nil
+}
+
+// openSession возвращает текущую сессию пользователя: из Redis, из базы или новую.
+func (h *Hub) openSession(c *Client) (*Session, error) {
+	userID := c.ID
+	if session, err := h.sessions.Get(userID); err != nil || session != nil {
+		return session, err
+	}
+
+	record, err := h.ChatRepository.FindOpenSession(userID)
+	if err != nil {
+		return nil, err
+	}
+	if record == nil {
+		record = &ChatSession{UserID: userID, Status: SessionOpen, OpenedAt: time.Now(), Priority: h.priorityFor(c)}
+		if err := h.ChatRepository.CreateSession(record); err != nil {
+			// Другая реплика успела создать сессию (уникальный индекс по открытой сессии)
+			if record, err = h.ChatRepository.FindOpenSession(userID); err != nil || record == nil {
+				return nil, fmt.Errorf("create chat session: %w", err)
+			}
+		}
+	}
+	return h.sessions.Restore(record)
+}
+
+// updateSession сохраняет переход сессии в базе. Ошибка не прерывает чат:
+// рабочее состояние уже в Redis.
+func (h *Hub) updateSession(sessionID uint, fields map[string]interface{}) {
+	if err := h.ChatRepository.UpdateSession(sessionID, fields); err != nil {
+		logger.Errorf("❌ Chat: failed to update session %d: %v", sessionID, err)
 	}
 }
 
 func (h *Hub) Run() {
+	if err := h.broker.Subscribe(controlChannel); err != nil {
+		logger.Errorf("❌ Chat: failed to subscribe to %s: %v", controlChannel, err)
+	}
+	go h.broker.Listen(h.deliver)
+
+	// Страховка: события других реплик могли не вызвать раздачу на этой
+	ticker := time.NewTicker(dispatchInterval)
+	defer ticker.Stop()
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
 
 	for {
 		select {
+		case <-ticker.C:
+			h.dispatch()
+
+		case <-heartbeat.C:
+			if err := h.sessions.Heartbeat(); err != nil {
+				logger.Error("Chat presence heartbeat failed", err)
+			}
+
 		case client := <-h.register:
-			h.mu.Lock()
-			h.clients[client] = true
-			h.mu.Unlock()
 			h.handleNewClient(client)
 
 		case client := <-h.unregister:
@@ -60,451 +173,597 @@ func (h *Hub) Run() {
 	}
 }
 
-func (h *Hub) handleNewClient(c *Client) {
-	h.mu.Lock()
-	defer h.mu.Unlock()
+// local возвращает карту локальных соединений для роли клиента.
+func (h *Hub) local(isManager bool) map[uint]map[*Client]bool {
+	if isManager {
+		return h.managers
+	}
+	return h.users
+}
 
+// channelFor — канал, на который подписана реплика, пока у нее есть соединения клиента.
+func channelFor(c *Client) string {
 	if c.IsManager {
-		// Менеджер сам выберет кого взять
-		return
+		return managerChannel(c.ID)
 	}
+	return sessionChannel(c.ID)
+}
 
-	// Ищем сессию по ID пользователя
-	session, exists := h.sessions[c.ID]
-	if !exists {
-		// Сессии нет - создаем новую
-		session = &Session{
-			ID:          generateUniqueID(),
-			UserID:      c.ID,
-			UserClients: make(map[*Client]bool),
-			History:     []*Message{},
-		}
-		session.UserClients[c] = true
-		h.sessions[c.ID] = session
+func (h *Hub) handleNewClient(c *Client) {
+	h.mu.Lock()
+	clients := h.local(c.IsManager)
+	first := len(clients[c.ID]) == 0
+	if first {
+		clients[c.ID] = make(map[*Client]bool)
+	}
+	clients[c.ID][c] = true
+	h.mu.Unlock()
 
-		// Загружаем историю сообщений для новой сессии
-		err := h.LoadLastMessages(c.ID, session, 50)
-		if err != nil {
-			logger.Error("LoadLastMessages failed", err)
+	if first {
+		if err := h.broker.Subscribe(channelFor(c)); err != nil {
+			logger.Error("Chat subscribe failed", err)
 		}
+	}
+	if _, err := h.sessions.Connect(c.ID, c.IsManager); err != nil {
+		logger.Error("Chat connection count failed", err)
+	}
 
-	} else {
-		// Сессия уже существует - просто добавляем нового клиента в пул
-		session.mu.Lock()
-		session.UserClients[c] = true
-		session.mu.Unlock()
+	if c.IsManager {
+		h.sendUnread(c)
+		// Подключился менеджер — раздаем ему очередь; брать вручную он тоже может
+		h.dispatch()
+		return
+	}
+
+	// Сессия переживает переподключения и рестарты
+	if _, err := h.openSession(c); err != nil {
+		logger.Error("Chat session open failed", err)
+		h.sendError(c, ErrCodeUnavailable, "Chat is temporarily unavailable")
+		return
 	}
-	sendHistoryToClient(c, session.History)
+	h.sendHistory(c, c.ID)
+	h.sendUnread(c)
 }
 
 func (h *Hub) removeClient(c *Client) {
 	h.mu.Lock()
-	defer h.mu.Unlock()
-
-	if _, ok := h.clients[c]; !ok {
+	clients := h.local(c.IsManager)
+	if !clients[c.ID][c] {
+		h.mu.Unlock()
 		return // Клиент уже удалён
 	}
-	delete(h.clients, c) // Удаляем клиента из общего списка
+	delete(clients[c.ID], c)
+	last := len(clients[c.ID]) == 0
+	if last {
+		delete(clients, c.ID)
+	}
+	h.mu.Unlock()
+
+	if last {
+		if err := h.broker.Unsubscribe(channelFor(c)); err != nil {
+			logger.Error("Chat unsubscribe failed", err)
+		}
+	}
+
+	remaining, err := h.sessions.Disconnect(c.ID, c.IsManager)
+	if err != nil {
+		logger.Error("Chat connection count failed", err)
+		return
+	}
+	if remaining > 0 {
+		// На этой или другой реплике еще есть соединения
+		return
+	}
 
 	if c.IsManager {
-		// Обработка отключения менеджера
-		if sessions, ok := h.managerSessions[c.ID]; ok {
-			for _, session := range sessions {
-				if session.UserID != 0 {
-					// Возвращаем пользователя в очередь ожидания
-					h.waitingUsers[session.UserID] = true
-
-					// Отправляем уведомление пользователю
-					for client := range session.UserClients {
-						h.sendSuccess(client, "Manager disconnected", nil) // ЖДИТЕ НООВОГО МЕНЕДЖЕРА ТИПА
-					}
-
-				}
-			}
-			delete(h.managerSessions, c.ID) // Удаляем все сессии менеджера
+		// Менеджер ушел совсем: пользователи возвращаются в очередь ожидания
+		released, err := h.sessions.ReleaseAll(c.ID)
+		if err != nil {
+			logger.Error("Chat release sessions failed", err)
 		}
-	} else {
-		// Логика отключения пользователя
-		if session, ok := h.sessions[c.ID]; ok {
-			session.mu.Lock()
-			// Удаляем конкретное соединение из пула сессии
-			delete(session.UserClients, c)
-			session.mu.Unlock()
-
-			// Если у пользователя не осталось активных соединений,
-			// уведомляем менеджера.
-			session.mu.RLock()
-			shouldNotifyManager := len(session.UserClients) == 0 && session.Manager != nil
-			session.mu.RUnlock()
-			if shouldNotifyManager {
-				payload, _ := json.Marshal(map[string]interface{}{
-					"event":   "user_disconnected_all", // Новый, более точный event
-					"user_id": c.ID,
-					"message": "User has closed all connections.",
-				})
-				safeSend(session.Manager, payload)
-			}
-			// ВАЖНО: саму сессию (h.sessions[c.ID]) мы не удаляем.
-			// Она хранит историю чата и может быть возобновлена.
+		for _, session := range released {
+			h.updateSession(session.ID, map[string]interface{}{
+				"status":        SessionWaiting,
+				"manager_id":    0,
+				"waiting_since": session.QueuedAt,
+			})
+			h.publish(sessionChannel(session.UserID), response("success", "Manager disconnected", nil)) // ЖДИТЕ НОВОГО МЕНЕДЖЕРА
 		}
+		// Пользователи вернулись в очередь на прежнее место — раздаем их другим
+		h.dispatch()
+		return
+	}
+
+	// У пользователя не осталось активных соединений — уведомляем менеджера.
+	// Саму сессию не удаляем: она может быть возобновлена.
+	session, err := h.sessions.Get(c.ID)
+	if err != nil {
+		logger.Error("Chat session lookup failed", err)
+		return
+	}
+	if session != nil && session.ManagerID != 0 {
+		h.publish(managerChannel(session.ManagerID), response("success", "User has closed all connections.", map[string]interface{}{
+			"event":   "user_disconnected_all",
+			"user_id": c.ID,
+		}))
 	}
 }
 
+// routeMessage разбирает кадр клиента и передает его обработчику по типу.
 func (h *Hub) routeMessage(sender *Client, message []byte) {
+	var in inbound
+	if err := decodeStrict(message, &in); err != nil || in.Type == "" {
+		h.sendError(sender, ErrCodeBadRequest, "Invalid frame: expected {type, id, payload}")
+		return
+	}
+	req := request{client: sender, id: in.ID, payload: in.Payload}
+	if len(in.ID) > maxClientIDLength {
+		req.id = ""
+		req.fail(ErrCodeBadRequest, fmt.Sprintf("id is longer than %d characters", maxClientIDLength))
+		return
+	}
+	// По id клиент отличает повтор от нового сообщения
+	if (in.Type == ReqMessage || in.Type == ReqMacro) && in.ID == "" {
+		req.fail(ErrCodeBadRequest, "id is required for messages")
+		return
+	}
+
 	if sender.IsManager {
-		h.handleManagerMessage(sender, message) // TODO НОРМ???????????????????????????77
+		h.handleManagerFrame(req, in.Type)
 	} else {
-		h.handleUserMessage(sender, message)
+		h.handleUserFrame(req, in.Type)
 	}
 }
 
-func (h *Hub) handleUserMessage(user *Client, message []byte) {
-	h.mu.Lock()
-	defer h.mu.Unlock()
+func (h *Hub) handleUserFrame(req request, frameType string) {
+	switch frameType {
+	case ReqMessage:
+		var input IncomingUserMessage
+		if !req.bind(&input) {
+			return
+		}
+		if err := input.validate(); err != nil {
+			req.fail(ErrCodeBadRequest, err.Error())
+			return
+		}
+		h.handleUserMessage(req, input)
+	case ReqTyping, ReqDelivered, ReqRead:
+		var ev ClientEvent
+		if !req.bind(&ev) {
+			return
+		}
+		if err := ev.validate(frameType, false); err != nil {
+			req.fail(ErrCodeBadRequest, err.Error())
+			return
+		}
+		h.handleUserEvent(req, frameType, ev)
+	case ReqHistory:
+		var input HistoryRequest
+		if req.bind(&input) {
+			h.sendHistoryPage(req, input)
+		}
+	case ReqRate:
+		var input RatingInput
+		if !req.bind(&input) {
+			return
+		}
+		if err := input.validate(); err != nil {
+			req.fail(ErrCodeBadRequest, err.Error())
+			return
+		}
+		h.rateSession(req, input)
+	case ReqTake, ReqList, ReqClose, ReqSkills, ReqMacro:
+		req.fail(ErrCodeForbidden, "Only managers can use this command")
+	default:
+		req.fail(ErrCodeUnknownType, fmt.Sprintf("Unknown frame type %q", frameType))
+	}
+}
 
-	session, ok := h.sessions[user.ID]
+func (h *Hub) handleManagerFrame(req request, frameType string) {
+	switch frameType {
+	case ReqMessage:
+		var msg ManagerMessage
+		if !req.bind(&msg) {
+			return
+		}
+		if err := msg.validate(); err != nil {
+			req.fail(ErrCodeBadRequest, err.Error())
+			return
+		}
+		h.handleManagerTextMessage(req, msg)
+	case ReqMacro:
+		var cmd MacroCommand
+		if !req.bind(&cmd) {
+			return
+		}
+		if err := cmd.validate(); err != nil {
+			req.fail(ErrCodeBadRequest, err.Error())
+			return
+		}
+		h.handleMacro(req, cmd)
+	case ReqTyping, ReqDelivered, ReqRead:
+		var ev ClientEvent
+		if !req.bind(&ev) {
+			return
+		}
+		if err := ev.validate(frameType, true); err != nil {
+			req.fail(ErrCodeBadRequest, err.Error())
+			return
+		}
+		h.handleManagerEvent(req, frameType, ev)
+	case ReqHistory:
+		var input HistoryRequest
+		if req.bind(&input) {
+			h.sendHistoryPage(req, input)
+		}
+	case ReqList:
+		if req.bind(&struct{}{}) {
+			h.listWaitingUsers(req)
+		}
+	case ReqTake, ReqClose, ReqSkills:
+		var cmd ManagerCommand
+		if !req.bind(&cmd) {
+			return
+		}
+		if err := cmd.validate(frameType); err != nil {
+			req.fail(ErrCodeBadRequest, err.Error())
+			return
+		}
+		switch frameType {
+		case ReqTake:
+			h.assignManagerToUser(req, cmd.UserID)
+		case ReqClose:
+			h.closeSession(req, cmd.UserID)
+		case ReqSkills:
+			h.setSkills(req, cmd.Tags)
+		}
+	case ReqRate:
+		req.fail(ErrCodeForbidden, "Only customers can rate sessions")
+	default:
+		req.fail(ErrCodeUnknownType, fmt.Sprintf("Unknown frame type %q", frameType))
+	}
+}
+
+// ackDuplicate подтверждает повтор уже сохраненного сообщения (клиент не дождался
+// ack и отправил кадр снова), не рассылая его второй раз.
+func (h *Hub) ackDuplicate(req request) bool {
+	existing, err := h.ChatRepository.FindMessageByClientID(req.client.ID, req.id)
+	if err != nil {
+		logger.Error("Chat duplicate lookup failed", err)
+		return false
+	}
+	if existing == nil {
+		return false
+	}
+	req.ack(Ack{MessageID: existing.ID, Duplicate: true})
+	return true
+}
+
+func (h *Hub) handleUserMessage(req request, input IncomingUserMessage) {
+	user := req.client
+	if h.ackDuplicate(req) {
+		return
+	}
+	attachment, ok := h.checkAttachment(req, input.Type, input.Content)
 	if !ok {
-		logger.Warn("Session not found", map[string]interface{}{"user_id": user.ID})
 		return
 	}
-
-	var input IncomingUserMessage
-	if err := json.Unmarshal(message, &input); err != nil {
-		input = IncomingUserMessage{
-			Content: string(message),
-			Type:    MsgTypeText,
-		}
+	if attachment != nil {
+		input.Type, input.FileName = attachment.Kind, attachment.FileName
 	}
 
-	if input.Type == "" {
-		input.Type = MsgTypeText
+	// После закрытия прошлой сессии первое сообщение открывает новую
+	session, err := h.openSession(user)
+	if err != nil {
+		logger.Error("Chat session open failed", err)
+		req.fail(ErrCodeUnavailable, "Chat is temporarily unavailable")
+		return
 	}
 
-	// 1. Создаем структуру (добавляем время!)
 	msg := &Message{
+		SessionID: session.ID,
 		FromID:    user.ID,
-		ToID:      0,
+		ToID:      session.ManagerID,
 		Content:   input.Content,
 		Type:      input.Type,
 		FileName:  input.FileName,
-		CreatedAt: time.Now(), // <--- ФИКС ВРЕМЕНИ
+		ClientID:  req.id,
+		CreatedAt: time.Now(),
 	}
 
-	// Логика определения получателя
-	if session.Manager != nil {
-		msg.ToID = session.Manager.ID
-	} else {
-		if _, exists := h.waitingUsers[user.ID]; !exists {
-			h.waitingUsers[user.ID] = true
+	// Пока менеджера нет, пользователь ждет в очереди
+	queued := false
+	if session.ManagerID == 0 {
+		if topic := normalizeTag(input.Topic); topic != "" && topic != session.Topic {
+			if err := h.sessions.SetTopic(user.ID, topic); err != nil {
+				logger.Error("Chat topic update failed", err)
+			}
+			h.updateSession(session.ID, map[string]interface{}{"topic": topic})
+		}
+		now := time.Now()
+		queued, err = h.sessions.Enqueue(user.ID, now, session.Priority)
+		if err != nil {
+			logger.Error("Chat enqueue failed", err)
+		}
+		if queued {
+			h.updateSession(session.ID, map[string]interface{}{"status": SessionWaiting, "waiting_since": now})
 		}
 	}
 
-	// 2. СНАЧАЛА СОХРАНЯЕМ (чтобы получить ID из базы)
-	h.saveMessageAndAppendToHistory(session, msg)
-
-	// 3. ТОЛЬКО ТЕПЕРЬ МАРШАЛИМ (теперь внутри msg есть реальный ID)
-	data, err := json.Marshal(msg)
-	if err != nil {
-		logger.Error("failed to marshal message:", err)
+	// СНАЧАЛА СОХРАНЯЕМ (чтобы получить ID из базы), потом рассылаем
+	if err := h.ChatRepository.SaveMessage(msg); err != nil {
+		// Тот же кадр мог одновременно прийти на другую реплику
+		if h.ackDuplicate(req) {
+			return
+		}
+		logger.Error("failed to save message:", err)
+		req.fail(ErrCodeUnavailable, "Chat is temporarily unavailable")
 		return
 	}
+	data := frame(FrameMessage, msg)
 
-	// 4. Отправляем
-	if session.Manager != nil {
-		safeSend(session.Manager, data)
+	// Всем устройствам пользователя (в том числе отправителю) и менеджеру
+	h.publish(sessionChannel(user.ID), data)
+	if session.ManagerID != 0 {
+		h.publish(managerChannel(session.ManagerID), data)
+		h.publishManagerUnread(session.ManagerID)
 	}
-
-	session.mu.RLock()
-	for client := range session.UserClients {
-		safeSend(client, data)
+	req.ack(Ack{MessageID: msg.ID})
+	if queued {
+		h.dispatch()
 	}
-	session.mu.RUnlock()
 }
 
-func (h *Hub) handleManagerMessage(manager *Client, message []byte) {
-	var cmd ManagerCommand
-	if err := json.Unmarshal(message, &cmd); err == nil && cmd.Command != "" {
-		switch cmd.Command {
-		case "take":
-			h.assignManagerToUser(manager, cmd.UserID)
-		case "list":
-			h.listWaitingUsers(manager)
-		case "close":
-			h.closeSession(manager, cmd.UserID)
-		}
+func (h *Hub) handleManagerTextMessage(req request, msg ManagerMessage) {
+	manager := req.client
+	if h.ackDuplicate(req) {
 		return
 	}
-	// Если не команда — значит обычное текстовое сообщение
-	h.handleManagerTextMessage(manager, message)
-}
 
-func (h *Hub) handleManagerTextMessage(manager *Client, message []byte) {
-	var msg ManagerMessage
-	if err := json.Unmarshal(message, &msg); err != nil {
-		logger.Warn("Failed to parse manager text message", err)
-		h.sendError(manager, "Invalid message format")
+	session, err := h.sessions.Get(msg.UserID)
+	if err != nil {
+		logger.Error("Chat session lookup failed", err)
+		req.fail(ErrCodeUnavailable, "Chat is temporarily unavailable")
 		return
 	}
-
-	if msg.Type == "" {
-		msg.Type = MsgTypeText
+	if session == nil || session.ManagerID != manager.ID {
+		req.fail(ErrCodeNotFound, "No active session with this user")
+		return
 	}
-
-	h.mu.RLock() // Используем блокировку на чтение, т.к. только ищем сессию
-	session := h.findSessionForManager(manager.ID, msg.UserID)
-	h.mu.RUnlock()
-
-	if session == nil {
-		h.sendError(manager, "No active session with this user")
+	attachment, ok := h.checkAttachment(req, msg.Type, msg.Content)
+	if !ok {
 		return
 	}
-
-	// Сохраняем и отправляем сообщение с учетом типа
-	messageObj := &Message{
-		FromID:   manager.ID,
-		ToID:     session.UserID,
-		Content:  msg.Content,
-		Type:     msg.Type,     // <--
-		FileName: msg.FileName, // <--
+	if attachment != nil {
+		msg.Type, msg.FileName = attachment.Kind, attachment.FileName
 	}
 
-	h.saveMessageAndAppendToHistory(session, messageObj)
-	msgData, _ := json.Marshal(messageObj)
-
-	// Создаем локальную копию списка клиентов, чтобы не держать блокировку во время отправки
-	var clientsToSend []*Client
-
-	session.mu.RLock()
-	for client := range session.UserClients {
-		clientsToSend = append(clientsToSend, client)
+	messageObj := &Message{
+		SessionID: session.ID,
+		FromID:    manager.ID,
+		ToID:      session.UserID,
+		Content:   msg.Content,
+		Type:      msg.Type,
+		FileName:  msg.FileName,
+		ClientID:  req.id,
+	}
+
+	if err := h.ChatRepository.SaveMessage(messageObj); err != nil {
+		if h.ackDuplicate(req) {
+			return
+		}
+		logger.Error("failed to save message:", err)
+		req.fail(ErrCodeUnavailable, "Chat is temporarily unavailable")
+		return
 	}
-	session.mu.RUnlock() // Разблокируем сессию сразу после копирования
 
-	// Отправляем сообщение всем активным клиентам пользователя, итерируясь по локальной копии
-	for _, client := range clientsToSend {
-		safeSend(client, msgData)
-	}
-	//session.User.Send <- msgData
+	// Всем активным соединениям пользователя, на какой бы реплике они ни были
+	h.publish(sessionChannel(session.UserID), frame(FrameMessage, messageObj))
+	h.publishUserUnread(session.UserID)
+	req.ack(Ack{MessageID: messageObj.ID})
 }
 
-// Модифицированная функция назначения менеджера:
-func (h *Hub) assignManagerToUser(manager *Client, userID uint) {
-	h.mu.Lock()
-	defer h.mu.Unlock()
-
-	// Ищем пользователя в waitingUsers
-	if _, ok := h.waitingUsers[userID]; !ok {
-		h.sendError(manager, fmt.Sprintf("User %d is not waiting.", userID))
+// assignManagerToUser — ручной take: менеджер берет конкретного пользователя, предел
+// сессий при этом не действует. Если двое менеджеров берут одного пользователя
+// одновременно, Redis отдаст его только одному.
+func (h *Hub) assignManagerToUser(req request, userID uint) {
+	manager := req.client
+	ok, err := h.sessions.Assign(userID, manager.ID, 0)
+	if err != nil {
+		logger.Error("Chat assign failed", err)
+		req.fail(ErrCodeUnavailable, "Chat is temporarily unavailable")
 		return
 	}
-
-	// Получаем пользователя из основной сессии, которая должна быть в h.sessions
-	userSession, ok := h.sessions[userID]
 	if !ok {
-		h.sendError(manager, fmt.Sprintf("Session for user %d not found.", userID))
+		req.fail(ErrCodeNotFound, fmt.Sprintf("User %d is not waiting.", userID))
 		return
 	}
 
-	userSession.Manager = manager
-	h.managerSessions[manager.ID] = append(h.managerSessions[manager.ID], userSession)
+	session, err := h.sessions.Get(userID)
+	if err != nil || session == nil {
+		req.fail(ErrCodeNotFound, fmt.Sprintf("Session for user %d not found.", userID))
+		return
+	}
+	req.ack(Ack{})
+	h.startSession(manager.ID, session, false)
+	// Очередь сдвинулась — остальным обновляем место
+	h.dispatch()
+}
 
-	delete(h.waitingUsers, userID) // Удаляем пользователя из очереди ожидания
+// startSession фиксирует назначение и сообщает о нем менеджеру (на всех его
+// устройствах, с историей) и пользователю.
+func (h *Hub) startSession(managerID uint, session *Session, auto bool) {
+	h.updateSession(session.ID, map[string]interface{}{
+		"status":      SessionActive,
+		"manager_id":  managerID,
+		"assigned_at": time.Now(),
+	})
 
-	// Отправляем историю менеджеру
-	for _, msg := range userSession.History {
-		msgData, _ := json.Marshal(msg)
-		safeSend(manager, msgData)
+	history, err := h.ChatRepository.GetLastMessages(session.UserID, historyLimit)
+	if err != nil {
+		logger.Error("LoadLastMessages failed", err)
 	}
-
-	h.sendSuccess(manager, fmt.Sprintf("Session %d started with user %d", userSession.ID, userID), nil)
-	userSession.mu.RLock()
-	for client := range userSession.UserClients {
-		h.sendSuccess(client, "A manager has joined your chat.", nil)
+	for _, msg := range history {
+		h.publish(managerChannel(managerID), frame(FrameMessage, msg))
 	}
-	userSession.mu.RUnlock()
+
+	started := SessionStarted{SessionID: session.ID, UserID: session.UserID, Topic: session.Topic, Auto: auto}
+	h.publish(managerChannel(managerID), response("success", fmt.Sprintf("Session %d started with user %d", session.ID, session.UserID), started))
+	h.publish(sessionChannel(session.UserID), response("success", "A manager has joined your chat.", nil))
+	h.publishManagerUnread(managerID)
 }
 
-func (h *Hub) listWaitingUsers(manager *Client) {
-	h.mu.Lock()
-	defer h.mu.Unlock()
-	if len(h.waitingUsers) == 0 {
-		h.sendSuccess(manager, "No users waiting.", []uint{})
+func (h *Hub) listWaitingUsers(req request) {
+	userIDs, err := h.sessions.Waiting()
+	if err != nil {
+		logger.Error("Chat waiting list failed", err)
+		req.fail(ErrCodeUnavailable, "Chat is temporarily unavailable")
 		return
 	}
-
-	var userIDs []uint
-	for userID := range h.waitingUsers {
-		userIDs = append(userIDs, userID)
+	if len(userIDs) == 0 {
+		req.respond("No users waiting.", []uint{})
+		return
 	}
 
-	h.sendSuccess(manager, "Waiting users list.", userIDs)
+	req.respond("Waiting users list.", userIDs)
 }
 
-func (h *Hub) closeSession(manager *Client, userID uint) {
-	// только отвязывает менеджера
-	h.mu.Lock()
-	defer h.mu.Unlock()
-
-	sessions := h.managerSessions[manager.ID]
-	for i, s := range sessions {
-		if s.UserID == userID {
-			h.managerSessions[manager.ID] = append(sessions[:i], sessions[i+1:]...)
-			break
-		}
+// closeSession завершает сессию: история остается, следующее сообщение
+// пользователя откроет новую сессию и поставит его в очередь.
+func (h *Hub) closeSession(req request, userID uint) {
+	manager := req.client
+	session, err := h.sessions.Get(userID)
+	if err != nil {
+		logger.Error("Chat session lookup failed", err)
+		req.fail(ErrCodeUnavailable, "Chat is temporarily unavailable")
+		return
 	}
-
-	session, ok := h.sessions[userID]
-	if !ok || session.Manager != manager {
-		h.sendError(manager, "Cannot close this session.")
+	if session == nil {
+		req.fail(ErrCodeNotFound, "Cannot close this session.")
 		return
 	}
-
-	// Отвязать менеджера
-	session.Manager = nil
-
-	//уже всё удаляли (но сессию оставить)
-	h.sendSuccess(manager, "Session closed.", nil)
-}
-
-// ------ Новые методы для отправки JSON -----
-
-func (h *Hub) sendResponse(client *Client, status, message string, payload interface{}) {
-	defer func() {
-		if r := recover(); r != nil {
-			logger.Warn(" Обработать закрытый канал")
-			// Обработать закрытый канал
-		}
-	}()
-	response := ServerResponse{
-		Status:  status,
-		Message: message,
-		Payload: payload,
+	ok, err := h.sessions.Close(userID, manager.ID)
+	if err != nil {
+		logger.Error("Chat close failed", err)
+		req.fail(ErrCodeUnavailable, "Chat is temporarily unavailable")
+		return
 	}
-	respBytes, _ := json.Marshal(response)
-	client.Send <- respBytes
-}
-
-func (h *Hub) sendSuccess(client *Client, message string, payload interface{}) {
-	h.sendResponse(client, "success", message, payload)
+	if !ok {
+		req.fail(ErrCodeNotFound, "Cannot close this session.")
+		return
+	}
+	closedAt := time.Now()
+	h.updateSession(session.ID, map[string]interface{}{"status": SessionClosed, "closed_at": closedAt})
+
+	req.respond("Session closed.", nil)
+	// Пользователь на любой реплике получает просьбу оценить разговор
+	h.publish(sessionChannel(userID), frame(FrameCSAT, CSATPrompt{
+		SessionID: session.ID,
+		ManagerID: manager.ID,
+		ClosedAt:  closedAt,
+	}))
+	// У менеджера освободилось место
+	h.dispatch()
 }
 
-func (h *Hub) sendError(client *Client, message string) {
-	h.sendResponse(client, "error", message, nil)
+// publish рассылает готовый кадр всем репликам, подписанным на канал.
+func (h *Hub) publish(channel string, data []byte) {
+	if err := h.broker.Publish(channel, event{Data: data}); err != nil {
+		logger.Error("Chat publish failed", err)
+	}
 }
 
-// // Уведомление об отключении клиента
-// func (h *Hub) notifyClientDisconnected(client *Client) {
-// 	// Отправка уведомления всем менеджерам о том, что клиент отключился
-// 	for _, session := range h.sessions {
-// 		if session.Manager != nil && session.User == client {
-// 			safeSend(session.Manager, []byte(fmt.Sprintf("User %d has disconnected.", client.ID)))
-
-// 		}
-// 	}
-// }
-
-// Новая функция поиска сессии:
-func (h *Hub) findSessionForManager(managerID, userID uint) *Session {
-
-	for _, session := range h.managerSessions[managerID] {
-		if session.UserID == userID {
-			return session
+// deliver получает события из Redis и раздает их локальным соединениям.
+func (h *Hub) deliver(channel string, ev event) {
+	if channel == controlChannel {
+		if ev.Disconnect != 0 {
+			h.closeLocal(ev.Disconnect)
 		}
+		return
 	}
-	return nil
-}
 
-func (h *Hub) saveMessageAndAppendToHistory(session *Session, msg *Message) {
-	if err := h.ChatRepository.SaveMessage(msg); err != nil {
-		logger.Error("failed to save message:", err)
+	var clients map[uint]map[*Client]bool
+	var id string
+	if rest, ok := strings.CutPrefix(channel, "chat:session:"); ok {
+		clients, id = h.users, rest
+	} else if rest, ok := strings.CutPrefix(channel, "chat:manager:"); ok {
+		clients, id = h.managers, rest
+	} else {
+		return
+	}
+	targetID, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return
 	}
 
-	// Блокируем сессию на запись перед изменением истории
-	session.mu.Lock()
-	session.History = append(session.History, msg)
-	session.mu.Unlock()
+	// Копируем список, чтобы не держать блокировку во время отправки
+	h.mu.RLock()
+	targets := make([]*Client, 0, len(clients[uint(targetID)]))
+	for client := range clients[uint(targetID)] {
+		targets = append(targets, client)
+	}
+	h.mu.RUnlock()
+
+	for _, client := range targets {
+		safeSend(client, ev.Data)
+	}
 }
 
-func (h *Hub) listActiveSessions(manager *Client) {
-	//unused (for admin mb?)
+// closeLocal закрывает соединения пользователя на этой реплике; ReadPump завершится и снимет клиента с учета.
+func (h *Hub) closeLocal(userID uint) {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
 
-	sessions := h.managerSessions[manager.ID]
-	response := make([]map[string]interface{}, 0, len(sessions))
-
-	for _, s := range sessions {
-		if s.UserID == 0 {
-			continue
-		}
-		response = append(response, map[string]interface{}{
-			"user_id": s.UserID,
-		})
+	for client := range h.users[userID] {
+		client.Conn.Close()
+	}
+	for client := range h.managers[userID] {
+		client.Conn.Close()
 	}
-
-	h.sendSuccess(manager, "Active sessions", response)
 }
 
-func (h *Hub) loadPreviousMessages(session *Session, limit int) { //TODO вызывается при скролле вверх , через js
-	if len(session.History) == 0 {
-		return // нет точек отсчета
+// DisconnectUser закрывает соединения пользователя на всех репликах.
+func (h *Hub) DisconnectUser(userID uint) {
+	if err := h.broker.Publish(controlChannel, event{Disconnect: userID}); err != nil {
+		logger.Error("Chat disconnect broadcast failed", err)
+		h.closeLocal(userID)
 	}
-
-	firstMsg := session.History[0]
-
-	// Загружаем сообщения из базы, отправленные ПЕРЕД первым в истории
-	messages, err := h.ChatRepository.GetMessagesBefore(session.UserID, firstMsg.ID, limit)
-	if err != nil {
-		logger.Error("failed to load previous messages:", err)
-		return
-	}
-
-	// Вставляем в начало истории
-	session.History = append(messages, session.History...)
 }
 
-func (h *Hub) LoadLastMessages(userID uint, session *Session, limit int) error {
-	messages, err := h.ChatRepository.GetLastMessages(userID, limit)
-	if err != nil {
-		return err
-	}
+// ------ Методы для отправки JSON -----
 
-	session.History = append([]*Message{}, messages...)
-	return nil
+func response(status, message string, payload interface{}) []byte {
+	return frame(FrameResponse, ServerResponse{
+		Status:  status,
+		Message: message,
+		Payload: payload,
+	})
 }
 
-func generateUniqueID() uint {
-	return uint(atomic.AddUint64(&sessionIDCounter, 1))
+// sendError — ошибка, не связанная с конкретным кадром клиента.
+func (h *Hub) sendError(client *Client, code, message string) {
+	safeSend(client, frame(FrameError, ErrorPayload{Code: code, Message: message}))
 }
-func (h *Hub) GetSessionHistory(userID uint) []*Message {
-	// be careful, no mutex
 
-	if session, ok := h.sessions[userID]; ok {
-		return session.History
+// sendHistory отправляет клиенту последние сообщения сессии пользователя userID.
+func (h *Hub) sendHistory(c *Client, userID uint) {
+	history, err := h.ChatRepository.GetLastMessages(userID, historyLimit)
+	if err != nil {
+		logger.Error("LoadLastMessages failed", err)
+		return
 	}
-	return nil
-}
-
-func (h *Hub) GetSessionHistoryForManager(managerID, userID uint) []*Message {
-	h.mu.RLock()
-	defer h.mu.RUnlock()
-
-	for _, session := range h.managerSessions[managerID] {
-		if session.UserID == userID {
-			return session.History
-		}
+	for _, msg := range history {
+		safeSend(c, frame(FrameMessage, msg))
 	}
-	return nil
 }
 
+// safeSend не блокирует хаб: закрытому или медленному клиенту сообщение не доставляется.
 func safeSend(c *Client, data []byte) {
-	defer func() {
-		if r := recover(); r != nil {
-			logger.Warn("Recovered in safeSend: possibly sending to closed channel", map[string]interface{}{
-				"client_id": c.ID,
-			})
-		}
-	}()
+	select {
+	case <-c.done:
+		return
+	default:
+	}
 
 	select {
 	case c.Send <- data:
@@ -513,13 +772,3 @@ func safeSend(c *Client, data []byte) {
 		logger.Warn("Send buffer full or slow client", map[string]interface{}{"client_id": c.ID})
 	}
 }
-func sendHistoryToClient(c *Client, history []*Message) {
-	for _, msg := range history {
-		msgData, err := json.Marshal(msg)
-		if err != nil {
-			logger.Error("failed to marshal message:", err)
-			continue
-		}
-		safeSend(c, msgData)
-	}
-}
@@ -0,0 +1,699 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ShopOnGO/ShopOnGO/configs"
+	"github.com/ShopOnGO/ShopOnGO/internal/rbac"
+	"github.com/ShopOnGO/ShopOnGO/internal/user"
+	"github.com/ShopOnGO/ShopOnGO/pkg/middleware"
+	"github.com/ShopOnGO/ShopOnGO/pkg/redisdb"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRepository — база чата в памяти, общая для всех реплик.
+type fakeRepository struct {
+	mu          sync.Mutex
+	messages    []*Message
+	sessions    []*ChatSession
+	attachments []*Attachment
+	canned      []*CannedResponse
+	ratings     []ChatRating
+	report      ReportFilter // последний запрошенный отчет
+}
+
+func (f *fakeRepository) SaveMessage(m *Message) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, existing := range f.messages {
+		if m.ClientID != "" && existing.FromID == m.FromID && existing.ClientID == m.ClientID {
+			return errors.New("duplicate key value violates unique constraint")
+		}
+	}
+	m.ID = uint(len(f.messages) + 1)
+	copied := *m
+	f.messages = append(f.messages, &copied)
+	return nil
+}
+
+func (f *fakeRepository) GetLastMessages(userID uint, limit int) ([]*Message, error) {
+	return f.GetMessagesBefore(userID, 0, limit)
+}
+
+func (f *fakeRepository) GetMessagesBefore(userID uint, beforeMsgID uint, limit int) ([]*Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []*Message
+	for _, m := range f.messages {
+		if (m.FromID == userID || m.ToID == userID) && (beforeMsgID == 0 || m.ID < beforeMsgID) {
+			copied := *m
+			out = append(out, &copied)
+		}
+	}
+	if len(out) > limit {
+		out = out[len(out)-limit:]
+	}
+	return out, nil
+}
+
+func (f *fakeRepository) FindMessageByClientID(fromID uint, clientID string) (*Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, m := range f.messages {
+		if m.FromID == fromID && m.ClientID == clientID {
+			copied := *m
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (f *fakeRepository) CreateAttachment(a *Attachment) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	a.ID = uint(len(f.attachments) + 1)
+	copied := *a
+	f.attachments = append(f.attachments, &copied)
+	return nil
+}
+
+func (f *fakeRepository) FindAttachment(ownerID uint, url string) (*Attachment, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, a := range f.attachments {
+		if a.OwnerID == ownerID && a.URL == url {
+			copied := *a
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (f *fakeRepository) ListCanned(ownerID uint) ([]CannedResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var result []CannedResponse
+	for _, c := range f.canned {
+		if c.OwnerID == ownerID || c.Shared {
+			result = append(result, *c)
+		}
+	}
+	return result, nil
+}
+
+func (f *fakeRepository) FindCanned(id uint) (*CannedResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, c := range f.canned {
+		if c.ID == id {
+			copied := *c
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (f *fakeRepository) CreateCanned(response *CannedResponse) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	response.ID = uint(len(f.canned) + 1)
+	copied := *response
+	f.canned = append(f.canned, &copied)
+	return nil
+}
+
+func (f *fakeRepository) UpdateCanned(response *CannedResponse) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i, c := range f.canned {
+		if c.ID == response.ID {
+			copied := *response
+			f.canned[i] = &copied
+		}
+	}
+	return nil
+}
+
+func (f *fakeRepository) DeleteCanned(id uint) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i, c := range f.canned {
+		if c.ID == id {
+			f.canned = append(f.canned[:i], f.canned[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+func (f *fakeRepository) FindSession(id uint) (*ChatSession, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, s := range f.sessions {
+		if s.ID == id {
+			copied := *s
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (f *fakeRepository) CreateRating(rating *ChatRating) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, r := range f.ratings {
+		if r.SessionID == rating.SessionID {
+			return false, nil
+		}
+	}
+	rating.ID = uint(len(f.ratings) + 1)
+	f.ratings = append(f.ratings, *rating)
+	return true, nil
+}
+
+// ManagerReport считается SQL-запросом; подделка только запоминает фильтр.
+func (f *fakeRepository) ManagerReport(filter ReportFilter) ([]ManagerReport, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.report = filter
+	return nil, nil
+}
+
+func (f *fakeRepository) MarkDelivered(userID uint, fromUser bool, upTo uint, at time.Time) error {
+	f.mark(userID, fromUser, upTo, func(m *Message) {
+		if m.DeliveredAt == nil {
+			m.DeliveredAt = &at
+		}
+	})
+	return nil
+}
+
+func (f *fakeRepository) MarkRead(userID uint, fromUser bool, upTo uint, at time.Time) error {
+	f.mark(userID, fromUser, upTo, func(m *Message) {
+		if m.DeliveredAt == nil {
+			m.DeliveredAt = &at
+		}
+		if m.ReadAt == nil {
+			m.ReadAt = &at
+		}
+	})
+	return nil
+}
+
+func (f *fakeRepository) mark(userID uint, fromUser bool, upTo uint, apply func(m *Message)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, m := range f.messages {
+		if m.ID <= upTo && ((fromUser && m.FromID == userID) || (!fromUser && m.ToID == userID)) {
+			apply(m)
+		}
+	}
+}
+
+func (f *fakeRepository) UnreadForUser(userID uint) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var count int64
+	for _, m := range f.messages {
+		if m.ToID == userID && m.ReadAt == nil {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (f *fakeRepository) UnreadForManager(managerID uint) ([]UnreadCount, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var counts []UnreadCount
+	for _, s := range f.sessions {
+		if s.ManagerID != managerID || s.Status != SessionActive {
+			continue
+		}
+		count := UnreadCount{UserID: s.UserID}
+		for _, m := range f.messages {
+			if m.SessionID == s.ID && m.FromID == s.UserID && m.ReadAt == nil {
+				count.Unread++
+			}
+		}
+		if count.Unread > 0 {
+			counts = append(counts, count)
+		}
+	}
+	return counts, nil
+}
+
+// message — сохраненное сообщение с отметками о доставке и прочтении.
+// SearchMessages вместо FTS ищет подстроку без учета регистра.
+func (f *fakeRepository) SearchMessages(filter SearchFilter) ([]SearchHit, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var hits []SearchHit
+	for i := len(f.messages) - 1; i >= 0; i-- {
+		m := f.messages[i]
+		hit := SearchHit{Message: *m}
+		for _, s := range f.sessions {
+			if s.ID == m.SessionID {
+				hit.UserID, hit.ManagerID = s.UserID, s.ManagerID
+			}
+		}
+		switch {
+		case filter.Query != "" && !strings.Contains(strings.ToLower(m.Content), strings.ToLower(filter.Query)),
+			filter.UserID != 0 && m.FromID != filter.UserID && m.ToID != filter.UserID,
+			filter.ManagerID != 0 && hit.ManagerID != filter.ManagerID && m.FromID != filter.ManagerID,
+			filter.From != nil && m.CreatedAt.Before(*filter.From),
+			filter.To != nil && !m.CreatedAt.Before(*filter.To):
+			continue
+		}
+		if filter.Query != "" {
+			hit.Snippet = m.Content
+		}
+		hits = append(hits, hit)
+	}
+	if filter.Offset >= len(hits) {
+		return nil, nil
+	}
+	hits = hits[filter.Offset:]
+	if len(hits) > filter.Limit {
+		hits = hits[:filter.Limit]
+	}
+	return hits, nil
+}
+
+func (f *fakeRepository) TranscriptMessages(userID uint, filter TranscriptFilter) ([]*Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var result []*Message
+	for _, m := range f.messages {
+		if (m.FromID == userID || m.ToID == userID) && (filter.SessionID == 0 || m.SessionID == filter.SessionID) {
+			copied := *m
+			result = append(result, &copied)
+		}
+	}
+	return result, nil
+}
+
+func (f *fakeRepository) message(id uint) Message {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return *f.messages[id-1]
+}
+
+func (f *fakeRepository) FindOpenSession(userID uint) (*ChatSession, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, s := range f.sessions {
+		if s.UserID == userID && s.Status != SessionClosed {
+			copied := *s
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (f *fakeRepository) CreateSession(session *ChatSession) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, s := range f.sessions {
+		if s.UserID == session.UserID && s.Status != SessionClosed {
+			return errors.New("duplicate key value violates unique constraint")
+		}
+	}
+	session.ID = uint(len(f.sessions) + 100)
+	copied := *session
+	f.sessions = append(f.sessions, &copied)
+	return nil
+}
+
+func (f *fakeRepository) UpdateSession(id uint, fields map[string]interface{}) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, s := range f.sessions {
+		if s.ID != id {
+			continue
+		}
+		for key, value := range fields {
+			switch key {
+			case "status":
+				s.Status = value.(string)
+			case "manager_id":
+				s.ManagerID, _ = value.(uint)
+			case "waiting_since":
+				if t, ok := value.(time.Time); ok {
+					s.WaitingSince = &t
+				} else {
+					s.WaitingSince = nil
+				}
+			case "assigned_at":
+				t := value.(time.Time)
+				s.AssignedAt = &t
+			case "closed_at":
+				t := value.(time.Time)
+				s.ClosedAt = &t
+			case "topic":
+				s.Topic = value.(string)
+			}
+		}
+	}
+	return nil
+}
+
+func (f *fakeRepository) HasClosedSession(userID uint) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, s := range f.sessions {
+		if s.UserID == userID && s.Status == SessionClosed {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (f *fakeRepository) OpenSessions() ([]ChatSession, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []ChatSession
+	for _, s := range f.sessions {
+		if s.Status != SessionClosed {
+			out = append(out, *s)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeRepository) session(userID uint) ChatSession {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var last ChatSession
+	for _, s := range f.sessions {
+		if s.UserID == userID {
+			last = *s
+		}
+	}
+	return last
+}
+
+// closedSession — пользователь уже обращался в поддержку.
+func (f *fakeRepository) closedSession(userID uint) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sessions = append(f.sessions, &ChatSession{ID: uint(len(f.sessions) + 100), UserID: userID, Status: SessionClosed})
+}
+
+type fakePermissions struct{}
+
+// fakePermissions: менеджер ведет чат, руководитель (lead) еще и просматривает переписки.
+func (fakePermissions) HasPermission(role, permission string) bool {
+	if permission == rbac.PermChatReview {
+		return role == "lead"
+	}
+	return role == "manager" || role == "lead"
+}
+
+// fakeUsers выдает пользователя с предсказуемыми именем и email.
+type fakeUsers struct{}
+
+func (fakeUsers) FindByID(id uint) (*user.User, error) {
+	return &user.User{Name: fmt.Sprintf("User %d", id), Email: fmt.Sprintf("user%d@example.com", id)}, nil
+}
+
+// cluster — несколько реплик чата с общими Redis (miniredis) и базой.
+type cluster struct {
+	redis    *miniredis.Miniredis
+	repo     *fakeRepository
+	config   configs.ChatConfig
+	replicas []*httptest.Server
+	services []*ChatService
+}
+
+// newCluster поднимает реплики с ручным назначением: менеджеры берут пользователей сами.
+func newCluster(t *testing.T, replicas int) *cluster {
+	return newRoutingCluster(t, replicas, configs.ChatConfig{})
+}
+
+func newRoutingCluster(t *testing.T, replicas int, config configs.ChatConfig) *cluster {
+	t.Helper()
+	c := &cluster{redis: miniredis.RunT(t), repo: &fakeRepository{}, config: config}
+	for i := 0; i < replicas; i++ {
+		c.addReplica(t)
+	}
+	return c
+}
+
+// addReplica запускает еще одну реплику; при старте она восстанавливает сессии из базы.
+func (c *cluster) addReplica(t *testing.T) int {
+	t.Helper()
+	rdb := &redisdb.RedisDB{Client: redis.NewClient(&redis.Options{Addr: c.redis.Addr()})}
+	service := NewChatService(ChatServiceDeps{
+		Config:         c.config,
+		ChatRepository: c.repo,
+		Sessions:       NewRedisSessionStore(rdb, fmt.Sprintf("replica-%d", len(c.replicas))),
+		Broker:         NewRedisBroker(rdb),
+		Permissions:    fakePermissions{},
+		Users:          fakeUsers{},
+		Media:          &fakeUploader{},
+	})
+	// Аутентификация не проверяется: пользователь и роль приходят в query
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, _ := strconv.ParseUint(r.URL.Query().Get("user"), 10, 64)
+		ctx := context.WithValue(r.Context(), middleware.ContextUserIDKey, uint(id))
+		ctx = context.WithValue(ctx, middleware.ContextRolesKey, r.URL.Query().Get("role"))
+		service.ServeWS(w, r.WithContext(ctx))
+	}))
+	t.Cleanup(server.Close)
+	c.replicas = append(c.replicas, server)
+	c.services = append(c.services, service)
+	return len(c.replicas) - 1
+}
+
+// dial подключает клиента к реплике и ждет, пока реплика подпишется на его канал.
+func (c *cluster) dial(t *testing.T, replica int, userID uint, role string) *websocket.Conn {
+	t.Helper()
+	url := "ws" + strings.TrimPrefix(c.replicas[replica].URL, "http") + fmt.Sprintf("/?user=%d&role=%s", userID, role)
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	channel := sessionChannel(userID)
+	if role == "manager" {
+		channel = managerChannel(userID)
+	}
+	require.Eventually(t, func() bool {
+		return c.redis.PubSubNumSub(channel)[channel] > 0
+	}, 2*time.Second, 10*time.Millisecond, "реплика не подписалась на %s", channel)
+	return conn
+}
+
+var frameSeq atomic.Int64
+
+// send отправляет кадр протокола с новым id и возвращает этот id.
+func send(t *testing.T, conn *websocket.Conn, frameType string, payload interface{}) string {
+	t.Helper()
+	id := fmt.Sprintf("f-%d", frameSeq.Add(1))
+	sendFrame(t, conn, frameType, id, payload)
+	return id
+}
+
+func sendFrame(t *testing.T, conn *websocket.Conn, frameType, id string, payload interface{}) {
+	t.Helper()
+	frame := map[string]interface{}{"type": frameType, "id": id}
+	if payload != nil {
+		frame["payload"] = payload
+	}
+	raw, err := json.Marshal(frame)
+	require.NoError(t, err)
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, raw))
+}
+
+// expect читает кадры, пока не встретит содержащий substr.
+func expect(t *testing.T, conn *websocket.Conn, substr string) string {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for {
+		_, data, err := conn.ReadMessage()
+		require.NoError(t, err, "не дождались кадра с %q", substr)
+		if strings.Contains(string(data), substr) {
+			return string(data)
+		}
+	}
+}
+
+func TestChatAcrossReplicas(t *testing.T) {
+	c := newCluster(t, 2)
+
+	user := c.dial(t, 0, 1, "buyer")
+	manager := c.dial(t, 1, 100, "manager")
+
+	// Пользователь пишет первым и попадает в очередь
+	send(t, user, ReqMessage, IncomingUserMessage{Content: "hello"})
+	expect(t, user, `"content":"hello"`)
+
+	send(t, manager, ReqList, nil)
+	expect(t, manager, `"payload":[1]`)
+
+	// Менеджер на другой реплике берет пользователя и получает историю
+	send(t, manager, ReqTake, ManagerCommand{UserID: 1})
+	expect(t, manager, `"content":"hello"`)
+	session := c.repo.session(1)
+	expect(t, manager, fmt.Sprintf("Session %d started with user 1", session.ID))
+	expect(t, user, "A manager has joined your chat.")
+	assert.Equal(t, SessionActive, session.Status)
+	assert.Equal(t, uint(100), session.ManagerID)
+
+	send(t, manager, ReqMessage, ManagerMessage{UserID: 1, Content: "how can I help?"})
+	expect(t, user, "how can I help?")
+
+	send(t, user, ReqMessage, IncomingUserMessage{Content: "where is my order"})
+	msg := expect(t, manager, "where is my order")
+	assert.Contains(t, msg, `"to_id":100`)
+
+	// Второе устройство пользователя на другой реплике видит ту же сессию
+	second := c.dial(t, 1, 1, "buyer")
+	expect(t, second, "how can I help?")
+	send(t, manager, ReqMessage, ManagerMessage{UserID: 1, Content: "on its way"})
+	expect(t, user, "on its way")
+	expect(t, second, "on its way")
+
+	// Закрытие завершает сессию; следующее сообщение открывает новую и ставит в очередь
+	send(t, manager, ReqClose, ManagerCommand{UserID: 1})
+	expect(t, manager, "Session closed.")
+	closed := c.repo.session(1)
+	assert.Equal(t, SessionClosed, closed.Status)
+	assert.NotNil(t, closed.ClosedAt)
+	send(t, manager, ReqMessage, ManagerMessage{UserID: 1, Content: "too late"})
+	expect(t, manager, "No active session with this user")
+	send(t, second, ReqMessage, IncomingUserMessage{Content: "one more question"})
+	msg = expect(t, user, "one more question")
+	reopened := c.repo.session(1)
+	assert.NotEqual(t, closed.ID, reopened.ID)
+	assert.Equal(t, SessionWaiting, reopened.Status)
+	assert.Contains(t, msg, fmt.Sprintf(`"session_id":%d`, reopened.ID))
+	send(t, manager, ReqList, nil)
+	expect(t, manager, `"payload":[1]`)
+}
+
+func TestSessionsSurviveRestart(t *testing.T) {
+	c := newCluster(t, 1)
+
+	waiting := c.dial(t, 0, 1, "buyer")
+	served := c.dial(t, 0, 2, "buyer")
+	manager := c.dial(t, 0, 100, "manager")
+	send(t, waiting, ReqMessage, IncomingUserMessage{Content: "first in line"})
+	expect(t, waiting, "first in line")
+	send(t, served, ReqMessage, IncomingUserMessage{Content: "need help"})
+	expect(t, served, "need help")
+	send(t, manager, ReqTake, ManagerCommand{UserID: 2})
+	expect(t, manager, "started with user 2")
+
+	// Деплой: Redis пуст, новая реплика поднимает состояние из базы
+	c.redis.FlushAll()
+	replica := c.addReplica(t)
+
+	manager = c.dial(t, replica, 100, "manager")
+	send(t, manager, ReqList, nil)
+	expect(t, manager, `"payload":[1]`)
+
+	served = c.dial(t, replica, 2, "buyer")
+	send(t, served, ReqMessage, IncomingUserMessage{Content: "still there?"})
+	expect(t, manager, "still there?")
+	send(t, manager, ReqMessage, ManagerMessage{UserID: 2, Content: "yes"})
+	expect(t, served, "yes")
+
+	// ID сессии — ID строки в базе
+	send(t, manager, ReqTake, ManagerCommand{UserID: 1})
+	expect(t, manager, fmt.Sprintf("Session %d started with user 1", c.repo.session(1).ID))
+}
+
+func TestOnlyOneManagerTakesUser(t *testing.T) {
+	c := newCluster(t, 2)
+
+	user := c.dial(t, 0, 1, "buyer")
+	first := c.dial(t, 0, 100, "manager")
+	second := c.dial(t, 1, 200, "manager")
+
+	send(t, user, ReqMessage, IncomingUserMessage{Content: "help"})
+	expect(t, user, `"content":"help"`)
+
+	send(t, first, ReqTake, ManagerCommand{UserID: 1})
+	expect(t, first, "started with user 1")
+	send(t, second, ReqTake, ManagerCommand{UserID: 1})
+	expect(t, second, "User 1 is not waiting.")
+
+	// Уход менеджера возвращает пользователя в очередь, и его может взять другой
+	first.Close()
+	expect(t, user, "Manager disconnected")
+	send(t, second, ReqTake, ManagerCommand{UserID: 1})
+	expect(t, second, "started with user 1")
+}
+
+func TestCrashedReplicaPresence(t *testing.T) {
+	online := func(store *RedisSessionStore) []uint {
+		ids, err := store.OnlineManagers()
+		require.NoError(t, err)
+		return ids
+	}
+
+	t.Run("Connections of a crashed replica expire", func(t *testing.T) {
+		c := newCluster(t, 2)
+		store := c.services[0].hub.sessions
+		c.dial(t, 0, 200, "manager")
+		c.dial(t, 1, 100, "manager")
+		require.Eventually(t, func() bool { return len(online(store)) == 2 }, 2*time.Second, 10*time.Millisecond)
+
+		// Реплика 1 зависла или упала: соединение не закрыто, heartbeat не приходит.
+		// Живая реплика 0 продлевает свой учет.
+		c.redis.FastForward(presenceTTL * 2 / 3)
+		require.NoError(t, store.Heartbeat())
+		c.redis.FastForward(presenceTTL * 2 / 3)
+
+		assert.Equal(t, []uint{200}, online(store))
+		count, err := store.Connect(100, true)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), count, "соединение упавшей реплики не учитывается")
+	})
+
+	t.Run("Restarted replica drops its stale connections", func(t *testing.T) {
+		c := newCluster(t, 2)
+		store := c.services[0].hub.sessions
+		c.dial(t, 1, 100, "manager")
+		require.Eventually(t, func() bool { return len(online(store)) == 1 }, 2*time.Second, 10*time.Millisecond)
+
+		// Реплика 1 поднялась заново под тем же именем
+		restarted := NewRedisSessionStore(store.redis, "replica-1")
+		require.NoError(t, restarted.ResetPresence())
+		assert.Empty(t, online(store))
+	})
+}
+
+func TestDisconnectUserOnAnyReplica(t *testing.T) {
+	c := newCluster(t, 2)
+
+	user := c.dial(t, 0, 1, "buyer")
+	c.services[1].DisconnectUser(1)
+
+	user.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var err error
+	for err == nil {
+		_, _, err = user.ReadMessage()
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		t.Fatal("соединение не закрыто другой репликой")
+	}
+}
@@ -11,11 +11,86 @@ const (
 )
 
 type Message struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	SessionID   uint       `gorm:"index" json:"session_id"` // 0 — сообщения до появления chat_sessions
+	FromID      uint       `gorm:"not null;uniqueIndex:idx_messages_client,where:client_id <> ''" json:"from_id"`
+	ToID        uint       `gorm:"not null" json:"to_id"`
+	Content     string     `gorm:"type:text;not null" json:"content"`
+	Type        string     `gorm:"default:'text'" json:"type"`                                                                        // "text", "image", "file"
+	FileName    string     `gorm:"type:varchar(255)" json:"file_name,omitempty"`                                                      // Оригинальное имя файла (для файлов)
+	ClientID    string     `gorm:"type:varchar(64);uniqueIndex:idx_messages_client,where:client_id <> ''" json:"client_id,omitempty"` // id кадра клиента, по нему отсекаются повторы
+	CreatedAt   time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	DeliveredAt *time.Time `json:"delivered_at,omitempty"` // получатель подтвердил доставку
+	ReadAt      *time.Time `json:"read_at,omitempty"`
+}
+
+// Статусы сессии чата
+const (
+	SessionOpen    = "open"    // пользователь подключился, но еще ничего не написал
+	SessionWaiting = "waiting" // ждет менеджера в очереди
+	SessionActive  = "active"  // менеджер ведет диалог
+	SessionClosed  = "closed"  // менеджер закрыл сессию; следующее сообщение откроет новую
+)
+
+// Приоритет в очереди: чем больше, тем раньше пользователь попадет к менеджеру.
+const (
+	PriorityRegular   = 0
+	PriorityReturning = 1 // уже обращался в поддержку
+	PrioritySeller    = 2
+	PriorityHighest   = PrioritySeller
+)
+
+// ChatSession — сессия поддержки. Postgres — источник истины: после рестарта
+// очередь и назначения менеджеров восстанавливаются отсюда в Redis.
+// У пользователя одновременно не больше одной незакрытой сессии.
+type ChatSession struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	UserID       uint       `gorm:"not null;uniqueIndex:idx_chat_sessions_user_open,where:status <> 'closed'" json:"user_id"`
+	ManagerID    uint       `gorm:"index" json:"manager_id,omitempty"`
+	Status       string     `gorm:"type:varchar(16);not null;index" json:"status"`
+	Priority     int        `gorm:"not null;default:0" json:"priority"`
+	Topic        string     `gorm:"type:varchar(64)" json:"topic,omitempty"`
+	OpenedAt     time.Time  `gorm:"not null" json:"opened_at"`
+	WaitingSince *time.Time `json:"waiting_since,omitempty"` // место в очереди
+	AssignedAt   *time.Time `json:"assigned_at,omitempty"`
+	ClosedAt     *time.Time `json:"closed_at,omitempty"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// Attachment — файл, загруженный пользователем в чат. Сообщение типа image или file
+// принимается, только если его URL выдан этому же отправителю.
+type Attachment struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	OwnerID   uint      `gorm:"not null;index" json:"owner_id"`
+	URL       string    `gorm:"type:varchar(1024);not null;uniqueIndex" json:"url"`
+	FileName  string    `gorm:"type:varchar(255)" json:"file_name"`
+	MimeType  string    `gorm:"type:varchar(127)" json:"mime_type"` // определен по содержимому
+	Kind      string    `gorm:"type:varchar(16)" json:"type"`       // MsgTypeImage или MsgTypeFile
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CannedResponse — заготовленный ответ менеджера. Личные видит только владелец,
+// общие (Shared) — все менеджеры. Плейсхолдеры в Body раскрываются при отправке макросом.
+type CannedResponse struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	OwnerID   uint      `gorm:"not null;index" json:"owner_id"`
+	Title     string    `gorm:"type:varchar(128);not null" json:"title"`
+	Body      string    `gorm:"type:text;not null" json:"body"`
+	Shared    bool      `gorm:"not null;default:false;index" json:"shared"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ChatRating — оценка пользователем закрытой сессии. Одна оценка на сессию; ключ
+// отчета — пользователь, менеджер и время закрытия.
+type ChatRating struct {
 	ID        uint      `gorm:"primaryKey" json:"id"`
-	FromID    uint      `gorm:"not null" json:"from_id"`
-	ToID      uint      `gorm:"not null" json:"to_id"`
-	Content   string    `gorm:"type:text;not null" json:"content"`
-	Type      string    `gorm:"default:'text'" json:"type"`                   // "text", "image", "file"
-	FileName  string    `gorm:"type:varchar(255)" json:"file_name,omitempty"` // Оригинальное имя файла (для файлов)
-	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	SessionID uint      `gorm:"not null;uniqueIndex" json:"session_id"`
+	UserID    uint      `gorm:"not null;index:idx_chat_ratings_key,priority:1" json:"user_id"`
+	ManagerID uint      `gorm:"not null;index:idx_chat_ratings_key,priority:2;index" json:"manager_id"`
+	ClosedAt  time.Time `gorm:"not null;index:idx_chat_ratings_key,priority:3" json:"closed_at"`
+	Score     int       `gorm:"not null;check:score BETWEEN 1 AND 5" json:"score"`
+	Comment   string    `gorm:"type:varchar(1000)" json:"comment,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
 }
@@ -1,9 +1,47 @@
 package chat
 
-// Команда от менеджера: взять пользователя, закрыть сессию и т.п.
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Ограничения полей во входящих кадрах
+const (
+	maxContentLength  = 4000 // символов в сообщении
+	maxFileNameLength = 255
+	maxTagLength      = 64
+	maxTags           = 20
+	maxCommentLength  = 1000
+	minScore          = 1
+	maxScore          = 5
+)
+
+// ManagerCommand — данные команд менеджера: take и close (UserID), skills (Tags).
 type ManagerCommand struct {
-	Command string `json:"command"`
-	UserID  uint   `json:"user_id,omitempty"`
+	UserID uint     `json:"user_id,omitempty"`
+	Tags   []string `json:"tags,omitempty"` // для "skills": темы, которые ведет менеджер
+}
+
+func (c ManagerCommand) validate(frameType string) error {
+	switch frameType {
+	case ReqTake, ReqClose:
+		if c.UserID == 0 {
+			return errors.New("user_id is required")
+		}
+	case ReqSkills:
+		if len(c.Tags) > maxTags {
+			return fmt.Errorf("too many tags, max %d", maxTags)
+		}
+		for _, tag := range c.Tags {
+			if utf8.RuneCountInString(tag) > maxTagLength {
+				return fmt.Errorf("tag is longer than %d characters", maxTagLength)
+			}
+		}
+	}
+	return nil
 }
 
 // Обычное текстовое сообщение от менеджера пользователю
@@ -14,9 +52,36 @@ type ManagerMessage struct {
 	FileName string `json:"file_name"` // <-- Добавили
 }
 
+func (m *ManagerMessage) validate() error {
+	if m.UserID == 0 {
+		return errors.New("user_id is required")
+	}
+	if m.Type == "" {
+		m.Type = MsgTypeText
+	}
+	return validateContent(m.Type, m.Content, m.FileName)
+}
+
+// MacroCommand — данные кадра macro: отправить пользователю UserID заготовленный
+// ответ ResponseID с подставленными данными пользователя.
+type MacroCommand struct {
+	UserID     uint `json:"user_id"`
+	ResponseID uint `json:"response_id"`
+}
+
+func (c MacroCommand) validate() error {
+	if c.UserID == 0 {
+		return errors.New("user_id is required")
+	}
+	if c.ResponseID == 0 {
+		return errors.New("response_id is required")
+	}
+	return nil
+}
+
 // Общий ответ сервера
 type ServerResponse struct {
-	Status  string      `json:"status"` // "success" или "error"
+	Status  string      `json:"status"` // "success"; ошибки приходят кадром error
 	Message string      `json:"message,omitempty"`
 	Payload interface{} `json:"payload,omitempty"`
 }
@@ -26,9 +91,213 @@ type UploadResponse struct {
 	URL      string `json:"url"`
 	FileName string `json:"file_name"`
 	Type     string `json:"type"`
+	MimeType string `json:"mime_type"`
+	Size     int64  `json:"size"`
 }
 type IncomingUserMessage struct {
 	Content  string `json:"content"`
 	Type     string `json:"type"`      // "text", "image", "file"
 	FileName string `json:"file_name"` // Опционально
+	Topic    string `json:"topic"`     // Опционально: тема обращения для выбора менеджера
+}
+
+func (m *IncomingUserMessage) validate() error {
+	if m.Type == "" {
+		m.Type = MsgTypeText
+	}
+	if utf8.RuneCountInString(m.Topic) > maxTagLength {
+		return fmt.Errorf("topic is longer than %d characters", maxTagLength)
+	}
+	return validateContent(m.Type, m.Content, m.FileName)
+}
+
+// validateContent проверяет общее для сообщений пользователя и менеджера.
+func validateContent(msgType, content, fileName string) error {
+	switch msgType {
+	case MsgTypeText, MsgTypeImage, MsgTypeFile:
+	default:
+		return fmt.Errorf("unknown message type %q", msgType)
+	}
+	if strings.TrimSpace(content) == "" {
+		return errors.New("content is required")
+	}
+	if utf8.RuneCountInString(content) > maxContentLength {
+		return fmt.Errorf("content is longer than %d characters", maxContentLength)
+	}
+	if utf8.RuneCountInString(fileName) > maxFileNameLength {
+		return fmt.Errorf("file_name is longer than %d characters", maxFileNameLength)
+	}
+	return nil
+}
+
+// QueuePosition — место пользователя в очереди ожидания (1 — следующий).
+type QueuePosition struct {
+	Position int `json:"position"`
+}
+
+// SessionStarted — менеджеру назначен пользователь (вручную или автоматически).
+type SessionStarted struct {
+	SessionID uint   `json:"session_id"`
+	UserID    uint   `json:"user_id"`
+	Topic     string `json:"topic,omitempty"`
+	Auto      bool   `json:"auto"`
+}
+
+// ClientEvent — данные кадров typing, delivered и read. Менеджер указывает UserID
+// переписки; MessageID — последнее доставленное или прочитанное сообщение (включительно).
+type ClientEvent struct {
+	UserID    uint `json:"user_id,omitempty"`
+	MessageID uint `json:"message_id,omitempty"`
+}
+
+func (e ClientEvent) validate(frameType string, manager bool) error {
+	if manager && e.UserID == 0 {
+		return errors.New("user_id is required")
+	}
+	if frameType != ReqTyping && e.MessageID == 0 {
+		return errors.New("message_id is required")
+	}
+	return nil
+}
+
+// Typing — собеседник печатает. UserID — пользователь, в чьей переписке это происходит.
+type Typing struct {
+	UserID uint `json:"user_id"`
+	FromID uint `json:"from_id"`
+}
+
+// Receipt — собеседник получил или прочитал сообщения до UpTo включительно.
+type Receipt struct {
+	Status string    `json:"status"` // ReqDelivered или ReqRead
+	UserID uint      `json:"user_id"`
+	FromID uint      `json:"from_id"`
+	UpTo   uint      `json:"up_to"`
+	At     time.Time `json:"at"`
+}
+
+// UnreadCount — непрочитанные в переписке с пользователем UserID.
+type UnreadCount struct {
+	UserID uint  `json:"user_id"`
+	Unread int64 `json:"unread"`
+}
+
+// UnreadSummary — непрочитанные по всем перепискам: у пользователя одна (с поддержкой),
+// у менеджера — по каждому пользователю, которого он ведет.
+type UnreadSummary struct {
+	Total         int64         `json:"total"`
+	Conversations []UnreadCount `json:"conversations"`
+}
+
+// HistoryRequest — данные кадра history: страница переписки с сообщениями старше Before
+// (0 — самые свежие). Менеджер указывает UserID пользователя, которого ведет.
+type HistoryRequest struct {
+	UserID uint `json:"user_id,omitempty"`
+	Before uint `json:"before,omitempty"`
+	Limit  int  `json:"limit,omitempty"`
+}
+
+// HistoryPage — страница переписки в хронологическом порядке. HasMore — есть более
+// старые сообщения; следующую страницу запрашивают с Before = ID первого сообщения.
+type HistoryPage struct {
+	UserID   uint       `json:"user_id"`
+	Messages []*Message `json:"messages"`
+	HasMore  bool       `json:"has_more"`
+}
+
+// SearchFilter — условия поиска по перепискам. Пустые поля не ограничивают выборку.
+type SearchFilter struct {
+	Query     string     `json:"q,omitempty"`          // полнотекстовый запрос (синтаксис websearch_to_tsquery)
+	UserID    uint       `json:"user_id,omitempty"`    // переписка с пользователем
+	ManagerID uint       `json:"manager_id,omitempty"` // сессии менеджера и его сообщения
+	From      *time.Time `json:"from,omitempty"`
+	To        *time.Time `json:"to,omitempty"` // не включая
+	Limit     int        `json:"limit,omitempty"`
+	Offset    int        `json:"offset,omitempty"`
+}
+
+// SearchHit — найденное сообщение. UserID и ManagerID берутся из сессии (0 — сообщение
+// до появления chat_sessions), Snippet — фрагмент с совпадениями в «».
+type SearchHit struct {
+	Message
+	UserID    uint   `json:"user_id"`
+	ManagerID uint   `json:"manager_id,omitempty"`
+	Snippet   string `json:"snippet,omitempty"`
+}
+
+type SearchResponse struct {
+	Hits []SearchHit `json:"hits"`
+}
+
+// TranscriptFilter — какую часть переписки с пользователем выгрузить: одну сессию и/или период.
+type TranscriptFilter struct {
+	SessionID uint       `json:"session_id,omitempty"`
+	From      *time.Time `json:"from,omitempty"`
+	To        *time.Time `json:"to,omitempty"`
+}
+
+// CannedResponseRequest — создание и изменение заготовленного ответа. В Body можно
+// использовать плейсхолдеры {{user.Name}} и {{user.Email}}.
+type CannedResponseRequest struct {
+	Title  string `json:"title" validate:"required,max=128"`
+	Body   string `json:"body" validate:"required,max=4000"`
+	Shared bool   `json:"shared"` // виден всем менеджерам
+}
+
+type CannedResponsesResponse struct {
+	Responses []CannedResponse `json:"responses"`
+}
+
+// CSATPrompt — менеджер закрыл сессию; клиент показывает форму оценки и отвечает кадром rate.
+type CSATPrompt struct {
+	SessionID uint      `json:"session_id"`
+	ManagerID uint      `json:"manager_id"`
+	ClosedAt  time.Time `json:"closed_at"`
+}
+
+// RatingInput — данные кадра rate: оценка 1–5 и необязательный комментарий.
+type RatingInput struct {
+	SessionID uint   `json:"session_id"`
+	Score     int    `json:"score"`
+	Comment   string `json:"comment,omitempty"`
+}
+
+func (r *RatingInput) validate() error {
+	if r.SessionID == 0 {
+		return errors.New("session_id is required")
+	}
+	if r.Score < minScore || r.Score > maxScore {
+		return fmt.Errorf("score must be between %d and %d", minScore, maxScore)
+	}
+	r.Comment = strings.TrimSpace(r.Comment)
+	if utf8.RuneCountInString(r.Comment) > maxCommentLength {
+		return fmt.Errorf("comment is longer than %d characters", maxCommentLength)
+	}
+	return nil
+}
+
+// ReportFilter — период отчета по закрытым сессиям и шаг группировки (ReportDay и т. д.).
+type ReportFilter struct {
+	From   time.Time
+	To     time.Time // не включая
+	Period string
+}
+
+// ManagerReport — показатели менеджера за один период. Время — в секундах;
+// nil — нет данных (например, ни одной оценки). CSAT — доля оценок 4 и 5, в процентах.
+type ManagerReport struct {
+	Period                  time.Time `json:"period"`
+	ManagerID               uint      `json:"manager_id"`
+	Sessions                int64     `json:"sessions"`
+	Ratings                 int64     `json:"ratings"`
+	AvgScore                *float64  `json:"avg_score"`
+	CSAT                    *float64  `json:"csat"`
+	AvgFirstResponseSeconds *float64  `json:"avg_first_response_seconds"`
+	AvgResolutionSeconds    *float64  `json:"avg_resolution_seconds"`
+}
+
+type ReportResponse struct {
+	From    time.Time       `json:"from"`
+	To      time.Time       `json:"to"`
+	Period  string          `json:"period"`
+	Reports []ManagerReport `json:"reports"`
 }
@@ -0,0 +1,192 @@
+package chat
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ShopOnGO/ShopOnGO/pkg/logger"
+)
+
+// Протокол WebSocket чата. Кадры в обе стороны — конверт {type, id, payload}:
+// type задает схему payload, id выдает клиент, и сервер ссылается на него в ack
+// и error. Версия согласуется при подключении через Sec-WebSocket-Protocol
+// ("shopongo.chat.v2"); клиент без подпротокола получает текущую версию.
+
+// ProtocolVersion — текущая версия протокола. Меняется при несовместимых изменениях.
+const ProtocolVersion = 2
+
+// supportedVersions — версии, которые обслуживает сервер, в порядке предпочтения.
+var supportedVersions = []int{ProtocolVersion}
+
+const (
+	maxFrameBytes     = 32 << 10 // предел входящего кадра
+	maxClientIDLength = 64
+)
+
+// Типы кадров от клиента
+const (
+	ReqMessage   = "message"   // IncomingUserMessage или ManagerMessage; id обязателен
+	ReqTyping    = "typing"    // ClientEvent
+	ReqDelivered = "delivered" // ClientEvent
+	ReqRead      = "read"      // ClientEvent
+	ReqHistory   = "history"   // HistoryRequest
+	ReqTake      = "take"      // ManagerCommand; только менеджер
+	ReqList      = "list"      // без данных; только менеджер
+	ReqClose     = "close"     // ManagerCommand; только менеджер
+	ReqSkills    = "skills"    // ManagerCommand; только менеджер
+	ReqMacro     = "macro"     // MacroCommand; только менеджер, id обязателен
+	ReqRate      = "rate"      // RatingInput; только пользователь, в ответ на csat
+)
+
+// Типы кадров от сервера
+const (
+	FrameHello    = "hello"    // Hello, первый кадр соединения
+	FrameAck      = "ack"      // Ack: кадр клиента обработан
+	FrameError    = "error"    // ErrorPayload
+	FrameMessage  = "message"  // Message
+	FrameResponse = "response" // ServerResponse: ответ на команду, уведомление
+	FrameTyping   = "typing"   // Typing
+	FrameReceipt  = "receipt"  // Receipt
+	FrameUnread   = "unread"   // UnreadSummary
+	FrameHistory  = "history"  // HistoryPage
+	FrameCSAT     = "csat"     // CSATPrompt: сессия закрыта, попросите оценку
+)
+
+// Коды ошибок в кадре error
+const (
+	ErrCodeBadRequest  = "bad_request"  // кадр не разобран или не прошел проверку
+	ErrCodeUnknownType = "unknown_type" // неизвестный тип кадра
+	ErrCodeForbidden   = "forbidden"    // тип кадра недоступен роли
+	ErrCodeNotFound    = "not_found"    // нет сессии или пользователя в очереди
+	ErrCodeUnavailable = "unavailable"  // временная ошибка сервера, кадр можно повторить
+)
+
+// Envelope — кадр от сервера. ID — id кадра клиента, на который это ответ.
+type Envelope struct {
+	Version int         `json:"v"`
+	Type    string      `json:"type"`
+	ID      string      `json:"id,omitempty"`
+	Payload interface{} `json:"payload,omitempty"`
+}
+
+// inbound — кадр от клиента.
+type inbound struct {
+	Type    string          `json:"type"`
+	ID      string          `json:"id,omitempty"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// Hello сообщает клиенту согласованную версию и ограничения.
+type Hello struct {
+	Version       int  `json:"version"`
+	UserID        uint `json:"user_id"`
+	Manager       bool `json:"manager"`
+	MaxFrameBytes int  `json:"max_frame_bytes"`
+}
+
+// Ack подтверждает кадр клиента. Для сообщений — ID сохраненного сообщения;
+// Duplicate — кадр с этим id уже был обработан, повторно он не рассылается.
+type Ack struct {
+	MessageID uint `json:"message_id,omitempty"`
+	Duplicate bool `json:"duplicate,omitempty"`
+}
+
+type ErrorPayload struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func subprotocol(version int) string {
+	return fmt.Sprintf("shopongo.chat.v%d", version)
+}
+
+func subprotocols() []string {
+	names := make([]string, 0, len(supportedVersions))
+	for _, version := range supportedVersions {
+		names = append(names, subprotocol(version))
+	}
+	return names
+}
+
+// negotiateVersion выбирает версию из предложенных клиентом подпротоколов.
+// false — общих версий нет.
+func negotiateVersion(offered []string) (int, bool) {
+	if len(offered) == 0 {
+		return ProtocolVersion, true
+	}
+	for _, version := range supportedVersions {
+		for _, name := range offered {
+			if name == subprotocol(version) {
+				return version, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// decodeStrict разбирает JSON без неизвестных полей и лишних данных после значения.
+func decodeStrict(data []byte, v interface{}) error {
+	if len(bytes.TrimSpace(data)) == 0 {
+		data = []byte("{}")
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if dec.More() {
+		return errors.New("unexpected data after JSON value")
+	}
+	return nil
+}
+
+// envelope упаковывает данные в кадр протокола.
+func envelope(frameType, id string, payload interface{}) []byte {
+	data, err := json.Marshal(Envelope{Version: ProtocolVersion, Type: frameType, ID: id, Payload: payload})
+	if err != nil {
+		logger.Error("failed to marshal chat frame:", err)
+	}
+	return data
+}
+
+// frame — кадр, не привязанный к кадру клиента (рассылки, уведомления).
+func frame(frameType string, payload interface{}) []byte {
+	return envelope(frameType, "", payload)
+}
+
+// request — кадр клиента в обработке. Ответы и ошибки ссылаются на его id.
+type request struct {
+	client  *Client
+	id      string
+	payload json.RawMessage
+}
+
+// bind разбирает payload; при ошибке клиент получает bad_request.
+func (r request) bind(v interface{}) bool {
+	if err := decodeStrict(r.payload, v); err != nil {
+		r.fail(ErrCodeBadRequest, "Invalid payload: "+err.Error())
+		return false
+	}
+	return true
+}
+
+func (r request) reply(frameType string, payload interface{}) {
+	safeSend(r.client, envelope(frameType, r.id, payload))
+}
+
+func (r request) respond(message string, payload interface{}) {
+	r.reply(FrameResponse, ServerResponse{Status: "success", Message: message, Payload: payload})
+}
+
+// ack подтверждает кадр; без id подтверждать нечего.
+func (r request) ack(ack Ack) {
+	if r.id != "" {
+		r.reply(FrameAck, ack)
+	}
+}
+
+func (r request) fail(code, message string) {
+	r.reply(FrameError, ErrorPayload{Code: code, Message: message})
+}
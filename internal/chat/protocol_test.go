@@ -0,0 +1,70 @@
+package chat
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProtocolNegotiation(t *testing.T) {
+	c := newCluster(t, 1)
+	url := "ws" + strings.TrimPrefix(c.replicas[0].URL, "http") + "/?user=1&role=buyer"
+
+	// Общих версий нет — отказ до апгрейда
+	_, resp, err := (&websocket.Dialer{Subprotocols: []string{"shopongo.chat.v1"}}).Dial(url, nil)
+	require.Error(t, err)
+	require.NotNil(t, resp)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	conn, resp, err := (&websocket.Dialer{Subprotocols: []string{"shopongo.chat.v1", "shopongo.chat.v2"}}).Dial(url, nil)
+	require.NoError(t, err)
+	defer conn.Close()
+	assert.Equal(t, "shopongo.chat.v2", resp.Header.Get("Sec-WebSocket-Protocol"))
+	expect(t, conn, fmt.Sprintf(`{"v":2,"type":"hello","payload":{"version":2,"user_id":1,"manager":false,"max_frame_bytes":%d}}`, maxFrameBytes))
+}
+
+func TestProtocolValidationAndAcks(t *testing.T) {
+	c := newCluster(t, 2)
+	user := c.dial(t, 0, 1, "buyer")
+	manager := c.dial(t, 1, 100, "manager")
+
+	// Кадры вне конверта больше не угадываются
+	require.NoError(t, user.WriteMessage(websocket.TextMessage, []byte("hello")))
+	expect(t, user, `"type":"error","payload":{"code":"bad_request"`)
+
+	// Ошибки ссылаются на id кадра клиента
+	sendFrame(t, user, "shout", "u-1", nil)
+	expect(t, user, `"type":"error","id":"u-1","payload":{"code":"unknown_type"`)
+	sendFrame(t, user, ReqMessage, "u-2", map[string]interface{}{"content": "hi", "color": "red"})
+	expect(t, user, `"type":"error","id":"u-2","payload":{"code":"bad_request","message":"Invalid payload: json: unknown field \"color\""`)
+	sendFrame(t, user, ReqMessage, "u-3", IncomingUserMessage{Content: "  "})
+	expect(t, user, `"id":"u-3","payload":{"code":"bad_request","message":"content is required"}`)
+	sendFrame(t, user, ReqMessage, "", IncomingUserMessage{Content: "no id"})
+	expect(t, user, `"message":"id is required for messages"`)
+	sendFrame(t, user, ReqTake, "u-4", ManagerCommand{UserID: 1})
+	expect(t, user, `"id":"u-4","payload":{"code":"forbidden"`)
+	sendFrame(t, manager, ReqTake, "m-1", ManagerCommand{})
+	expect(t, manager, `"id":"m-1","payload":{"code":"bad_request","message":"user_id is required"}`)
+
+	// Кадр больше прежних 512 байт проходит
+	long := strings.Repeat("a", 2000)
+	sendFrame(t, user, ReqMessage, "u-5", IncomingUserMessage{Content: long})
+	expect(t, user, `{"v":2,"type":"ack","id":"u-5","payload":{"message_id":1}}`)
+
+	// Повтор после обрыва не дублирует сообщение
+	sendFrame(t, user, ReqMessage, "u-5", IncomingUserMessage{Content: long})
+	expect(t, user, `{"v":2,"type":"ack","id":"u-5","payload":{"message_id":1,"duplicate":true}}`)
+	sendFrame(t, manager, ReqHistory, "m-2", nil)
+	expect(t, manager, `"id":"m-2","payload":{"code":"bad_request"`)
+
+	sendFrame(t, manager, ReqTake, "m-3", ManagerCommand{UserID: 1})
+	expect(t, manager, `"type":"ack","id":"m-3"`)
+	sendFrame(t, manager, ReqHistory, "m-4", HistoryRequest{UserID: 1})
+	page := expect(t, manager, `"type":"history","id":"m-4"`)
+	assert.Equal(t, 1, strings.Count(page, long), "сообщение сохранено один раз")
+}
@@ -0,0 +1,146 @@
+package chat
+
+import (
+	"time"
+
+	"github.com/ShopOnGO/ShopOnGO/pkg/logger"
+)
+
+// handleUserEvent — пользователь печатает или подтверждает ответы поддержки.
+func (h *Hub) handleUserEvent(req request, frameType string, ev ClientEvent) {
+	user := req.client
+	session, err := h.sessions.Get(user.ID)
+	if err != nil {
+		logger.Error("Chat session lookup failed", err)
+		req.fail(ErrCodeUnavailable, "Chat is temporarily unavailable")
+		return
+	}
+	managerID := uint(0)
+	if session != nil {
+		managerID = session.ManagerID
+	}
+
+	switch frameType {
+	case ReqTyping:
+		if managerID != 0 {
+			h.publish(managerChannel(managerID), frame(FrameTyping, Typing{UserID: user.ID, FromID: user.ID}))
+		}
+	case ReqDelivered, ReqRead:
+		receipt, ok := h.markMessages(req, frameType, user.ID, false, ev.MessageID)
+		if !ok {
+			return
+		}
+		if managerID != 0 {
+			h.publish(managerChannel(managerID), frame(FrameReceipt, receipt))
+		}
+		if frameType == ReqRead {
+			// Счетчик на остальных устройствах пользователя
+			h.publishUserUnread(user.ID)
+		}
+	}
+	req.ack(Ack{})
+}
+
+// handleManagerEvent — менеджер печатает или подтверждает сообщения пользователя,
+// которого он ведет.
+func (h *Hub) handleManagerEvent(req request, frameType string, ev ClientEvent) {
+	manager := req.client
+	session, err := h.sessions.Get(ev.UserID)
+	if err != nil {
+		logger.Error("Chat session lookup failed", err)
+		req.fail(ErrCodeUnavailable, "Chat is temporarily unavailable")
+		return
+	}
+	if session == nil || session.ManagerID != manager.ID {
+		req.fail(ErrCodeNotFound, "No active session with this user")
+		return
+	}
+
+	switch frameType {
+	case ReqTyping:
+		h.publish(sessionChannel(ev.UserID), frame(FrameTyping, Typing{UserID: ev.UserID, FromID: manager.ID}))
+	case ReqDelivered, ReqRead:
+		receipt, ok := h.markMessages(req, frameType, ev.UserID, true, ev.MessageID)
+		if !ok {
+			return
+		}
+		h.publish(sessionChannel(ev.UserID), frame(FrameReceipt, receipt))
+		if frameType == ReqRead {
+			h.publishManagerUnread(manager.ID)
+		}
+	}
+	req.ack(Ack{})
+}
+
+// markMessages сохраняет отметку о доставке или прочтении сообщений переписки
+// с userID до upTo включительно.
+func (h *Hub) markMessages(req request, frameType string, userID uint, fromUser bool, upTo uint) (Receipt, bool) {
+	now := time.Now()
+	mark := h.ChatRepository.MarkDelivered
+	if frameType == ReqRead {
+		mark = h.ChatRepository.MarkRead
+	}
+	if err := mark(userID, fromUser, upTo, now); err != nil {
+		logger.Errorf("❌ Chat: failed to mark messages %s: %v", frameType, err)
+		req.fail(ErrCodeUnavailable, "Chat is temporarily unavailable")
+		return Receipt{}, false
+	}
+	return Receipt{Status: frameType, UserID: userID, FromID: req.client.ID, UpTo: upTo, At: now}, true
+}
+
+// userUnread — непрочитанные ответы поддержки у пользователя.
+func (h *Hub) userUnread(userID uint) (*UnreadSummary, error) {
+	count, err := h.ChatRepository.UnreadForUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	return &UnreadSummary{Total: count, Conversations: []UnreadCount{{UserID: userID, Unread: count}}}, nil
+}
+
+// managerUnread — непрочитанные менеджером сообщения по его текущим пользователям.
+func (h *Hub) managerUnread(managerID uint) (*UnreadSummary, error) {
+	counts, err := h.ChatRepository.UnreadForManager(managerID)
+	if err != nil {
+		return nil, err
+	}
+	summary := &UnreadSummary{Conversations: []UnreadCount{}}
+	for _, c := range counts {
+		summary.Total += c.Unread
+		summary.Conversations = append(summary.Conversations, c)
+	}
+	return summary, nil
+}
+
+func (h *Hub) publishUserUnread(userID uint) {
+	summary, err := h.userUnread(userID)
+	if err != nil {
+		logger.Error("Chat unread count failed", err)
+		return
+	}
+	h.publish(sessionChannel(userID), frame(FrameUnread, summary))
+}
+
+func (h *Hub) publishManagerUnread(managerID uint) {
+	summary, err := h.managerUnread(managerID)
+	if err != nil {
+		logger.Error("Chat unread count failed", err)
+		return
+	}
+	h.publish(managerChannel(managerID), frame(FrameUnread, summary))
+}
+
+// sendUnread отправляет счетчики только что подключившемуся клиенту.
+func (h *Hub) sendUnread(c *Client) {
+	var summary *UnreadSummary
+	var err error
+	if c.IsManager {
+		summary, err = h.managerUnread(c.ID)
+	} else {
+		summary, err = h.userUnread(c.ID)
+	}
+	if err != nil {
+		logger.Error("Chat unread count failed", err)
+		return
+	}
+	safeSend(c, frame(FrameUnread, summary))
+}
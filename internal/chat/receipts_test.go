@@ -0,0 +1,58 @@
+package chat
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReceiptsTypingAndUnread(t *testing.T) {
+	c := newCluster(t, 2)
+
+	user := c.dial(t, 0, 1, "buyer")
+	expect(t, user, `{"v":2,"type":"unread","payload":{"total":0,`)
+	manager := c.dial(t, 1, 100, "manager")
+
+	send(t, user, ReqMessage, IncomingUserMessage{Content: "hello"})
+	expect(t, user, `{"v":2,"type":"message","payload":{"id":1,`)
+	send(t, manager, ReqTake, ManagerCommand{UserID: 1})
+	expect(t, manager, `"type":"unread","payload":{"total":1,"conversations":[{"user_id":1,"unread":1}]}`)
+
+	// Набор текста виден собеседнику на другой реплике
+	send(t, manager, ReqTyping, ClientEvent{UserID: 1})
+	expect(t, user, `"type":"typing","payload":{"user_id":1,"from_id":100}`)
+	send(t, user, ReqTyping, ClientEvent{})
+	expect(t, manager, `"type":"typing","payload":{"user_id":1,"from_id":1}`)
+
+	// Менеджер прочитал — пользователь видит отметку, счетчик менеджера обнулился
+	send(t, manager, ReqRead, ClientEvent{UserID: 1, MessageID: 1})
+	expect(t, user, `"type":"receipt","payload":{"status":"read","user_id":1,"from_id":100,"up_to":1`)
+	expect(t, manager, `"type":"unread","payload":{"total":0`)
+	read := c.repo.message(1)
+	require.NotNil(t, read.ReadAt)
+	assert.NotNil(t, read.DeliveredAt, "прочитанное считается доставленным")
+
+	// Ответ поддержки увеличивает счетчик пользователя, в том числе для REST
+	send(t, manager, ReqMessage, ManagerMessage{UserID: 1, Content: "hi!"})
+	expect(t, user, `"type":"unread","payload":{"total":1`)
+	summary, err := c.services[0].Unread(1, "buyer")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), summary.Total)
+
+	send(t, user, ReqDelivered, ClientEvent{MessageID: 2})
+	expect(t, manager, `"type":"receipt","payload":{"status":"delivered","user_id":1,"from_id":1,"up_to":2`)
+	delivered := c.repo.message(2)
+	assert.NotNil(t, delivered.DeliveredAt)
+	assert.Nil(t, delivered.ReadAt)
+
+	send(t, user, ReqRead, ClientEvent{MessageID: 2})
+	expect(t, user, `"type":"unread","payload":{"total":0`)
+	summary, err = c.services[1].Unread(100, "manager")
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), summary.Total)
+
+	// Чужую переписку менеджер отметить не может
+	send(t, manager, ReqRead, ClientEvent{UserID: 2, MessageID: 2})
+	expect(t, manager, "No active session with this user")
+}
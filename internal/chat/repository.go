@@ -1,7 +1,12 @@
 package chat
 
 import (
+	"errors"
+	"time"
+
 	"github.com/ShopOnGO/ShopOnGO/pkg/db"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ChatRepository struct {
@@ -54,11 +59,16 @@ func (r *ChatRepository) GetLastMessages(userID uint, limit int) ([]*Message, er
 	return messages, nil
 }
 
+// GetMessagesBefore — до limit сообщений переписки пользователя (в обе стороны)
+// с ID меньше beforeMsgID, в хронологическом порядке. beforeMsgID = 0 — последние.
 func (r *ChatRepository) GetMessagesBefore(userID uint, beforeMsgID uint, limit int) ([]*Message, error) {
 	var messages []*Message
 
-	err := r.Database.DB.
-		Where("from_id = ? AND id < ?", userID, beforeMsgID).
+	query := r.Database.DB.Where("(from_id = ? OR to_id = ?)", userID, userID)
+	if beforeMsgID != 0 {
+		query = query.Where("id < ?", beforeMsgID)
+	}
+	err := query.
 		Order("id DESC").
 		Limit(limit).
 		Find(&messages).Error
@@ -74,3 +84,276 @@ func (r *ChatRepository) GetMessagesBefore(userID uint, beforeMsgID uint, limit
 
 	return messages, nil
 }
+
+// FindOpenSession возвращает незакрытую сессию пользователя или nil, nil.
+func (r *ChatRepository) FindOpenSession(userID uint) (*ChatSession, error) {
+	var session ChatSession
+	err := r.Database.DB.
+		Where("user_id = ? AND status <> ?", userID, SessionClosed).
+		Take(&session).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// FindMessageByClientID ищет сообщение отправителя по id кадра клиента; nil, nil — не найдено.
+func (r *ChatRepository) FindMessageByClientID(fromID uint, clientID string) (*Message, error) {
+	var message Message
+	err := r.Database.DB.
+		Where("from_id = ? AND client_id = ?", fromID, clientID).
+		Take(&message).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &message, nil
+}
+
+// HasClosedSession сообщает, обращался ли пользователь в поддержку раньше.
+func (r *ChatRepository) HasClosedSession(userID uint) (bool, error) {
+	var count int64
+	err := r.Database.DB.Model(&ChatSession{}).
+		Where("user_id = ? AND status = ?", userID, SessionClosed).
+		Limit(1).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *ChatRepository) CreateSession(session *ChatSession) error {
+	return r.Database.DB.Create(session).Error
+}
+
+// UpdateSession меняет только переданные поля, в том числе нулевые (manager_id = 0).
+func (r *ChatRepository) UpdateSession(id uint, fields map[string]interface{}) error {
+	return r.Database.DB.Model(&ChatSession{}).Where("id = ?", id).Updates(fields).Error
+}
+
+// OpenSessions — все незакрытые сессии, по ним восстанавливается состояние хаба.
+func (r *ChatRepository) OpenSessions() ([]ChatSession, error) {
+	var sessions []ChatSession
+	err := r.Database.DB.
+		Where("status <> ?", SessionClosed).
+		Order("id").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+// MarkDelivered отмечает доставленными сообщения переписки с пользователем userID
+// до upTo включительно: от пользователя (fromUser) или ему.
+func (r *ChatRepository) MarkDelivered(userID uint, fromUser bool, upTo uint, at time.Time) error {
+	return r.conversation(userID, fromUser, upTo).
+		Where("delivered_at IS NULL").
+		Update("delivered_at", at).Error
+}
+
+// MarkRead отмечает сообщения прочитанными; прочитанное считается и доставленным.
+func (r *ChatRepository) MarkRead(userID uint, fromUser bool, upTo uint, at time.Time) error {
+	return r.conversation(userID, fromUser, upTo).
+		Where("read_at IS NULL").
+		Updates(map[string]interface{}{
+			"read_at":      at,
+			"delivered_at": gorm.Expr("COALESCE(delivered_at, ?)", at),
+		}).Error
+}
+
+func (r *ChatRepository) conversation(userID uint, fromUser bool, upTo uint) *gorm.DB {
+	query := r.Database.DB.Model(&Message{}).Where("id <= ?", upTo)
+	if fromUser {
+		return query.Where("from_id = ?", userID)
+	}
+	return query.Where("to_id = ?", userID)
+}
+
+// UnreadForUser — непрочитанные пользователем ответы поддержки.
+func (r *ChatRepository) UnreadForUser(userID uint) (int64, error) {
+	var count int64
+	err := r.Database.DB.Model(&Message{}).
+		Where("to_id = ? AND read_at IS NULL", userID).
+		Count(&count).Error
+	return count, err
+}
+
+// UnreadForManager — непрочитанные менеджером сообщения по каждому пользователю,
+// которого он сейчас ведет (включая написанные, пока пользователь ждал в очереди).
+func (r *ChatRepository) UnreadForManager(managerID uint) ([]UnreadCount, error) {
+	var counts []UnreadCount
+	err := r.Database.DB.Model(&Message{}).
+		Select("messages.from_id AS user_id, COUNT(*) AS unread").
+		Joins("JOIN chat_sessions ON chat_sessions.id = messages.session_id").
+		Where("chat_sessions.manager_id = ? AND chat_sessions.status = ?", managerID, SessionActive).
+		Where("messages.from_id = chat_sessions.user_id AND messages.read_at IS NULL").
+		Group("messages.from_id").
+		Order("messages.from_id").
+		Scan(&counts).Error
+	return counts, err
+}
+
+func (r *ChatRepository) CreateAttachment(attachment *Attachment) error {
+	return r.Database.DB.Create(attachment).Error
+}
+
+// FindAttachment ищет вложение по URL среди загруженных пользователем ownerID; nil, nil — не найдено.
+func (r *ChatRepository) FindAttachment(ownerID uint, url string) (*Attachment, error) {
+	var attachment Attachment
+	err := r.Database.DB.
+		Where("owner_id = ? AND url = ?", ownerID, url).
+		Take(&attachment).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+// messageVector — выражение индекса idx_messages_content_fts (см. migrations). Запрос должен
+// повторять его дословно, с той же конфигурацией, иначе индекс не используется.
+const messageVector = "to_tsvector('russian', messages.content)"
+
+// SearchMessages ищет сообщения по тексту (Postgres FTS), пользователю, менеджеру и периоду.
+// С запросом сначала идут самые релевантные, без него — самые новые.
+func (r *ChatRepository) SearchMessages(filter SearchFilter) ([]SearchHit, error) {
+	columns := "messages.*, COALESCE(chat_sessions.user_id, 0) AS user_id, COALESCE(chat_sessions.manager_id, 0) AS manager_id"
+	query := r.Database.DB.Table("messages").
+		Joins("LEFT JOIN chat_sessions ON chat_sessions.id = messages.session_id")
+
+	if filter.UserID != 0 {
+		query = query.Where("(messages.from_id = ? OR messages.to_id = ?)", filter.UserID, filter.UserID)
+	}
+	if filter.ManagerID != 0 {
+		query = query.Where("(chat_sessions.manager_id = ? OR messages.from_id = ?)", filter.ManagerID, filter.ManagerID)
+	}
+	if filter.From != nil {
+		query = query.Where("messages.created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("messages.created_at < ?", *filter.To)
+	}
+
+	if filter.Query == "" {
+		query = query.Select(columns)
+	} else {
+		tsQuery := gorm.Expr("websearch_to_tsquery('russian', ?)", filter.Query)
+		query = query.
+			Select(columns+", ts_headline('russian', messages.content, ?, 'MaxFragments=2, StartSel=«, StopSel=»') AS snippet", tsQuery).
+			Where(messageVector+" @@ ?", tsQuery).
+			Order(gorm.Expr("ts_rank("+messageVector+", ?) DESC", tsQuery))
+	}
+
+	var hits []SearchHit
+	err := query.
+		Order("messages.id DESC").
+		Limit(filter.Limit).
+		Offset(filter.Offset).
+		Scan(&hits).Error
+	return hits, err
+}
+
+// TranscriptMessages — переписка с пользователем (в обе стороны) в хронологическом порядке.
+func (r *ChatRepository) TranscriptMessages(userID uint, filter TranscriptFilter) ([]*Message, error) {
+	query := r.Database.DB.Where("(from_id = ? OR to_id = ?)", userID, userID)
+	if filter.SessionID != 0 {
+		query = query.Where("session_id = ?", filter.SessionID)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+
+	var messages []*Message
+	err := query.Order("id").Find(&messages).Error
+	return messages, err
+}
+
+// ListCanned — личные заготовки менеджера и все общие.
+func (r *ChatRepository) ListCanned(ownerID uint) ([]CannedResponse, error) {
+	var responses []CannedResponse
+	err := r.Database.DB.
+		Where("owner_id = ? OR shared", ownerID).
+		Order("shared, title, id").
+		Find(&responses).Error
+	return responses, err
+}
+
+// FindCanned ищет заготовку по ID; nil, nil — не найдена.
+func (r *ChatRepository) FindCanned(id uint) (*CannedResponse, error) {
+	var response CannedResponse
+	err := r.Database.DB.Take(&response, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+func (r *ChatRepository) CreateCanned(response *CannedResponse) error {
+	return r.Database.DB.Create(response).Error
+}
+
+func (r *ChatRepository) UpdateCanned(response *CannedResponse) error {
+	return r.Database.DB.Save(response).Error
+}
+
+func (r *ChatRepository) DeleteCanned(id uint) error {
+	return r.Database.DB.Delete(&CannedResponse{}, id).Error
+}
+
+// FindSession ищет сессию по ID; nil, nil — не найдена.
+func (r *ChatRepository) FindSession(id uint) (*ChatSession, error) {
+	var session ChatSession
+	err := r.Database.DB.Take(&session, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// CreateRating сохраняет оценку; false — сессия уже оценена, оценка не изменилась.
+func (r *ChatRepository) CreateRating(rating *ChatRating) (bool, error) {
+	result := r.Database.DB.
+		Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "session_id"}}, DoNothing: true}).
+		Create(rating)
+	return result.RowsAffected > 0, result.Error
+}
+
+// ManagerReport — показатели менеджеров по сессиям, закрытым в [From, To), с разбивкой
+// по периодам. Первый ответ и решение отсчитываются от первого сообщения пользователя в сессии.
+func (r *ChatRepository) ManagerReport(filter ReportFilter) ([]ManagerReport, error) {
+	var reports []ManagerReport
+	err := r.Database.DB.Raw(`
+		WITH closed AS (
+			SELECT s.id, s.manager_id, s.closed_at,
+				(SELECT MIN(m.created_at) FROM messages m WHERE m.session_id = s.id AND m.from_id = s.user_id) AS first_user_at,
+				(SELECT MIN(m.created_at) FROM messages m WHERE m.session_id = s.id AND m.from_id <> s.user_id) AS first_reply_at
+			FROM chat_sessions s
+			WHERE s.status = ? AND s.manager_id <> 0 AND s.closed_at >= ? AND s.closed_at < ?
+		)
+		SELECT date_trunc(?, c.closed_at) AS period,
+			c.manager_id,
+			COUNT(*) AS sessions,
+			COUNT(r.id) AS ratings,
+			AVG(r.score) AS avg_score,
+			100.0 * COUNT(*) FILTER (WHERE r.score >= 4) / NULLIF(COUNT(r.id), 0) AS csat,
+			AVG(EXTRACT(EPOCH FROM c.first_reply_at - c.first_user_at)) FILTER (WHERE c.first_reply_at >= c.first_user_at) AS avg_first_response_seconds,
+			AVG(EXTRACT(EPOCH FROM c.closed_at - c.first_user_at)) AS avg_resolution_seconds
+		FROM closed c
+		LEFT JOIN chat_ratings r ON r.session_id = c.id
+		GROUP BY 1, 2
+		ORDER BY 1, 2
+	`, SessionClosed, filter.From, filter.To, filter.Period).Scan(&reports).Error
+	return reports, err
+}
@@ -0,0 +1,191 @@
+package chat
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ShopOnGO/ShopOnGO/configs"
+	"github.com/ShopOnGO/ShopOnGO/pkg/logger"
+)
+
+// dispatchInterval — как часто реплика сама пересматривает очередь.
+const dispatchInterval = 5 * time.Second
+
+// routingManager — менеджер онлайн глазами раздачи.
+type routingManager struct {
+	id     uint
+	load   int64
+	skills map[string]bool
+}
+
+// priorityFor определяет приоритет новой сессии: продавцы обслуживаются первыми,
+// затем вернувшиеся покупатели.
+func (h *Hub) priorityFor(c *Client) int {
+	if c.Role == "seller" {
+		return PrioritySeller
+	}
+	returning, err := h.ChatRepository.HasClosedSession(c.ID)
+	if err != nil {
+		logger.Errorf("❌ Chat: failed to check previous sessions of user %d: %v", c.ID, err)
+	}
+	if returning {
+		return PriorityReturning
+	}
+	return PriorityRegular
+}
+
+// normalizeTag приводит тему или навык к одному виду.
+func normalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
+
+// setSkills задает темы, по которым менеджеру назначаются обращения.
+func (h *Hub) setSkills(req request, tags []string) {
+	var skills []string
+	for _, tag := range tags {
+		if tag = normalizeTag(tag); tag != "" {
+			skills = append(skills, tag)
+		}
+	}
+	if err := h.sessions.SetSkills(req.client.ID, skills); err != nil {
+		logger.Error("Chat skills update failed", err)
+		req.fail(ErrCodeUnavailable, "Chat is temporarily unavailable")
+		return
+	}
+	req.respond("Skills updated.", skills)
+	h.dispatch()
+}
+
+// dispatch раздает очередь менеджерам онлайн (если включено автоназначение) и
+// сообщает оставшимся пользователям их место. Реплики могут раздавать одновременно:
+// назначение атомарно в Redis, и пользователь достанется только одному менеджеру.
+func (h *Hub) dispatch() {
+	h.dispatchMu.Lock()
+	defer h.dispatchMu.Unlock()
+
+	waiting, err := h.sessions.Waiting()
+	if err != nil {
+		logger.Error("Chat queue read failed", err)
+		return
+	}
+	if len(waiting) == 0 {
+		return
+	}
+
+	var managers []*routingManager
+	if h.routing.AutoAssign {
+		managers = h.onlineManagers()
+	}
+
+	position := 0
+	for _, userID := range waiting {
+		session, err := h.sessions.Get(userID)
+		if err != nil || session == nil {
+			continue
+		}
+		if manager := h.pickManager(managers, session.Topic); manager != nil {
+			ok, err := h.sessions.Assign(userID, manager.id, h.routing.MaxSessionsPerManager)
+			if err != nil {
+				logger.Error("Chat auto-assign failed", err)
+			}
+			if ok {
+				manager.load++
+				session.ManagerID = manager.id
+				h.startSession(manager.id, session, true)
+				continue
+			}
+			// Пользователя забрали с другой реплики или менеджер занят — обновим нагрузку
+			if load, err := h.sessions.Load(manager.id); err == nil {
+				manager.load = load
+			}
+			if still, _ := h.sessions.Get(userID); still == nil || still.ManagerID != 0 {
+				continue
+			}
+		}
+
+		position++
+		changed, err := h.sessions.SetPosition(userID, position)
+		if err != nil {
+			logger.Error("Chat queue position update failed", err)
+			continue
+		}
+		if changed {
+			h.publish(sessionChannel(userID), response("success", "Queue position", QueuePosition{Position: position}))
+		}
+	}
+}
+
+// onlineManagers собирает нагрузку и навыки менеджеров онлайн.
+func (h *Hub) onlineManagers() []*routingManager {
+	ids, err := h.sessions.OnlineManagers()
+	if err != nil {
+		logger.Error("Chat managers read failed", err)
+		return nil
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	managers := make([]*routingManager, 0, len(ids))
+	for _, id := range ids {
+		load, err := h.sessions.Load(id)
+		if err != nil {
+			continue
+		}
+		tags, err := h.sessions.Skills(id)
+		if err != nil {
+			continue
+		}
+		skills := make(map[string]bool, len(tags))
+		for _, tag := range tags {
+			skills[tag] = true
+		}
+		managers = append(managers, &routingManager{id: id, load: load, skills: skills})
+	}
+	return managers
+}
+
+// pickManager выбирает менеджера для обращения с темой topic. Сначала — менеджеры
+// с этим навыком, если их нет или все заняты — менеджеры общего профиля.
+func (h *Hub) pickManager(managers []*routingManager, topic string) *routingManager {
+	var skilled, general []*routingManager
+	for _, m := range managers {
+		if h.routing.MaxSessionsPerManager > 0 && m.load >= int64(h.routing.MaxSessionsPerManager) {
+			continue
+		}
+		switch {
+		case topic != "" && m.skills[topic]:
+			skilled = append(skilled, m)
+		case len(m.skills) == 0:
+			general = append(general, m)
+		case topic == "":
+			// Обращение без темы может взять любой менеджер
+			general = append(general, m)
+		}
+	}
+
+	candidates := skilled
+	if len(candidates) == 0 {
+		candidates = general
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	if h.routing.AssignStrategy == configs.ChatAssignRoundRobin {
+		n, err := h.sessions.NextRoundRobin()
+		if err != nil {
+			logger.Error("Chat round-robin counter failed", err)
+			return candidates[0]
+		}
+		return candidates[int(n%int64(len(candidates)))]
+	}
+
+	// least_busy: меньше всего сессий, при равенстве — меньший ID
+	best := candidates[0]
+	for _, m := range candidates[1:] {
+		if m.load < best.load {
+			best = m
+		}
+	}
+	return best
+}
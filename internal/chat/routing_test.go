@@ -0,0 +1,96 @@
+package chat
+
+import (
+	"testing"
+
+	"github.com/ShopOnGO/ShopOnGO/configs"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAutoAssignRespectsCapacity(t *testing.T) {
+	c := newRoutingCluster(t, 2, configs.ChatConfig{
+		AutoAssign:            true,
+		AssignStrategy:        configs.ChatAssignLeastBusy,
+		MaxSessionsPerManager: 1,
+	})
+
+	first := c.dial(t, 0, 100, "manager")
+	second := c.dial(t, 1, 200, "manager")
+	users := []uint{1, 2, 3}
+	conns := make(map[uint]*websocket.Conn)
+	for _, id := range users {
+		conns[id] = c.dial(t, int(id)%2, id, "buyer")
+	}
+
+	// Менеджеры свободны: первые двое распределяются по одному на каждого
+	send(t, conns[1], ReqMessage, IncomingUserMessage{Content: "first"})
+	expect(t, first, `"user_id":1,`)
+	expect(t, conns[1], "A manager has joined your chat.")
+	send(t, conns[2], ReqMessage, IncomingUserMessage{Content: "second"})
+	expect(t, second, `"user_id":2,`)
+
+	// Оба заняты — третий ждет и знает свое место
+	send(t, conns[3], ReqMessage, IncomingUserMessage{Content: "third"})
+	expect(t, conns[3], `"payload":{"position":1}`)
+	assert.Equal(t, SessionWaiting, c.repo.session(3).Status)
+
+	// Место освободилось — очередь разбирается сама
+	send(t, first, ReqClose, ManagerCommand{UserID: 1})
+	started := expect(t, first, "started with user 3")
+	assert.Contains(t, started, `"auto":true`)
+	assert.Equal(t, uint(100), c.repo.session(3).ManagerID)
+
+	// Менеджер ушел — его пользователь возвращается в очередь, а ручной take доступен
+	second.Close()
+	expect(t, conns[2], "Manager disconnected")
+	expect(t, conns[2], `"payload":{"position":1}`)
+	send(t, first, ReqTake, ManagerCommand{UserID: 2})
+	expect(t, first, "started with user 2")
+}
+
+func TestQueuePriority(t *testing.T) {
+	c := newCluster(t, 1)
+	c.repo.closedSession(2)
+
+	regular := c.dial(t, 0, 1, "buyer")
+	returning := c.dial(t, 0, 2, "buyer")
+	seller := c.dial(t, 0, 3, "seller")
+	manager := c.dial(t, 0, 100, "manager")
+
+	send(t, regular, ReqMessage, IncomingUserMessage{Content: "regular"})
+	expect(t, regular, `"content":"regular"`)
+	send(t, returning, ReqMessage, IncomingUserMessage{Content: "returning"})
+	expect(t, returning, `"content":"returning"`)
+	send(t, seller, ReqMessage, IncomingUserMessage{Content: "seller"})
+	expect(t, seller, `"content":"seller"`)
+
+	// Продавцы первыми, затем вернувшиеся покупатели, внутри — по времени
+	send(t, manager, ReqList, nil)
+	expect(t, manager, `"payload":[3,2,1]`)
+	expect(t, regular, `"payload":{"position":3}`)
+}
+
+func TestRoutingBySkills(t *testing.T) {
+	c := newRoutingCluster(t, 1, configs.ChatConfig{
+		AutoAssign:            true,
+		AssignStrategy:        configs.ChatAssignRoundRobin,
+		MaxSessionsPerManager: 5,
+	})
+
+	payments := c.dial(t, 0, 100, "manager")
+	general := c.dial(t, 0, 200, "manager")
+	send(t, payments, ReqSkills, ManagerCommand{Tags: []string{" Payments "}})
+	expect(t, payments, "Skills updated.")
+
+	user := c.dial(t, 0, 1, "buyer")
+	send(t, user, ReqMessage, IncomingUserMessage{Content: "card declined", Topic: "payments"})
+	started := expect(t, payments, "started with user 1")
+	assert.Contains(t, started, `"topic":"payments"`)
+
+	// Темы, которую никто не ведет, достаются менеджерам общего профиля
+	other := c.dial(t, 0, 2, "buyer")
+	send(t, other, ReqMessage, IncomingUserMessage{Content: "where is my parcel", Topic: "delivery"})
+	expect(t, general, "started with user 2")
+	assert.Equal(t, "delivery", c.repo.session(2).Topic)
+}
@@ -1,10 +1,12 @@
 package chat
 
 import (
-	"fmt"
-	"log"
 	"net/http"
 
+	"github.com/ShopOnGO/ShopOnGO/configs"
+	"github.com/ShopOnGO/ShopOnGO/internal/rbac"
+	"github.com/ShopOnGO/ShopOnGO/pkg/logger"
+	"github.com/ShopOnGO/ShopOnGO/pkg/media"
 	"github.com/ShopOnGO/ShopOnGO/pkg/middleware"
 	"github.com/gorilla/websocket"
 )
@@ -15,26 +17,68 @@ var upgrader = websocket.Upgrader{
 	CheckOrigin: func(r *http.Request) bool {
 		return true // Разрешаем все подключения (пока)
 	},
+	Subprotocols: subprotocols(),
 }
 
 type ChatService struct {
-	hub  *Hub
-	repo *ChatRepository
+	hub           *Hub
+	repo          Repository
+	permissions   middleware.PermissionChecker
+	audit         AuditRecorder
+	media         Uploader
+	scanner       media.Scanner
+	maxUploadSize int64
 }
 
-func NewChatService(repo *ChatRepository) *ChatService {
-	hub := NewHub(repo)
+type ChatServiceDeps struct {
+	Config         configs.ChatConfig
+	ChatRepository Repository
+	Sessions       *RedisSessionStore
+	Broker         *RedisBroker
+	Permissions    middleware.PermissionChecker
+	Users          UserFinder
+	Audit          AuditRecorder // журнал поиска и выгрузки стенограмм
+	Media          Uploader
+	Scanner        media.Scanner // nil — без антивирусной проверки
+	MaxUploadSize  int64         // 0 — defaultMaxUploadSize
+}
+
+func NewChatService(deps ChatServiceDeps) *ChatService {
+	hub := NewHub(deps.ChatRepository, deps.Sessions, deps.Broker, deps.Config, deps.Users)
+	if err := hub.Restore(); err != nil {
+		logger.Errorf("❌ Chat: failed to restore sessions: %v", err)
+	}
 	go hub.Run()
-	return &ChatService{
-		hub:  hub,
-		repo: repo,
+	service := &ChatService{
+		hub:           hub,
+		repo:          deps.ChatRepository,
+		permissions:   deps.Permissions,
+		audit:         deps.Audit,
+		media:         deps.Media,
+		scanner:       deps.Scanner,
+		maxUploadSize: deps.MaxUploadSize,
+	}
+	if service.scanner == nil {
+		service.scanner = media.NopScanner{}
 	}
+	if service.maxUploadSize <= 0 {
+		service.maxUploadSize = defaultMaxUploadSize
+	}
+	return service
 }
 
 func (c *ChatService) ServeWS(w http.ResponseWriter, r *http.Request) {
+	// Версию протокола выбираем до апгрейда, чтобы отказать обычным HTTP-ответом
+	version, ok := negotiateVersion(websocket.Subprotocols(r))
+	if !ok {
+		http.Error(w, "unsupported chat protocol version", http.StatusBadRequest)
+		return
+	}
+
 	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
-		log.Fatal(err)
+		// Upgrade уже ответил клиенту ошибкой, сервер продолжает работу
+		logger.Errorf("❌ Chat: websocket upgrade failed: %v", err)
 		return
 	}
 
@@ -51,13 +95,38 @@ func (c *ChatService) ServeWS(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "unauthorized", http.StatusUnauthorized)
 		return
 	}
-	fmt.Println(role)
-	isManager := (role == "manager")
-	fmt.Println(isManager)
+	isManager := c.permissions.HasPermission(role, rbac.PermChatManage)
 	// Создаем клиента
-	client := NewClient(conn, c.hub, userID, isManager)
+	client := NewClient(conn, c.hub, userID, role, isManager, version)
+	// hello — первый кадр соединения
+	safeSend(client, frame(FrameHello, Hello{
+		Version:       version,
+		UserID:        userID,
+		Manager:       isManager,
+		MaxFrameBytes: maxFrameBytes,
+	}))
 	c.hub.register <- client
 	// Запуск горутин для обработки сообщений
 	go client.ReadPump()
 	go client.WritePump()
 }
+
+// DisconnectUser закрывает все WebSocket-соединения пользователя (например, после блокировки).
+func (c *ChatService) DisconnectUser(userID uint) {
+	c.hub.DisconnectUser(userID)
+}
+
+// Unread — непрочитанные сообщения для значка в шапке сайта: у покупателя — ответы
+// поддержки, у менеджера — сообщения пользователей, которых он ведет.
+func (c *ChatService) Unread(userID uint, role string) (*UnreadSummary, error) {
+	if c.permissions.HasPermission(role, rbac.PermChatManage) {
+		return c.hub.managerUnread(userID)
+	}
+	return c.hub.userUnread(userID)
+}
+
+// History — страница переписки для REST. Менеджер получает историю пользователя,
+// которого ведет (userID), покупатель — свою.
+func (c *ChatService) History(requesterID uint, role string, req HistoryRequest) (*HistoryPage, error) {
+	return c.hub.history(requesterID, c.permissions.HasPermission(role, rbac.PermChatManage), req)
+}
@@ -0,0 +1,420 @@
+package chat
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ShopOnGO/ShopOnGO/pkg/redisdb"
+	"github.com/go-redis/redis/v8"
+)
+
+// Session — чат пользователя с поддержкой. У пользователя одна сессия;
+// ManagerID == 0, пока ее не взял менеджер.
+type Session struct {
+	ID        uint
+	UserID    uint
+	ManagerID uint
+	Priority  int       // см. PriorityRegular и далее
+	Topic     string    // тема обращения для маршрутизации по навыкам
+	QueuedAt  time.Time // первая постановка в очередь; при возврате в очередь место сохраняется
+}
+
+const (
+	waitingKey    = "chat:waiting"  // ZSET: пользователь -> приоритет и время в очереди
+	replicasKey   = "chat:replicas" // SET реплик, у которых есть соединения
+	connsPrefix   = "chat:conns:"   // HASH chat:conns:<реплика>: "manager:<id>"/"user:<id>" -> число соединений
+	roundRobinKey = "chat:round_robin"
+)
+
+// Соединения учитываются по репликам: хеш реплики живет presenceTTL и продлевается
+// heartbeat-ом, поэтому после падения реплики ее соединения пропадают из учета сами.
+const (
+	presenceTTL       = 30 * time.Second
+	heartbeatInterval = 10 * time.Second
+)
+
+// restoreScript кладет сессию из базы в Redis, если ее там еще нет: при гонке
+// двух реплик обе получат одну и ту же сессию.
+var restoreScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 1 then
+	return 0
+end
+redis.call('HSET', KEYS[1], 'id', ARGV[1], 'manager_id', ARGV[2], 'priority', ARGV[4], 'topic', ARGV[5])
+if ARGV[2] ~= '0' then
+	redis.call('SADD', KEYS[2], ARGV[3])
+end
+return 1
+`)
+
+// enqueueScript ставит пользователя в очередь, если его там нет, и запоминает
+// время постановки. Повторная постановка не сдвигает место.
+var enqueueScript = redis.NewScript(`
+local added = redis.call('ZADD', KEYS[1], 'NX', ARGV[2], ARGV[1])
+if added == 1 then
+	redis.call('HSET', KEYS[2], 'queued_at', ARGV[3])
+end
+return added
+`)
+
+// assignScript отдает пользователя менеджеру, только если он еще в очереди и у менеджера
+// меньше ARGV[3] сессий (0 — без предела): из двух реплик, назначающих одного
+// пользователя, выигрывает одна.
+var assignScript = redis.NewScript(`
+local limit = tonumber(ARGV[3])
+if limit > 0 and redis.call('SCARD', KEYS[3]) >= limit then
+	return 0
+end
+if redis.call('ZREM', KEYS[1], ARGV[1]) == 0 then
+	return 0
+end
+redis.call('HSET', KEYS[2], 'manager_id', ARGV[2])
+redis.call('HDEL', KEYS[2], 'position')
+redis.call('SADD', KEYS[3], ARGV[1])
+return 1
+`)
+
+// closeScript удаляет сессию из Redis, только если ее ведет этот менеджер.
+var closeScript = redis.NewScript(`
+if redis.call('HGET', KEYS[1], 'manager_id') ~= ARGV[2] then
+	return 0
+end
+redis.call('DEL', KEYS[1])
+redis.call('SREM', KEYS[2], ARGV[1])
+return 1
+`)
+
+// releaseScript отвязывает менеджера, только если сессию ведет именно он.
+var releaseScript = redis.NewScript(`
+if redis.call('HGET', KEYS[1], 'manager_id') ~= ARGV[2] then
+	return 0
+end
+redis.call('HSET', KEYS[1], 'manager_id', 0)
+redis.call('SREM', KEYS[2], ARGV[1])
+return 1
+`)
+
+// presenceScript меняет счетчик соединений на этой реплике (KEYS[1]) и возвращает
+// сумму по всем живым репликам. Реплики, чей хеш истек, убираются из KEYS[2].
+var presenceScript = redis.NewScript(`
+local n = redis.call('HINCRBY', KEYS[1], ARGV[1], ARGV[2])
+if n <= 0 then
+	redis.call('HDEL', KEYS[1], ARGV[1])
+end
+if redis.call('EXISTS', KEYS[1]) == 1 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[3])
+	redis.call('SADD', KEYS[2], ARGV[4])
+end
+local total = 0
+for _, replica in ipairs(redis.call('SMEMBERS', KEYS[2])) do
+	local key = ARGV[5] .. replica
+	if redis.call('EXISTS', key) == 0 then
+		redis.call('SREM', KEYS[2], replica)
+	else
+		total = total + tonumber(redis.call('HGET', key, ARGV[1]) or '0')
+	end
+end
+return total
+`)
+
+// heartbeatScript продлевает учет соединений реплики, пока они у нее есть.
+var heartbeatScript = redis.NewScript(`
+if redis.call('PEXPIRE', KEYS[1], ARGV[1]) == 1 then
+	redis.call('SADD', KEYS[2], ARGV[2])
+end
+return 0
+`)
+
+// positionScript запоминает место в очереди и сообщает, изменилось ли оно.
+var positionScript = redis.NewScript(`
+if redis.call('HGET', KEYS[1], 'position') == ARGV[1] then
+	return 0
+end
+redis.call('HSET', KEYS[1], 'position', ARGV[1])
+return 1
+`)
+
+// RedisSessionStore хранит текущие сессии чата, очередь ожидания и счетчики
+// соединений, общие для всех реплик. Это рабочая копия: сессии создаются
+// и закрываются в chat_sessions, а после потери Redis восстанавливаются оттуда.
+type RedisSessionStore struct {
+	redis   *redisdb.RedisDB
+	replica string // имя этой реплики в учете соединений
+}
+
+// NewRedisSessionStore создает хранилище для реплики replica. Имя должно переживать
+// рестарт (например, имя пода), чтобы при старте реплика сбросила свой старый учет;
+// пустое имя заменяется случайным.
+func NewRedisSessionStore(r *redisdb.RedisDB, replica string) *RedisSessionStore {
+	if replica == "" {
+		b := make([]byte, 8)
+		_, _ = rand.Read(b)
+		replica = hex.EncodeToString(b)
+	}
+	return &RedisSessionStore{redis: r, replica: replica}
+}
+
+// Restore кладет сессию из базы в Redis и возвращает актуальную версию из Redis.
+func (s *RedisSessionStore) Restore(record *ChatSession) (*Session, error) {
+	keys := []string{s.sessionKey(record.UserID), s.managerKey(record.ManagerID)}
+	args := []interface{}{record.ID, record.ManagerID, record.UserID, record.Priority, record.Topic}
+	if err := restoreScript.Run(context.Background(), s.redis, keys, args...).Err(); err != nil {
+		return nil, err
+	}
+	return s.Get(record.UserID)
+}
+
+// Get возвращает nil, nil, если у пользователя нет открытой сессии.
+func (s *RedisSessionStore) Get(userID uint) (*Session, error) {
+	res, err := s.redis.HMGet(context.Background(), s.sessionKey(userID), "id", "manager_id", "priority", "topic", "queued_at").Result()
+	if err != nil {
+		return nil, err
+	}
+	field := func(i int) string {
+		value, _ := res[i].(string)
+		return value
+	}
+	if field(0) == "" {
+		return nil, nil
+	}
+	sessionID, err := strconv.ParseUint(field(0), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("bad chat session id %q: %w", field(0), err)
+	}
+	managerID, _ := strconv.ParseUint(field(1), 10, 64)
+	priority, _ := strconv.Atoi(field(2))
+	session := &Session{
+		ID:        uint(sessionID),
+		UserID:    userID,
+		ManagerID: uint(managerID),
+		Priority:  priority,
+		Topic:     field(3),
+	}
+	if queuedAt, err := strconv.ParseInt(field(4), 10, 64); err == nil {
+		session.QueuedAt = time.UnixMilli(queuedAt)
+	}
+	return session, nil
+}
+
+// SetTopic задает тему обращения для маршрутизации.
+func (s *RedisSessionStore) SetTopic(userID uint, topic string) error {
+	return s.redis.HSet(context.Background(), s.sessionKey(userID), "topic", topic).Err()
+}
+
+// Enqueue ставит пользователя в очередь ожидания. Очередь упорядочена по приоритету,
+// внутри приоритета — по времени. true — пользователь только что встал в очередь.
+func (s *RedisSessionStore) Enqueue(userID uint, since time.Time, priority int) (bool, error) {
+	keys := []string{waitingKey, s.sessionKey(userID)}
+	return runFlag(enqueueScript, s.redis, keys, userID, queueScore(priority, since), since.UnixMilli())
+}
+
+// queueScore: меньше — раньше. Приоритет важнее времени ожидания.
+func queueScore(priority int, since time.Time) float64 {
+	if priority < PriorityRegular {
+		priority = PriorityRegular
+	}
+	if priority > PriorityHighest {
+		priority = PriorityHighest
+	}
+	const tier = 1e13 // больше любого времени в миллисекундах
+	return float64(PriorityHighest-priority)*tier + float64(since.UnixMilli())
+}
+
+// Waiting возвращает ожидающих пользователей в порядке очереди.
+func (s *RedisSessionStore) Waiting() ([]uint, error) {
+	members, err := s.redis.ZRange(context.Background(), waitingKey, 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+	return parseIDs(members), nil
+}
+
+// SetPosition запоминает место пользователя в очереди; true — место изменилось.
+func (s *RedisSessionStore) SetPosition(userID uint, position int) (bool, error) {
+	return runFlag(positionScript, s.redis, []string{s.sessionKey(userID)}, position)
+}
+
+// Assign отдает ожидающего пользователя менеджеру, если у того меньше limit сессий
+// (0 — без предела). false — пользователь не в очереди (уже взят другим менеджером
+// или ничего не писал) или менеджер занят.
+func (s *RedisSessionStore) Assign(userID, managerID uint, limit int) (bool, error) {
+	keys := []string{waitingKey, s.sessionKey(userID), s.managerKey(managerID)}
+	return runFlag(assignScript, s.redis, keys, userID, managerID, limit)
+}
+
+// Close завершает сессию: следующее сообщение пользователя откроет новую.
+// false — сессию ведет не этот менеджер.
+func (s *RedisSessionStore) Close(userID, managerID uint) (bool, error) {
+	keys := []string{s.sessionKey(userID), s.managerKey(managerID)}
+	return runFlag(closeScript, s.redis, keys, userID, managerID)
+}
+
+// Release отвязывает менеджера от сессии. false — сессию ведет не он.
+func (s *RedisSessionStore) Release(userID, managerID uint) (bool, error) {
+	keys := []string{s.sessionKey(userID), s.managerKey(managerID)}
+	return runFlag(releaseScript, s.redis, keys, userID, managerID)
+}
+
+// ReleaseAll отвязывает менеджера от всех его сессий и возвращает пользователей
+// в очередь на их прежнее место. Возвращает освобожденные сессии.
+func (s *RedisSessionStore) ReleaseAll(managerID uint) ([]*Session, error) {
+	members, err := s.redis.SMembers(context.Background(), s.managerKey(managerID)).Result()
+	if err != nil {
+		return nil, err
+	}
+	var released []*Session
+	for _, userID := range parseIDs(members) {
+		ok, err := s.Release(userID, managerID)
+		if err != nil {
+			return released, err
+		}
+		if !ok {
+			continue
+		}
+		session, err := s.Get(userID)
+		if err != nil || session == nil {
+			return released, err
+		}
+		since := session.QueuedAt
+		if since.IsZero() {
+			since = time.Now()
+		}
+		if _, err := s.Enqueue(userID, since, session.Priority); err != nil {
+			return released, err
+		}
+		session.ManagerID = 0
+		released = append(released, session)
+	}
+	return released, nil
+}
+
+// Load — число активных сессий менеджера.
+func (s *RedisSessionStore) Load(managerID uint) (int64, error) {
+	return s.redis.SCard(context.Background(), s.managerKey(managerID)).Result()
+}
+
+// OnlineManagers — менеджеры, подключенные хотя бы к одной живой реплике.
+func (s *RedisSessionStore) OnlineManagers() ([]uint, error) {
+	ctx := context.Background()
+	replicas, err := s.redis.SMembers(ctx, replicasKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	var members []string
+	for _, replica := range replicas {
+		conns, err := s.redis.HGetAll(ctx, connsPrefix+replica).Result()
+		if err != nil {
+			return nil, err
+		}
+		for field, count := range conns {
+			id := strings.TrimPrefix(field, "manager:")
+			if id == field || seen[id] || count == "0" {
+				continue
+			}
+			seen[id] = true
+			members = append(members, id)
+		}
+	}
+	return parseIDs(members), nil
+}
+
+// SetSkills заменяет навыки (темы) менеджера. Пустой список — менеджер общего профиля.
+func (s *RedisSessionStore) SetSkills(managerID uint, tags []string) error {
+	_, err := s.redis.TxPipelined(context.Background(), func(pipe redis.Pipeliner) error {
+		pipe.Del(context.Background(), s.skillsKey(managerID))
+		for _, tag := range tags {
+			pipe.SAdd(context.Background(), s.skillsKey(managerID), tag)
+		}
+		return nil
+	})
+	return err
+}
+
+func (s *RedisSessionStore) Skills(managerID uint) ([]string, error) {
+	return s.redis.SMembers(context.Background(), s.skillsKey(managerID)).Result()
+}
+
+// NextRoundRobin — общий для всех реплик счетчик для назначения по кругу.
+func (s *RedisSessionStore) NextRoundRobin() (int64, error) {
+	return s.redis.Incr(context.Background(), roundRobinKey).Result()
+}
+
+// Connect учитывает новое соединение и возвращает число соединений на всех живых репликах.
+func (s *RedisSessionStore) Connect(userID uint, isManager bool) (int64, error) {
+	return s.presence(userID, isManager, 1)
+}
+
+// Disconnect снимает соединение с учета и возвращает число оставшихся.
+func (s *RedisSessionStore) Disconnect(userID uint, isManager bool) (int64, error) {
+	return s.presence(userID, isManager, -1)
+}
+
+func (s *RedisSessionStore) presence(userID uint, isManager bool, delta int64) (int64, error) {
+	keys := []string{s.connsKey(), replicasKey}
+	args := []interface{}{connField(userID, isManager), delta, presenceTTL.Milliseconds(), s.replica, connsPrefix}
+	return presenceScript.Run(context.Background(), s.redis, keys, args...).Int64()
+}
+
+// Heartbeat продлевает учет соединений этой реплики. Если реплика упадет, не закрыв
+// соединения, они перестанут учитываться через presenceTTL.
+func (s *RedisSessionStore) Heartbeat() error {
+	keys := []string{s.connsKey(), replicasKey}
+	return heartbeatScript.Run(context.Background(), s.redis, keys, presenceTTL.Milliseconds(), s.replica).Err()
+}
+
+// ResetPresence сбрасывает учет соединений этой реплики, оставшийся от прошлого запуска.
+func (s *RedisSessionStore) ResetPresence() error {
+	_, err := s.redis.TxPipelined(context.Background(), func(pipe redis.Pipeliner) error {
+		pipe.Del(context.Background(), s.connsKey())
+		pipe.SRem(context.Background(), replicasKey, s.replica)
+		return nil
+	})
+	return err
+}
+
+func (s *RedisSessionStore) sessionKey(userID uint) string {
+	return fmt.Sprintf("chat:session:%d", userID)
+}
+
+func (s *RedisSessionStore) managerKey(managerID uint) string {
+	return fmt.Sprintf("chat:manager_sessions:%d", managerID)
+}
+
+func (s *RedisSessionStore) skillsKey(managerID uint) string {
+	return fmt.Sprintf("chat:manager_skills:%d", managerID)
+}
+
+func (s *RedisSessionStore) connsKey() string {
+	return connsPrefix + s.replica
+}
+
+func connField(userID uint, isManager bool) string {
+	if isManager {
+		return fmt.Sprintf("manager:%d", userID)
+	}
+	return fmt.Sprintf("user:%d", userID)
+}
+
+func runFlag(script *redis.Script, r *redisdb.RedisDB, keys []string, args ...interface{}) (bool, error) {
+	n, err := script.Run(context.Background(), r, keys, args...).Int()
+	if errors.Is(err, redis.Nil) {
+		return false, nil
+	}
+	return n == 1, err
+}
+
+func parseIDs(members []string) []uint {
+	ids := make([]uint, 0, len(members))
+	for _, m := range members {
+		if id, err := strconv.ParseUint(m, 10, 64); err == nil {
+			ids = append(ids, uint(id))
+		}
+	}
+	return ids
+}
@@ -0,0 +1,219 @@
+package chat
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/ShopOnGO/ShopOnGO/internal/audit"
+	"github.com/ShopOnGO/ShopOnGO/pkg/middleware"
+)
+
+// Форматы стенограммы
+const (
+	TranscriptHTML = "html"
+	TranscriptPDF  = "pdf" // HTML с разметкой для печати: PDF получают печатью из браузера или wkhtmltopdf
+	TranscriptText = "text"
+)
+
+const (
+	defaultSearchLimit = 50
+	maxSearchLimit     = 200
+)
+
+// AuditRecorder пишет запись в журнал аудита. Каждый поиск и каждая выгрузка
+// стенограммы попадают в журнал.
+type AuditRecorder interface {
+	Record(ctx context.Context, entry *audit.Entry) error
+}
+
+// Transcript — готовый файл стенограммы.
+type Transcript struct {
+	FileName    string
+	ContentType string
+	Body        []byte
+}
+
+// Search ищет сообщения по всем перепискам (для руководителей поддержки).
+func (c *ChatService) Search(ctx context.Context, filter SearchFilter) ([]SearchHit, error) {
+	filter.Query = strings.TrimSpace(filter.Query)
+	if filter.Limit <= 0 {
+		filter.Limit = defaultSearchLimit
+	}
+	if filter.Limit > maxSearchLimit {
+		filter.Limit = maxSearchLimit
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	hits, err := c.repo.SearchMessages(filter)
+	entry := &audit.Entry{Action: "chat.search", Request: audit.JSON(filter)}
+	if filter.UserID != 0 {
+		entry.ResourceID = fmt.Sprint(filter.UserID)
+	}
+	c.recordAccess(ctx, entry, err)
+	if err != nil {
+		return nil, err
+	}
+	if hits == nil {
+		hits = []SearchHit{}
+	}
+	return hits, nil
+}
+
+// Transcript выгружает переписку с пользователем userID в формате format.
+func (c *ChatService) Transcript(ctx context.Context, userID uint, filter TranscriptFilter, format string) (*Transcript, error) {
+	renderer, ok := transcriptRenderers[format]
+	if !ok {
+		return nil, ErrUnknownFormat
+	}
+
+	messages, err := c.repo.TranscriptMessages(userID, filter)
+	if err == nil && len(messages) == 0 {
+		err = ErrTranscriptNotFound
+	}
+	c.recordAccess(ctx, &audit.Entry{
+		Action:     "chat.transcript",
+		ResourceID: fmt.Sprint(userID),
+		Request: audit.JSON(struct {
+			TranscriptFilter
+			Format string `json:"format"`
+		}{filter, format}),
+	}, err)
+	if err != nil {
+		return nil, err
+	}
+
+	view := transcriptView{
+		UserID:      userID,
+		Print:       format == TranscriptPDF,
+		GeneratedAt: time.Now().UTC().Format(transcriptTimeLayout),
+		Messages:    make([]transcriptLine, 0, len(messages)),
+	}
+	view.GeneratedBy, _ = ctx.Value(middleware.ContextUserIDKey).(uint)
+	for _, m := range messages {
+		view.Messages = append(view.Messages, newTranscriptLine(userID, m))
+	}
+
+	var buf bytes.Buffer
+	if err := renderer.execute(&buf, view); err != nil {
+		return nil, fmt.Errorf("render transcript: %w", err)
+	}
+	return &Transcript{
+		FileName:    fmt.Sprintf("chat-transcript-%d%s", userID, renderer.suffix),
+		ContentType: renderer.contentType,
+		Body:        buf.Bytes(),
+	}, nil
+}
+
+// recordAccess пишет в журнал аудита обращение к перепискам, в том числе неудачное.
+func (c *ChatService) recordAccess(ctx context.Context, entry *audit.Entry, err error) {
+	if c.audit == nil {
+		return
+	}
+	entry.Success = err == nil
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	c.audit.Record(ctx, entry)
+}
+
+const transcriptTimeLayout = "02.01.2006 15:04:05 MST"
+
+type transcriptView struct {
+	UserID      uint
+	GeneratedBy uint
+	GeneratedAt string
+	Print       bool
+	Messages    []transcriptLine
+}
+
+type transcriptLine struct {
+	Author     string
+	Support    bool
+	At         string
+	Content    string
+	Attachment bool // Content — ссылка на файл
+	FileName   string
+}
+
+func newTranscriptLine(userID uint, m *Message) transcriptLine {
+	line := transcriptLine{
+		Author:  fmt.Sprintf("Покупатель #%d", m.FromID),
+		At:      m.CreatedAt.UTC().Format(transcriptTimeLayout),
+		Content: m.Content,
+	}
+	if m.FromID != userID {
+		line.Author = fmt.Sprintf("Поддержка #%d", m.FromID)
+		line.Support = true
+	}
+	if m.Type == MsgTypeImage || m.Type == MsgTypeFile {
+		line.Attachment = true
+		line.FileName = m.FileName
+		if line.FileName == "" {
+			line.FileName = m.Content
+		}
+	}
+	return line
+}
+
+type transcriptRenderer struct {
+	contentType string
+	suffix      string
+	execute     func(w io.Writer, data any) error
+}
+
+var transcriptRenderers = map[string]transcriptRenderer{
+	TranscriptHTML: {"text/html; charset=utf-8", ".html", transcriptHTMLTemplate.Execute},
+	TranscriptPDF:  {"text/html; charset=utf-8", "-print.html", transcriptHTMLTemplate.Execute},
+	TranscriptText: {"text/plain; charset=utf-8", ".txt", transcriptTextTemplate.Execute},
+}
+
+var transcriptHTMLTemplate = htmltemplate.Must(htmltemplate.New("transcript").Parse(`<!DOCTYPE html>
+<html lang="ru">
+<head>
+<meta charset="utf-8">
+<title>Переписка с пользователем #{{.UserID}}</title>
+<style>
+body { font-family: Arial, sans-serif; margin: 2em; color: #222; }
+.message { margin: 0 0 1em; }
+.meta { color: #666; font-size: 0.85em; }
+.support .author { color: #0a58ca; }
+.content { white-space: pre-wrap; }
+{{- if .Print}}
+@page { size: A4; margin: 15mm; }
+body { margin: 0; font-size: 11pt; }
+.message { page-break-inside: avoid; }
+a::after { content: " (" attr(href) ")"; color: #666; font-size: 0.8em; word-break: break-all; }
+{{- end}}
+</style>
+</head>
+<body>
+<h1>Переписка с пользователем #{{.UserID}}</h1>
+<p class="meta">Сообщений: {{len .Messages}}. Выгружено {{.GeneratedAt}} пользователем #{{.GeneratedBy}}.</p>
+{{- range .Messages}}
+<div class="message{{if .Support}} support{{end}}">
+<div class="meta"><span class="author">{{.Author}}</span> · {{.At}}</div>
+{{- if .Attachment}}
+<div class="content"><a href="{{.Content}}">{{.FileName}}</a></div>
+{{- else}}
+<div class="content">{{.Content}}</div>
+{{- end}}
+</div>
+{{- end}}
+</body>
+</html>
+`))
+
+var transcriptTextTemplate = texttemplate.Must(texttemplate.New("transcript").Parse(`Переписка с пользователем #{{.UserID}}
+Сообщений: {{len .Messages}}. Выгружено {{.GeneratedAt}} пользователем #{{.GeneratedBy}}.
+{{range .Messages}}
+[{{.At}}] {{.Author}}: {{if .Attachment}}[файл: {{.FileName}}] {{end}}{{.Content}}
+{{- end}}
+`))
@@ -0,0 +1,98 @@
+package chat
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ShopOnGO/ShopOnGO/internal/audit"
+	"github.com/ShopOnGO/ShopOnGO/pkg/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeRecorder struct {
+	mu      sync.Mutex
+	entries []audit.Entry
+}
+
+func (f *fakeRecorder) Record(ctx context.Context, entry *audit.Entry) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	entry.ActorID, _ = ctx.Value(middleware.ContextUserIDKey).(uint)
+	f.entries = append(f.entries, *entry)
+	return nil
+}
+
+func TestSearchAndTranscript(t *testing.T) {
+	c := newCluster(t, 1)
+	recorder := &fakeRecorder{}
+	service := c.services[0]
+	service.audit = recorder
+	ctx := context.WithValue(context.Background(), middleware.ContextUserIDKey, uint(7))
+
+	user := c.dial(t, 0, 1, "buyer")
+	manager := c.dial(t, 0, 100, "manager")
+	send(t, user, ReqMessage, IncomingUserMessage{Content: "Где мой заказ?"})
+	expect(t, user, `"type":"ack"`)
+	send(t, manager, ReqTake, ManagerCommand{UserID: 1})
+	expect(t, manager, `"type":"ack"`)
+	send(t, manager, ReqMessage, ManagerMessage{UserID: 1, Content: "<script>alert(1)</script> Заказ в пути"})
+	expect(t, manager, `"type":"ack"`)
+	attachment, err := service.Upload(ctx, 1, bytes.NewReader(pngHeader), "check.png")
+	require.NoError(t, err)
+	send(t, user, ReqMessage, IncomingUserMessage{Content: attachment.URL, Type: MsgTypeImage})
+	expect(t, user, `"type":"ack"`)
+
+	hits, err := service.Search(ctx, SearchFilter{Query: "заказ"})
+	require.NoError(t, err)
+	require.Len(t, hits, 2)
+	assert.Equal(t, uint(100), hits[0].FromID, "новые сначала")
+	assert.Equal(t, uint(1), hits[1].UserID)
+	hits, err = service.Search(ctx, SearchFilter{Query: "заказ", UserID: 2})
+	require.NoError(t, err)
+	assert.Empty(t, hits)
+
+	html, err := service.Transcript(ctx, 1, TranscriptFilter{}, TranscriptHTML)
+	require.NoError(t, err)
+	assert.Equal(t, "chat-transcript-1.html", html.FileName)
+	body := string(html.Body)
+	assert.Contains(t, body, "&lt;script&gt;alert(1)&lt;/script&gt; Заказ в пути")
+	assert.NotContains(t, body, "<script>")
+	assert.Contains(t, body, `<a href="`+attachment.URL+`">check.png</a>`)
+	assert.Contains(t, body, "Поддержка #100")
+	assert.NotContains(t, body, "@page")
+
+	pdf, err := service.Transcript(ctx, 1, TranscriptFilter{}, TranscriptPDF)
+	require.NoError(t, err)
+	assert.Contains(t, string(pdf.Body), "@page")
+
+	text, err := service.Transcript(ctx, 1, TranscriptFilter{}, TranscriptText)
+	require.NoError(t, err)
+	assert.Equal(t, "text/plain; charset=utf-8", text.ContentType)
+	assert.Contains(t, string(text.Body), "Покупатель #1: [файл: check.png] "+attachment.URL)
+	assert.Equal(t, 6, strings.Count(string(text.Body), "\n"), "две строки заголовка, пустая строка и три сообщения")
+
+	_, err = service.Transcript(ctx, 1, TranscriptFilter{}, "docx")
+	assert.ErrorIs(t, err, ErrUnknownFormat)
+	_, err = service.Transcript(ctx, 2, TranscriptFilter{}, TranscriptHTML)
+	assert.ErrorIs(t, err, ErrTranscriptNotFound)
+
+	// В журнал попадает каждое обращение, в том числе неудачное; неизвестный формат — нет
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	require.Len(t, recorder.entries, 6)
+	for _, entry := range recorder.entries {
+		assert.Equal(t, uint(7), entry.ActorID)
+	}
+	assert.Equal(t, "chat.search", recorder.entries[0].Action)
+	assert.Equal(t, "2", recorder.entries[1].ResourceID)
+	assert.Equal(t, "chat.transcript", recorder.entries[2].Action)
+	assert.True(t, recorder.entries[2].Success)
+	assert.JSONEq(t, `{"format":"html"}`, string(recorder.entries[2].Request))
+	last := recorder.entries[5]
+	assert.False(t, last.Success)
+	assert.Equal(t, ErrTranscriptNotFound.Error(), last.Error)
+}
@@ -0,0 +1,10 @@
+package identity
+
+import "errors"
+
+var (
+	ErrIdentityNotFound      = errors.New("identity not found")
+	ErrAlreadyLinked         = errors.New("this account is already linked to another user")
+	ErrProviderAlreadyLinked = errors.New("provider is already linked to your account")
+	ErrLastLoginMethod       = errors.New("cannot unlink the last login method, set a password first")
+)
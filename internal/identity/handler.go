@@ -0,0 +1,90 @@
+package identity
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ShopOnGO/ShopOnGO/configs"
+	"github.com/ShopOnGO/ShopOnGO/pkg/logger"
+	"github.com/ShopOnGO/ShopOnGO/pkg/middleware"
+	"github.com/ShopOnGO/ShopOnGO/pkg/res"
+	"github.com/gorilla/mux"
+)
+
+type IdentityHandlerDeps struct {
+	*configs.Config
+	Guard *middleware.TokenGuard
+	*IdentityService
+}
+
+type IdentityHandler struct {
+	*configs.Config
+	*IdentityService
+}
+
+func NewIdentityHandler(router *mux.Router, deps IdentityHandlerDeps) {
+	handler := &IdentityHandler{
+		Config:          deps.Config,
+		IdentityService: deps.IdentityService,
+	}
+	router.Handle("/auth/identities", middleware.IsAuthed(handler.List(), deps.Config, deps.Guard)).Methods("GET")
+	router.Handle("/auth/identities/{provider}", middleware.IsAuthed(handler.Unlink(), deps.Config, deps.Guard)).Methods("DELETE")
+}
+
+// List returns the providers linked to the current user.
+// @Summary      Linked providers
+// @Description  Returns external login providers linked to the current account.
+// @Tags         auth
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Success      200  {array}   UserIdentity
+// @Failure      401  {string}  string  "Not authorized"
+// @Router       /auth/identities [get]
+func (h *IdentityHandler) List() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, _ := r.Context().Value(middleware.ContextUserIDKey).(uint)
+
+		identities, err := h.IdentityService.List(userID)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		res.Json(w, identities, http.StatusOK)
+	}
+}
+
+// Unlink removes a linked provider.
+// @Summary      Unlink provider
+// @Description  Unlinks an external login provider. Not allowed if it is the only way to sign in (no password set and no other providers).
+// @Tags         auth
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        provider  path  string  true  "Provider name, e.g. google"
+// @Success      200  {object}  map[string]string
+// @Failure      404  {string}  string  "Provider is not linked"
+// @Failure      409  {string}  string  "Last login method"
+// @Router       /auth/identities/{provider} [delete]
+func (h *IdentityHandler) Unlink() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, _ := r.Context().Value(middleware.ContextUserIDKey).(uint)
+		provider := mux.Vars(r)["provider"]
+
+		if err := h.IdentityService.Unlink(userID, provider); err != nil {
+			writeError(w, r, err)
+			return
+		}
+		res.Json(w, map[string]string{"message": "Provider unlinked"}, http.StatusOK)
+	}
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, ErrIdentityNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, ErrLastLoginMethod), errors.Is(err, ErrAlreadyLinked), errors.Is(err, ErrProviderAlreadyLinked):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		logger.FromContext(r.Context()).Errorw("❌ identity error", logger.Err(err))
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
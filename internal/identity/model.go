@@ -0,0 +1,13 @@
+package identity
+
+import "gorm.io/gorm"
+
+// UserIdentity связывает пользователя с аккаунтом внешнего провайдера.
+// У пользователя может быть несколько identity, пара (provider, subject) уникальна.
+type UserIdentity struct {
+	gorm.Model `swaggerignore:"true"`
+	UserID     uint   `gorm:"not null;index" json:"user_id"`
+	Provider   string `gorm:"not null;uniqueIndex:idx_identity_provider_subject" json:"provider"` // "google", ...
+	Subject    string `gorm:"not null;uniqueIndex:idx_identity_provider_subject" json:"-"`        // id пользователя у провайдера
+	Email      string `json:"email"`
+}
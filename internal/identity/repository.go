@@ -0,0 +1,49 @@
+package identity
+
+import (
+	"errors"
+
+	"github.com/ShopOnGO/ShopOnGO/pkg/db"
+	"gorm.io/gorm"
+)
+
+type IdentityRepository struct {
+	Database *db.Db
+}
+
+func NewIdentityRepository(database *db.Db) *IdentityRepository {
+	return &IdentityRepository{Database: database}
+}
+
+func (repo *IdentityRepository) Create(identity *UserIdentity) error {
+	return repo.Database.DB.Create(identity).Error
+}
+
+func (repo *IdentityRepository) FindByProviderSubject(provider, subject string) (*UserIdentity, error) {
+	var identity UserIdentity
+	result := repo.Database.DB.Where("provider = ? AND subject = ?", provider, subject).First(&identity)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, ErrIdentityNotFound
+	}
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &identity, nil
+}
+
+func (repo *IdentityRepository) FindByUserID(userID uint) ([]UserIdentity, error) {
+	var identities []UserIdentity
+	result := repo.Database.DB.Where("user_id = ?", userID).Order("id").Find(&identities)
+	return identities, result.Error
+}
+
+func (repo *IdentityRepository) Delete(userID uint, provider string) error {
+	result := repo.Database.DB.Unscoped().Where("user_id = ? AND provider = ?", userID, provider).Delete(&UserIdentity{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrIdentityNotFound
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package identity
+
+import (
+	"errors"
+
+	"github.com/ShopOnGO/ShopOnGO/pkg/di"
+	"github.com/ShopOnGO/ShopOnGO/pkg/logger"
+)
+
+type Repository interface {
+	Create(identity *UserIdentity) error
+	FindByProviderSubject(provider, subject string) (*UserIdentity, error)
+	FindByUserID(userID uint) ([]UserIdentity, error)
+	Delete(userID uint, provider string) error
+}
+
+type IdentityService struct {
+	Repository     Repository
+	UserRepository di.IUserRepository
+}
+
+func NewIdentityService(repository Repository, userRepository di.IUserRepository) *IdentityService {
+	return &IdentityService{
+		Repository:     repository,
+		UserRepository: userRepository,
+	}
+}
+
+// FindUserID возвращает пользователя, привязанного к (provider, subject), или ErrIdentityNotFound.
+func (service *IdentityService) FindUserID(provider, subject string) (uint, error) {
+	identity, err := service.Repository.FindByProviderSubject(provider, subject)
+	if err != nil {
+		return 0, err
+	}
+	return identity.UserID, nil
+}
+
+// Link привязывает внешний аккаунт к пользователю. Повторная привязка того же
+// аккаунта к тому же пользователю не считается ошибкой.
+func (service *IdentityService) Link(userID uint, provider, subject, email string) error {
+	existing, err := service.Repository.FindByProviderSubject(provider, subject)
+	if err == nil {
+		if existing.UserID == userID {
+			return nil
+		}
+		return ErrAlreadyLinked
+	}
+	if !errors.Is(err, ErrIdentityNotFound) {
+		return err
+	}
+
+	identities, err := service.Repository.FindByUserID(userID)
+	if err != nil {
+		return err
+	}
+	for _, identity := range identities {
+		if identity.Provider == provider {
+			return ErrProviderAlreadyLinked
+		}
+	}
+
+	if err := service.Repository.Create(&UserIdentity{
+		UserID:   userID,
+		Provider: provider,
+		Subject:  subject,
+		Email:    email,
+	}); err != nil {
+		return err
+	}
+	logger.Infof("🔗 Provider %s linked to user %d", provider, userID)
+	return nil
+}
+
+func (service *IdentityService) List(userID uint) ([]UserIdentity, error) {
+	return service.Repository.FindByUserID(userID)
+}
+
+// Unlink отвязывает провайдера, если у пользователя остается другой способ входа.
+func (service *IdentityService) Unlink(userID uint, provider string) error {
+	u, err := service.UserRepository.FindByID(userID)
+	if err != nil {
+		return err
+	}
+	identities, err := service.Repository.FindByUserID(userID)
+	if err != nil {
+		return err
+	}
+
+	found := false
+	for _, identity := range identities {
+		if identity.Provider == provider {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return ErrIdentityNotFound
+	}
+	if u.PasswordHash == "" && len(identities) == 1 {
+		return ErrLastLoginMethod
+	}
+
+	if err := service.Repository.Delete(userID, provider); err != nil {
+		return err
+	}
+	logger.Infof("🔗 Provider %s unlinked from user %d", provider, userID)
+	return nil
+}
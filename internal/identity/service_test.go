@@ -0,0 +1,83 @@
+package identity
+
+import (
+	"testing"
+
+	"github.com/ShopOnGO/ShopOnGO/internal/user"
+	"github.com/ShopOnGO/ShopOnGO/internal/user/usertest"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+type memoryRepository struct {
+	identities []UserIdentity
+}
+
+func (m *memoryRepository) Create(identity *UserIdentity) error {
+	m.identities = append(m.identities, *identity)
+	return nil
+}
+
+func (m *memoryRepository) FindByProviderSubject(provider, subject string) (*UserIdentity, error) {
+	for i := range m.identities {
+		if m.identities[i].Provider == provider && m.identities[i].Subject == subject {
+			return &m.identities[i], nil
+		}
+	}
+	return nil, ErrIdentityNotFound
+}
+
+func (m *memoryRepository) FindByUserID(userID uint) ([]UserIdentity, error) {
+	var result []UserIdentity
+	for _, identity := range m.identities {
+		if identity.UserID == userID {
+			result = append(result, identity)
+		}
+	}
+	return result, nil
+}
+
+func (m *memoryRepository) Delete(userID uint, provider string) error {
+	for i, identity := range m.identities {
+		if identity.UserID == userID && identity.Provider == provider {
+			m.identities = append(m.identities[:i], m.identities[i+1:]...)
+			return nil
+		}
+	}
+	return ErrIdentityNotFound
+}
+
+func newTestService(passwordHash string) *IdentityService {
+	users := usertest.NewRepository(&user.User{Model: gorm.Model{ID: 1}, PasswordHash: passwordHash})
+	return NewIdentityService(&memoryRepository{}, users)
+}
+
+func TestLink(t *testing.T) {
+	service := newTestService("")
+
+	assert.NoError(t, service.Link(1, "google", "sub-1", "a@a.ru"))
+	assert.NoError(t, service.Link(1, "google", "sub-1", "a@a.ru"), "повторная привязка того же аккаунта допустима")
+	assert.ErrorIs(t, service.Link(2, "google", "sub-1", "a@a.ru"), ErrAlreadyLinked)
+	assert.ErrorIs(t, service.Link(1, "google", "sub-2", "b@a.ru"), ErrProviderAlreadyLinked)
+
+	userID, err := service.FindUserID("google", "sub-1")
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), userID)
+}
+
+func TestUnlink(t *testing.T) {
+	t.Run("Last login method", func(t *testing.T) {
+		service := newTestService("")
+		service.Link(1, "google", "sub-1", "a@a.ru")
+
+		assert.ErrorIs(t, service.Unlink(1, "google"), ErrLastLoginMethod)
+	})
+
+	t.Run("Password set", func(t *testing.T) {
+		service := newTestService("hash")
+		service.Link(1, "google", "sub-1", "a@a.ru")
+
+		assert.NoError(t, service.Unlink(1, "google"))
+		assert.ErrorIs(t, service.Unlink(1, "google"), ErrIdentityNotFound)
+	})
+}
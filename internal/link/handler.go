@@ -17,6 +17,7 @@ type LinkHandlerDeps struct { // содержит все необходимые
 	LinkService    *LinkService
 	LinkRepository *LinkRepository
 	Config         *configs.Config
+	Guard          *middleware.TokenGuard
 	EventBus       *event.EventBus
 }
 type LinkHandler struct { // это уже рабоая структура
@@ -31,11 +32,11 @@ func NewLinkHandler(router *mux.Router, deps LinkHandlerDeps) {
 		LinkService:    deps.LinkService,
 		EventBus:       deps.EventBus,
 	}
-	router.Handle("/link", middleware.IsAuthed(handler.Create(), deps.Config)).Methods("POST")
-	router.Handle("/link/{id}", middleware.IsAuthed(handler.Update(), deps.Config)).Methods("PATCH")
-	router.Handle("/link/{id}", middleware.IsAuthed(handler.Delete(), deps.Config)).Methods("DELETE")
+	router.Handle("/link", middleware.IsAuthed(handler.Create(), deps.Config, deps.Guard)).Methods("POST")
+	router.Handle("/link/{id}", middleware.IsAuthed(handler.Update(), deps.Config, deps.Guard)).Methods("PATCH")
+	router.Handle("/link/{id}", middleware.IsAuthed(handler.Delete(), deps.Config, deps.Guard)).Methods("DELETE")
 	router.HandleFunc("/goto/{hash}", handler.GoTo()).Methods("GET")
-	router.Handle("/link", middleware.IsAuthed(handler.GetAll(), deps.Config)).Methods("GET")	
+	router.Handle("/link", middleware.IsAuthed(handler.GetAll(), deps.Config, deps.Guard)).Methods("GET")	
 }
 
 // Create создает новую короткую ссылку
@@ -14,6 +14,7 @@ import (
 
 type NotificationHandlerDeps struct {
 	Config *configs.Config
+	Guard  *middleware.TokenGuard
 	Kafka  *kafkaService.KafkaService
 }
 
@@ -27,7 +28,7 @@ func NewNotificationHandler(router *mux.Router, deps NotificationHandlerDeps) {
 		Config: deps.Config,
 		Kafka:  deps.Kafka,
 	}
-	router.Handle("/notifications", middleware.IsAuthed(handler.AddNotification(), deps.Config)).Methods("POST")
+	router.Handle("/notifications", middleware.IsAuthed(handler.AddNotification(), deps.Config, deps.Guard)).Methods("POST")
 	//router.Handle("/notifications/{id}", middleware.IsAuthed(handler.UpdateReview(), deps.Config)).Methods("PUT")
 	//router.Handle("/notifications/{id}", middleware.IsAuthed(handler.DeleteReview(), deps.Config)).Methods("DELETE")
 }
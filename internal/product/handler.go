@@ -5,6 +5,7 @@ import (
 	"net/http"
 
 	"github.com/ShopOnGO/ShopOnGO/configs"
+	"github.com/ShopOnGO/ShopOnGO/internal/rbac"
 	"github.com/ShopOnGO/ShopOnGO/pkg/kafkaService"
 	"github.com/ShopOnGO/ShopOnGO/pkg/logger"
 	"github.com/ShopOnGO/ShopOnGO/pkg/middleware"
@@ -13,8 +14,11 @@ import (
 )
 
 type ProductHandlerDeps struct {
-	Config *configs.Config
-	Kafka  *kafkaService.KafkaService
+	Config      *configs.Config
+	Guard       *middleware.TokenGuard
+	Kafka       *kafkaService.KafkaService
+	// Permissions разрешает права роли из JWT
+	Permissions middleware.PermissionChecker
 }
 
 type ProductHandler struct {
@@ -29,8 +33,9 @@ func NewProductHandler(router *mux.Router, deps ProductHandlerDeps) {
 	}
 
 	protectedAddProduct := middleware.IsAuthed(
-		middleware.CheckRole(handler.AddProduct(), []string{"seller", "admin"}),
+		middleware.RequirePermission(handler.AddProduct(), rbac.PermProductWrite, deps.Permissions),
 		deps.Config,
+		deps.Guard,
 	)
 	router.Handle("/products", protectedAddProduct).Methods("POST")
 }
@@ -70,17 +75,6 @@ func (h *ProductHandler) AddProduct() http.HandlerFunc {
             userID = id
         }
 
-		roleVal := r.Context().Value(middleware.ContextRolesKey)
-		var role string
-		if id, ok := roleVal.(string); ok && id != "" {
-			role = id
-		}
-
-		if role == "buyer" {
-			http.Error(w, "unauthorized, only sellers and admins can create products", http.StatusUnauthorized)
-			return
-		}
-
 		event := productCreatedEvent{
 			Action:  "create",
 			UserID:  userID,
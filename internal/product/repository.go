@@ -0,0 +1,31 @@
+package product
+
+import (
+	"errors"
+
+	"github.com/ShopOnGO/ShopOnGO/pkg/db"
+)
+
+// ProductRepository читает товары из общей с admin-сервисом таблицы.
+type ProductRepository struct {
+	Database *db.Db
+}
+
+func NewProductRepository(database *db.Db) *ProductRepository {
+	return &ProductRepository{
+		Database: database,
+	}
+}
+
+// FindByID находит товар по ID, включая помеченные удаленными.
+func (repo *ProductRepository) FindByID(id uint) (*Product, error) {
+	if id == 0 {
+		return nil, errors.New("product ID cannot be empty")
+	}
+	var product Product
+	result := repo.Database.DB.Unscoped().First(&product, id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &product, nil
+}
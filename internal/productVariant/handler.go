@@ -6,6 +6,7 @@ import (
 	"strconv"
 
 	"github.com/ShopOnGO/ShopOnGO/configs"
+	"github.com/ShopOnGO/ShopOnGO/internal/rbac"
 	"github.com/ShopOnGO/ShopOnGO/pkg/kafkaService"
 	"github.com/ShopOnGO/ShopOnGO/pkg/logger"
 	"github.com/ShopOnGO/ShopOnGO/pkg/middleware"
@@ -15,8 +16,11 @@ import (
 )
 
 type ProductVariantHandlerDeps struct {
-	Config *configs.Config
-	Kafka  *kafkaService.KafkaService
+	Config      *configs.Config
+	Guard       *middleware.TokenGuard
+	Kafka       *kafkaService.KafkaService
+	// Permissions разрешает права роли из JWT
+	Permissions middleware.PermissionChecker
 }
 
 type ProductVariantHandler struct {
@@ -30,8 +34,9 @@ func NewProductVariantHandler(router *mux.Router, deps ProductVariantHandlerDeps
 		Kafka:  deps.Kafka,
 	}
 	protectedAddProductVariant := middleware.IsAuthed(
-		middleware.CheckRole(handler.AddProductVariant(), []string{"seller", "admin"}),
+		middleware.RequirePermission(handler.AddProductVariant(), rbac.PermProductWrite, deps.Permissions),
 		deps.Config,
+		deps.Guard,
 	)
 	router.Handle("/product/{id}/product-variants", protectedAddProductVariant).Methods("POST")
 }
@@ -81,17 +86,6 @@ func (h *ProductVariantHandler) AddProductVariant() http.HandlerFunc {
 			userID = id
 		}
 
-		roleVal := r.Context().Value(middleware.ContextRolesKey)
-		var role string
-		if id, ok := roleVal.(string); ok && id != "" {
-			role = id
-		}
-
-		if role == "buyer" {
-			http.Error(w, "unauthorized, only sellers and admins can create products", http.StatusUnauthorized)
-			return
-		}
-
 		event := productVariantCreatedEvent{
 			Action:  		"create",
 			ProductID: 		productID,
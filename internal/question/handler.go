@@ -17,6 +17,7 @@ import (
 
 type QuestionHandlerDeps struct {
 	Config *configs.Config
+	Guard  *middleware.TokenGuard
 	Kafka  *kafkaService.KafkaService
 }
 
@@ -33,10 +34,10 @@ func NewQuestionHandler(router *mux.Router, deps QuestionHandlerDeps) {
 	}
 
 	router.Handle("/questions", middleware.AuthOrGuest(handler.AddQuestion(), deps.Config)).Methods("POST")
-	router.Handle("/questions/{id}", middleware.IsAuthed(handler.AnswerQuestion(), deps.Config)).Methods("PUT")
-	router.Handle("/questions/{id}", middleware.IsAuthed(handler.DeleteQuestion(), deps.Config)).Methods("DELETE")
-	router.Handle("/questions/{id}/likes", middleware.IsAuthed(handler.AddLikeToQuestion(), deps.Config)).Methods("PUT")
-	router.Handle("/questions/{id}/unlikes", middleware.IsAuthed(handler.RemoveLikeToQuestion(), deps.Config)).Methods("PUT")
+	router.Handle("/questions/{id}", middleware.IsAuthed(handler.AnswerQuestion(), deps.Config, deps.Guard)).Methods("PUT")
+	router.Handle("/questions/{id}", middleware.IsAuthed(handler.DeleteQuestion(), deps.Config, deps.Guard)).Methods("DELETE")
+	router.Handle("/questions/{id}/likes", middleware.IsAuthed(handler.AddLikeToQuestion(), deps.Config, deps.Guard)).Methods("PUT")
+	router.Handle("/questions/{id}/unlikes", middleware.IsAuthed(handler.RemoveLikeToQuestion(), deps.Config, deps.Guard)).Methods("PUT")
 }
 
 // AddQuestion adds a new question for a product.
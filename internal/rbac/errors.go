@@ -0,0 +1,9 @@
+package rbac
+
+import "errors"
+
+var (
+	ErrUnknownPermission = errors.New("unknown permission")
+	ErrInvalidRole       = errors.New("role must not be empty")
+	ErrNotGranted        = errors.New("permission is not granted to this role")
+)
@@ -0,0 +1,156 @@
+package rbac
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ShopOnGO/ShopOnGO/configs"
+	"github.com/ShopOnGO/ShopOnGO/pkg/logger"
+	"github.com/ShopOnGO/ShopOnGO/pkg/middleware"
+	"github.com/ShopOnGO/ShopOnGO/pkg/req"
+	"github.com/ShopOnGO/ShopOnGO/pkg/res"
+	"github.com/gorilla/mux"
+)
+
+type RBACHandlerDeps struct {
+	*configs.Config
+	Guard *middleware.TokenGuard
+	*PermissionService
+}
+
+type RBACHandler struct {
+	*configs.Config
+	*PermissionService
+}
+
+func NewRBACHandler(router *mux.Router, deps RBACHandlerDeps) {
+	handler := &RBACHandler{
+		Config:            deps.Config,
+		PermissionService: deps.PermissionService,
+	}
+	router.Handle("/auth/permissions", middleware.IsAuthed(handler.GetMine(), deps.Config, deps.Guard)).Methods("GET")
+
+	manage := func(next http.Handler) http.Handler {
+		return middleware.IsAuthed(middleware.RequirePermission(next, PermRBACManage, deps.PermissionService), deps.Config, deps.Guard)
+	}
+	router.Handle("/admin/rbac/roles", manage(handler.ListRoles())).Methods("GET")
+	router.Handle("/admin/rbac/roles/{role}/permissions", manage(handler.Grant())).Methods("POST")
+	router.Handle("/admin/rbac/roles/{role}/permissions/{permission}", manage(handler.Revoke())).Methods("DELETE")
+}
+
+// GetMine returns the caller's effective permissions.
+// @Summary      Мои права
+// @Description  Возвращает права, которые дает роль из access-токена.
+// @Tags         auth
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Success      200  {object}  PermissionsResponse
+// @Failure      401  {string}  string  "Not authorized"
+// @Router       /auth/permissions [get]
+func (h *RBACHandler) GetMine() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		role, _ := r.Context().Value(middleware.ContextRolesKey).(string)
+
+		permissions, err := h.PermissionService.PermissionsFor(role)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		res.Json(w, PermissionsResponse{Role: role, Permissions: permissions}, http.StatusOK)
+	}
+}
+
+// ListRoles returns the role -> permissions mapping.
+// @Summary      Права ролей
+// @Description  Возвращает все роли и выданные им права. Требует права rbac:manage.
+// @Tags         admin
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Success      200  {object}  RolesResponse
+// @Failure      403  {string}  string  "Forbidden"
+// @Router       /admin/rbac/roles [get]
+func (h *RBACHandler) ListRoles() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		roles, err := h.PermissionService.Roles()
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		res.Json(w, RolesResponse{Roles: roles}, http.StatusOK)
+	}
+}
+
+// Grant grants a permission to a role.
+// @Summary      Выдать право роли
+// @Description  Выдает роли право из каталога. Повторная выдача не считается ошибкой. Требует права rbac:manage.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        role  path  string        true  "Роль"
+// @Param        body  body  GrantRequest  true  "Право"
+// @Success      200  {object}  PermissionsResponse
+// @Failure      400  {string}  string  "Неизвестное право"
+// @Failure      403  {string}  string  "Forbidden"
+// @Router       /admin/rbac/roles/{role}/permissions [post]
+func (h *RBACHandler) Grant() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := req.HandleBody[GrantRequest](&w, r)
+		if err != nil {
+			return
+		}
+		role := mux.Vars(r)["role"]
+
+		if err := h.PermissionService.Grant(role, body.Permission); err != nil {
+			writeError(w, r, err)
+			return
+		}
+		h.writeRole(w, r, role)
+	}
+}
+
+// Revoke revokes a permission from a role.
+// @Summary      Отозвать право у роли
+// @Description  Отзывает право у роли. Действует для всех выданных токенов без перевыпуска; другие инстансы применяют изменение в течение 30 секунд. Требует права rbac:manage.
+// @Tags         admin
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        role        path  string  true  "Роль"
+// @Param        permission  path  string  true  "Право, например product:write"
+// @Success      200  {object}  PermissionsResponse
+// @Failure      404  {string}  string  "Право не выдано"
+// @Failure      403  {string}  string  "Forbidden"
+// @Router       /admin/rbac/roles/{role}/permissions/{permission} [delete]
+func (h *RBACHandler) Revoke() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		role := vars["role"]
+
+		if err := h.PermissionService.Revoke(role, vars["permission"]); err != nil {
+			writeError(w, r, err)
+			return
+		}
+		h.writeRole(w, r, role)
+	}
+}
+
+func (h *RBACHandler) writeRole(w http.ResponseWriter, r *http.Request, role string) {
+	permissions, err := h.PermissionService.PermissionsFor(role)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	res.Json(w, PermissionsResponse{Role: role, Permissions: permissions}, http.StatusOK)
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, ErrUnknownPermission), errors.Is(err, ErrInvalidRole):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, ErrNotGranted):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		logger.FromContext(r.Context()).Errorw("❌ rbac error", logger.Err(err))
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
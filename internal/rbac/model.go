@@ -0,0 +1,18 @@
+package rbac
+
+import "gorm.io/gorm"
+
+// Permission — право из каталога.
+type Permission struct {
+	gorm.Model  `swaggerignore:"true"`
+	Name        string `gorm:"not null;uniqueIndex" json:"name"`
+	Description string `json:"description"`
+}
+
+// RolePermission связывает роль пользователя (user.User.Role) с правом.
+type RolePermission struct {
+	gorm.Model   `swaggerignore:"true"`
+	Role         string     `gorm:"not null;uniqueIndex:idx_role_permission" json:"role"`
+	PermissionID uint       `gorm:"not null;uniqueIndex:idx_role_permission" json:"permission_id"`
+	Permission   Permission `gorm:"constraint:OnDelete:CASCADE" json:"permission"`
+}
@@ -0,0 +1,14 @@
+package rbac
+
+type GrantRequest struct {
+	Permission string `json:"permission" validate:"required"`
+}
+
+type RolesResponse struct {
+	Roles map[string][]string `json:"roles"`
+}
+
+type PermissionsResponse struct {
+	Role        string   `json:"role"`
+	Permissions []string `json:"permissions"`
+}
@@ -0,0 +1,37 @@
+package rbac
+
+// Права доступа. Имя права — "ресурс:действие".
+const (
+	PermProductWrite  = "product:write"
+	PermCatalogManage = "catalog:manage"
+	PermChatManage    = "chat:manage"
+	PermChatReview    = "chat:review"
+	PermChatReport    = "chat:report"
+	PermUserManage    = "user:manage"
+	PermUserBan       = "user:ban"
+	PermSellerReview  = "seller:review"
+	PermRBACManage    = "rbac:manage"
+	PermAuditRead     = "audit:read"
+)
+
+// Definition описывает право из каталога и роли, которым оно выдается по умолчанию.
+type Definition struct {
+	Name         string
+	Description  string
+	DefaultRoles []string
+}
+
+// Catalog — все права, известные приложению. Новое право добавляется в БД при старте,
+// роли по умолчанию получают его один раз — дальнейшие изменения делаются через API.
+var Catalog = []Definition{
+	{PermProductWrite, "Создание товаров и вариантов", []string{"seller", "admin"}},
+	{PermCatalogManage, "Управление категориями, брендами и товарами в админке", []string{"admin"}},
+	{PermChatManage, "Ответы покупателям в чате поддержки", []string{"manager", "admin"}},
+	{PermChatReview, "Поиск по перепискам поддержки и выгрузка стенограмм", []string{"manager", "admin"}},
+	{PermChatReport, "Отчет по качеству поддержки: CSAT, время ответа и решения", []string{"admin"}},
+	{PermUserManage, "Создание, изменение и удаление пользователей", []string{"admin"}},
+	{PermUserBan, "Блокировка и разблокировка пользователей", []string{"moderator", "admin"}},
+	{PermSellerReview, "Рассмотрение заявок продавцов", []string{"admin"}},
+	{PermRBACManage, "Управление правами ролей", []string{"admin"}},
+	{PermAuditRead, "Просмотр журнала аудита", []string{"admin"}},
+}
@@ -0,0 +1,66 @@
+package rbac
+
+import (
+	"errors"
+
+	"github.com/ShopOnGO/ShopOnGO/pkg/db"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type RBACRepository struct {
+	Database *db.Db
+}
+
+func NewRBACRepository(database *db.Db) *RBACRepository {
+	return &RBACRepository{Database: database}
+}
+
+// EnsurePermission создает право, если его еще нет. created сообщает, что право новое.
+func (repo *RBACRepository) EnsurePermission(name, description string) (*Permission, bool, error) {
+	permission := Permission{Name: name}
+	result := repo.Database.DB.Where(Permission{Name: name}).
+		Attrs(Permission{Description: description}).
+		FirstOrCreate(&permission)
+	if result.Error != nil {
+		return nil, false, result.Error
+	}
+	return &permission, result.RowsAffected > 0, nil
+}
+
+func (repo *RBACRepository) FindPermission(name string) (*Permission, error) {
+	var permission Permission
+	result := repo.Database.DB.Where("name = ?", name).First(&permission)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, ErrUnknownPermission
+	}
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &permission, nil
+}
+
+func (repo *RBACRepository) ListRolePermissions() ([]RolePermission, error) {
+	var mappings []RolePermission
+	result := repo.Database.DB.Preload("Permission").Order("role, permission_id").Find(&mappings)
+	return mappings, result.Error
+}
+
+// Grant идемпотентен: повторная выдача того же права не считается ошибкой.
+func (repo *RBACRepository) Grant(role string, permissionID uint) error {
+	return repo.Database.DB.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&RolePermission{Role: role, PermissionID: permissionID}).Error
+}
+
+func (repo *RBACRepository) Revoke(role string, permissionID uint) error {
+	result := repo.Database.DB.Unscoped().
+		Where("role = ? AND permission_id = ?", role, permissionID).
+		Delete(&RolePermission{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotGranted
+	}
+	return nil
+}
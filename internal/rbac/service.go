@@ -0,0 +1,164 @@
+package rbac
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ShopOnGO/ShopOnGO/pkg/logger"
+)
+
+// cacheTTL — как долго держим соответствие роль -> права в памяти. Изменения,
+// сделанные через API на другом инстансе, становятся видны не позже чем через cacheTTL.
+const cacheTTL = 30 * time.Second
+
+type Repository interface {
+	EnsurePermission(name, description string) (*Permission, bool, error)
+	FindPermission(name string) (*Permission, error)
+	ListRolePermissions() ([]RolePermission, error)
+	Grant(role string, permissionID uint) error
+	Revoke(role string, permissionID uint) error
+}
+
+// PermissionService разрешает права по роли из JWT. Сами права в токен не пишутся,
+// поэтому отзыв права не требует перевыпуска токенов: на инстансе, принявшем
+// изменение, он действует сразу, на остальных — не позже чем через cacheTTL (30 с).
+type PermissionService struct {
+	Repository Repository
+
+	mu       sync.RWMutex
+	roles    map[string]map[string]bool
+	loadedAt time.Time
+	now      func() time.Time
+}
+
+func NewPermissionService(repository Repository) *PermissionService {
+	return &PermissionService{
+		Repository: repository,
+		now:        time.Now,
+	}
+}
+
+// SeedDefaults заносит каталог прав в БД. Права по умолчанию выдаются только при
+// первом появлении права, чтобы не возвращать то, что администратор отозвал.
+func (service *PermissionService) SeedDefaults() error {
+	for _, def := range Catalog {
+		permission, created, err := service.Repository.EnsurePermission(def.Name, def.Description)
+		if err != nil {
+			return err
+		}
+		if !created {
+			continue
+		}
+		for _, role := range def.DefaultRoles {
+			if err := service.Repository.Grant(role, permission.ID); err != nil {
+				return err
+			}
+		}
+		logger.Infof("🔑 Permission %s granted to %v", def.Name, def.DefaultRoles)
+	}
+	return service.reload()
+}
+
+// HasPermission реализует middleware.PermissionChecker.
+func (service *PermissionService) HasPermission(role, permission string) bool {
+	roles, err := service.load()
+	if err != nil {
+		logger.Error("❌ failed to load role permissions: " + err.Error())
+		return false
+	}
+	return roles[role][permission]
+}
+
+func (service *PermissionService) PermissionsFor(role string) ([]string, error) {
+	roles, err := service.load()
+	if err != nil {
+		return nil, err
+	}
+	return sortedKeys(roles[role]), nil
+}
+
+// Roles возвращает все роли, у которых есть хотя бы одно право.
+func (service *PermissionService) Roles() (map[string][]string, error) {
+	roles, err := service.load()
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string][]string, len(roles))
+	for role, permissions := range roles {
+		result[role] = sortedKeys(permissions)
+	}
+	return result, nil
+}
+
+func (service *PermissionService) Grant(role, permissionName string) error {
+	if role == "" {
+		return ErrInvalidRole
+	}
+	permission, err := service.Repository.FindPermission(permissionName)
+	if err != nil {
+		return err
+	}
+	if err := service.Repository.Grant(role, permission.ID); err != nil {
+		return err
+	}
+	logger.Infof("🔑 Permission %s granted to role %s", permissionName, role)
+	return service.reload()
+}
+
+func (service *PermissionService) Revoke(role, permissionName string) error {
+	permission, err := service.Repository.FindPermission(permissionName)
+	if err != nil {
+		return err
+	}
+	if err := service.Repository.Revoke(role, permission.ID); err != nil {
+		return err
+	}
+	logger.Infof("🔒 Permission %s revoked from role %s", permissionName, role)
+	return service.reload()
+}
+
+func (service *PermissionService) load() (map[string]map[string]bool, error) {
+	service.mu.RLock()
+	roles, loadedAt := service.roles, service.loadedAt
+	service.mu.RUnlock()
+
+	if roles != nil && service.now().Sub(loadedAt) < cacheTTL {
+		return roles, nil
+	}
+	if err := service.reload(); err != nil {
+		return nil, err
+	}
+	service.mu.RLock()
+	defer service.mu.RUnlock()
+	return service.roles, nil
+}
+
+func (service *PermissionService) reload() error {
+	mappings, err := service.Repository.ListRolePermissions()
+	if err != nil {
+		return err
+	}
+	roles := make(map[string]map[string]bool)
+	for _, m := range mappings {
+		if roles[m.Role] == nil {
+			roles[m.Role] = make(map[string]bool)
+		}
+		roles[m.Role][m.Permission.Name] = true
+	}
+
+	service.mu.Lock()
+	service.roles = roles
+	service.loadedAt = service.now()
+	service.mu.Unlock()
+	return nil
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,122 @@
+package rbac
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// memoryRepository хранит каталог и выдачи в памяти вместо Postgres.
+type memoryRepository struct {
+	permissions map[string]*Permission
+	grants      map[string]map[uint]bool
+	loads       int
+}
+
+func newMemoryRepository() *memoryRepository {
+	return &memoryRepository{
+		permissions: map[string]*Permission{},
+		grants:      map[string]map[uint]bool{},
+	}
+}
+
+func (m *memoryRepository) EnsurePermission(name, description string) (*Permission, bool, error) {
+	if p, ok := m.permissions[name]; ok {
+		return p, false, nil
+	}
+	p := &Permission{Model: gorm.Model{ID: uint(len(m.permissions) + 1)}, Name: name, Description: description}
+	m.permissions[name] = p
+	return p, true, nil
+}
+
+func (m *memoryRepository) FindPermission(name string) (*Permission, error) {
+	p, ok := m.permissions[name]
+	if !ok {
+		return nil, ErrUnknownPermission
+	}
+	return p, nil
+}
+
+func (m *memoryRepository) ListRolePermissions() ([]RolePermission, error) {
+	m.loads++
+	var result []RolePermission
+	for role, ids := range m.grants {
+		for _, p := range m.permissions {
+			if ids[p.ID] {
+				result = append(result, RolePermission{Role: role, PermissionID: p.ID, Permission: *p})
+			}
+		}
+	}
+	return result, nil
+}
+
+func (m *memoryRepository) Grant(role string, permissionID uint) error {
+	if m.grants[role] == nil {
+		m.grants[role] = map[uint]bool{}
+	}
+	m.grants[role][permissionID] = true
+	return nil
+}
+
+func (m *memoryRepository) Revoke(role string, permissionID uint) error {
+	if !m.grants[role][permissionID] {
+		return ErrNotGranted
+	}
+	delete(m.grants[role], permissionID)
+	return nil
+}
+
+func TestSeedDefaults(t *testing.T) {
+	repo := newMemoryRepository()
+	service := NewPermissionService(repo)
+	require.NoError(t, service.SeedDefaults())
+
+	assert.True(t, service.HasPermission("seller", PermProductWrite))
+	assert.True(t, service.HasPermission("manager", PermChatManage))
+	assert.True(t, service.HasPermission("admin", PermRBACManage))
+	assert.False(t, service.HasPermission("buyer", PermProductWrite))
+	assert.False(t, service.HasPermission("seller", PermChatManage))
+
+	// отозванное право по умолчанию не возвращается при следующем старте
+	require.NoError(t, service.Revoke("seller", PermProductWrite))
+	require.NoError(t, service.SeedDefaults())
+	assert.False(t, service.HasPermission("seller", PermProductWrite))
+}
+
+func TestGrantRevoke(t *testing.T) {
+	service := NewPermissionService(newMemoryRepository())
+	require.NoError(t, service.SeedDefaults())
+
+	require.NoError(t, service.Grant("moderator", PermChatManage))
+	assert.True(t, service.HasPermission("moderator", PermChatManage))
+
+	assert.ErrorIs(t, service.Grant("moderator", "orders:refund"), ErrUnknownPermission)
+	assert.ErrorIs(t, service.Grant("", PermChatManage), ErrInvalidRole)
+	assert.ErrorIs(t, service.Revoke("buyer", PermChatManage), ErrNotGranted)
+
+	permissions, err := service.PermissionsFor("moderator")
+	require.NoError(t, err)
+	assert.Equal(t, []string{PermChatManage, PermUserBan}, permissions)
+}
+
+func TestCacheTTL(t *testing.T) {
+	repo := newMemoryRepository()
+	service := NewPermissionService(repo)
+	require.NoError(t, service.SeedDefaults())
+
+	now := time.Unix(1700000000, 0)
+	service.now = func() time.Time { return now }
+	require.NoError(t, service.reload())
+	loads := repo.loads
+
+	// изменение в обход сервиса (другой инстанс) видно только после истечения кеша
+	repo.Grant("buyer", repo.permissions[PermChatManage].ID)
+	assert.False(t, service.HasPermission("buyer", PermChatManage))
+	assert.Equal(t, loads, repo.loads)
+
+	now = now.Add(cacheTTL)
+	assert.True(t, service.HasPermission("buyer", PermChatManage))
+}
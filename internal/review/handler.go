@@ -16,8 +16,10 @@ import (
 )
 
 type ReviewHandlerDeps struct {
-	Config *configs.Config
-	Kafka  *kafkaService.KafkaService
+	Config   *configs.Config
+	Guard    *middleware.TokenGuard
+	Kafka    *kafkaService.KafkaService
+	Verifier middleware.VerificationChecker
 }
 
 type ReviewHandler struct {
@@ -30,11 +32,15 @@ func NewReviewHandler(router *mux.Router, deps ReviewHandlerDeps) {
 		Config: deps.Config,
 		Kafka:  deps.Kafka,
 	}
-	router.Handle("/reviews", middleware.IsAuthed(handler.AddReview(), deps.Config)).Methods("POST")
-	router.Handle("/reviews/{id}", middleware.IsAuthed(handler.UpdateReview(), deps.Config)).Methods("PUT")
-	router.Handle("/reviews/{id}", middleware.IsAuthed(handler.DeleteReview(), deps.Config)).Methods("DELETE")
-	router.Handle("/reviews/{id}/likes", middleware.IsAuthed(handler.AddLikeToReview(), deps.Config)).Methods("PUT")
-	router.Handle("/reviews/{id}/unlikes", middleware.IsAuthed(handler.RemoveLikeToReview(), deps.Config)).Methods("PUT")
+	router.Handle("/reviews", middleware.IsAuthed(
+		middleware.RequireVerifiedEmail(handler.AddReview(), "review", deps.Verifier),
+		deps.Config,
+		deps.Guard,
+	)).Methods("POST")
+	router.Handle("/reviews/{id}", middleware.IsAuthed(handler.UpdateReview(), deps.Config, deps.Guard)).Methods("PUT")
+	router.Handle("/reviews/{id}", middleware.IsAuthed(handler.DeleteReview(), deps.Config, deps.Guard)).Methods("DELETE")
+	router.Handle("/reviews/{id}/likes", middleware.IsAuthed(handler.AddLikeToReview(), deps.Config, deps.Guard)).Methods("PUT")
+	router.Handle("/reviews/{id}/unlikes", middleware.IsAuthed(handler.RemoveLikeToReview(), deps.Config, deps.Guard)).Methods("PUT")
 }
 
 // AddReview добавляет новый отзыв
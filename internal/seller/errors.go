@@ -0,0 +1,10 @@
+package seller
+
+import "errors"
+
+var (
+	ErrApplicationNotFound = errors.New("seller application not found")
+	ErrApplicationPending  = errors.New("you already have a pending seller application")
+	ErrNotEligible         = errors.New("only buyers can apply to become a seller")
+	ErrInvalidTransition   = errors.New("application has already been reviewed")
+)
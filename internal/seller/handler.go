@@ -0,0 +1,198 @@
+package seller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/ShopOnGO/ShopOnGO/configs"
+	"github.com/ShopOnGO/ShopOnGO/internal/rbac"
+	"github.com/ShopOnGO/ShopOnGO/pkg/logger"
+	"github.com/ShopOnGO/ShopOnGO/pkg/middleware"
+	"github.com/ShopOnGO/ShopOnGO/pkg/req"
+	"github.com/ShopOnGO/ShopOnGO/pkg/res"
+	"github.com/gorilla/mux"
+)
+
+type SellerHandlerDeps struct {
+	*configs.Config
+	Guard *middleware.TokenGuard
+	*SellerService
+	Verifier    middleware.VerificationChecker
+	Permissions middleware.PermissionChecker
+}
+
+type SellerHandler struct {
+	*configs.Config
+	*SellerService
+}
+
+func NewSellerHandler(router *mux.Router, deps SellerHandlerDeps) {
+	handler := &SellerHandler{
+		Config:        deps.Config,
+		SellerService: deps.SellerService,
+	}
+
+	protectedApply := middleware.IsAuthed(
+		middleware.RequireVerifiedEmail(handler.Apply(), "seller_upgrade", deps.Verifier),
+		deps.Config,
+		deps.Guard,
+	)
+	router.Handle("/seller/applications", protectedApply).Methods("POST")
+	router.Handle("/seller/applications/me", middleware.IsAuthed(handler.GetMine(), deps.Config, deps.Guard)).Methods("GET")
+
+	reviewer := func(next http.Handler) http.Handler {
+		return middleware.IsAuthed(middleware.RequirePermission(next, rbac.PermSellerReview, deps.Permissions), deps.Config, deps.Guard)
+	}
+	router.Handle("/admin/seller-applications", reviewer(handler.List())).Methods("GET")
+	router.Handle("/admin/seller-applications/{id:[0-9]+}/approve", reviewer(handler.Approve())).Methods("POST")
+	router.Handle("/admin/seller-applications/{id:[0-9]+}/reject", reviewer(handler.Reject())).Methods("POST")
+}
+
+// Apply submits a seller application.
+// @Summary      Подать заявку продавца
+// @Description  Создает заявку на получение роли продавца с данными магазина и ссылками на документы. Роль меняется только после одобрения администратором. Требует подтвержденного email.
+// @Tags         seller
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        body  body  ApplyRequest  true  "Данные магазина"
+// @Success      201   {object}  SellerApplication
+// @Failure      403   {string}  string  "Email не подтвержден или роль не позволяет подать заявку"
+// @Failure      409   {string}  string  "Уже есть заявка на рассмотрении"
+// @Router       /seller/applications [post]
+func (h *SellerHandler) Apply() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := req.HandleBody[ApplyRequest](&w, r)
+		if err != nil {
+			return
+		}
+		userID, _ := r.Context().Value(middleware.ContextUserIDKey).(uint)
+
+		app, err := h.SellerService.Apply(userID, body)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		res.Json(w, app, http.StatusCreated)
+	}
+}
+
+// GetMine returns the caller's latest application.
+// @Summary      Моя заявка продавца
+// @Description  Возвращает последнюю заявку текущего пользователя и ее статус.
+// @Tags         seller
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Success      200  {object}  SellerApplication
+// @Failure      404  {string}  string  "Заявок нет"
+// @Router       /seller/applications/me [get]
+func (h *SellerHandler) GetMine() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, _ := r.Context().Value(middleware.ContextUserIDKey).(uint)
+
+		app, err := h.SellerService.GetLatest(userID)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		res.Json(w, app, http.StatusOK)
+	}
+}
+
+// List returns seller applications for review.
+// @Summary      Список заявок продавцов
+// @Description  Возвращает заявки, по умолчанию все; фильтр по статусу через ?status=pending. Требует права seller:review.
+// @Tags         admin
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        status  query  string  false  "pending, approved или rejected"
+// @Param        limit   query  int     false  "Не больше 50"
+// @Param        offset  query  int     false  "Смещение"
+// @Success      200  {object}  ListResponse
+// @Failure      403  {string}  string  "Forbidden"
+// @Router       /admin/seller-applications [get]
+func (h *SellerHandler) List() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		limit, _ := strconv.Atoi(query.Get("limit"))
+		offset, _ := strconv.Atoi(query.Get("offset"))
+
+		apps, err := h.SellerService.List(query.Get("status"), limit, offset)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		res.Json(w, ListResponse{Applications: apps}, http.StatusOK)
+	}
+}
+
+// Approve approves a pending application.
+// @Summary      Одобрить заявку продавца
+// @Description  Переводит заявку в approved, назначает пользователю роль seller, завершает его старые сессии и отправляет уведомление.
+// @Tags         admin
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id  path  int  true  "ID заявки"
+// @Success      200  {object}  SellerApplication
+// @Failure      404  {string}  string  "Заявка не найдена"
+// @Failure      409  {string}  string  "Заявка уже рассмотрена"
+// @Router       /admin/seller-applications/{id}/approve [post]
+func (h *SellerHandler) Approve() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, _ := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+		adminID, _ := r.Context().Value(middleware.ContextUserIDKey).(uint)
+
+		app, err := h.SellerService.Approve(uint(id), adminID)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		res.Json(w, app, http.StatusOK)
+	}
+}
+
+// Reject rejects a pending application.
+// @Summary      Отклонить заявку продавца
+// @Description  Переводит заявку в rejected с указанием причины и отправляет уведомление заявителю.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id    path  int            true  "ID заявки"
+// @Param        body  body  RejectRequest  true  "Причина отказа"
+// @Success      200  {object}  SellerApplication
+// @Failure      404  {string}  string  "Заявка не найдена"
+// @Failure      409  {string}  string  "Заявка уже рассмотрена"
+// @Router       /admin/seller-applications/{id}/reject [post]
+func (h *SellerHandler) Reject() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := req.HandleBody[RejectRequest](&w, r)
+		if err != nil {
+			return
+		}
+		id, _ := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+		adminID, _ := r.Context().Value(middleware.ContextUserIDKey).(uint)
+
+		app, err := h.SellerService.Reject(uint(id), adminID, body.Reason)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		res.Json(w, app, http.StatusOK)
+	}
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, ErrApplicationNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, ErrApplicationPending), errors.Is(err, ErrInvalidTransition):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, ErrNotEligible):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	default:
+		logger.FromContext(r.Context()).Errorw("❌ seller application error", logger.Err(err))
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,29 @@
+package seller
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+const (
+	StatusPending  = "pending"
+	StatusApproved = "approved"
+	StatusRejected = "rejected"
+)
+
+// SellerApplication — заявка покупателя на получение роли продавца.
+// Переходы статуса: pending -> approved | rejected, других нет.
+type SellerApplication struct {
+	gorm.Model      `swaggerignore:"true"`
+	UserID          uint           `gorm:"not null;index" json:"user_id"`
+	StoreName       string         `gorm:"not null" json:"store_name"`
+	StoreAddress    string         `gorm:"not null" json:"store_address"`
+	StorePhone      string         `json:"store_phone,omitempty"`
+	Documents       pq.StringArray `gorm:"type:text[]" json:"documents" swaggertype:"array,string"`
+	Status          string         `gorm:"not null;default:'pending';index" json:"status"`
+	RejectionReason string         `json:"rejection_reason,omitempty"`
+	ReviewedBy      *uint          `json:"reviewed_by,omitempty"`
+	ReviewedAt      *time.Time     `json:"reviewed_at,omitempty"`
+}
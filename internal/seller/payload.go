@@ -0,0 +1,17 @@
+package seller
+
+type ApplyRequest struct {
+	StoreName    string   `json:"store_name" validate:"required,max=255"`
+	StoreAddress string   `json:"store_address" validate:"required,max=255"`
+	StorePhone   string   `json:"store_phone,omitempty" validate:"omitempty,e164"`
+	Documents    []string `json:"documents" validate:"required,min=1,max=10,dive,url"` // ссылки на загруженные документы
+	AcceptTerms  bool     `json:"accept_terms" validate:"eq=true"`
+}
+
+type RejectRequest struct {
+	Reason string `json:"reason" validate:"required,max=1000"`
+}
+
+type ListResponse struct {
+	Applications []SellerApplication `json:"applications"`
+}
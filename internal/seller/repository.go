@@ -0,0 +1,117 @@
+package seller
+
+import (
+	"errors"
+	"time"
+
+	"github.com/ShopOnGO/ShopOnGO/internal/user"
+	"github.com/ShopOnGO/ShopOnGO/pkg/db"
+	"gorm.io/gorm"
+)
+
+type SellerApplicationRepository struct {
+	Database *db.Db
+}
+
+func NewSellerApplicationRepository(database *db.Db) *SellerApplicationRepository {
+	return &SellerApplicationRepository{Database: database}
+}
+
+func (repo *SellerApplicationRepository) Create(app *SellerApplication) error {
+	return repo.Database.DB.Create(app).Error
+}
+
+func (repo *SellerApplicationRepository) FindByID(id uint) (*SellerApplication, error) {
+	var app SellerApplication
+	result := repo.Database.DB.First(&app, id)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, ErrApplicationNotFound
+	}
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &app, nil
+}
+
+func (repo *SellerApplicationRepository) FindLatestByUserID(userID uint) (*SellerApplication, error) {
+	var app SellerApplication
+	result := repo.Database.DB.Where("user_id = ?", userID).Order("id DESC").First(&app)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, ErrApplicationNotFound
+	}
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &app, nil
+}
+
+func (repo *SellerApplicationRepository) HasPending(userID uint) (bool, error) {
+	var count int64
+	result := repo.Database.DB.Model(&SellerApplication{}).
+		Where("user_id = ? AND status = ?", userID, StatusPending).
+		Count(&count)
+	return count > 0, result.Error
+}
+
+func (repo *SellerApplicationRepository) List(status string, limit, offset int) ([]SellerApplication, error) {
+	var apps []SellerApplication
+	query := repo.Database.DB.Order("id")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	result := query.Limit(limit).Offset(offset).Find(&apps)
+	return apps, result.Error
+}
+
+// Approve одобряет заявку и в той же транзакции назначает пользователю роль продавца.
+func (repo *SellerApplicationRepository) Approve(id, reviewerID uint) (*SellerApplication, error) {
+	var app *SellerApplication
+	err := repo.Database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		app, err = review(tx, id, reviewerID, StatusApproved, "")
+		if err != nil {
+			return err
+		}
+		return tx.Model(&user.User{}).Where("id = ?", app.UserID).Updates(map[string]interface{}{
+			"role":          "seller",
+			"store_name":    app.StoreName,
+			"store_address": app.StoreAddress,
+			"store_phone":   app.StorePhone,
+			"accept_terms":  true,
+		}).Error
+	})
+	return app, err
+}
+
+func (repo *SellerApplicationRepository) Reject(id, reviewerID uint, reason string) (*SellerApplication, error) {
+	return review(repo.Database.DB, id, reviewerID, StatusRejected, reason)
+}
+
+// review переводит заявку из pending в новый статус. Условие на статус в UPDATE
+// не дает двум администраторам одновременно рассмотреть одну заявку.
+func review(tx *gorm.DB, id, reviewerID uint, status, reason string) (*SellerApplication, error) {
+	now := time.Now()
+	result := tx.Model(&SellerApplication{}).
+		Where("id = ? AND status = ?", id, StatusPending).
+		Updates(map[string]interface{}{
+			"status":           status,
+			"rejection_reason": reason,
+			"reviewed_by":      reviewerID,
+			"reviewed_at":      now,
+		})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	var app SellerApplication
+	if err := tx.First(&app, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrApplicationNotFound
+		}
+		return nil, err
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrInvalidTransition
+	}
+	return &app, nil
+}
@@ -0,0 +1,152 @@
+package seller
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/ShopOnGO/ShopOnGO/pkg/di"
+	"github.com/ShopOnGO/ShopOnGO/pkg/logger"
+	"github.com/ShopOnGO/ShopOnGO/pkg/oauth2"
+)
+
+const defaultListLimit = 50
+
+type Repository interface {
+	Create(app *SellerApplication) error
+	FindByID(id uint) (*SellerApplication, error)
+	FindLatestByUserID(userID uint) (*SellerApplication, error)
+	HasPending(userID uint) (bool, error)
+	List(status string, limit, offset int) ([]SellerApplication, error)
+	Approve(id, reviewerID uint) (*SellerApplication, error)
+	Reject(id, reviewerID uint, reason string) (*SellerApplication, error)
+}
+
+// EventProducer — отправка событий уведомлений (kafkaService.KafkaService).
+type EventProducer interface {
+	Produce(ctx context.Context, key, value []byte) error
+}
+
+// RoleCache сообщает проверке токенов новую роль, пока живы токены со старой
+// (user.RedisRoleCache).
+type RoleCache interface {
+	SetRole(userID uint, role string) error
+}
+
+type SellerService struct {
+	Repository     Repository
+	UserRepository di.IUserRepository
+	Sessions       oauth2.OAuth2Service
+	Notifications  EventProducer
+	Roles          RoleCache
+}
+
+func NewSellerService(repository Repository, userRepository di.IUserRepository, sessions oauth2.OAuth2Service, notifications EventProducer, roles RoleCache) *SellerService {
+	return &SellerService{
+		Repository:     repository,
+		UserRepository: userRepository,
+		Sessions:       sessions,
+		Notifications:  notifications,
+		Roles:          roles,
+	}
+}
+
+// Apply создает заявку. Роль пользователя при этом не меняется.
+func (service *SellerService) Apply(userID uint, data *ApplyRequest) (*SellerApplication, error) {
+	u, err := service.UserRepository.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if u.Role != "buyer" {
+		return nil, ErrNotEligible
+	}
+	pending, err := service.Repository.HasPending(userID)
+	if err != nil {
+		return nil, err
+	}
+	if pending {
+		return nil, ErrApplicationPending
+	}
+
+	app := &SellerApplication{
+		UserID:       userID,
+		StoreName:    data.StoreName,
+		StoreAddress: data.StoreAddress,
+		StorePhone:   data.StorePhone,
+		Documents:    data.Documents,
+		Status:       StatusPending,
+	}
+	if err := service.Repository.Create(app); err != nil {
+		return nil, err
+	}
+	logger.Infof("🏪 Seller application %d submitted by user %d", app.ID, userID)
+	return app, nil
+}
+
+func (service *SellerService) GetLatest(userID uint) (*SellerApplication, error) {
+	return service.Repository.FindLatestByUserID(userID)
+}
+
+func (service *SellerService) List(status string, limit, offset int) ([]SellerApplication, error) {
+	if limit <= 0 || limit > defaultListLimit {
+		limit = defaultListLimit
+	}
+	return service.Repository.List(status, limit, offset)
+}
+
+// Approve назначает роль продавца. Уже выданные access-токены получают новую роль
+// через кеш ролей, а refresh-токены с ролью покупателя отзываются.
+func (service *SellerService) Approve(id, adminID uint) (*SellerApplication, error) {
+	app, err := service.Repository.Approve(id, adminID)
+	if err != nil {
+		return nil, err
+	}
+	logger.Infof("✅ Seller application %d approved by admin %d", id, adminID)
+
+	if err := service.Roles.SetRole(app.UserID, "seller"); err != nil {
+		logger.Error("❌ failed to cache seller role after approval: " + err.Error())
+	}
+	if err := service.Sessions.RevokeAll(app.UserID); err != nil {
+		logger.Error("❌ failed to revoke sessions after seller approval: " + err.Error())
+	}
+	service.notify(app, "SELLER_APPLICATION_APPROVED")
+	return app, nil
+}
+
+func (service *SellerService) Reject(id, adminID uint, reason string) (*SellerApplication, error) {
+	app, err := service.Repository.Reject(id, adminID, reason)
+	if err != nil {
+		return nil, err
+	}
+	logger.Infof("🚫 Seller application %d rejected by admin %d", id, adminID)
+
+	service.notify(app, "SELLER_APPLICATION_REJECTED")
+	return app, nil
+}
+
+// notify отправляет заявителю уведомление о решении. Ошибка отправки не отменяет решение.
+func (service *SellerService) notify(app *SellerApplication, subtype string) {
+	event := map[string]interface{}{
+		"action":   "create",
+		"category": "SELLER",
+		"subtype":  subtype,
+		"userID":   app.UserID,
+		"wasInDlq": false,
+		"payload": map[string]interface{}{
+			"applicationID": app.ID,
+			"status":        app.Status,
+			"storeName":     app.StoreName,
+			"reason":        app.RejectionReason,
+		},
+	}
+
+	eventBytes, err := json.Marshal(event)
+	if err != nil {
+		logger.Error("❌ ошибка сериализации события: " + err.Error())
+		return
+	}
+	if err := service.Notifications.Produce(context.Background(), []byte("seller-application"), eventBytes); err != nil {
+		logger.Errorf("❌ ошибка отправки сообщения в Kafka: %v", err)
+		return
+	}
+	logger.Infof("📨 Уведомление о заявке продавца %d отправлено в Kafka", app.ID)
+}
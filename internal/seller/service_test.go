@@ -0,0 +1,202 @@
+package seller
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ShopOnGO/ShopOnGO/configs"
+	"github.com/ShopOnGO/ShopOnGO/internal/user"
+	"github.com/ShopOnGO/ShopOnGO/internal/user/usertest"
+	"github.com/ShopOnGO/ShopOnGO/pkg/jwt"
+	"github.com/ShopOnGO/ShopOnGO/pkg/middleware"
+	"github.com/ShopOnGO/ShopOnGO/pkg/oauth2/oauth2test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// memoryRepository повторяет переходы статусов репозитория в памяти.
+type memoryRepository struct {
+	apps  []*SellerApplication
+	users *usertest.Repository
+}
+
+func (m *memoryRepository) Create(app *SellerApplication) error {
+	app.ID = uint(len(m.apps) + 1)
+	m.apps = append(m.apps, app)
+	return nil
+}
+
+func (m *memoryRepository) FindByID(id uint) (*SellerApplication, error) {
+	if id == 0 || int(id) > len(m.apps) {
+		return nil, ErrApplicationNotFound
+	}
+	return m.apps[id-1], nil
+}
+
+func (m *memoryRepository) FindLatestByUserID(userID uint) (*SellerApplication, error) {
+	for i := len(m.apps) - 1; i >= 0; i-- {
+		if m.apps[i].UserID == userID {
+			return m.apps[i], nil
+		}
+	}
+	return nil, ErrApplicationNotFound
+}
+
+func (m *memoryRepository) HasPending(userID uint) (bool, error) {
+	for _, app := range m.apps {
+		if app.UserID == userID && app.Status == StatusPending {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *memoryRepository) List(status string, limit, offset int) ([]SellerApplication, error) {
+	return nil, nil
+}
+
+func (m *memoryRepository) Approve(id, reviewerID uint) (*SellerApplication, error) {
+	app, err := m.review(id, reviewerID, StatusApproved, "")
+	if err == nil {
+		m.users.Users[app.UserID].Role = "seller"
+	}
+	return app, err
+}
+
+func (m *memoryRepository) Reject(id, reviewerID uint, reason string) (*SellerApplication, error) {
+	return m.review(id, reviewerID, StatusRejected, reason)
+}
+
+func (m *memoryRepository) review(id, reviewerID uint, status, reason string) (*SellerApplication, error) {
+	app, err := m.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if app.Status != StatusPending {
+		return nil, ErrInvalidTransition
+	}
+	app.Status = status
+	app.RejectionReason = reason
+	app.ReviewedBy = &reviewerID
+	return app, nil
+}
+
+// fakeRoleCache — кеш ролей, который читает проверка токенов.
+type fakeRoleCache map[uint]string
+
+func (f fakeRoleCache) SetRole(userID uint, role string) error {
+	f[userID] = role
+	return nil
+}
+
+func (f fakeRoleCache) CurrentRole(userID uint) (string, error) { return f[userID], nil }
+
+type fakeProducer struct {
+	events []map[string]interface{}
+}
+
+func (f *fakeProducer) Produce(ctx context.Context, key, value []byte) error {
+	var event map[string]interface{}
+	json.Unmarshal(value, &event)
+	f.events = append(f.events, event)
+	return nil
+}
+
+func newTestService() (*SellerService, *memoryRepository, *oauth2test.Sessions, *fakeProducer) {
+	users := usertest.NewRepository(
+		&user.User{Model: gorm.Model{ID: 1}, Role: "buyer"},
+		&user.User{Model: gorm.Model{ID: 2}, Role: "admin"},
+	)
+	repo := &memoryRepository{users: users}
+	sessions := &oauth2test.Sessions{}
+	producer := &fakeProducer{}
+	return NewSellerService(repo, users, sessions, producer, fakeRoleCache{}), repo, sessions, producer
+}
+
+var application = &ApplyRequest{
+	StoreName:    "My Store",
+	StoreAddress: "Main St 1",
+	Documents:    []string{"https://example.com/license.pdf"},
+	AcceptTerms:  true,
+}
+
+func TestApply(t *testing.T) {
+	service, _, _, _ := newTestService()
+
+	app, err := service.Apply(1, application)
+	require.NoError(t, err)
+	assert.Equal(t, StatusPending, app.Status)
+
+	_, err = service.Apply(1, application)
+	assert.ErrorIs(t, err, ErrApplicationPending)
+
+	_, err = service.Apply(2, application)
+	assert.ErrorIs(t, err, ErrNotEligible)
+}
+
+func TestApprove(t *testing.T) {
+	service, repo, sessions, producer := newTestService()
+	app, _ := service.Apply(1, application)
+
+	approved, err := service.Approve(app.ID, 2)
+	require.NoError(t, err)
+	assert.Equal(t, StatusApproved, approved.Status)
+	assert.Equal(t, "seller", repo.users.Users[1].Role)
+	assert.Equal(t, []uint{1}, sessions.Revoked, "старые токены с ролью покупателя отзываются")
+	require.Len(t, producer.events, 1)
+	assert.Equal(t, "SELLER_APPLICATION_APPROVED", producer.events[0]["subtype"])
+
+	_, err = service.Reject(app.ID, 2, "late")
+	assert.ErrorIs(t, err, ErrInvalidTransition)
+}
+
+func TestApprove_IssuedTokenGetsSellerRole(t *testing.T) {
+	service, _, _, _ := newTestService()
+	roles := service.Roles.(fakeRoleCache)
+	conf := &configs.Config{OAuth: configs.OAuthConfig{Secret: "secret"}}
+	guard := &middleware.TokenGuard{Roles: roles}
+
+	token, err := jwt.NewJWT(conf.OAuth.Secret).Create(jwt.JWTData{UserID: 1, Role: "buyer"}, time.Minute)
+	require.NoError(t, err)
+
+	var role string
+	handler := middleware.IsAuthed(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		role, _ = r.Context().Value(middleware.ContextRolesKey).(string)
+	}), conf, guard)
+	call := func() {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Authorization", "Bearer "+token)
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	call()
+	assert.Equal(t, "buyer", role)
+
+	app, _ := service.Apply(1, application)
+	_, err = service.Approve(app.ID, 2)
+	require.NoError(t, err)
+
+	call()
+	assert.Equal(t, "seller", role, "токен, выданный до одобрения, получает роль продавца")
+}
+
+func TestReject(t *testing.T) {
+	service, repo, sessions, producer := newTestService()
+	app, _ := service.Apply(1, application)
+
+	rejected, err := service.Reject(app.ID, 2, "documents are unreadable")
+	require.NoError(t, err)
+	assert.Equal(t, StatusRejected, rejected.Status)
+	assert.Equal(t, "buyer", repo.users.Users[1].Role)
+	assert.Empty(t, sessions.Revoked)
+	assert.Equal(t, "SELLER_APPLICATION_REJECTED", producer.events[0]["subtype"])
+
+	// после отказа можно подать новую заявку
+	_, err = service.Apply(1, application)
+	assert.NoError(t, err)
+}
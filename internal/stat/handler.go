@@ -19,6 +19,7 @@ const (
 type StatHandlerDeps struct { // содержит все необходимые элементы заполнения. это DC
 	StatRepository *StatRepository
 	Config         *configs.Config
+	Guard          *middleware.TokenGuard
 }
 type StatHandler struct { // это уже рабоая структура
 	StatRepository *StatRepository
@@ -28,7 +29,7 @@ func NewStatHandler(router *mux.Router, deps StatHandlerDeps) {
 	handler := &StatHandler{
 		StatRepository: deps.StatRepository,
 	}
-	router.Handle("/stat", middleware.IsAuthed(handler.GetStat(), deps.Config)).Methods("GET")
+	router.Handle("/stat", middleware.IsAuthed(handler.GetStat(), deps.Config, deps.Guard)).Methods("GET")
 }
 
 // GetStat получает статистику переходов по ссылкам за указанный период
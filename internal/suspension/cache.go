@@ -0,0 +1,45 @@
+package suspension
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ShopOnGO/ShopOnGO/pkg/redisdb"
+)
+
+// RedisBanCache — множество заблокированных ID. Временная блокировка хранится
+// с TTL до ее окончания и исчезает из кеша сама.
+type RedisBanCache struct {
+	redis *redisdb.RedisDB
+}
+
+func NewRedisBanCache(r *redisdb.RedisDB) *RedisBanCache {
+	return &RedisBanCache{redis: r}
+}
+
+// Add помечает пользователя заблокированным; ttl == 0 — бессрочно.
+func (c *RedisBanCache) Add(userID uint, ttl time.Duration) error {
+	return c.redis.Set(context.Background(), c.key(userID), 1, ttl).Err()
+}
+
+func (c *RedisBanCache) Remove(userID uint) error {
+	return c.redis.Del(context.Background(), c.key(userID)).Err()
+}
+
+func (c *RedisBanCache) Exists(userID uint) (bool, error) {
+	n, err := c.redis.Exists(context.Background(), c.key(userID)).Result()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// IsBanned реализует middleware.BanChecker: кеш проверяется на каждом запросе с токеном.
+func (c *RedisBanCache) IsBanned(userID uint) (bool, error) {
+	return c.Exists(userID)
+}
+
+func (c *RedisBanCache) key(userID uint) string {
+	return fmt.Sprintf("banned:user:%d", userID)
+}
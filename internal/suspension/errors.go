@@ -0,0 +1,11 @@
+package suspension
+
+import "errors"
+
+var (
+	ErrUserNotFound     = errors.New("user not found")
+	ErrNotBanned        = errors.New("user is not banned")
+	ErrCannotBanSelf    = errors.New("you cannot ban yourself")
+	ErrProtectedAccount = errors.New("users who can ban others cannot be banned")
+	ErrInvalidExpiry    = errors.New("ban expiry must be in the future")
+)
@@ -0,0 +1,136 @@
+package suspension
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/ShopOnGO/ShopOnGO/configs"
+	"github.com/ShopOnGO/ShopOnGO/internal/rbac"
+	"github.com/ShopOnGO/ShopOnGO/pkg/logger"
+	"github.com/ShopOnGO/ShopOnGO/pkg/middleware"
+	"github.com/ShopOnGO/ShopOnGO/pkg/req"
+	"github.com/ShopOnGO/ShopOnGO/pkg/res"
+	"github.com/gorilla/mux"
+)
+
+type SuspensionHandlerDeps struct {
+	*configs.Config
+	Guard *middleware.TokenGuard
+	*SuspensionService
+	Permissions middleware.PermissionChecker
+}
+
+type SuspensionHandler struct {
+	*configs.Config
+	*SuspensionService
+}
+
+func NewSuspensionHandler(router *mux.Router, deps SuspensionHandlerDeps) {
+	handler := &SuspensionHandler{
+		Config:            deps.Config,
+		SuspensionService: deps.SuspensionService,
+	}
+	moderator := func(next http.Handler) http.Handler {
+		return middleware.IsAuthed(middleware.RequirePermission(next, rbac.PermUserBan, deps.Permissions), deps.Config, deps.Guard)
+	}
+	router.Handle("/admin/users/{id:[0-9]+}/ban", moderator(handler.GetStatus())).Methods("GET")
+	router.Handle("/admin/users/{id:[0-9]+}/ban", moderator(handler.Ban())).Methods("POST")
+	router.Handle("/admin/users/{id:[0-9]+}/ban", moderator(handler.Unban())).Methods("DELETE")
+}
+
+// GetStatus returns the user's ban status.
+// @Summary      Статус блокировки
+// @Description  Возвращает статус пользователя, причину и срок блокировки. Требует права user:ban.
+// @Tags         admin
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id  path  int  true  "ID пользователя"
+// @Success      200  {object}  BanStatusResponse
+// @Failure      404  {string}  string  "Пользователь не найден"
+// @Router       /admin/users/{id}/ban [get]
+func (h *SuspensionHandler) GetStatus() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, _ := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+
+		status, err := h.SuspensionService.Status(uint(id))
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		res.Json(w, status, http.StatusOK)
+	}
+}
+
+// Ban suspends a user.
+// @Summary      Заблокировать пользователя
+// @Description  Блокирует пользователя с причиной и необязательным сроком (RFC3339, без срока — бессрочно). Все сессии пользователя завершаются, чат отключается, действие пишется в журнал аудита. Требует права user:ban.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id    path  int         true  "ID пользователя"
+// @Param        body  body  BanRequest  true  "Причина и срок"
+// @Success      200  {object}  BanStatusResponse
+// @Failure      400  {string}  string  "Срок в прошлом"
+// @Failure      403  {string}  string  "Нельзя заблокировать себя или модератора"
+// @Failure      404  {string}  string  "Пользователь не найден"
+// @Router       /admin/users/{id}/ban [post]
+func (h *SuspensionHandler) Ban() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := req.HandleBody[BanRequest](&w, r)
+		if err != nil {
+			return
+		}
+		id, _ := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+		actorID, _ := r.Context().Value(middleware.ContextUserIDKey).(uint)
+
+		status, err := h.SuspensionService.Ban(r.Context(), actorID, uint(id), body.Reason, body.Until)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		res.Json(w, status, http.StatusOK)
+	}
+}
+
+// Unban lifts a suspension.
+// @Summary      Разблокировать пользователя
+// @Description  Снимает блокировку и пишет действие в журнал аудита. Требует права user:ban.
+// @Tags         admin
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id  path  int  true  "ID пользователя"
+// @Success      200  {object}  BanStatusResponse
+// @Failure      404  {string}  string  "Пользователь не найден"
+// @Failure      409  {string}  string  "Пользователь не заблокирован"
+// @Router       /admin/users/{id}/ban [delete]
+func (h *SuspensionHandler) Unban() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, _ := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+		actorID, _ := r.Context().Value(middleware.ContextUserIDKey).(uint)
+
+		status, err := h.SuspensionService.Unban(r.Context(), actorID, uint(id))
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		res.Json(w, status, http.StatusOK)
+	}
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, ErrUserNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, ErrNotBanned):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, ErrCannotBanSelf), errors.Is(err, ErrProtectedAccount):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, ErrInvalidExpiry):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		logger.FromContext(r.Context()).Errorw("❌ suspension error", logger.Err(err))
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
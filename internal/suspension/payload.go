@@ -0,0 +1,15 @@
+package suspension
+
+import "time"
+
+type BanRequest struct {
+	Reason string     `json:"reason" validate:"required,max=500"`
+	Until  *time.Time `json:"until,omitempty"` // пусто — бессрочно
+}
+
+type BanStatusResponse struct {
+	UserID uint       `json:"user_id"`
+	Status string     `json:"status"`
+	Reason string     `json:"reason,omitempty"`
+	Until  *time.Time `json:"until,omitempty"`
+}
@@ -0,0 +1,40 @@
+package suspension
+
+import (
+	"time"
+
+	"github.com/ShopOnGO/ShopOnGO/internal/user"
+	"github.com/ShopOnGO/ShopOnGO/pkg/db"
+)
+
+type SuspensionRepository struct {
+	Database *db.Db
+}
+
+func NewSuspensionRepository(database *db.Db) *SuspensionRepository {
+	return &SuspensionRepository{Database: database}
+}
+
+func (repo *SuspensionRepository) SetBan(userID uint, reason string, until *time.Time) error {
+	return repo.Database.DB.Model(&user.User{}).Where("id = ?", userID).Updates(map[string]any{
+		"status":       user.StatusBanned,
+		"ban_reason":   reason,
+		"banned_until": until,
+	}).Error
+}
+
+func (repo *SuspensionRepository) ClearBan(userID uint) error {
+	return repo.Database.DB.Model(&user.User{}).
+		Where("id = ? AND status = ?", userID, user.StatusBanned).
+		Updates(map[string]any{
+			"status":       user.StatusActive,
+			"ban_reason":   nil,
+			"banned_until": nil,
+		}).Error
+}
+
+func (repo *SuspensionRepository) ListBanned() ([]user.User, error) {
+	var users []user.User
+	result := repo.Database.DB.Where("status = ?", user.StatusBanned).Find(&users)
+	return users, result.Error
+}
@@ -0,0 +1,223 @@
+package suspension
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ShopOnGO/ShopOnGO/internal/audit"
+	"github.com/ShopOnGO/ShopOnGO/internal/rbac"
+	"github.com/ShopOnGO/ShopOnGO/internal/user"
+	"github.com/ShopOnGO/ShopOnGO/pkg/di"
+	"github.com/ShopOnGO/ShopOnGO/pkg/logger"
+	"github.com/ShopOnGO/ShopOnGO/pkg/middleware"
+	"github.com/ShopOnGO/ShopOnGO/pkg/oauth2"
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	SetBan(userID uint, reason string, until *time.Time) error
+	ClearBan(userID uint) error
+	ListBanned() ([]user.User, error)
+}
+
+type Cache interface {
+	Add(userID uint, ttl time.Duration) error
+	Remove(userID uint) error
+	Exists(userID uint) (bool, error)
+}
+
+type AuditRecorder interface {
+	Record(ctx context.Context, entry *audit.Entry) error
+}
+
+// ChatDisconnector закрывает открытые WebSocket-соединения пользователя.
+type ChatDisconnector interface {
+	DisconnectUser(userID uint)
+}
+
+type SuspensionService struct {
+	Repository     Repository
+	Cache          Cache
+	UserRepository di.IUserRepository
+	Sessions       oauth2.OAuth2Service
+	Audit          AuditRecorder
+	Chat           ChatDisconnector
+	Permissions    middleware.PermissionChecker
+	now            func() time.Time
+}
+
+type SuspensionServiceDeps struct {
+	Repository     Repository
+	Cache          Cache
+	UserRepository di.IUserRepository
+	Sessions       oauth2.OAuth2Service
+	Audit          AuditRecorder
+	Chat           ChatDisconnector
+	Permissions    middleware.PermissionChecker
+}
+
+func NewSuspensionService(deps SuspensionServiceDeps) *SuspensionService {
+	return &SuspensionService{
+		Repository:     deps.Repository,
+		Cache:          deps.Cache,
+		UserRepository: deps.UserRepository,
+		Sessions:       deps.Sessions,
+		Audit:          deps.Audit,
+		Chat:           deps.Chat,
+		Permissions:    deps.Permissions,
+		now:            time.Now,
+	}
+}
+
+// IsBanned реализует middleware.BanChecker.
+func (service *SuspensionService) IsBanned(userID uint) (bool, error) {
+	return service.Cache.Exists(userID)
+}
+
+// Ban блокирует пользователя до until (nil — бессрочно): сохраняет причину, кладет ID
+// в кеш, отзывает refresh-токены, закрывает чат и пишет запись аудита. Повторный бан
+// обновляет причину и срок.
+func (service *SuspensionService) Ban(ctx context.Context, actorID, userID uint, reason string, until *time.Time) (*BanStatusResponse, error) {
+	if actorID == userID {
+		return nil, ErrCannotBanSelf
+	}
+	var ttl time.Duration
+	if until != nil {
+		ttl = until.Sub(service.now())
+		if ttl <= 0 {
+			return nil, ErrInvalidExpiry
+		}
+	}
+
+	u, err := service.findUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if service.Permissions.HasPermission(u.Role, rbac.PermUserBan) {
+		return nil, ErrProtectedAccount
+	}
+	before := statusOf(u)
+
+	// Сначала кеш: по нему проверяются запросы, и бан, записанный только в БД,
+	// не действовал бы до следующего SyncCache. Если БД не записалась, кеш откатываем.
+	if err := service.Cache.Add(userID, ttl); err != nil {
+		return nil, err
+	}
+	if err := service.Repository.SetBan(userID, reason, until); err != nil {
+		if before.Status != user.StatusBanned {
+			if rmErr := service.Cache.Remove(userID); rmErr != nil {
+				logger.Errorf("❌ Failed to roll back ban cache of user %d: %v", userID, rmErr)
+			}
+		}
+		return nil, err
+	}
+	if err := service.Sessions.RevokeAll(userID); err != nil {
+		logger.Errorf("❌ Failed to revoke sessions of banned user %d: %v", userID, err)
+	}
+	if service.Chat != nil {
+		service.Chat.DisconnectUser(userID)
+	}
+
+	after := &BanStatusResponse{UserID: userID, Status: user.StatusBanned, Reason: reason, Until: until}
+	service.Audit.Record(ctx, &audit.Entry{
+		ActorID:    actorID,
+		Action:     "user.ban",
+		ResourceID: fmt.Sprint(userID),
+		Before:     audit.JSON(before),
+		After:      audit.JSON(after),
+		Success:    true,
+	})
+	logger.Infof("🚫 User %d banned by %d until %v: %s", userID, actorID, until, reason)
+	return after, nil
+}
+
+func (service *SuspensionService) Unban(ctx context.Context, actorID, userID uint) (*BanStatusResponse, error) {
+	u, err := service.findUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if u.Status != user.StatusBanned {
+		return nil, ErrNotBanned
+	}
+	before := statusOf(u)
+
+	if err := service.Repository.ClearBan(userID); err != nil {
+		return nil, err
+	}
+	if err := service.Cache.Remove(userID); err != nil {
+		return nil, err
+	}
+
+	after := &BanStatusResponse{UserID: userID, Status: user.StatusActive}
+	service.Audit.Record(ctx, &audit.Entry{
+		ActorID:    actorID,
+		Action:     "user.unban",
+		ResourceID: fmt.Sprint(userID),
+		Before:     audit.JSON(before),
+		After:      audit.JSON(after),
+		Success:    true,
+	})
+	logger.Infof("✅ User %d unbanned by %d", userID, actorID)
+	return after, nil
+}
+
+func (service *SuspensionService) Status(userID uint) (*BanStatusResponse, error) {
+	u, err := service.findUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if u.Status == user.StatusBanned && !u.IsBanned(service.now()) {
+		// срок истек, статус сбросит SyncCache
+		return &BanStatusResponse{UserID: u.ID, Status: user.StatusActive}, nil
+	}
+	return statusOf(u), nil
+}
+
+// SyncCache восстанавливает кеш из БД (например, после очистки Redis) и снимает
+// истекшие временные блокировки. Вызывается при старте.
+func (service *SuspensionService) SyncCache() error {
+	users, err := service.Repository.ListBanned()
+	if err != nil {
+		return err
+	}
+	now := service.now()
+	for _, u := range users {
+		if !u.IsBanned(now) {
+			if err := service.Repository.ClearBan(u.ID); err != nil {
+				return err
+			}
+			continue
+		}
+		var ttl time.Duration
+		if u.BannedUntil != nil {
+			ttl = u.BannedUntil.Sub(now)
+		}
+		if err := service.Cache.Add(u.ID, ttl); err != nil {
+			return err
+		}
+	}
+	logger.Infof("🚫 Ban cache synced: %d banned users", len(users))
+	return nil
+}
+
+func (service *SuspensionService) findUser(userID uint) (*user.User, error) {
+	u, err := service.UserRepository.FindByID(userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && u == nil) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+func statusOf(u *user.User) *BanStatusResponse {
+	return &BanStatusResponse{
+		UserID: u.ID,
+		Status: u.Status,
+		Reason: u.BanReason,
+		Until:  u.BannedUntil,
+	}
+}
@@ -0,0 +1,216 @@
+package suspension
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ShopOnGO/ShopOnGO/internal/audit"
+	"github.com/ShopOnGO/ShopOnGO/internal/rbac"
+	"github.com/ShopOnGO/ShopOnGO/internal/user"
+	"github.com/ShopOnGO/ShopOnGO/internal/user/usertest"
+	"github.com/ShopOnGO/ShopOnGO/pkg/oauth2/oauth2test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// fakeUsers дополняет общий двойник методами блокировки; SetBan/ClearBan меняют
+// пользователей так же, как Postgres.
+type fakeUsers struct {
+	*usertest.Repository
+	setBanErr error
+}
+
+func (f *fakeUsers) SetBan(userID uint, reason string, until *time.Time) error {
+	if f.setBanErr != nil {
+		return f.setBanErr
+	}
+	u := f.Users[userID]
+	u.Status, u.BanReason, u.BannedUntil = user.StatusBanned, reason, until
+	return nil
+}
+func (f *fakeUsers) ClearBan(userID uint) error {
+	u := f.Users[userID]
+	u.Status, u.BanReason, u.BannedUntil = user.StatusActive, "", nil
+	return nil
+}
+func (f *fakeUsers) ListBanned() ([]user.User, error) {
+	var banned []user.User
+	for _, u := range f.Users {
+		if u.Status == user.StatusBanned {
+			banned = append(banned, *u)
+		}
+	}
+	return banned, nil
+}
+
+type fakeCache struct {
+	ttl    map[uint]time.Duration
+	addErr error
+}
+
+func (f *fakeCache) Add(userID uint, ttl time.Duration) error {
+	if f.addErr != nil {
+		return f.addErr
+	}
+	f.ttl[userID] = ttl
+	return nil
+}
+func (f *fakeCache) Remove(userID uint) error { delete(f.ttl, userID); return nil }
+func (f *fakeCache) Exists(userID uint) (bool, error) {
+	_, ok := f.ttl[userID]
+	return ok, nil
+}
+
+type fakeRecorder struct {
+	entries []*audit.Entry
+}
+
+func (f *fakeRecorder) Record(ctx context.Context, entry *audit.Entry) error {
+	f.entries = append(f.entries, entry)
+	return nil
+}
+
+type fakeChat struct {
+	disconnected []uint
+}
+
+func (f *fakeChat) DisconnectUser(userID uint) { f.disconnected = append(f.disconnected, userID) }
+
+// moderatorsOnly — у модераторов и админов есть user:ban.
+type moderatorsOnly struct{}
+
+func (moderatorsOnly) HasPermission(role, permission string) bool {
+	return permission == rbac.PermUserBan && (role == "moderator" || role == "admin")
+}
+
+type fixture struct {
+	service  *SuspensionService
+	users    *fakeUsers
+	cache    *fakeCache
+	sessions *oauth2test.Sessions
+	recorder *fakeRecorder
+	chat     *fakeChat
+	now      time.Time
+}
+
+func newFixture() *fixture {
+	f := &fixture{
+		users: &fakeUsers{Repository: usertest.NewRepository(
+			&user.User{Model: gorm.Model{ID: 1}, Role: "moderator", Status: user.StatusActive},
+			&user.User{Model: gorm.Model{ID: 2}, Role: "buyer", Status: user.StatusActive},
+			&user.User{Model: gorm.Model{ID: 3}, Role: "admin", Status: user.StatusActive},
+		)},
+		cache:    &fakeCache{ttl: map[uint]time.Duration{}},
+		sessions: &oauth2test.Sessions{},
+		recorder: &fakeRecorder{},
+		chat:     &fakeChat{},
+		now:      time.Unix(1700000000, 0),
+	}
+	f.service = NewSuspensionService(SuspensionServiceDeps{
+		Repository:     f.users,
+		Cache:          f.cache,
+		UserRepository: f.users,
+		Sessions:       f.sessions,
+		Audit:          f.recorder,
+		Chat:           f.chat,
+		Permissions:    moderatorsOnly{},
+	})
+	f.service.now = func() time.Time { return f.now }
+	return f
+}
+
+func TestBan(t *testing.T) {
+	t.Run("Temporary ban revokes sessions and is audited", func(t *testing.T) {
+		f := newFixture()
+		until := f.now.Add(24 * time.Hour)
+
+		status, err := f.service.Ban(context.Background(), 1, 2, "spam", &until)
+		require.NoError(t, err)
+		assert.Equal(t, user.StatusBanned, status.Status)
+
+		banned, _ := f.service.IsBanned(2)
+		assert.True(t, banned)
+		assert.Equal(t, 24*time.Hour, f.cache.ttl[2])
+		assert.Equal(t, []uint{2}, f.sessions.Revoked)
+		assert.Equal(t, []uint{2}, f.chat.disconnected)
+
+		require.Len(t, f.recorder.entries, 1)
+		entry := f.recorder.entries[0]
+		assert.Equal(t, "user.ban", entry.Action)
+		assert.Equal(t, uint(1), entry.ActorID)
+		assert.Equal(t, "2", entry.ResourceID)
+		assert.Contains(t, string(entry.Before), `"status":"active"`)
+		assert.Contains(t, string(entry.After), `"reason":"spam"`)
+	})
+
+	t.Run("Permanent ban has no cache TTL", func(t *testing.T) {
+		f := newFixture()
+		_, err := f.service.Ban(context.Background(), 1, 2, "fraud", nil)
+		require.NoError(t, err)
+		assert.Equal(t, time.Duration(0), f.cache.ttl[2])
+	})
+
+	t.Run("Cache and database stay consistent on failure", func(t *testing.T) {
+		f := newFixture()
+		f.cache.addErr = errors.New("redis down")
+		_, err := f.service.Ban(context.Background(), 1, 2, "spam", nil)
+		assert.Error(t, err)
+		assert.Equal(t, user.StatusActive, f.users.Users[2].Status)
+
+		f.cache.addErr = nil
+		f.users.setBanErr = errors.New("db down")
+		_, err = f.service.Ban(context.Background(), 1, 2, "spam", nil)
+		assert.Error(t, err)
+		banned, _ := f.service.IsBanned(2)
+		assert.False(t, banned)
+		assert.Empty(t, f.sessions.Revoked)
+	})
+
+	t.Run("Rejected cases", func(t *testing.T) {
+		f := newFixture()
+		past := f.now.Add(-time.Minute)
+
+		_, err := f.service.Ban(context.Background(), 1, 1, "x", nil)
+		assert.ErrorIs(t, err, ErrCannotBanSelf)
+		_, err = f.service.Ban(context.Background(), 1, 3, "x", nil)
+		assert.ErrorIs(t, err, ErrProtectedAccount)
+		_, err = f.service.Ban(context.Background(), 1, 2, "x", &past)
+		assert.ErrorIs(t, err, ErrInvalidExpiry)
+		_, err = f.service.Ban(context.Background(), 1, 99, "x", nil)
+		assert.ErrorIs(t, err, ErrUserNotFound)
+		assert.Empty(t, f.recorder.entries)
+	})
+}
+
+func TestUnban(t *testing.T) {
+	f := newFixture()
+	_, err := f.service.Unban(context.Background(), 1, 2)
+	assert.ErrorIs(t, err, ErrNotBanned)
+
+	f.service.Ban(context.Background(), 1, 2, "spam", nil)
+	status, err := f.service.Unban(context.Background(), 1, 2)
+	require.NoError(t, err)
+	assert.Equal(t, user.StatusActive, status.Status)
+
+	banned, _ := f.service.IsBanned(2)
+	assert.False(t, banned)
+	require.Len(t, f.recorder.entries, 2)
+	assert.Equal(t, "user.unban", f.recorder.entries[1].Action)
+}
+
+func TestSyncCache(t *testing.T) {
+	f := newFixture()
+	expired := f.now.Add(-time.Hour)
+	active := f.now.Add(time.Hour)
+	f.users.Users[2].Status, f.users.Users[2].BannedUntil = user.StatusBanned, &expired
+	f.users.Users[4] = &user.User{Model: gorm.Model{ID: 4}, Status: user.StatusBanned, BannedUntil: &active}
+
+	require.NoError(t, f.service.SyncCache())
+
+	assert.Equal(t, user.StatusActive, f.users.Users[2].Status, "истекшая блокировка снимается")
+	assert.NotContains(t, f.cache.ttl, uint(2))
+	assert.Equal(t, time.Hour, f.cache.ttl[4])
+}
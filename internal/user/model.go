@@ -1,18 +1,33 @@
 package user
 
 import (
+	"time"
+
 	"github.com/ShopOnGO/ShopOnGO/internal/cart"
 	"gorm.io/gorm"
 )
 
+const (
+	StatusActive  = "active"
+	StatusBanned  = "banned"
+	StatusDeleted = "deleted"
+)
+
 type User struct {
 	gorm.Model   `swaggerignore:"true"` // Включает ID, CreatedAt, UpdatedAt, DeletedAt
 	Name         string `gorm:"not null"`
 	Email        string `gorm:"unique;not null;index"`
 	PasswordHash string `gorm:"default:null"`
 	Role         string `gorm:"not null;default:'buyer'"` // "admin", "seller", "buyer"
-	Provider     string `gorm:"not null;default:'local'"` // "local", "google", "facebook"
+	Provider     string `gorm:"not null;default:'local'"` // провайдер регистрации: "local" или имя OAuth-провайдера; привязанные провайдеры — в identity.UserIdentity
 	Status       string `gorm:"not null;default:'active'"` // "active", "banned", "deleted"
+
+	BanReason   string     `gorm:"default:null"`
+	BannedUntil *time.Time `gorm:"default:null"` // nil при Status == "banned" — бессрочная блокировка
+
+	EmailVerified   bool       `gorm:"not null;default:false"`
+	EmailVerifiedAt *time.Time `gorm:"default:null"`
+
 	Phone        string `gorm:"default:null"`
 	ProfileImage string `gorm:"default:null"`
 
@@ -24,3 +39,12 @@ type User struct {
 
 	Cart *cart.Cart `gorm:"foreignKey:UserID"`
 }
+
+// IsBanned сообщает, действует ли блокировка на момент now. Истекшая временная
+// блокировка не считается действующей, даже если статус еще не сброшен.
+func (u *User) IsBanned(now time.Time) bool {
+	if u.Status != StatusBanned {
+		return false
+	}
+	return u.BannedUntil == nil || u.BannedUntil.After(now)
+}
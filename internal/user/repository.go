@@ -2,6 +2,7 @@ package user
 
 import (
 	"errors"
+	"time"
 
 	"github.com/ShopOnGO/ShopOnGO/pkg/db"
 )
@@ -44,6 +45,18 @@ func (repo *UserRepository) FindByEmail(email string) (*User, error) {
 	return &user, nil
 }
 
+func (repo *UserRepository) FindByID(id uint) (*User, error) {
+	if id == 0 {
+		return nil, errors.New("user ID cannot be empty")
+	}
+	var user User
+	result := repo.Database.DB.First(&user, id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &user, nil
+}
+
 func (repo *UserRepository) Update(user *User) (*User, error) {
 	if user.ID == 0 {
 		return nil, errors.New("user ID is required for update")
@@ -71,7 +84,7 @@ func (repo *UserRepository) UpdateUserPassword(id uint, newPassword string) erro
 
 		return errors.New("user ID is required for UpdateUserPassword")
 	}
-	result := repo.Database.DB.Model(&User{}).Where("id = ?", id).Update("password", newPassword)
+	result := repo.Database.DB.Model(&User{}).Where("id = ?", id).Update("password_hash", newPassword)
 	return result.Error
 }
 
@@ -103,4 +116,23 @@ func (repo *UserRepository) GetNameByID(id uint) (string, error) {
         return "", result.Error
     }
 	return name, nil
-}
\ No newline at end of file
+}
+func (repo *UserRepository) MarkEmailVerified(id uint) error {
+	if id == 0 {
+		return errors.New("user ID is required for MarkEmailVerified")
+	}
+	result := repo.Database.DB.Model(&User{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"email_verified":    true,
+		"email_verified_at": time.Now(),
+	})
+	return result.Error
+}
+
+func (repo *UserRepository) IsEmailVerified(id uint) (bool, error) {
+	var verified bool
+	result := repo.Database.DB.Model(&User{}).Select("email_verified").Where("id = ?", id).First(&verified)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return verified, nil
+}
@@ -0,0 +1,39 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/ShopOnGO/ShopOnGO/pkg/redisdb"
+)
+
+// RedisRoleCache хранит роль, назначенную после выдачи access-токенов. Запись живет
+// столько же, сколько access-токен: все токены со старой ролью истекают раньше нее.
+type RedisRoleCache struct {
+	redis *redisdb.RedisDB
+	ttl   time.Duration
+}
+
+func NewRedisRoleCache(r *redisdb.RedisDB, ttl time.Duration) *RedisRoleCache {
+	return &RedisRoleCache{redis: r, ttl: ttl}
+}
+
+func (c *RedisRoleCache) SetRole(userID uint, role string) error {
+	return c.redis.Set(context.Background(), c.key(userID), role, c.ttl).Err()
+}
+
+// CurrentRole реализует middleware.RoleSource; пустая строка — роль не менялась.
+func (c *RedisRoleCache) CurrentRole(userID uint) (string, error) {
+	role, err := c.redis.Get(context.Background(), c.key(userID)).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	return role, err
+}
+
+func (c *RedisRoleCache) key(userID uint) string {
+	return fmt.Sprintf("role:user:%d", userID)
+}
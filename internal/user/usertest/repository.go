@@ -0,0 +1,115 @@
+// Package usertest содержит общий для тестов двойник репозитория пользователей.
+package usertest
+
+import (
+	"github.com/ShopOnGO/ShopOnGO/internal/user"
+	"github.com/ShopOnGO/ShopOnGO/pkg/di"
+	"gorm.io/gorm"
+)
+
+var _ di.IUserRepository = (*Repository)(nil)
+
+// Repository реализует di.IUserRepository в памяти и ведет себя как user.UserRepository:
+// отсутствующий пользователь — gorm.ErrRecordNotFound, чтение отдает копию,
+// изменения через методы видны при следующем чтении.
+type Repository struct {
+	Users map[uint]*user.User
+}
+
+func NewRepository(users ...*user.User) *Repository {
+	repo := &Repository{Users: map[uint]*user.User{}}
+	for _, u := range users {
+		repo.Users[u.ID] = u
+	}
+	return repo
+}
+
+func (r *Repository) Create(u *user.User) (*user.User, error) {
+	if u.ID == 0 {
+		u.ID = uint(len(r.Users) + 1)
+	}
+	saved := *u
+	r.Users[u.ID] = &saved
+	return u, nil
+}
+
+func (r *Repository) FindByEmail(email string) (*user.User, error) {
+	for _, u := range r.Users {
+		if u.Email == email {
+			found := *u
+			return &found, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *Repository) FindByID(id uint) (*user.User, error) {
+	u, ok := r.Users[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	found := *u
+	return &found, nil
+}
+
+func (r *Repository) Update(u *user.User) (*user.User, error) {
+	saved := *u
+	r.Users[u.ID] = &saved
+	return u, nil
+}
+
+func (r *Repository) Delete(id uint) error {
+	delete(r.Users, id)
+	return nil
+}
+
+func (r *Repository) UpdateUserPassword(id uint, newPassword string) error {
+	u, ok := r.Users[id]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+	u.PasswordHash = newPassword
+	return nil
+}
+
+func (r *Repository) GetUserRoleByEmail(email string) (string, error) {
+	u, err := r.FindByEmail(email)
+	if err != nil {
+		return "", err
+	}
+	return u.Role, nil
+}
+
+func (r *Repository) UpdateRole(u *user.User, newRole string) error {
+	stored, ok := r.Users[u.ID]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+	stored.Role = newRole
+	return nil
+}
+
+func (r *Repository) GetNameByID(id uint) (string, error) {
+	u, err := r.FindByID(id)
+	if err != nil {
+		return "", err
+	}
+	return u.Name, nil
+}
+
+func (r *Repository) MarkEmailVerified(id uint) error {
+	u, ok := r.Users[id]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+	u.EmailVerified = true
+	return nil
+}
+
+func (r *Repository) IsEmailVerified(id uint) (bool, error) {
+	u, err := r.FindByID(id)
+	if err != nil {
+		return false, err
+	}
+	return u.EmailVerified, nil
+}
@@ -4,16 +4,22 @@ import (
 	"os"
 
 	"github.com/ShopOnGO/ShopOnGO/configs"
+	"github.com/ShopOnGO/ShopOnGO/internal/account"
+	"github.com/ShopOnGO/ShopOnGO/internal/audit"
+	"github.com/ShopOnGO/ShopOnGO/internal/auth/mfa"
 	"github.com/ShopOnGO/ShopOnGO/internal/brand"
 	"github.com/ShopOnGO/ShopOnGO/internal/cart"
 	"github.com/ShopOnGO/ShopOnGO/internal/category"
 	"github.com/ShopOnGO/ShopOnGO/internal/chat"
 	"github.com/ShopOnGO/ShopOnGO/internal/favorites"
+	"github.com/ShopOnGO/ShopOnGO/internal/identity"
 	"github.com/ShopOnGO/ShopOnGO/internal/link"
 	"github.com/ShopOnGO/ShopOnGO/internal/product"
 	"github.com/ShopOnGO/ShopOnGO/internal/productVariant"
 	"github.com/ShopOnGO/ShopOnGO/internal/question"
+	"github.com/ShopOnGO/ShopOnGO/internal/rbac"
 	"github.com/ShopOnGO/ShopOnGO/internal/review"
+	"github.com/ShopOnGO/ShopOnGO/internal/seller"
 	"github.com/ShopOnGO/ShopOnGO/internal/stat"
 	"github.com/ShopOnGO/ShopOnGO/internal/user"
 	"github.com/ShopOnGO/ShopOnGO/pkg/logger"
@@ -41,22 +47,55 @@ func RunMigrations() error {
 		panic(err)
 	}
 
+	// колонка появляется впервые: все пользователи, созданные до нее, считаются подтвержденными
+	backfillEmailVerified := !db.Migrator().HasColumn(&user.User{}, "email_verified")
+
 	err = db.AutoMigrate(
 		&link.Link{},
 		&stat.Stat{},
 		&user.User{},
+		&mfa.MFASettings{}, &mfa.RecoveryCode{},
+		&identity.UserIdentity{},
+		&rbac.Permission{}, &rbac.RolePermission{},
+		&audit.Entry{},
+		&account.Address{},
+		&seller.SellerApplication{},
 		&product.Product{}, &productVariant.ProductVariant{},
 		&category.Category{},
 		&brand.Brand{},
 		&cart.Cart{}, &cart.CartItem{}, &favorites.Favorite{},
 		&review.Review{}, &question.Question{},
-		&chat.Message{},
+		&chat.Message{}, &chat.ChatSession{}, &chat.Attachment{}, &chat.CannedResponse{}, &chat.ChatRating{},
 	)
 
 	if err != nil {
 		return err
 	}
 
+	if backfillEmailVerified {
+		err = db.Exec(`UPDATE users SET email_verified = true, email_verified_at = created_at WHERE email_verified = false`).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	// журнал аудита только дополняется: UPDATE и DELETE молча игнорируются
+	err = db.Exec(`
+		CREATE OR REPLACE RULE audit_entries_no_update AS ON UPDATE TO audit_entries DO INSTEAD NOTHING;
+		CREATE OR REPLACE RULE audit_entries_no_delete AS ON DELETE TO audit_entries DO INSTEAD NOTHING;
+	`).Error
+	if err != nil {
+		return err
+	}
+
+	// полнотекстовый поиск по чату; выражение совпадает с запросом в chat.SearchMessages
+	err = db.Exec(`
+		CREATE INDEX IF NOT EXISTS idx_messages_content_fts ON messages USING GIN (to_tsvector('russian', content));
+	`).Error
+	if err != nil {
+		return err
+	}
+
 	logger.Info("✅ Migrations completed successfully")
 	return nil
 }
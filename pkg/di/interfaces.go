@@ -18,22 +18,37 @@ type IStatRepository interface {
 type IUserRepository interface {
 	Create(user *user.User) (*user.User, error)
 	FindByEmail(email string) (*user.User, error)
+	FindByID(id uint) (*user.User, error)
 	Update(user *user.User) (*user.User, error)
 	Delete(id uint) error
 	UpdateUserPassword(id uint, newPassword string) error
 	GetUserRoleByEmail(email string) (string, error)
 	UpdateRole(user *user.User, newRole string) error
 	GetNameByID(id uint) (string, error)
+	MarkEmailVerified(id uint) error
+	IsEmailVerified(id uint) (bool, error)
 }
 
-type IRedisResetRepository interface { // not used on this project(redirected to notifications)
-	SaveToken(email, code string, expiresAt time.Time) error
+type IRedisResetRepository interface {
+	SaveToken(email, codeHash string, expiresAt time.Time) error
 	GetToken(email string) (string, time.Time, error)
 	DeleteToken(email string) error
+	IncrementAttempts(email string, ttl time.Duration) (int, error)
+	SaveTicket(ticketHash, email string, ttl time.Duration) error
+	ConsumeTicket(ticketHash string) (string, error)
 	GetResetCodeCount(email string) (int, error)
 	IncrementResetCodeCount(email string, ttl time.Duration) error
 }
 
+type IRedisVerificationRepository interface {
+	SaveCode(email, codeHash string, expiresAt time.Time) error
+	GetCode(email string) (string, time.Time, error)
+	DeleteCode(email string) error
+	IncrementAttempts(email string, ttl time.Duration) (int, error)
+	GetRequestCount(email string) (int, error)
+	IncrementRequestCount(email string, ttl time.Duration) error
+}
+
 type IProductRepository interface {
 	Create(product *product.Product) (*product.Product, error)
 	GetByCategory(id uint) ([]product.Product, error)
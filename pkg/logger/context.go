@@ -0,0 +1,96 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+type ctxKey struct{}
+
+// fieldSet — поля запроса. Набор общий для всех производных контекстов запроса:
+// поля, добавленные внутренними middleware (user_id, route), видны и внешним,
+// например итоговой записи middleware.Logging.
+type fieldSet struct {
+	mu     sync.RWMutex
+	fields []Field
+}
+
+func (s *fieldSet) snapshot() []Field {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]Field(nil), s.fields...)
+}
+
+// NewContext начинает новый набор полей (обычно в начале обработки запроса).
+// Поля родительского набора, если он есть, копируются.
+func NewContext(ctx context.Context, fields ...Field) context.Context {
+	set := &fieldSet{}
+	if parent, ok := ctx.Value(ctxKey{}).(*fieldSet); ok {
+		set.fields = parent.snapshot()
+	}
+	set.fields = append(set.fields, fields...)
+	return context.WithValue(ctx, ctxKey{}, set)
+}
+
+// AddFields дополняет набор полей контекста. Если набора нет, создает его.
+func AddFields(ctx context.Context, fields ...Field) context.Context {
+	set, ok := ctx.Value(ctxKey{}).(*fieldSet)
+	if !ok {
+		return NewContext(ctx, fields...)
+	}
+	set.mu.Lock()
+	set.fields = append(set.fields, fields...)
+	set.mu.Unlock()
+	return ctx
+}
+
+// Entry — логгер с заранее заданными полями.
+type Entry struct {
+	fields []Field
+}
+
+// FromContext возвращает логгер с полями запроса (request_id, user_id, route и т.д.).
+// Для контекста без полей пишет как обычные функции пакета.
+func FromContext(ctx context.Context) *Entry {
+	if ctx == nil {
+		return &Entry{}
+	}
+	if set, ok := ctx.Value(ctxKey{}).(*fieldSet); ok {
+		return &Entry{fields: set.snapshot()}
+	}
+	return &Entry{}
+}
+
+// With возвращает новый Entry с дополнительными полями.
+func (e *Entry) With(fields ...Field) *Entry {
+	return &Entry{fields: append(append([]Field(nil), e.fields...), fields...)}
+}
+
+func (e *Entry) Debugw(msg string, fields ...Field) { e.log(DEBUG, msg, fields) }
+
+func (e *Entry) Infow(msg string, fields ...Field) { e.log(INFO, msg, fields) }
+
+func (e *Entry) Warnw(msg string, fields ...Field) { e.log(WARN, msg, fields) }
+
+func (e *Entry) Errorw(msg string, fields ...Field) { e.log(ERROR, msg, fields) }
+
+func (e *Entry) Debugf(format string, v ...interface{}) { e.logf(DEBUG, format, v) }
+
+func (e *Entry) Infof(format string, v ...interface{}) { e.logf(INFO, format, v) }
+
+func (e *Entry) Warnf(format string, v ...interface{}) { e.logf(WARN, format, v) }
+
+func (e *Entry) Errorf(format string, v ...interface{}) { e.logf(ERROR, format, v) }
+
+func (e *Entry) log(level LogLevel, msg string, fields []Field) {
+	if enabled(level) {
+		write(level, msg, append(append([]Field(nil), e.fields...), fields...))
+	}
+}
+
+func (e *Entry) logf(level LogLevel, format string, v []interface{}) {
+	if enabled(level) {
+		write(level, fmt.Sprintf(format, v...), e.fields)
+	}
+}
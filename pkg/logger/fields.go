@@ -0,0 +1,95 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Field — типизированное поле структурированной записи лога.
+type Field struct {
+	Key   string
+	Value any
+}
+
+func String(key, value string) Field { return Field{Key: key, Value: value} }
+
+func Int(key string, value int) Field { return Field{Key: key, Value: value} }
+
+func Int64(key string, value int64) Field { return Field{Key: key, Value: value} }
+
+func Uint(key string, value uint) Field { return Field{Key: key, Value: value} }
+
+func Bool(key string, value bool) Field { return Field{Key: key, Value: value} }
+
+func Duration(key string, value time.Duration) Field { return Field{Key: key, Value: value.String()} }
+
+// Err добавляет поле "error"; nil-ошибка пишется как пустая строка.
+func Err(err error) Field {
+	if err == nil {
+		return Field{Key: "error", Value: ""}
+	}
+	return Field{Key: "error", Value: err.Error()}
+}
+
+// Any — поле произвольного типа; значение сериализуется в JSON.
+func Any(key string, value any) Field { return Field{Key: key, Value: value} }
+
+// Debugw, Infow, Warnw, Errorw пишут сообщение с полями: "key=value" в текстовом
+// формате, отдельные ключи в JSON. Значения полей с чувствительными ключами
+// заменяются на [REDACTED].
+func Debugw(msg string, fields ...Field) { write(DEBUG, msg, fields) }
+
+func Infow(msg string, fields ...Field) { write(INFO, msg, fields) }
+
+func Warnw(msg string, fields ...Field) { write(WARN, msg, fields) }
+
+func Errorw(msg string, fields ...Field) { write(ERROR, msg, fields) }
+
+func formatFields(fields []Field) string {
+	if len(fields) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	for _, f := range fields {
+		b.WriteByte(' ')
+		b.WriteString(f.Key)
+		b.WriteByte('=')
+		b.WriteString(formatValue(f))
+	}
+	return b.String()
+}
+
+func formatValue(f Field) string {
+	s := fieldString(f)
+	if strings.ContainsAny(s, " \t\n\"=") {
+		s = fmt.Sprintf("%q", s)
+	}
+	return s
+}
+
+// fieldString — значение поля строкой с уже вырезанными секретами.
+func fieldString(f Field) string {
+	if IsRedactedKey(f.Key) {
+		return Redacted
+	}
+	var s string
+	switch v := f.Value.(type) {
+	case string:
+		s = v
+	case fmt.Stringer:
+		s = v.String()
+	case int, int64, uint, uint64, bool, float64:
+		s = fmt.Sprint(v)
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			s = fmt.Sprintf("%v", v)
+		} else {
+			s = string(data)
+		}
+	}
+	// маскируем до экранирования: в %q кавычки JSON превращаются в \" и ключи не узнаются
+	return RedactString(s)
+}
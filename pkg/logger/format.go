@@ -0,0 +1,110 @@
+package logger
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// Format — формат записи лога.
+type Format string
+
+const (
+	FormatText Format = "text" // "2006/01/02 15:04:05.0 INFO: сообщение key=value"
+	FormatJSON Format = "json" // одно событие — одна строка JSON, для сборщика логов
+)
+
+var (
+	currentFormat  = FormatText
+	consoleEnabled = true
+)
+
+// ParseFormat разбирает LOG_FORMAT; неизвестное значение — текстовый формат.
+func ParseFormat(s string) Format {
+	if strings.EqualFold(strings.TrimSpace(s), string(FormatJSON)) {
+		return FormatJSON
+	}
+	return FormatText
+}
+
+// SetFormat задает формат для всех выводов.
+func SetFormat(format Format) {
+	logMutex.Lock()
+	defer logMutex.Unlock()
+	currentFormat = format
+}
+
+// SetConsoleOutput включает или отключает вывод в консоль (например, когда
+// логи собираются только из файлов).
+func SetConsoleOutput(enabled bool) {
+	logMutex.Lock()
+	defer logMutex.Unlock()
+	consoleEnabled = enabled
+	if enabled {
+		consoleLogger.SetOutput(os.Stdout)
+	} else {
+		consoleLogger.SetOutput(io.Discard)
+	}
+}
+
+// reservedKeys — ключи JSON-записи, которые не могут перезаписать поля.
+var reservedKeys = map[string]bool{"ts": true, "level": true, "msg": true}
+
+// render формирует готовую строку записи в текущем формате, вырезая секреты.
+func render(level LogLevel, msg string, fields []Field) string {
+	if currentFormat != FormatJSON {
+		return formatLogPrefix(level.String()) + RedactString(msg+formatFields(fields))
+	}
+
+	var b strings.Builder
+	b.WriteString(`{"ts":`)
+	writeJSON(&b, time.Now().UTC().Format(time.RFC3339Nano))
+	b.WriteString(`,"level":`)
+	writeJSON(&b, level.String())
+	b.WriteString(`,"msg":`)
+	writeJSON(&b, RedactString(strings.TrimRight(msg, "\n")))
+
+	// при повторе ключа побеждает последнее значение, порядок — первого появления
+	order := make([]string, 0, len(fields))
+	values := make(map[string]Field, len(fields))
+	for _, f := range fields {
+		key := f.Key
+		if reservedKeys[key] {
+			key = "field." + key
+		}
+		if _, seen := values[key]; !seen {
+			order = append(order, key)
+		}
+		values[key] = f
+	}
+	for _, key := range order {
+		b.WriteByte(',')
+		writeJSON(&b, key)
+		b.WriteByte(':')
+		writeJSON(&b, jsonValue(values[key]))
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// jsonValue сохраняет числа и булевы значения как есть, остальное — строкой без секретов.
+func jsonValue(f Field) any {
+	if IsRedactedKey(f.Key) {
+		return Redacted
+	}
+	switch v := f.Value.(type) {
+	case int, int64, uint, uint64, float64, bool:
+		return v
+	}
+	return fieldString(f)
+}
+
+func writeJSON(b *strings.Builder, v any) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		data, _ = json.Marshal(err.Error())
+	}
+	b.Write(data)
+}
@@ -0,0 +1,82 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// captureJSON переключает логгер в JSON и возвращает разобранные записи консоли.
+func captureJSON(t *testing.T) func() []map[string]any {
+	t.Helper()
+	var console bytes.Buffer
+	consoleLogger.SetOutput(&console)
+	prevLevel, prevFormat := currentLogLevel, currentFormat
+	currentLogLevel = DEBUG
+	SetFormat(FormatJSON)
+	t.Cleanup(func() {
+		consoleLogger.SetOutput(os.Stdout)
+		currentLogLevel = prevLevel
+		SetFormat(prevFormat)
+	})
+
+	return func() []map[string]any {
+		var events []map[string]any
+		for _, line := range strings.Split(strings.TrimSpace(console.String()), "\n") {
+			var event map[string]any
+			require.NoError(t, json.Unmarshal([]byte(line), &event), "каждая строка — отдельный JSON: %s", line)
+			events = append(events, event)
+		}
+		return events
+	}
+}
+
+func TestJSONFormat(t *testing.T) {
+	read := captureJSON(t)
+
+	Infof("multi\nline %s", "message")
+	Errorw("request failed", Int("status", 500), Bool("retry", false), String("msg", "field named msg"), String("token", "secret-value"))
+
+	events := read()
+	require.Len(t, events, 2, "многострочное сообщение остается одной записью")
+
+	assert.Equal(t, "INFO", events[0]["level"])
+	assert.Equal(t, "multi\nline message", events[0]["msg"])
+	assert.NotEmpty(t, events[0]["ts"])
+
+	assert.Equal(t, "ERROR", events[1]["level"])
+	assert.Equal(t, "request failed", events[1]["msg"])
+	assert.Equal(t, float64(500), events[1]["status"], "числа остаются числами")
+	assert.Equal(t, false, events[1]["retry"])
+	assert.Equal(t, "field named msg", events[1]["field.msg"], "поле не перезаписывает служебный ключ")
+	assert.Equal(t, Redacted, events[1]["token"])
+}
+
+func TestFromContext(t *testing.T) {
+	read := captureJSON(t)
+
+	ctx := NewContext(context.Background(), String("request_id", "req-1"))
+	inner := AddFields(context.WithValue(ctx, struct{}{}, 1), Uint("user_id", 7))
+	AddFields(inner, String("route", "/me/addresses/{id}"))
+
+	FromContext(inner).Infof("address %d updated", 3)
+	FromContext(ctx).With(String("component", "test")).Warnw("outer sees inner fields")
+	FromContext(context.Background()).Infow("no request")
+
+	events := read()
+	require.Len(t, events, 3)
+	for _, event := range events[:2] {
+		assert.Equal(t, "req-1", event["request_id"])
+		assert.Equal(t, float64(7), event["user_id"])
+		assert.Equal(t, "/me/addresses/{id}", event["route"])
+	}
+	assert.Equal(t, "address 3 updated", events[0]["msg"])
+	assert.Equal(t, "test", events[1]["component"])
+	assert.NotContains(t, events[2], "request_id")
+}
@@ -21,12 +21,7 @@ var (
 	infoFileLogger  *log.Logger = log.New(io.Discard, "", 0)
 	warnFileLogger  *log.Logger = log.New(io.Discard, "", 0)
 
-	// Файловые дескрипторы для ротации
-	currentDebugFile *os.File
-	currentInfoFile  *os.File
-	currentWarnFile  *os.File
-	currentLogHour   string
-	logMutex         = &sync.Mutex{}
+	logMutex = &sync.Mutex{}
 
 	// ПЕРЕМЕННЫЕ ДЛЯ УПРАВЛЕНИЯ ЗАПИСЬЮ В ФАЙЛЫ
 	fileLoggingEnabled bool   // Флаг, разрешающий запись в файлы
@@ -72,8 +67,8 @@ func InitLogger(level LogLevel, fileLogLevel LogLevel) {
 
 	// Логгеры УЖЕ существуют. Мы просто сообщаем об изменении уровней.
 	// Используем consoleLogger, который гарантированно не nil.
-	consoleLogger.Printf("INFO: (Консоль) Минимальный уровень логирования установлен: %s", currentLogLevel)
-	consoleLogger.Printf("INFO: (Файлы)    Минимальный уровень логирования установлен: %s", currentFileLogLevel)
+	consoleLogger.Println(render(INFO, fmt.Sprintf("(Консоль) Минимальный уровень логирования установлен: %s", currentLogLevel), nil))
+	consoleLogger.Println(render(INFO, fmt.Sprintf("(Файлы)    Минимальный уровень логирования установлен: %s", currentFileLogLevel), nil))
 }
 
 // EnableFileLogging активирует запись логов в файлы для конкретного режима.
@@ -86,123 +81,114 @@ func EnableFileLogging(subDir string) {
 	logDirectory = subDir
 
 	// Логируем сам факт включения, чтобы это было видно и в консоли, и в файле
-	msg := fmt.Sprintf("INFO: Запись логов в файлы ВКЛЮЧЕНА. Директория: logs/%s", subDir)
-	consoleLogger.Println(formatLogPrefix("INFO") + msg)
+	msg := render(INFO, fmt.Sprintf("Запись логов в файлы ВКЛЮЧЕНА. Директория: logs/%s", subDir), nil)
+	consoleLogger.Println(msg)
 
 	// Принудительно обновляем файлы сразу после включения
-	updateLogFilesUnsafe() // Используем версию без блокировки, т.к. мьютекс уже захвачен
+	if !updateLogFilesUnsafe() { // Используем версию без блокировки, т.к. мьютекс уже захвачен
+		return
+	}
 	infoFileLogger.Println(msg)
 	debugFileLogger.Println(msg)
 	warnFileLogger.Println(msg) // Пишем и в новый лог
+
+	// Сжимаем и чистим то, что осталось от прошлых запусков
+	startHousekeepingUnsafe()
 }
 
 // CloseFileLogs отключает запись, закрывает файловые дескрипторы и сбрасывает состояние.
+// Дожидается фонового сжатия и очистки файлов.
 func CloseFileLogs() {
 	logMutex.Lock()
-	defer logMutex.Unlock()
-
 	if !fileLoggingEnabled {
+		logMutex.Unlock()
 		return
 	}
 
-	msg := "INFO: Запись логов в файлы остановлена, файлы закрыты."
-	consoleLogger.Println(formatLogPrefix("INFO") + msg)
+	msg := render(INFO, "Запись логов в файлы остановлена, файлы закрыты.", nil)
+	consoleLogger.Println(msg)
 	// Записываем прощальное сообщение во все файлы
-	if infoFileLogger != nil {
-		infoFileLogger.Println(msg)
-	}
-	if debugFileLogger != nil {
-		debugFileLogger.Println(msg)
-	}
-	if warnFileLogger != nil {
-		warnFileLogger.Println(msg)
-	}
+	infoFileLogger.Println(msg)
+	debugFileLogger.Println(msg)
+	warnFileLogger.Println(msg)
 
-	// Закрываем все файловые дескрипторы
+	// Закрываем все файловые дескрипторы, вывод логгеров сбрасывается в "никуда"
 	closeFiles()
 
 	fileLoggingEnabled = false
 	logDirectory = ""
-	currentLogHour = ""
+	filesFailedUntil = time.Time{}
+	logMutex.Unlock()
 
-	// Сбрасываем вывод логгеров в "никуда"
-	debugFileLogger.SetOutput(io.Discard)
-	infoFileLogger.SetOutput(io.Discard)
-	warnFileLogger.SetOutput(io.Discard)
+	housekeeping.Wait()
 }
 
-// updateLogFilesUnsafe обновляет все лог-файлы, если наступил новый час.
-func updateLogFilesUnsafe() {
+// updateLogFilesUnsafe открывает новые лог-файлы, если наступил новый час или файл
+// превысил MaxFileSize. Закрытые файлы сжимаются и чистятся в фоне.
+// Возвращает false, если файлы сейчас недоступны и писать нужно только в консоль.
+func updateLogFilesUnsafe() bool {
 	if !fileLoggingEnabled {
-		return
-	}
-
-	hourFormat := "2006-01-02_15"
-	currentHour := time.Now().Format(hourFormat)
-
-	if currentHour == currentLogHour {
-		return
-	}
-
-	// Закрываем старые файлы
-	closeFiles()
+		return true
+	}
+	now := time.Now()
+	if now.Before(filesFailedUntil) {
+		return false
+	}
+
+	currentHour := now.Format(hourFormat)
+	rotated := false
+	for _, f := range logFiles {
+		if !f.stale(currentHour) {
+			continue
+		}
+		if f.file != nil {
+			f.close()
+			rotated = true
+		}
+		if err := f.open(currentHour); err != nil {
+			disableFilesUnsafe(err)
+			return false
+		}
+		if f == debugFile {
+			// Показываем, где искать файл
+			if absDebugPath, err := filepath.Abs(f.path); err == nil {
+				consoleLogger.Println(render(INFO, fmt.Sprintf("(Logger) Открываю DEBUG файл: %s", absDebugPath), nil))
+			}
+		}
+	}
+	if !filesFailedUntil.IsZero() {
+		filesFailedUntil = time.Time{}
+		consoleLogger.Println(render(INFO, "(Logger) Запись логов в файлы восстановлена", nil))
+	}
+	if rotated {
+		startHousekeepingUnsafe()
+	}
+	return true
+}
 
-	// --- DEBUG ---
-	debugDir := fmt.Sprintf("logs/%s/debug", logDirectory)
-	if err := os.MkdirAll(debugDir, 0755); err != nil {
-		log.Fatalf("FATAL: Не удалось создать директорию %s: %v", debugDir, err)
-	}
-	debugFileName := fmt.Sprintf("%s/%s.log", debugDir, currentHour)
-	// --- 2. ДОБАВИТЬ ЭТОТ БЛОК ДЛЯ ДИАГНОСТИКИ ---
-	absDebugPath, errPath := filepath.Abs(debugFileName)
-	if errPath != nil {
-		consoleLogger.Printf("WARN: (Logger) Не удалось получить абс. путь для %s: %v", debugFileName, errPath)
-	} else {
-		// ВОТ ЭТА СТРОКА ПОКАЖЕТ, ГДЕ ИСКАТЬ ФАЙЛ
-		consoleLogger.Printf("INFO: (Logger) Открываю DEBUG файл: %s", absDebugPath)
-	}
-	// --- КОНЕЦ БЛОКА ДИАГNOСТИКИ ---
-	debugFile, err := os.OpenFile(debugFileName, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
-	if err != nil {
-		log.Fatalf("FATAL: Не удалось открыть файл лога %s: %v", debugFileName, err)
-	}
-	currentDebugFile = debugFile
-	debugFileLogger.SetOutput(currentDebugFile)
+// writeFiles пишет готовую строку в файлы по уровню: DEBUG — только в debug,
+// INFO — еще и в info+, WARN и выше — во все. Возвращает false, если файлы недоступны.
+func writeFiles(level LogLevel, msg string) bool {
+	logMutex.Lock()
+	defer logMutex.Unlock()
 
-	// --- INFO+ ---
-	infoDir := fmt.Sprintf("logs/%s/info+", logDirectory)
-	if err := os.MkdirAll(infoDir, 0755); err != nil {
-		log.Fatalf("FATAL: Не удалось создать директорию %s: %v", infoDir, err)
+	if !updateLogFilesUnsafe() { // Проверяем ротацию
+		return false
 	}
-	infoFileName := fmt.Sprintf("%s/%s.log", infoDir, currentHour)
-	infoFile, err := os.OpenFile(infoFileName, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
-	if err != nil {
-		log.Fatalf("FATAL: Не удалось открыть файл лога %s: %v", infoFileName, err)
+	debugFileLogger.Println(msg)
+	if level >= INFO {
+		infoFileLogger.Println(msg)
 	}
-	currentInfoFile = infoFile
-	infoFileLogger.SetOutput(currentInfoFile)
-
-	// --- НОВЫЙ БЛОК: WARN+ ---
-	warnDir := fmt.Sprintf("logs/%s/warn+", logDirectory)
-	if err := os.MkdirAll(warnDir, 0755); err != nil {
-		log.Fatalf("FATAL: Не удалось создать директорию %s: %v", warnDir, err)
+	if level >= WARN {
+		warnFileLogger.Println(msg)
 	}
-	warnFileName := fmt.Sprintf("%s/%s.log", warnDir, currentHour)
-	warnFile, err := os.OpenFile(warnFileName, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
-	if err != nil {
-		log.Fatalf("FATAL: Не удалось открыть файл лога %s: %v", warnFileName, err)
+	for _, f := range logFiles {
+		if f.err != nil {
+			disableFilesUnsafe(f.err)
+			return false
+		}
 	}
-	currentWarnFile = warnFile
-	warnFileLogger.SetOutput(currentWarnFile)
-
-	currentLogHour = currentHour
-}
-
-// updateLogFiles теперь просто обертка с мьютексом
-func updateLogFiles() {
-	logMutex.Lock()
-	defer logMutex.Unlock()
-	updateLogFilesUnsafe()
+	return true
 }
 
 // formatLogPrefix генерирует префикс для сообщения лога.
@@ -210,119 +196,64 @@ func formatLogPrefix(level string) string {
 	return fmt.Sprintf("%s %s: ", time.Now().Format("2006/01/02 15:04:05.0"), level)
 }
 
-// Debug пишет только в debug-файл и, если уровень позволяет, в консоль.
-func Debugf(format string, v ...interface{}) {
-	// 1. Определяем, куда нужно писать
-	writeToConsole := currentLogLevel <= DEBUG
-	// Проверка: Пишем в файл, если МИН. УРОВЕНЬ ФАЙЛА (current) <= УРОВНЮ СООБЩЕНИЯ (DEBUG)
-	writeToFile := currentFileLogLevel <= DEBUG
-
-	if !writeToConsole && !writeToFile {
-		return // Ничего не делаем
-	}
-
-	// 2. Форматируем (только если нужно)
-	msg := formatLogPrefix("DEBUG") + fmt.Sprintf(format, v...)
-
-	// 3. Пишем в консоль
-	if writeToConsole {
-		consoleLogger.Println(msg)
-	}
-
-	// 4. Пишем в файл
-	if writeToFile {
-		updateLogFiles() // Проверяем ротацию
-		debugFileLogger.Println(msg)
-	}
+// enabled сообщает, попадет ли сообщение уровня level хотя бы в один вывод.
+func enabled(level LogLevel) bool {
+	return currentLogLevel <= level || currentFileLogLevel <= level
 }
 
-// Info пишет в debug и info файлы и, если уровень позволяет, в консоль.
-func Infof(format string, v ...interface{}) {
-	// 1. Определяем, куда нужно писать
-	writeToConsole := currentLogLevel <= INFO
-	// Проверка: Пишем в файл, если МИН. УРОВЕНЬ ФАЙЛА (current) <= УРОВНЮ СООБЩЕНИЯ (INFO)
-	writeToFile := currentFileLogLevel <= INFO
+// write — единственная точка вывода. Перед записью в любой вывод (консоль или файл)
+// из сообщения и полей вырезаются секреты, см. render.
+func write(level LogLevel, text string, fields []Field) {
+	writeToConsole := currentLogLevel <= level
+	writeToFile := currentFileLogLevel <= level
 
 	if !writeToConsole && !writeToFile {
-		return // Ничего не делаем
+		return
 	}
 
-	// 2. Форматируем (только если нужно)
-	msg := formatLogPrefix("INFO") + fmt.Sprintf(format, v...)
+	msg := render(level, text, fields)
 
-	// 3. Пишем в консоль
 	if writeToConsole {
 		consoleLogger.Println(msg)
 	}
 
-	// 4. Пишем в файл
-	if writeToFile {
-		updateLogFiles()
-		debugFileLogger.Println(msg)
-		infoFileLogger.Println(msg)
+	// Если диск недоступен, запись не теряется, а уходит в консоль
+	if writeToFile && !writeFiles(level, msg) && !writeToConsole {
+		degradedLogger().Println(msg)
 	}
 }
 
-// Warn пишет во все файлы и, если уровень позволяет, в консоль.
-func Warnf(format string, v ...interface{}) {
-	// 1. Определяем, куда нужно писать
-	writeToConsole := currentLogLevel <= WARN
-	// Проверка: Пишем в файл, если МИН. УРОВЕНЬ ФАЙЛА (current) <= УРОВНЮ СООБЩЕНИЯ (WARN)
-	writeToFile := currentFileLogLevel <= WARN
-
-	if !writeToConsole && !writeToFile {
-		return // Ничего не делаем
+// Debugf пишет только в debug-файл и, если уровень позволяет, в консоль.
+func Debugf(format string, v ...interface{}) {
+	if enabled(DEBUG) {
+		write(DEBUG, fmt.Sprintf(format, v...), nil)
 	}
+}
 
-	// 2. Форматируем (только если нужно)
-	msg := formatLogPrefix("WARN") + fmt.Sprintf(format, v...)
-
-	// 3. Пишем в консоль
-	if writeToConsole {
-		consoleLogger.Println(msg)
+// Infof пишет в debug и info файлы и, если уровень позволяет, в консоль.
+func Infof(format string, v ...interface{}) {
+	if enabled(INFO) {
+		write(INFO, fmt.Sprintf(format, v...), nil)
 	}
+}
 
-	// 4. Пишем в файл
-	if writeToFile {
-		updateLogFiles()
-		debugFileLogger.Println(msg)
-		infoFileLogger.Println(msg)
-		warnFileLogger.Println(msg)
+// Warnf пишет во все файлы и, если уровень позволяет, в консоль.
+func Warnf(format string, v ...interface{}) {
+	if enabled(WARN) {
+		write(WARN, fmt.Sprintf(format, v...), nil)
 	}
 }
 
-// Error пишет в оба файла и, если уровень позволяет, в консоль.
+// Errorf пишет во все файлы и, если уровень позволяет, в консоль.
 func Errorf(format string, v ...interface{}) {
-	// 1. Определяем, куда нужно писать
-	writeToConsole := currentLogLevel <= ERROR
-	// Проверка: Пишем в файл, если МИН. УРОВЕНЬ ФАЙЛА (current) <= УРОВНЮ СООБЩЕНИЯ (ERROR)
-	writeToFile := currentFileLogLevel <= ERROR
-
-	if !writeToConsole && !writeToFile {
-		return // Ничего не делаем
-	}
-
-	// 2. Форматируем (только если нужно)
-	msg := formatLogPrefix("ERROR") + fmt.Sprintf(format, v...)
-
-	// 3. Пишем в консоль
-	if writeToConsole {
-		consoleLogger.Println(msg)
-	}
-
-	// 4. Пишем в файл
-	if writeToFile {
-		updateLogFiles()
-		debugFileLogger.Println(msg)
-		infoFileLogger.Println(msg)
-		warnFileLogger.Println(msg)
+	if enabled(ERROR) {
+		write(ERROR, fmt.Sprintf(format, v...), nil)
 	}
 }
 
-// Fatal пишет во все места и завершает программу.
-// Fatal пишет во все места и завершает программу.
+// Fatalf пишет во все места и завершает программу.
 func Fatalf(format string, v ...interface{}) {
-	msg := formatLogPrefix("FATAL") + fmt.Sprintf(format, v...)
+	msg := render(FATAL, fmt.Sprintf(format, v...), nil)
 
 	logMutex.Lock()
 	defer logMutex.Unlock()
@@ -347,17 +278,8 @@ func Fatalf(format string, v ...interface{}) {
 
 // closeFiles — внутренняя функция для закрытия всех открытых файлов логов.
 func closeFiles() {
-	if currentDebugFile != nil {
-		currentDebugFile.Close()
-		currentDebugFile = nil
-	}
-	if currentInfoFile != nil {
-		currentInfoFile.Close()
-		currentInfoFile = nil
-	}
-	if currentWarnFile != nil { // ДОБАВЛЕНО
-		currentWarnFile.Close()
-		currentWarnFile = nil
+	for _, f := range logFiles {
+		f.close()
 	}
 }
 
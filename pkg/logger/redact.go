@@ -0,0 +1,147 @@
+package logger
+
+import (
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Redacted заменяет значение секрета во всех логах.
+const Redacted = "[REDACTED]"
+
+var (
+	redactMu sync.RWMutex
+
+	// redactedKeys — имена полей, параметров запроса и заголовков, значения которых
+	// никогда не пишутся в лог. Сравнение без учета регистра, "-" и "_".
+	redactedKeys = map[string]struct{}{}
+
+	keyPattern *regexp.Regexp
+
+	// Секреты, которые узнаются по виду значения, а не по имени поля.
+	valuePatterns = []*regexp.Regexp{
+		regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9\-._~+/]+=*`),
+		regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`), // JWT
+		regexp.MustCompile(`\$2[abxy]?\$\d{2}\$[./A-Za-z0-9]{53}`),              // bcrypt
+	}
+)
+
+// DefaultRedactedKeys — ключи, которые маскируются всегда.
+var DefaultRedactedKeys = []string{
+	"password", "passwd", "current_password", "new_password", "confirm_password", "password_hash",
+	"token", "access_token", "refresh_token", "reset_token", "link_token", "id_token", "challenge_token",
+	"code", "otp", "ticket", "secret", "client_secret", "api_key",
+	"authorization", "cookie", "set-cookie",
+}
+
+func init() {
+	SetRedactedKeys(DefaultRedactedKeys)
+}
+
+// SetRedactedKeys заменяет список маскируемых ключей.
+func SetRedactedKeys(keys []string) {
+	redactMu.Lock()
+	defer redactMu.Unlock()
+
+	redactedKeys = map[string]struct{}{}
+	for _, key := range keys {
+		if key = normalizeKey(key); key != "" {
+			redactedKeys[key] = struct{}{}
+		}
+	}
+	keyPattern = buildKeyPattern(keys)
+}
+
+// AddRedactedKeys добавляет ключи к уже заданным (например, из LOG_REDACT_KEYS).
+func AddRedactedKeys(keys ...string) {
+	redactMu.RLock()
+	all := make([]string, 0, len(redactedKeys)+len(keys))
+	for key := range redactedKeys {
+		all = append(all, key)
+	}
+	redactMu.RUnlock()
+	SetRedactedKeys(append(all, keys...))
+}
+
+// IsRedactedKey сообщает, маскируется ли значение с таким именем.
+func IsRedactedKey(key string) bool {
+	redactMu.RLock()
+	defer redactMu.RUnlock()
+	_, ok := redactedKeys[normalizeKey(key)]
+	return ok
+}
+
+// RedactQuery маскирует значения чувствительных параметров в строке запроса.
+// Строка, которую не удалось разобрать, заменяется целиком.
+func RedactQuery(rawQuery string) string {
+	if rawQuery == "" {
+		return ""
+	}
+	values, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return Redacted
+	}
+	for key := range values {
+		if IsRedactedKey(key) {
+			for i := range values[key] {
+				values[key][i] = Redacted
+			}
+		}
+	}
+	return strings.ReplaceAll(values.Encode(), url.QueryEscape(Redacted), Redacted)
+}
+
+// RedactString маскирует секреты в произвольном тексте: пары key=value и "key":"value"
+// с чувствительными ключами, Bearer-токены, JWT и bcrypt-хеши.
+func RedactString(s string) string {
+	redactMu.RLock()
+	pattern := keyPattern
+	redactMu.RUnlock()
+
+	// сначала значения: иначе "Authorization: Bearer x" потеряет только слово Bearer
+	for _, p := range valuePatterns {
+		s = p.ReplaceAllStringFunc(s, func(match string) string {
+			if strings.HasPrefix(strings.ToLower(match), "bearer") {
+				return match[:len("bearer")] + " " + Redacted
+			}
+			return Redacted
+		})
+	}
+	if pattern != nil {
+		s = pattern.ReplaceAllString(s, "${1}"+Redacted)
+	}
+	return s
+}
+
+func normalizeKey(key string) string {
+	key = strings.ToLower(strings.TrimSpace(key))
+	key = strings.ReplaceAll(key, "-", "")
+	return strings.ReplaceAll(key, "_", "")
+}
+
+// buildKeyPattern собирает регулярку вида (key)\s*[:=]\s*value. Ключ может быть
+// в кавычках (JSON), значение — в кавычках или до разделителя.
+func buildKeyPattern(keys []string) *regexp.Regexp {
+	if len(keys) == 0 {
+		return nil
+	}
+	quoted := make([]string, 0, len(keys))
+	for _, key := range keys {
+		key = strings.ToLower(strings.TrimSpace(key))
+		if key == "" {
+			continue
+		}
+		// "reset_token", "reset-token" и "resettoken" — один ключ
+		parts := strings.FieldsFunc(key, func(r rune) bool { return r == '_' || r == '-' })
+		for i := range parts {
+			parts[i] = regexp.QuoteMeta(parts[i])
+		}
+		quoted = append(quoted, strings.Join(parts, `[_-]?`))
+	}
+	// длинные ключи раньше коротких, чтобы "access_token" не обрезался до "token"
+	sort.Slice(quoted, func(i, j int) bool { return len(quoted[i]) > len(quoted[j]) })
+
+	return regexp.MustCompile(`(?i)((?:^|[^A-Za-z0-9_-])"?(?:` + strings.Join(quoted, "|") + `)"?\s*[:=]\s*"?)([^"&\s,;}]+)`)
+}
@@ -0,0 +1,301 @@
+package logger
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Retention — политика хранения файловых логов. Нулевое значение поля — без ограничения.
+type Retention struct {
+	MaxAge       time.Duration // закрытые файлы старше удаляются
+	MaxTotalSize int64         // предел размера logs/<subDir> в байтах; сверх него удаляются самые старые файлы
+	MaxFileSize  int64         // размер файла в байтах, после которого в том же часе начинается новый
+	Compress     bool          // сжимать закрытые файлы в .gz
+}
+
+const (
+	hourFormat = "2006-01-02_15"
+	// fileRetryInterval — через сколько снова пробовать писать в файлы после сбоя диска.
+	fileRetryInterval = time.Minute
+)
+
+var (
+	retention Retention
+
+	// Потоки файловых логов: каждый пишет в свою подпапку и ротируется независимо
+	debugFile = &logFile{kind: "debug", logger: debugFileLogger}
+	infoFile  = &logFile{kind: "info+", logger: infoFileLogger}
+	warnFile  = &logFile{kind: "warn+", logger: warnFileLogger}
+	logFiles  = []*logFile{debugFile, infoFile, warnFile}
+
+	// Пока файлы недоступны (нет места, нет прав), логи идут только в консоль
+	filesFailedUntil time.Time
+
+	// Сжатие и очистка идут в фоне, но не параллельно друг другу
+	housekeeping      sync.WaitGroup
+	housekeepingMutex sync.Mutex
+
+	// stderrLogger — запасной вывод, если консоль отключена, а файлы недоступны
+	stderrLogger = log.New(os.Stderr, "", 0)
+)
+
+// SetRetention задает политику хранения. Применяется при следующей ротации.
+func SetRetention(r Retention) {
+	logMutex.Lock()
+	defer logMutex.Unlock()
+	retention = r
+}
+
+// logFile — один поток файловых логов (debug, info+ или warn+).
+// Все поля меняются только под logMutex.
+type logFile struct {
+	kind   string      // имя подпапки
+	logger *log.Logger // логгер, который пишет в этот поток
+	file   *os.File
+	path   string // путь к открытому файлу, относительно рабочей директории
+	hour   string // час, к которому относится открытый файл
+	size   int64
+	err    error // первая ошибка записи в открытый файл
+}
+
+// Write считает записанные байты для ротации по размеру и запоминает ошибку диска.
+func (f *logFile) Write(p []byte) (int, error) {
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	if err != nil && f.err == nil {
+		f.err = err
+	}
+	return n, err
+}
+
+// stale сообщает, что пора открыть новый файл: сменился час или превышен размер.
+func (f *logFile) stale(hour string) bool {
+	return f.file == nil || f.hour != hour || (retention.MaxFileSize > 0 && f.size >= retention.MaxFileSize)
+}
+
+// open открывает файл logs/<subDir>/<kind>/<час>[.N].log. Если за этот час уже есть
+// заполненные или сжатые части (например, после рестарта), берется следующий номер.
+func (f *logFile) open(hour string) error {
+	dir := filepath.Join("logs", logDirectory, f.kind)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("не удалось создать директорию %s: %w", dir, err)
+	}
+
+	for seq := 0; ; seq++ {
+		path := segmentPath(dir, hour, seq)
+		if _, err := os.Stat(path + ".gz"); err == nil {
+			continue
+		}
+		var size int64
+		if info, err := os.Stat(path); err == nil {
+			if retention.MaxFileSize > 0 && info.Size() >= retention.MaxFileSize {
+				continue
+			}
+			size = info.Size()
+		}
+
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+		if err != nil {
+			return fmt.Errorf("не удалось открыть файл лога %s: %w", path, err)
+		}
+		f.file, f.path, f.hour, f.size, f.err = file, path, hour, size, nil
+		f.logger.SetOutput(f)
+		return nil
+	}
+}
+
+// close закрывает файл; до следующего open поток пишет в никуда.
+func (f *logFile) close() {
+	f.logger.SetOutput(io.Discard)
+	if f.file != nil {
+		f.file.Close()
+	}
+	f.file, f.path, f.hour, f.size, f.err = nil, "", "", 0, nil
+}
+
+// segmentPath — имя части лога: первая часть часа без номера, следующие — .1, .2, ...
+func segmentPath(dir, hour string, seq int) string {
+	if seq == 0 {
+		return filepath.Join(dir, hour+".log")
+	}
+	return filepath.Join(dir, fmt.Sprintf("%s.%d.log", hour, seq))
+}
+
+// disableFilesUnsafe закрывает файлы после сбоя диска и переводит логгер в режим
+// "только консоль" до следующей попытки. Сервер при этом продолжает работать.
+func disableFilesUnsafe(err error) {
+	closeFiles()
+	filesFailedUntil = time.Now().Add(fileRetryInterval)
+	degradedLogger().Println(render(WARN, fmt.Sprintf("(Logger) Запись логов в файлы недоступна: %v. Пишем только в консоль, повтор через %s", err, fileRetryInterval), nil))
+}
+
+// degradedLogger — куда писать то, что не удалось записать в файлы.
+func degradedLogger() *log.Logger {
+	if consoleEnabled {
+		return consoleLogger
+	}
+	return stderrLogger
+}
+
+// startHousekeepingUnsafe запускает в фоне сжатие закрытых файлов и очистку по политике хранения.
+func startHousekeepingUnsafe() {
+	root := filepath.Join("logs", logDirectory)
+	policy := retention
+	housekeeping.Add(1)
+	go func() {
+		defer housekeeping.Done()
+		housekeepingMutex.Lock()
+		defer housekeepingMutex.Unlock()
+		if err := cleanup(root, policy); err != nil {
+			Warnf("(Logger) Ошибка обслуживания файлов логов: %v", err)
+		}
+	}()
+}
+
+// activePaths возвращает файлы, в которые сейчас идет запись.
+func activePaths() map[string]bool {
+	logMutex.Lock()
+	defer logMutex.Unlock()
+	active := make(map[string]bool, len(logFiles))
+	for _, f := range logFiles {
+		if f.path != "" {
+			active[f.path] = true
+		}
+	}
+	return active
+}
+
+type archivedFile struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// cleanup сжимает закрытые файлы под root, удаляет файлы старше MaxAge и, если
+// каталог больше MaxTotalSize, удаляет самые старые файлы. Открытые файлы не трогает.
+func cleanup(root string, policy Retention) error {
+	var files []archivedFile
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if strings.HasSuffix(path, ".gz.tmp") {
+			// Недописанный архив от прерванного сжатия: исходный файл еще на месте
+			return os.Remove(path)
+		}
+		if !strings.HasSuffix(path, ".log") && !strings.HasSuffix(path, ".log.gz") {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		files = append(files, archivedFile{path: path, size: info.Size(), modTime: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Список открытых файлов берем после обхода: файл, закрытый к этому моменту,
+	// больше не откроется, а открытый позже в список обхода не попал.
+	active := activePaths()
+
+	var total int64
+	closed := files[:0]
+	for _, file := range files {
+		if active[file.path] {
+			total += file.size
+			continue
+		}
+		if policy.Compress && strings.HasSuffix(file.path, ".log") {
+			compressed, err := compressFile(file.path)
+			if err != nil {
+				return err
+			}
+			file.path, file.size = compressed, fileSize(compressed)
+		}
+		if policy.MaxAge > 0 && time.Since(file.modTime) > policy.MaxAge {
+			if err := os.Remove(file.path); err != nil {
+				return err
+			}
+			continue
+		}
+		total += file.size
+		closed = append(closed, file)
+	}
+
+	if policy.MaxTotalSize <= 0 || total <= policy.MaxTotalSize {
+		return nil
+	}
+	sort.Slice(closed, func(i, j int) bool { return closed[i].modTime.Before(closed[j].modTime) })
+	for _, file := range closed {
+		if total <= policy.MaxTotalSize {
+			break
+		}
+		if err := os.Remove(file.path); err != nil {
+			return err
+		}
+		total -= file.size
+	}
+	return nil
+}
+
+// compressFile сжимает path в path.gz с сохранением времени изменения и удаляет исходник.
+func compressFile(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	src, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	target := path + ".gz"
+	tmp := target + ".tmp"
+	dst, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return "", err
+	}
+	gz := gzip.NewWriter(dst)
+	_, err = io.Copy(gz, src)
+	if closeErr := gz.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chtimes(tmp, info.ModTime(), info.ModTime())
+	}
+	if err == nil {
+		err = os.Rename(tmp, target)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return "", err
+	}
+	return target, os.Remove(path)
+}
+
+func fileSize(path string) int64 {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
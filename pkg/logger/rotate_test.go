@@ -0,0 +1,127 @@
+package logger
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// inTempDir переносит рабочую директорию (и вместе с ней logs/) во временный каталог.
+func inTempDir(t *testing.T, policy Retention) string {
+	t.Helper()
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	dir := t.TempDir()
+	require.NoError(t, os.Chdir(dir))
+
+	var console bytes.Buffer
+	consoleLogger.SetOutput(&console)
+	prevConsole, prevFile := currentLogLevel, currentFileLogLevel
+	currentLogLevel, currentFileLogLevel = FATAL, DEBUG
+	SetRetention(policy)
+
+	t.Cleanup(func() {
+		CloseFileLogs()
+		SetRetention(Retention{})
+		consoleLogger.SetOutput(os.Stdout)
+		currentLogLevel, currentFileLogLevel = prevConsole, prevFile
+		os.Chdir(wd)
+	})
+	return dir
+}
+
+func listLogs(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	return names
+}
+
+func TestSizeRotationAndCompression(t *testing.T) {
+	inTempDir(t, Retention{MaxFileSize: 512, Compress: true})
+	EnableFileLogging("rotation")
+
+	for i := 0; i < 20; i++ {
+		Debugf("line %02d %s", i, strings.Repeat("x", 64))
+	}
+	CloseFileLogs()
+
+	hour := time.Now().Format(hourFormat)
+	names := listLogs(t, filepath.Join("logs", "rotation", "debug"))
+	require.Greater(t, len(names), 2, "файл делится по размеру: %v", names)
+	assert.Contains(t, names, hour+".log.gz", "закрытая часть сжата")
+	assert.Contains(t, names, hour+".1.log.gz")
+
+	gz, err := os.Open(filepath.Join("logs", "rotation", "debug", hour+".log.gz"))
+	require.NoError(t, err)
+	defer gz.Close()
+	r, err := gzip.NewReader(gz)
+	require.NoError(t, err)
+	data, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "line 00")
+
+	// После рестарта запись продолжается в новую часть, архивы не перезаписываются
+	EnableFileLogging("rotation")
+	Debugf("after restart")
+	assert.NotContains(t, debugFile.path, hour+".log")
+	assert.True(t, strings.HasSuffix(debugFile.path, ".log"))
+}
+
+func TestRetentionPrunesOldAndOversizedFiles(t *testing.T) {
+	inTempDir(t, Retention{MaxAge: 24 * time.Hour, MaxTotalSize: 2500})
+	dir := filepath.Join("logs", "retention", "info+")
+	require.NoError(t, os.MkdirAll(dir, 0755))
+
+	write := func(name string, age time.Duration) {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, bytes.Repeat([]byte("a"), 1000), 0644))
+		mtime := time.Now().Add(-age)
+		require.NoError(t, os.Chtimes(path, mtime, mtime))
+	}
+	write("2000-01-01_00.log.gz", 48*time.Hour) // старше MaxAge
+	write("older.log", 4*time.Hour)             // самый старый, вытесняется по размеру
+	write("old.log", 3*time.Hour)
+	write("recent.log", time.Hour)
+
+	EnableFileLogging("retention")
+	housekeeping.Wait()
+
+	names := listLogs(t, dir)
+	assert.NotContains(t, names, "2000-01-01_00.log.gz")
+	assert.NotContains(t, names, "older.log")
+	assert.Contains(t, names, "old.log")
+	assert.Contains(t, names, "recent.log")
+	assert.Contains(t, names, time.Now().Format(hourFormat)+".log", "открытый файл не удаляется")
+}
+
+func TestUnavailableDiskFallsBackToConsole(t *testing.T) {
+	inTempDir(t, Retention{})
+	// logs — обычный файл, создать в нем каталоги нельзя
+	require.NoError(t, os.WriteFile("logs", nil, 0644))
+
+	var fallback bytes.Buffer
+	stderrLogger.SetOutput(&fallback)
+	t.Cleanup(func() { stderrLogger.SetOutput(os.Stderr) })
+	SetConsoleOutput(false)
+	t.Cleanup(func() { SetConsoleOutput(true) })
+
+	EnableFileLogging("broken")
+	Warnf("still visible")
+
+	out := fallback.String()
+	assert.Contains(t, out, "Запись логов в файлы недоступна")
+	assert.Contains(t, out, "still visible", "запись не теряется")
+}
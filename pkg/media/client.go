@@ -0,0 +1,65 @@
+package media
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"time"
+)
+
+// Client загружает файлы в Media Service, который кладет их в S3 и возвращает публичный URL.
+type Client struct {
+	URL  string
+	HTTP *http.Client
+}
+
+func NewClient(url string) *Client {
+	return &Client{
+		URL:  url,
+		HTTP: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Upload отправляет файл полем "file" и возвращает URL из ответа {"url": "..."}.
+func (c *Client) Upload(file io.Reader, filename string) (string, error) {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+
+	part, err := writer.CreateFormFile("file", filename)
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(part, file); err != nil {
+		return "", err
+	}
+	if err := writer.Close(); err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, c.URL, body)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("media service returned status: %d", resp.StatusCode)
+	}
+
+	var result struct {
+		URL string `json:"url"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+	return result.URL, nil
+}
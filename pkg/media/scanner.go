@@ -0,0 +1,109 @@
+package media
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// ErrInfected — антивирус нашел в файле угрозу.
+var ErrInfected = errors.New("file is infected")
+
+// Scanner проверяет содержимое файла перед загрузкой. nil — файл чистый,
+// ошибка с ErrInfected — найдена угроза, любая другая — проверить не удалось.
+type Scanner interface {
+	Scan(ctx context.Context, file io.Reader) error
+}
+
+// NewScanner возвращает ClamAV-сканер для clamd по адресу addr или NopScanner,
+// если адрес не задан.
+func NewScanner(addr string) Scanner {
+	if addr == "" {
+		return NopScanner{}
+	}
+	return NewClamAVScanner(addr)
+}
+
+// NopScanner пропускает все файлы (антивирус не настроен).
+type NopScanner struct{}
+
+func (NopScanner) Scan(context.Context, io.Reader) error { return nil }
+
+// ClamAVScanner проверяет файлы через clamd по TCP командой INSTREAM.
+type ClamAVScanner struct {
+	Addr      string
+	Timeout   time.Duration
+	ChunkSize int
+}
+
+func NewClamAVScanner(addr string) *ClamAVScanner {
+	return &ClamAVScanner{Addr: addr, Timeout: 30 * time.Second, ChunkSize: 64 << 10}
+}
+
+func (s *ClamAVScanner) Scan(ctx context.Context, file io.Reader) error {
+	dialer := net.Dialer{Timeout: s.Timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", s.Addr)
+	if err != nil {
+		return fmt.Errorf("clamav connect: %w", err)
+	}
+	defer conn.Close()
+	deadline := time.Now().Add(s.Timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	conn.SetDeadline(deadline)
+
+	// Формат INSTREAM: куски с длиной (uint32, big endian), в конце кусок нулевой длины
+	if _, err := conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return fmt.Errorf("clamav write: %w", err)
+	}
+	chunk := make([]byte, s.ChunkSize)
+	size := make([]byte, 4)
+	for {
+		n, readErr := file.Read(chunk)
+		if n > 0 {
+			binary.BigEndian.PutUint32(size, uint32(n))
+			if _, err := conn.Write(size); err != nil {
+				return fmt.Errorf("clamav write: %w", err)
+			}
+			if _, err := conn.Write(chunk[:n]); err != nil {
+				return fmt.Errorf("clamav write: %w", err)
+			}
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return readErr
+		}
+	}
+	binary.BigEndian.PutUint32(size, 0)
+	if _, err := conn.Write(size); err != nil {
+		return fmt.Errorf("clamav write: %w", err)
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && !(errors.Is(err, io.EOF) && reply != "") {
+		return fmt.Errorf("clamav read: %w", err)
+	}
+	return parseClamAVReply(strings.TrimRight(reply, "\x00\n"))
+}
+
+// parseClamAVReply разбирает ответ вида "stream: OK" или "stream: <сигнатура> FOUND".
+func parseClamAVReply(reply string) error {
+	result := strings.TrimSpace(strings.TrimPrefix(reply, "stream:"))
+	switch {
+	case result == "OK":
+		return nil
+	case strings.HasSuffix(result, " FOUND"):
+		return fmt.Errorf("%w: %s", ErrInfected, strings.TrimSuffix(result, " FOUND"))
+	default:
+		return fmt.Errorf("clamav: %s", reply)
+	}
+}
@@ -0,0 +1,78 @@
+package media
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClamd принимает INSTREAM и находит "угрозу" в файлах с EICAR.
+func fakeClamd(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				if cmd, err := r.ReadString(0); err != nil || cmd != "zINSTREAM\x00" {
+					conn.Write([]byte("UNKNOWN COMMAND\x00"))
+					return
+				}
+				var data bytes.Buffer
+				size := make([]byte, 4)
+				for {
+					if _, err := io.ReadFull(r, size); err != nil {
+						return
+					}
+					n := binary.BigEndian.Uint32(size)
+					if n == 0 {
+						break
+					}
+					if _, err := io.CopyN(&data, r, int64(n)); err != nil {
+						return
+					}
+				}
+				if bytes.Contains(data.Bytes(), []byte("EICAR")) {
+					conn.Write([]byte("stream: Eicar-Test-Signature FOUND\x00"))
+					return
+				}
+				conn.Write([]byte("stream: OK\x00"))
+			}(conn)
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func TestClamAVScanner(t *testing.T) {
+	scanner := NewClamAVScanner(fakeClamd(t))
+	scanner.ChunkSize = 16 // файл уходит несколькими кусками
+
+	assert.NoError(t, scanner.Scan(context.Background(), strings.NewReader(strings.Repeat("clean ", 20))))
+
+	err := scanner.Scan(context.Background(), strings.NewReader("prefix EICAR suffix"))
+	require.ErrorIs(t, err, ErrInfected)
+	assert.Contains(t, err.Error(), "Eicar-Test-Signature")
+
+	unreachable := NewClamAVScanner("127.0.0.1:1")
+	err = unreachable.Scan(context.Background(), strings.NewReader("data"))
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, ErrInfected)
+
+	assert.IsType(t, NopScanner{}, NewScanner(""))
+}
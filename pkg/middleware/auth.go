@@ -18,7 +18,45 @@ const (
 	ContextRolesKey  key = "ContextRolesKey"
 )
 
-func ValidateToken(tokenString string, secret string) (uint, string, error) {
+// RoleSource отдает роль, назначенную пользователю после выдачи его токенов.
+// Пустая строка — роль не менялась.
+type RoleSource interface {
+	CurrentRole(userID uint) (string, error)
+}
+
+// TokenGuard — проверки владельца access-токена, которых нет в самом JWT.
+// Передается явно в IsAuthed и в рукопожатие WebSocket.
+type TokenGuard struct {
+	Bans  BanChecker
+	Roles RoleSource
+}
+
+// CheckBanned возвращает ErrAccountSuspended, если владелец токена заблокирован.
+func (g *TokenGuard) CheckBanned(userID uint) error {
+	if g == nil {
+		return nil
+	}
+	return CheckBanned(g.Bans, userID)
+}
+
+// Role возвращает действующую роль: токен мог быть выдан до смены роли
+// (например, до одобрения заявки продавца). Если источник недоступен, остается роль из JWT.
+func (g *TokenGuard) Role(userID uint, tokenRole string) string {
+	if g == nil || g.Roles == nil {
+		return tokenRole
+	}
+	role, err := g.Roles.CurrentRole(userID)
+	if err != nil {
+		logger.Errorf("❌ Failed to load current role for user %d: %v", userID, err)
+		return tokenRole
+	}
+	if role == "" {
+		return tokenRole
+	}
+	return role
+}
+
+func ValidateToken(tokenString string, secret string, guard *TokenGuard) (uint, string, error) {
 	isValid, data, err := jwt.NewJWT(secret).Parse(tokenString)
 
 	if err != nil {
@@ -27,37 +65,47 @@ func ValidateToken(tokenString string, secret string) (uint, string, error) {
 	if !isValid {
 		return 0, "", errors.New("token is not valid")
 	}
+	if err := guard.CheckBanned(data.UserID); err != nil {
+		return 0, "", err
+	}
 
-	return data.UserID, data.Role, nil
+	return data.UserID, guard.Role(data.UserID, data.Role), nil
 }
 
-func IsAuthed(next http.Handler, config *configs.Config) http.Handler {
+func IsAuthed(next http.Handler, config *configs.Config, guard *TokenGuard) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		authedHeader := r.Header.Get("Authorization")
+		log := logger.FromContext(r.Context())
 		if !strings.HasPrefix(authedHeader, "Bearer ") {
-			logger.Error("❌ No valid Bearer prefix")
+			log.Errorw("❌ No valid Bearer prefix")
 			writeUnauthed(w)
 			return
 		}
 
 		token := strings.TrimPrefix(authedHeader, "Bearer ")
 
-		userID, role, err := ValidateToken(token, config.OAuth.Secret)
+		userID, role, err := ValidateToken(token, config.OAuth.Secret, guard)
 
 		if err != nil {
+			if errors.Is(err, ErrAccountSuspended) {
+				log.Warnf("❌ Suspended user tried to access %s", r.URL.Path)
+				http.Error(w, err.Error(), http.StatusForbidden)
+				return
+			}
 			if strings.Contains(err.Error(), "expired") {
-				logger.Error("❌ Token expired:", err)
+				log.Errorw("❌ Token expired", logger.Err(err))
 				http.Error(w, "Token expired", http.StatusUnauthorized)
 				return
 			}
-			logger.Error("❌ Invalid token:", err)
+			log.Errorw("❌ Invalid token", logger.Err(err))
 			http.Error(w, "Invalid token", http.StatusUnauthorized)
 			return
 		}
-		logger.Info("✅ Token is valid for:", userID)
 		ctx := context.WithValue(r.Context(), ContextUserIDKey, userID)
-		logger.Info("Role:", role)
 		ctx = context.WithValue(ctx, ContextRolesKey, role)
+		// user_id и role попадают во все записи запроса, включая итоговую в Logging
+		ctx = logger.AddFields(ctx, logger.Uint("user_id", userID), logger.String("role", role))
+		logger.FromContext(ctx).Debugw("✅ Token is valid")
 		req := r.WithContext(ctx)
 		next.ServeHTTP(w, req)
 	})
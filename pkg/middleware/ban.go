@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"errors"
+
+	"github.com/ShopOnGO/ShopOnGO/pkg/logger"
+)
+
+var ErrAccountSuspended = errors.New("account is suspended")
+
+// BanChecker сообщает, заблокирован ли пользователь (кеш заблокированных в Redis).
+type BanChecker interface {
+	IsBanned(userID uint) (bool, error)
+}
+
+// CheckBanned возвращает ErrAccountSuspended для заблокированного пользователя.
+// Ошибка кеша не блокирует запрос (fail-open): при бане refresh-токены уже отозваны,
+// а access-токен живет недолго, поэтому недоступность Redis не должна класть вход
+// для всех пользователей. Ошибка логируется, чтобы сбой был виден.
+func CheckBanned(bans BanChecker, userID uint) error {
+	if bans == nil {
+		return nil
+	}
+	banned, err := bans.IsBanned(userID)
+	if err != nil {
+		logger.Errorf("❌ Failed to check ban for user %d, allowing request: %v", userID, err)
+		return nil
+	}
+	if banned {
+		return ErrAccountSuspended
+	}
+	return nil
+}
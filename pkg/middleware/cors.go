@@ -16,7 +16,7 @@ func CORS(next http.Handler) http.Handler {
 
 		if r.Method == http.MethodOptions {
 			header.Set("Access-Control-Allow-Methods", "GET,PUT,POST,DELETE,HEAD,PATCH")
-			header.Set("Access-Control-Allow-Headers", "authorization,content-type,content-length")
+			header.Set("Access-Control-Allow-Headers", "authorization,content-type,content-length,x-request-id")
 			header.Set("Access-Control-Max-Age", "86400")
 			return
 		} // middleware разрешает междоменные запросы с любого домена!!!
@@ -2,7 +2,6 @@ package middleware
 
 import (
 	"bufio"
-	"encoding/json"
 	"fmt"
 	"net"
 	"net/http"
@@ -25,24 +24,18 @@ func Logging(next http.Handler) http.Handler {
 
 		next.ServeHTTP(lw, r)
 
-		logData := map[string]interface{}{
-			"status":         lw.status,
-			"method":         r.Method,
-			"path":           r.URL.Path,
-			"query":          r.URL.RawQuery,
-			"remote_addr":    r.RemoteAddr,
-			"user_agent":     r.UserAgent(),
-			"content_length": r.ContentLength,
-			"duration_ms":    time.Since(start).Milliseconds(),
-		}
-
-		logJSON, err := json.MarshalIndent(logData, "", "  ")
-		if err != nil {
-			logger.Error("Failed to format log data", map[string]interface{}{"error": err.Error()})
-			return
-		}
-
-		logger.Info("Request processed:\n"+string(logJSON), nil)
+		// query маскируется: WebSocket чата передает токен в ?token=
+		// request_id, user_id и route приходят из контекста запроса
+		logger.FromContext(r.Context()).Infow("Request processed",
+			logger.Int("status", lw.status),
+			logger.String("method", r.Method),
+			logger.String("path", r.URL.Path),
+			logger.String("query", logger.RedactQuery(r.URL.RawQuery)),
+			logger.String("remote_addr", r.RemoteAddr),
+			logger.String("user_agent", r.UserAgent()),
+			logger.Int64("content_length", r.ContentLength),
+			logger.Int64("duration_ms", time.Since(start).Milliseconds()),
+		)
 	})
 }
 
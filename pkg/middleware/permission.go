@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/ShopOnGO/ShopOnGO/pkg/logger"
+)
+
+// PermissionChecker разрешает права по роли из JWT.
+type PermissionChecker interface {
+	HasPermission(role, permission string) bool
+}
+
+// RequirePermission пропускает запрос, только если у роли пользователя есть право permission.
+// Должен стоять после IsAuthed.
+func RequirePermission(next http.Handler, permission string, checker PermissionChecker) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		role, _ := r.Context().Value(ContextRolesKey).(string)
+		if role == "" {
+			logger.Error("❌ No role found in request context. Required permission:", permission)
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+
+		if !checker.HasPermission(role, permission) {
+			logger.Warnf("❌ Role %q lacks permission %q", role, permission)
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"regexp"
+
+	"github.com/ShopOnGO/ShopOnGO/pkg/logger"
+)
+
+const (
+	RequestIDHeader         = "X-Request-ID"
+	ContextRequestIDKey key = "ContextRequestIDKey"
+)
+
+// допускаем ID от прокси/клиента, только если он не раздует логи и аудит
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// RequestID присваивает запросу ID (берет из X-Request-ID или генерирует новый),
+// кладет его в контекст и возвращает в заголовке ответа. Здесь же начинается набор
+// полей логгера запроса, см. logger.FromContext.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID.MatchString(id) {
+			id = newRequestID()
+		}
+		w.Header().Set(RequestIDHeader, id)
+		ctx := context.WithValue(r.Context(), ContextRequestIDKey, id)
+		ctx = logger.NewContext(ctx, logger.String("request_id", id))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// RequestIDFromContext возвращает ID текущего запроса или пустую строку.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(ContextRequestIDKey).(string)
+	return id
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/ShopOnGO/ShopOnGO/pkg/logger"
+	"github.com/gorilla/mux"
+)
+
+// Route добавляет в поля логгера шаблон маршрута ("/admin/users/{id}"), а не сырой путь,
+// чтобы записи группировались по эндпоинту. Подключается через router.Use: шаблон
+// известен только после сопоставления маршрута.
+func Route(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if route := mux.CurrentRoute(r); route != nil {
+			if tmpl, err := route.GetPathTemplate(); err == nil {
+				logger.AddFields(r.Context(), logger.String("route", tmpl))
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/ShopOnGO/ShopOnGO/pkg/logger"
+)
+
+// VerificationChecker решает, может ли пользователь выполнить действие до подтверждения email.
+type VerificationChecker interface {
+	CheckAllowed(userID uint, action string) error
+}
+
+// RequireVerifiedEmail запрещает действие action пользователям с неподтвержденным email.
+// Должен стоять после IsAuthed.
+func RequireVerifiedEmail(next http.Handler, action string, checker VerificationChecker) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := r.Context().Value(ContextUserIDKey).(uint)
+		if !ok {
+			writeUnauthed(w)
+			return
+		}
+
+		if err := checker.CheckAllowed(userID, action); err != nil {
+			logger.Warnf("❌ Action %q denied for user %d: %v", action, userID, err)
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -2,10 +2,12 @@ package oauth2
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"time"
 
 	"github.com/ShopOnGO/ShopOnGO/configs"
+	"github.com/ShopOnGO/ShopOnGO/pkg/middleware"
 	"github.com/gorilla/mux"
 )
 
@@ -36,6 +38,7 @@ func NewOAuth2Handler(router *mux.Router, deps OAuth2HandlerDeps) {
 // @Produce        json
 // @Success        200 {object} map[string]string "Новый access-токен"
 // @Failure        401 {string} string "Refresh-токен отсутствует или недействителен"
+// @Failure        403 {string} string "Аккаунт заблокирован"
 // @Failure        500 {string} string "Ошибка сервера при обновлении токена"
 // @Router         /oauth/token [post]
 func (h *OAuth2Handler) HandleToken(w http.ResponseWriter, r *http.Request) {
@@ -48,6 +51,10 @@ func (h *OAuth2Handler) HandleToken(w http.ResponseWriter, r *http.Request) {
 	refreshToken := cookie.Value
 
 	accessToken, newRefreshToken, err := h.service.RefreshTokens(refreshToken)
+	if errors.Is(err, middleware.ErrAccountSuspended) {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusUnauthorized)
 		return
@@ -0,0 +1,26 @@
+// Package oauth2test содержит общий для тестов двойник oauth2.OAuth2Service.
+package oauth2test
+
+import "github.com/ShopOnGO/ShopOnGO/pkg/oauth2"
+
+var _ oauth2.OAuth2Service = (*Sessions)(nil)
+
+// Sessions запоминает, чьи сессии были завершены.
+type Sessions struct {
+	Revoked []uint
+}
+
+func (s *Sessions) GenerateTokens(userID uint, role string) (string, string, error) {
+	return "", "", nil
+}
+
+func (s *Sessions) RefreshTokens(refreshToken string) (string, string, error) {
+	return "", "", nil
+}
+
+func (s *Sessions) Logout(refreshToken string, userID uint) error { return nil }
+
+func (s *Sessions) RevokeAll(userID uint) error {
+	s.Revoked = append(s.Revoked, userID)
+	return nil
+}
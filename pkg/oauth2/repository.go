@@ -14,6 +14,7 @@ type RefreshTokenRepository interface {
 	GetRefreshTokenData(refreshToken string) (*RefreshTokenData, error)
 	StoreRefreshToken(data *RefreshTokenData, refreshToken string, expiresIn time.Duration) error
 	DeleteRefreshToken(refreshToken string, userID uint) error
+	DeleteUserRefreshTokens(userID uint) error
 }
 
 // RedisRefreshTokenRepository реализует RefreshTokenRepository с помощью Redis.
@@ -81,3 +82,16 @@ func (r *RedisRefreshTokenRepository) DeleteRefreshToken(refreshToken string, us
     return r.redis.Del(r.ctx, tokenKey, userKey).Err()
 }
 
+
+// DeleteUserRefreshTokens удаляет refresh-токен пользователя, не зная его значения.
+func (r *RedisRefreshTokenRepository) DeleteUserRefreshTokens(userID uint) error {
+	userKey := fmt.Sprintf("refresh:user:%d", userID)
+	token, err := r.redis.Get(r.ctx, userKey).Result()
+	if err == redis.Nil {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return r.redis.Del(r.ctx, fmt.Sprintf("refresh:%s", token), userKey).Err()
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/ShopOnGO/ShopOnGO/configs"
 	"github.com/ShopOnGO/ShopOnGO/pkg/jwt"
 	"github.com/ShopOnGO/ShopOnGO/pkg/logger"
+	"github.com/ShopOnGO/ShopOnGO/pkg/middleware"
 
 	"github.com/go-oauth2/oauth2/v4"
 	"github.com/go-oauth2/oauth2/v4/manage"
@@ -20,6 +21,7 @@ type OAuth2Service interface {
 	GenerateTokens(userID uint, role string) (accessToken, refreshToken string, err error)
 	RefreshTokens(refreshToken string) (accessToken, newRefreshToken string, err error)
 	Logout(refreshToken string, userID uint) error
+	RevokeAll(userID uint) error
 }
 
 // oauth2ServiceImpl – реализация OAuth2Service.
@@ -29,11 +31,13 @@ type oauth2ServiceImpl struct {
 	secret     string
 	jwtTTL     time.Duration
 	refreshTTL time.Duration
+	bans       middleware.BanChecker
 	ctx        context.Context
 }
 
 // NewOAuth2Service создаёт новый сервис, используя конфигурацию и репозиторий.
-func NewOAuth2Service(config *configs.Config, repo RefreshTokenRepository) OAuth2Service {
+// bans проверяет блокировку при обновлении токенов.
+func NewOAuth2Service(config *configs.Config, repo RefreshTokenRepository, bans middleware.BanChecker) OAuth2Service {
 	// Инициализация менеджера OAuth2
 	mgr := manage.NewDefaultManager()
 	mgr.SetAuthorizeCodeTokenCfg(manage.DefaultAuthorizeCodeTokenCfg)
@@ -60,6 +64,7 @@ func NewOAuth2Service(config *configs.Config, repo RefreshTokenRepository) OAuth
 		secret:     config.OAuth.Secret,
 		jwtTTL:     config.OAuth.JWTTTL,
 		refreshTTL: config.Redis.RefreshTokenTTL,
+		bans:       bans,
 		ctx:        context.Background(),
 	}
 }
@@ -108,6 +113,10 @@ func (s *oauth2ServiceImpl) RefreshTokens(refreshToken string) (string, string,
 	if err != nil {
 		return "", "", ErrInvalidOrExpiredRefreshToken
 	}
+	if err := middleware.CheckBanned(s.bans, data.UserID); err != nil {
+		s.repo.DeleteRefreshToken(refreshToken, data.UserID)
+		return "", "", err
+	}
 
 	newAccessToken, newRefreshToken, err := s.GenerateTokens(data.UserID, data.Role)
 	if err != nil {
@@ -126,4 +135,9 @@ func (s *oauth2ServiceImpl) RefreshTokens(refreshToken string) (string, string,
 // Logout удаляет refresh-токен, вызывая метод репозитория.
 func (s *oauth2ServiceImpl) Logout(refreshToken string, userID uint) error {
 	return s.repo.DeleteRefreshToken(refreshToken, userID)
-}
\ No newline at end of file
+}
+
+// RevokeAll завершает все сессии пользователя (например, после смены пароля).
+func (s *oauth2ServiceImpl) RevokeAll(userID uint) error {
+	return s.repo.DeleteUserRefreshTokens(userID)
+}
@@ -43,6 +43,10 @@ func (f *fakeRefreshTokenRepo) DeleteRefreshToken(refreshToken string, userID ui
 	return nil
 }
 
+func (f *fakeRefreshTokenRepo) DeleteUserRefreshTokens(userID uint) error {
+	return nil
+}
+
 type OAuth2Service interface {
 	GenerateTokens(userID uint, role string) (string, string, error)
 	RefreshTokens(refreshToken string) (string, string, error)
@@ -74,7 +78,7 @@ func TestGenerateTokens_Success(t *testing.T) {
 		},
 	}
 
-	service := oauth2.NewOAuth2Service(conf, fakeRepo)
+	service := oauth2.NewOAuth2Service(conf, fakeRepo, nil)
 
 	accessToken, refreshToken, err := service.GenerateTokens(123, "admin")
 	if err != nil {
@@ -103,7 +107,7 @@ func TestGenerateTokens_RepoStoreError(t *testing.T) {
 		},
 	}
 
-	service := oauth2.NewOAuth2Service(conf, fakeRepo)
+	service := oauth2.NewOAuth2Service(conf, fakeRepo, nil)
 
 	_, _, err := service.GenerateTokens(123, "admin")
 	if err == nil {
@@ -132,7 +136,7 @@ func TestGenerateTokens_StoredData(t *testing.T) {
 		},
 	}
 
-	service := oauth2.NewOAuth2Service(conf, fakeRepo)
+	service := oauth2.NewOAuth2Service(conf, fakeRepo, nil)
 
 	userID := uint(123)
 	role := "admin"
@@ -177,7 +181,7 @@ func TestRefreshTokens_Success(t *testing.T) {
 		},
 	}
 
-	service := oauth2.NewOAuth2Service(conf, fakeRepo)
+	service := oauth2.NewOAuth2Service(conf, fakeRepo, nil)
 
 	newAccessToken, newRefreshToken, err := service.RefreshTokens("valid_refresh_token")
 	if err != nil {
@@ -198,7 +202,7 @@ func TestRefreshTokens_InvalidToken(t *testing.T) {
 		},
 	}
 
-	service := oauth2.NewOAuth2Service(conf, fakeRepo)
+	service := oauth2.NewOAuth2Service(conf, fakeRepo, nil)
 
 	_, _, err := service.RefreshTokens("invalid_refresh_token")
 	if err == nil || err.Error() != "invalid or expired refresh token" {
@@ -216,7 +220,7 @@ func TestLogout_Success(t *testing.T) {
 		},
 	}
 
-	service := oauth2.NewOAuth2Service(conf, fakeRepo)
+	service := oauth2.NewOAuth2Service(conf, fakeRepo, nil)
 
 	err := service.Logout("valid_refresh_token", 1)
 	if err != nil {
@@ -236,7 +240,7 @@ func TestLogout_Failure(t *testing.T) {
 		},
 	}
 
-	service := oauth2.NewOAuth2Service(conf, fakeRepo)
+	service := oauth2.NewOAuth2Service(conf, fakeRepo, nil)
 
 	err := service.Logout("valid_refresh_token", 1)
 	if err == nil || !errors.Is(err, expectedErr) {
@@ -0,0 +1,195 @@
+package oauthprovider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ShopOnGO/ShopOnGO/configs"
+	"golang.org/x/oauth2"
+)
+
+// GenericProvider работает с любым OIDC-провайдером через discovery, а с
+// обычным OAuth2 (GitHub, VK) — через явно заданные эндпоинты и имена claims.
+type GenericProvider struct {
+	conf   configs.OAuthProviderConfig
+	client *http.Client
+
+	mu          sync.Mutex
+	oauth       *oauth2.Config
+	userInfoURL string
+}
+
+func NewGenericProvider(conf configs.OAuthProviderConfig, client *http.Client) *GenericProvider {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &GenericProvider{conf: conf, client: client}
+}
+
+func (p *GenericProvider) Name() string {
+	return p.conf.Name
+}
+
+func (p *GenericProvider) AuthCodeURL(ctx context.Context, state, verifier string) (string, error) {
+	cfg, _, err := p.endpoints(ctx)
+	if err != nil {
+		return "", err
+	}
+	return cfg.AuthCodeURL(state, oauth2.AccessTypeOffline, oauth2.S256ChallengeOption(verifier)), nil
+}
+
+func (p *GenericProvider) Exchange(ctx context.Context, code, verifier string) (*UserInfo, error) {
+	cfg, userInfoURL, err := p.endpoints(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, p.client)
+	token, err := cfg.Exchange(ctx, code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, userInfoURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := cfg.Client(ctx, token).Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("userinfo request failed: %s", resp.Status)
+	}
+
+	claims := map[string]interface{}{}
+	decoder := json.NewDecoder(resp.Body)
+	decoder.UseNumber()
+	if err := decoder.Decode(&claims); err != nil {
+		return nil, err
+	}
+	return p.mapClaims(claims)
+}
+
+// endpoints возвращает oauth2-конфиг, при первом вызове выполняя discovery.
+// Ошибка discovery не кешируется, чтобы провайдер заработал после восстановления сети.
+func (p *GenericProvider) endpoints(ctx context.Context) (*oauth2.Config, string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.oauth != nil {
+		return p.oauth, p.userInfoURL, nil
+	}
+
+	authURL, tokenURL, userInfoURL := p.conf.AuthURL, p.conf.TokenURL, p.conf.UserInfoURL
+	if p.conf.Issuer != "" {
+		doc, err := p.discover(ctx)
+		if err != nil {
+			return nil, "", err
+		}
+		if authURL == "" {
+			authURL = doc.AuthorizationEndpoint
+		}
+		if tokenURL == "" {
+			tokenURL = doc.TokenEndpoint
+		}
+		if userInfoURL == "" {
+			userInfoURL = doc.UserInfoEndpoint
+		}
+	}
+	if authURL == "" || tokenURL == "" || userInfoURL == "" {
+		return nil, "", fmt.Errorf("%w: provider %s has no endpoints configured", ErrDiscovery, p.conf.Name)
+	}
+
+	scopes := p.conf.Scopes
+	if len(scopes) == 0 && p.conf.Issuer != "" {
+		scopes = []string{"openid", "email", "profile"}
+	}
+	p.oauth = &oauth2.Config{
+		ClientID:     p.conf.ClientID,
+		ClientSecret: p.conf.ClientSecret,
+		RedirectURL:  p.conf.RedirectURL,
+		Scopes:       scopes,
+		Endpoint:     oauth2.Endpoint{AuthURL: authURL, TokenURL: tokenURL},
+	}
+	p.userInfoURL = userInfoURL
+	return p.oauth, p.userInfoURL, nil
+}
+
+type discoveryDocument struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserInfoEndpoint      string `json:"userinfo_endpoint"`
+}
+
+func (p *GenericProvider) discover(ctx context.Context) (*discoveryDocument, error) {
+	issuer := strings.TrimSuffix(p.conf.Issuer, "/")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, issuer+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDiscovery, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: %s", ErrDiscovery, resp.Status)
+	}
+
+	var doc discoveryDocument
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDiscovery, err)
+	}
+	// Спецификация требует совпадения issuer, иначе документ мог быть подменен
+	if strings.TrimSuffix(doc.Issuer, "/") != issuer {
+		return nil, fmt.Errorf("%w: issuer mismatch %q", ErrDiscovery, doc.Issuer)
+	}
+	return &doc, nil
+}
+
+func (p *GenericProvider) mapClaims(claims map[string]interface{}) (*UserInfo, error) {
+	info := &UserInfo{
+		Subject: claimString(claims, orDefault(p.conf.SubjectClaim, "sub")),
+		Email:   claimString(claims, orDefault(p.conf.EmailClaim, "email")),
+		Name:    claimString(claims, orDefault(p.conf.NameClaim, "name")),
+	}
+	if info.Subject == "" {
+		return nil, ErrNoSubject
+	}
+	if p.conf.TrustEmail {
+		info.EmailVerified = info.Email != ""
+	} else {
+		// Apple и некоторые другие провайдеры отдают email_verified строкой
+		verified, _ := strconv.ParseBool(claimString(claims, orDefault(p.conf.EmailVerifiedClaim, "email_verified")))
+		info.EmailVerified = verified
+	}
+	return info, nil
+}
+
+func claimString(claims map[string]interface{}, name string) string {
+	switch v := claims[name].(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return ""
+	}
+}
+
+func orDefault(value, def string) string {
+	if value == "" {
+		return def
+	}
+	return value
+}
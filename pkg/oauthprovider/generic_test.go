@@ -0,0 +1,106 @@
+package oauthprovider_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ShopOnGO/ShopOnGO/configs"
+	"github.com/ShopOnGO/ShopOnGO/pkg/oauthprovider"
+	"github.com/ShopOnGO/ShopOnGO/pkg/oauthprovider/oidctest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+func newProvider(conf configs.OAuthProviderConfig) *oauthprovider.GenericProvider {
+	conf.ClientID = oidctest.ClientID
+	conf.ClientSecret = oidctest.ClientSecret
+	conf.RedirectURL = "http://localhost/oauth/" + conf.Name + "/login"
+	return oauthprovider.NewGenericProvider(conf, nil)
+}
+
+// login проходит весь authorization code flow против фейкового сервера.
+func login(t *testing.T, server *oidctest.Server, p oauthprovider.Provider) (*oauthprovider.UserInfo, error) {
+	verifier := oauth2.GenerateVerifier()
+	authURL, err := p.AuthCodeURL(context.Background(), "state-1", verifier)
+	require.NoError(t, err)
+
+	code, state, err := server.Authorize(authURL)
+	require.NoError(t, err)
+	assert.Equal(t, "state-1", state)
+
+	return p.Exchange(context.Background(), code, verifier)
+}
+
+func TestGenericProvider_OIDC(t *testing.T) {
+	server := oidctest.NewServer(map[string]interface{}{
+		"sub":            "yandex-42",
+		"email":          "user@yandex.ru",
+		"email_verified": true,
+		"name":           "Yandex User",
+	})
+	defer server.Close()
+
+	p := newProvider(configs.OAuthProviderConfig{Name: "yandex", Issuer: server.Issuer()})
+	info, err := login(t, server, p)
+
+	require.NoError(t, err)
+	assert.Equal(t, &oauthprovider.UserInfo{
+		Subject:       "yandex-42",
+		Email:         "user@yandex.ru",
+		EmailVerified: true,
+		Name:          "Yandex User",
+	}, info)
+}
+
+func TestGenericProvider_WrongVerifier(t *testing.T) {
+	server := oidctest.NewServer(map[string]interface{}{"sub": "1"})
+	defer server.Close()
+
+	p := newProvider(configs.OAuthProviderConfig{Name: "yandex", Issuer: server.Issuer()})
+	authURL, err := p.AuthCodeURL(context.Background(), "state-1", oauth2.GenerateVerifier())
+	require.NoError(t, err)
+	code, _, err := server.Authorize(authURL)
+	require.NoError(t, err)
+
+	_, err = p.Exchange(context.Background(), code, oauth2.GenerateVerifier())
+	assert.Error(t, err)
+}
+
+func TestGenericProvider_ClaimMapping(t *testing.T) {
+	// GitHub-подобный ответ: числовой id, без email_verified
+	server := oidctest.NewServer(map[string]interface{}{
+		"id":    12345678,
+		"email": "octo@github.com",
+		"login": "octocat",
+	})
+	defer server.Close()
+
+	p := newProvider(configs.OAuthProviderConfig{
+		Name:         "github",
+		AuthURL:      server.URL + "/authorize",
+		TokenURL:     server.URL + "/token",
+		UserInfoURL:  server.URL + "/userinfo",
+		SubjectClaim: "id",
+		NameClaim:    "login",
+		TrustEmail:   true,
+	})
+	info, err := login(t, server, p)
+
+	require.NoError(t, err)
+	assert.Equal(t, "12345678", info.Subject)
+	assert.Equal(t, "octocat", info.Name)
+	assert.True(t, info.EmailVerified)
+}
+
+func TestRegistry(t *testing.T) {
+	conf := &configs.Config{
+		Google:    configs.GoogleConfig{ClientID: "google-client"},
+		Providers: []configs.OAuthProviderConfig{{Name: "yandex"}},
+	}
+	registry := oauthprovider.NewRegistryFromConfig(conf, nil)
+
+	assert.Equal(t, []string{"google", "yandex"}, registry.Names())
+	_, err := registry.Get("facebook")
+	assert.ErrorIs(t, err, oauthprovider.ErrUnknownProvider)
+}
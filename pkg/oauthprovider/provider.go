@@ -0,0 +1,29 @@
+package oauthprovider
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	ErrUnknownProvider = errors.New("unknown oauth provider")
+	ErrDiscovery       = errors.New("oidc discovery failed")
+	ErrNoSubject       = errors.New("provider did not return user id")
+)
+
+// UserInfo — данные пользователя, общие для всех провайдеров.
+type UserInfo struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// Provider — внешний провайдер входа по OAuth2 authorization code с PKCE.
+type Provider interface {
+	Name() string
+	// AuthCodeURL строит URL страницы согласия с заданным state и PKCE verifier.
+	AuthCodeURL(ctx context.Context, state, verifier string) (string, error)
+	// Exchange обменивает код на токен и возвращает данные пользователя.
+	Exchange(ctx context.Context, code, verifier string) (*UserInfo, error)
+}
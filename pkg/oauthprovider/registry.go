@@ -0,0 +1,65 @@
+package oauthprovider
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/ShopOnGO/ShopOnGO/configs"
+)
+
+const googleIssuer = "https://accounts.google.com"
+
+// Registry хранит провайдеры входа по имени, которое используется в URL (/oauth/{provider}/login).
+type Registry struct {
+	providers map[string]Provider
+}
+
+func NewRegistry(providers ...Provider) *Registry {
+	registry := &Registry{providers: map[string]Provider{}}
+	for _, p := range providers {
+		registry.Register(p)
+	}
+	return registry
+}
+
+// NewRegistryFromConfig собирает провайдеры из configs.Config.
+// Google по-прежнему настраивается через CLIENT_ID/CLIENT_SECRET/REDIRECT_URL,
+// если не описан явно в OAUTH_PROVIDERS.
+func NewRegistryFromConfig(conf *configs.Config, client *http.Client) *Registry {
+	registry := NewRegistry()
+	if conf.Google.ClientID != "" {
+		registry.Register(NewGenericProvider(configs.OAuthProviderConfig{
+			Name:         "google",
+			ClientID:     conf.Google.ClientID,
+			ClientSecret: conf.Google.ClientSecret,
+			RedirectURL:  conf.Google.RedirectURL,
+			Issuer:       googleIssuer,
+		}, client))
+	}
+	for _, providerConf := range conf.Providers {
+		registry.Register(NewGenericProvider(providerConf, client))
+	}
+	return registry
+}
+
+func (r *Registry) Register(p Provider) {
+	r.providers[p.Name()] = p
+}
+
+func (r *Registry) Get(name string) (Provider, error) {
+	p, ok := r.providers[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownProvider, name)
+	}
+	return p, nil
+}
+
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.providers))
+	for name := range r.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,52 @@
+package password
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// RangeDirectory проверяет пароли по локальной копии базы утечек в формате
+// k-anonymity (как у Have I Been Pwned): SHA-1 пароля делится на префикс из 5
+// hex-символов и суффикс. Каждый файл <dir>/<PREFIX> содержит строки "SUFFIX:COUNT".
+// Сам пароль и полный хеш никуда не передаются и не хранятся.
+type RangeDirectory struct {
+	Dir string
+}
+
+func NewRangeDirectory(dir string) *RangeDirectory {
+	return &RangeDirectory{Dir: dir}
+}
+
+func (d *RangeDirectory) IsBreached(password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:5], hash[5:]
+
+	file, err := os.Open(filepath.Join(d.Dir, prefix))
+	if errors.Is(err, os.ErrNotExist) {
+		file, err = os.Open(filepath.Join(d.Dir, prefix+".txt"))
+	}
+	if errors.Is(err, os.ErrNotExist) {
+		// диапазона нет в выгрузке — совпадений нет
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		candidate, count, _ := strings.Cut(line, ":")
+		if strings.EqualFold(candidate, suffix) && count != "0" {
+			return true, nil
+		}
+	}
+	return false, scanner.Err()
+}
@@ -0,0 +1,96 @@
+package password
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/ShopOnGO/ShopOnGO/configs"
+	"github.com/ShopOnGO/ShopOnGO/pkg/logger"
+)
+
+// maxLength — bcrypt учитывает только первые 72 байта пароля.
+const maxLength = 72
+
+var (
+	ErrWeakPassword     = errors.New("password does not meet the policy")
+	ErrBreachedPassword = errors.New("password appears in a known data breach, choose another one")
+)
+
+// Классы символов, которые можно требовать через PASSWORD_REQUIRED_CLASSES.
+const (
+	ClassLetter = "letter"
+	ClassLower  = "lower"
+	ClassUpper  = "upper"
+	ClassDigit  = "digit"
+	ClassSymbol = "symbol"
+)
+
+var classCheckers = map[string]func(rune) bool{
+	ClassLetter: unicode.IsLetter,
+	ClassLower:  unicode.IsLower,
+	ClassUpper:  unicode.IsUpper,
+	ClassDigit:  unicode.IsDigit,
+	ClassSymbol: func(r rune) bool { return unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r) },
+}
+
+// BreachedChecker сообщает, встречался ли пароль в утечках.
+type BreachedChecker interface {
+	IsBreached(password string) (bool, error)
+}
+
+// Policy — требования к новым паролям: регистрация, сброс и смена пароля.
+type Policy struct {
+	MinLength       int
+	RequiredClasses []string
+	Breached        BreachedChecker // nil — проверка по утечкам отключена
+}
+
+func NewPolicy(conf configs.PasswordConfig) *Policy {
+	policy := &Policy{
+		MinLength: conf.MinLength,
+	}
+	for _, class := range conf.RequiredClasses {
+		class = strings.ToLower(class)
+		if _, ok := classCheckers[class]; !ok {
+			logger.Warnf("⚠️ неизвестный класс символов в политике паролей: %s", class)
+			continue
+		}
+		policy.RequiredClasses = append(policy.RequiredClasses, class)
+	}
+	if conf.BreachedListDir != "" {
+		policy.Breached = NewRangeDirectory(conf.BreachedListDir)
+	}
+	return policy
+}
+
+// Validate возвращает ErrWeakPassword или ErrBreachedPassword с пояснением.
+// Если список утечек недоступен, проверка пропускается: пользователь не должен
+// оставаться без возможности сменить пароль из-за сбоя хранилища.
+func (p *Policy) Validate(password string) error {
+	if n := len([]rune(password)); n < p.MinLength {
+		return fmt.Errorf("%w: at least %d characters required", ErrWeakPassword, p.MinLength)
+	}
+	if len(password) > maxLength {
+		return fmt.Errorf("%w: at most %d bytes allowed", ErrWeakPassword, maxLength)
+	}
+	for _, class := range p.RequiredClasses {
+		if !strings.ContainsFunc(password, classCheckers[class]) {
+			return fmt.Errorf("%w: must contain a %s character", ErrWeakPassword, class)
+		}
+	}
+
+	if p.Breached == nil {
+		return nil
+	}
+	breached, err := p.Breached.IsBreached(password)
+	if err != nil {
+		logger.Errorf("❌ не удалось проверить пароль по списку утечек: %v", err)
+		return nil
+	}
+	if breached {
+		return ErrBreachedPassword
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package password
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ShopOnGO/ShopOnGO/configs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeRange кладет пароль в каталог утечек в формате диапазонов SHA-1.
+func writeRange(t *testing.T, dir, password string) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	content := "0000000000000000000000000000000000A:3\n" + hash[5:] + ":42\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, hash[:5]), []byte(content), 0o600))
+}
+
+func TestPolicyValidate(t *testing.T) {
+	dir := t.TempDir()
+	writeRange(t, dir, "password123")
+
+	policy := NewPolicy(configs.PasswordConfig{
+		MinLength:       8,
+		RequiredClasses: []string{"letter", "digit", "unknown"},
+		BreachedListDir: dir,
+	})
+	assert.Equal(t, []string{ClassLetter, ClassDigit}, policy.RequiredClasses, "неизвестные классы отбрасываются")
+
+	tests := []struct {
+		name     string
+		password string
+		err      error
+	}{
+		{"Too short", "abc12", ErrWeakPassword},
+		{"Too long for bcrypt", strings.Repeat("a1", 37), ErrWeakPassword},
+		{"No digit", "onlyletters", ErrWeakPassword},
+		{"No letter", "1234567890", ErrWeakPassword},
+		{"Breached", "password123", ErrBreachedPassword},
+		{"Valid", "correct horse 7", nil},
+		{"Valid unicode", "пароль2024", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Validate(tt.password)
+			if tt.err == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+}
+
+func TestRangeDirectoryMissingRange(t *testing.T) {
+	breached, err := NewRangeDirectory(t.TempDir()).IsBreached("anything")
+	require.NoError(t, err)
+	assert.False(t, breached)
+}
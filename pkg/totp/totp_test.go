@@ -0,0 +1,59 @@
+package totp_test
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ShopOnGO/ShopOnGO/pkg/totp"
+)
+
+// Секрет из тестовых векторов RFC 6238 (ASCII "12345678901234567890").
+var rfcSecret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestCodeAt_RFC6238Vectors(t *testing.T) {
+	// RFC 6238 приводит 8-значные коды, последние 6 цифр совпадают с нашими.
+	cases := map[int64]string{
+		59:          "287082",
+		1111111109:  "081804",
+		1111111111:  "050471",
+		1234567890:  "005924",
+		2000000000:  "279037",
+		20000000000: "353130",
+	}
+	for unix, want := range cases {
+		got, err := totp.CodeAt(rfcSecret, totp.Step(time.Unix(unix, 0)))
+		if err != nil {
+			t.Fatalf("CodeAt(%d): %v", unix, err)
+		}
+		if got != want {
+			t.Errorf("CodeAt(%d) = %s, ожидалось %s", unix, got, want)
+		}
+	}
+}
+
+func TestValidate_Skew(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	prev, _ := totp.CodeAt(rfcSecret, totp.Step(now)-1)
+
+	if _, ok := totp.Validate(rfcSecret, prev, now, 1); !ok {
+		t.Error("код предыдущего интервала должен приниматься при skew=1")
+	}
+	if _, ok := totp.Validate(rfcSecret, prev, now, 0); ok {
+		t.Error("код предыдущего интервала не должен приниматься при skew=0")
+	}
+	if _, ok := totp.Validate(rfcSecret, "12345", now, 1); ok {
+		t.Error("код неверной длины не должен приниматься")
+	}
+}
+
+func TestURI(t *testing.T) {
+	uri := totp.URI("ShopOnGO", "user@example.com", "ABC")
+	if !strings.HasPrefix(uri, "otpauth://totp/ShopOnGO:user@example.com?") {
+		t.Errorf("неожиданный URI: %s", uri)
+	}
+	if !strings.Contains(uri, "secret=ABC") || !strings.Contains(uri, "issuer=ShopOnGO") {
+		t.Errorf("URI не содержит secret/issuer: %s", uri)
+	}
+}